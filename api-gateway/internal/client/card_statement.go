@@ -0,0 +1,40 @@
+package client
+
+import (
+	"context"
+	"time"
+
+	card_v1 "github.com/mrevds/pizza-app/api-gateway/pkg/user-card_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// StatementRequest - выписка по карте за период [From, To). To = 0 - по текущий момент.
+// Пользователя Card Service берет из Token - access-токена user-service.
+type StatementRequest struct {
+	Token  string
+	CardID int64
+	From   time.Time
+	To     time.Time
+	Format string
+}
+
+// ExportStatement открывает поток выписки в Card Service. Ошибки запроса (неверный
+// период, чужая карта) приходят из первого Recv, io.EOF - выписка закончилась.
+func (c *GRPCClients) ExportStatement(ctx context.Context, req StatementRequest) (card_v1.CardV1_ExportStatementClient, error) {
+	ctx = metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+req.Token)
+	return card_v1.NewCardV1Client(c.CardServiceConn).ExportStatement(ctx, &card_v1.ExportStatementRequest{
+		CardId: req.CardID,
+		From:   optionalTimestamp(req.From),
+		To:     optionalTimestamp(req.To),
+		Format: req.Format,
+	})
+}
+
+// optionalTimestamp - нулевое время не передается
+func optionalTimestamp(t time.Time) *timestamppb.Timestamp {
+	if t.IsZero() {
+		return nil
+	}
+	return timestamppb.New(t)
+}
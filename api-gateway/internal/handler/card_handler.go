@@ -1,11 +1,19 @@
 package handler
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
+	"io"
+	"mime"
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/mrevds/pizza-app/api-gateway/internal/client"
 	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 type CardHandler struct {
@@ -137,3 +145,107 @@ func (h *CardHandler) Deposit(w http.ResponseWriter, r *http.Request) {
 
 	_ = json.NewEncoder(w).Encode(response)
 }
+
+// ExportStatement - выписка по карте файлом (csv, ofx, jsonl). Файл передается
+// по мере получения частей от Card Service, целиком в памяти не собирается.
+func (h *CardHandler) ExportStatement(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	cardID, err := strconv.ParseInt(q.Get("card_id"), 10, 64)
+	if err != nil {
+		http.Error(w, "card_id is required", http.StatusBadRequest)
+		return
+	}
+	from, err := parseStatementTime(q.Get("from"))
+	if err != nil || from.IsZero() {
+		http.Error(w, "from is required: RFC3339 or YYYY-MM-DD", http.StatusBadRequest)
+		return
+	}
+	to, err := parseStatementTime(q.Get("to"))
+	if err != nil {
+		http.Error(w, "to must be RFC3339 or YYYY-MM-DD", http.StatusBadRequest)
+		return
+	}
+
+	h.logger.Info("exporting statement",
+		zap.Int64("card_id", cardID),
+		zap.String("format", q.Get("format")),
+	)
+
+	ctx, cancel := context.WithCancel(r.Context())
+	defer cancel()
+	stream, err := h.clients.ExportStatement(ctx, client.StatementRequest{
+		Token:  r.Context().Value("token").(string),
+		CardID: cardID,
+		From:   from,
+		To:     to,
+		Format: q.Get("format"),
+	})
+	if err != nil {
+		writeGRPCError(w, err)
+		return
+	}
+	// Ошибки проверки запроса приходят до данных и отдаются обычным HTTP статусом
+	chunk, err := stream.Recv()
+	if err != nil {
+		writeGRPCError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", chunk.ContentType)
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": chunk.FileName}))
+	w.WriteHeader(http.StatusOK)
+
+	// WriteTimeout сервера рассчитан на обычные запросы, для выписки срок
+	// продлевается перед каждой частью
+	rc := http.NewResponseController(w)
+	for {
+		_ = rc.SetWriteDeadline(time.Now().Add(statementWriteTimeout))
+		if _, err := w.Write(chunk.Data); err != nil {
+			h.logger.Warn("statement download interrupted", zap.Int64("card_id", cardID), zap.Error(err))
+			return
+		}
+		_ = rc.Flush()
+
+		chunk, err = stream.Recv()
+		if errors.Is(err, io.EOF) {
+			return
+		}
+		if err != nil {
+			// Статус уже отправлен: обрываем соединение, чтобы клиент не принял
+			// неполный файл за целый
+			h.logger.Error("statement stream failed", zap.Int64("card_id", cardID), zap.Error(err))
+			panic(http.ErrAbortHandler)
+		}
+	}
+}
+
+const statementWriteTimeout = 15 * time.Second
+
+func parseStatementTime(s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse("2006-01-02", s); err == nil {
+		return t, nil
+	}
+	return time.Parse(time.RFC3339, s)
+}
+
+// writeGRPCError отдает ошибку Card Service соответствующим HTTP статусом
+func writeGRPCError(w http.ResponseWriter, err error) {
+	st := status.Convert(err)
+	code := http.StatusBadGateway
+	switch st.Code() {
+	case codes.InvalidArgument:
+		code = http.StatusBadRequest
+	case codes.NotFound:
+		code = http.StatusNotFound
+	case codes.FailedPrecondition:
+		code = http.StatusConflict
+	case codes.PermissionDenied:
+		code = http.StatusForbidden
+	case codes.Unavailable:
+		code = http.StatusServiceUnavailable
+	}
+	http.Error(w, st.Message(), code)
+}
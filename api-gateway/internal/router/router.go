@@ -42,6 +42,7 @@ func NewRouter(clients *client.GRPCClients, logger *zap.Logger) *mux.Router {
 	cardRouter.HandleFunc("", cardHandler.GetUserCards).Methods("GET")
 	cardRouter.HandleFunc("", cardHandler.AddCard).Methods("POST")
 	cardRouter.HandleFunc("/balance", cardHandler.GetBalance).Methods("GET")
+	cardRouter.HandleFunc("/statement", cardHandler.ExportStatement).Methods("GET")
 	cardRouter.HandleFunc("/deposit", cardHandler.Deposit).Methods("POST")
 	cardRouter.HandleFunc("/withdraw", cardHandler.Deposit).Methods("POST") // Пока заглушка
 	cardRouter.HandleFunc("/transfer", cardHandler.Deposit).Methods("POST") // Пока заглушка
@@ -31,11 +31,11 @@ func NewDB(lc fx.Lifecycle, cfg *config.Config) (*DB, error) {
 		OnStart: func(ctx context.Context) error {
 			pool, err = pgxpool.ConnectConfig(ctx, poolConfig)
 			if err != nil {
-				return fmt.Errorf("Error", err)
+				return fmt.Errorf("failed to connect to database: %w", err)
 			}
 			if err = pool.Ping(ctx); err != nil {
 				pool.Close()
-				return fmt.Errorf("failed to ping database", err)
+				return fmt.Errorf("failed to ping database: %w", err)
 			}
 			db.Pool = pool
 			fmt.Println("Database connected successfully")
@@ -49,5 +49,5 @@ func NewDB(lc fx.Lifecycle, cfg *config.Config) (*DB, error) {
 			return nil
 		},
 	})
-	return db, err
+	return db, nil
 }
@@ -0,0 +1,171 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/mrevds/pizza-app/card-service/client"
+	"github.com/mrevds/pizza-app/card-service/internal/app"
+	"github.com/mrevds/pizza-app/card-service/internal/config"
+	"github.com/mrevds/pizza-app/card-service/internal/money"
+	"github.com/mrevds/pizza-app/card-service/internal/service"
+
+	"go.uber.org/fx"
+)
+
+func runCommand(name string, args []string) {
+	switch name {
+	case "rotate-keys":
+		var rotator *service.KeyRotator
+		runOnce(fx.Populate(&rotator), func(ctx context.Context) error {
+			rotated, err := rotator.Rotate(ctx)
+			log.Printf("rotate-keys: %d card keys re-wrapped", rotated)
+			return err
+		})
+	case "reconcile":
+		reconcile(args)
+	case "gift-cards":
+		issueGiftCards(args)
+	default:
+		log.Fatalf("unknown command %q, available: rotate-keys, reconcile, gift-cards", name)
+	}
+}
+
+// reconcile сверяет балансы карт и пишет отчет JSON в stdout или -output.
+// Без -fix завершается с ошибкой, если нашлись расхождения.
+func reconcile(args []string) {
+	flags := flag.NewFlagSet("reconcile", flag.ExitOnError)
+	fix := flags.Bool("fix", false, "write adjustment transactions and reset stored balances to the ledger")
+	reason := flags.String("reason", "", "audit reason for adjustments, required with -fix")
+	cardID := flags.Int64("card-id", 0, "reconcile one card, 0 - all cards")
+	output := flags.String("output", "", "report file, stdout by default")
+	_ = flags.Parse(args)
+
+	var svc service.CardService
+	runOnce(fx.Populate(&svc), func(ctx context.Context) error {
+		report, err := svc.Reconcile(ctx, service.ReconcileInput{CardID: *cardID, Fix: *fix, Reason: *reason})
+		if err != nil {
+			return err
+		}
+		out := os.Stdout
+		if *output != "" {
+			if out, err = os.Create(*output); err != nil {
+				return err
+			}
+			defer out.Close()
+		}
+		enc := json.NewEncoder(out)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(report); err != nil {
+			return err
+		}
+		log.Printf("reconcile: %d cards checked, %d with drift, %d fixed",
+			report.CardsChecked, report.CardsWithDrift, report.CardsFixed)
+		if report.CardsWithDrift > report.CardsFixed {
+			return fmt.Errorf("%d cards with balance drift", report.CardsWithDrift-report.CardsFixed)
+		}
+		return nil
+	})
+}
+
+// issueGiftCards выпускает партию подарочных карт для партнера и пишет CSV с кодами
+// и PIN в stdout или -output. Каждая карта выпускается отдельно: при ошибке в файле
+// остаются уже выпущенные карты.
+func issueGiftCards(args []string) {
+	flags := flag.NewFlagSet("gift-cards", flag.ExitOnError)
+	count := flags.Int("count", 0, "number of gift cards to issue")
+	amountMinor := flags.Int64("amount-minor", 0, "face value of each card in minor units, 100000 = 1000.00 RUB")
+	currency := flags.String("currency", "RUB", "currency of the cards")
+	expiry := flags.String("expiry", "", "expiry date MM/YY, empty - no expiry")
+	batch := flags.String("batch", "", "batch id for the partner")
+	output := flags.String("output", "", "CSV file, stdout by default")
+	_ = flags.Parse(args)
+
+	if *count <= 0 {
+		log.Fatal("gift-cards: -count must be positive")
+	}
+	if *amountMinor <= 0 {
+		log.Fatal("gift-cards: -amount-minor must be positive")
+	}
+	// Целое число минимальных единиц: номинал не проходит через float
+	units := *amountMinor
+	exp, err := money.Exponent(money.NormalizeCurrency(*currency))
+	if err != nil {
+		log.Fatalf("gift-cards: -currency: %v", err)
+	}
+
+	var svc service.CardService
+	runOnce(fx.Populate(&svc), func(ctx context.Context) error {
+		out := os.Stdout
+		if *output != "" {
+			// В файле PIN в открытом виде
+			if out, err = os.OpenFile(*output, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o600); err != nil {
+				return err
+			}
+			defer out.Close()
+		}
+		w := csv.NewWriter(out)
+		defer w.Flush()
+		if err := w.Write([]string{"code", "pin", "amount", "currency", "expiry_date", "batch_id", "card_id"}); err != nil {
+			return err
+		}
+
+		issued := 0
+		defer func() { log.Printf("gift-cards: %d of %d cards issued", issued, *count) }()
+		for issued < *count {
+			gc, err := svc.IssueGiftCard(ctx, service.GiftCardIssueInput{
+				Amount:     money.Money{UnitsMinor: units, Currency: *currency},
+				ExpiryDate: *expiry,
+				BatchID:    *batch,
+			})
+			if err != nil {
+				return err
+			}
+			issued++
+			err = w.Write([]string{gc.Code, gc.PIN, money.Format(units, exp), gc.Card.Currency,
+				gc.Card.ExpiryDate, *batch, strconv.FormatInt(gc.Card.ID, 10)})
+			if err != nil {
+				return err
+			}
+		}
+		w.Flush()
+		return w.Error()
+	})
+}
+
+// runOnce поднимает зависимости приложения без gRPC сервера, выполняет fn и завершается
+func runOnce(populate fx.Option, fn func(ctx context.Context) error) {
+	application := fx.New(
+		fx.Provide(
+			config.Load,
+			client.NewDB,
+		),
+		app.Module,
+		populate,
+		fx.NopLogger,
+	)
+
+	startCtx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+	if err := application.Start(startCtx); err != nil {
+		log.Fatalf("failed to start: %v", err)
+	}
+
+	runErr := fn(context.Background())
+
+	stopCtx, cancelStop := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancelStop()
+	if err := application.Stop(stopCtx); err != nil {
+		log.Printf("failed to stop: %v", err)
+	}
+	if runErr != nil {
+		log.Fatalf("command failed: %v", runErr)
+	}
+}
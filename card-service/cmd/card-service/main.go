@@ -0,0 +1,99 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net"
+	"os"
+
+	"github.com/mrevds/pizza-app/card-service/client"
+	"github.com/mrevds/pizza-app/card-service/internal/app"
+	"github.com/mrevds/pizza-app/card-service/internal/config"
+	"github.com/mrevds/pizza-app/card-service/internal/events"
+	"github.com/mrevds/pizza-app/card-service/internal/scheduler"
+
+	"go.uber.org/fx"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/reflection"
+
+	pb "github.com/mrevds/pizza-app/card-service/pkg/user-card_v1"
+	pbV2 "github.com/mrevds/pizza-app/card-service/pkg/user-card_v2"
+)
+
+func main() {
+	// card-service <command> - разовые служебные команды (см. commands.go)
+	if len(os.Args) > 1 {
+		runCommand(os.Args[1], os.Args[2:])
+		return
+	}
+
+	fx.New(
+		fx.Provide(
+			config.Load,
+			client.NewDB,
+		),
+		app.Module,
+		fx.Invoke(registerGRPCServer),
+		fx.Invoke(registerScheduler),
+		fx.Invoke(registerEventHub),
+	).Run()
+}
+
+func registerGRPCServer(
+	lc fx.Lifecycle,
+	grpcServer *grpc.Server,
+	handler pb.CardV1Server,
+	handlerV2 pbV2.CardV2Server,
+	cfg *config.Config) {
+	lc.Append(fx.Hook{
+		OnStart: func(ctx context.Context) error {
+			lis, err := net.Listen("tcp", fmt.Sprintf(":%d", cfg.Server.Port))
+			if err != nil {
+				return err
+			}
+			pb.RegisterCardV1Server(grpcServer, handler)
+			pbV2.RegisterCardV2Server(grpcServer, handlerV2)
+			reflection.Register(grpcServer)
+			go func() {
+				log.Printf("GRPC server listening at %d", cfg.Server.Port)
+				if err := grpcServer.Serve(lis); err != nil {
+					log.Fatalf("failed to serve: %v", err)
+				}
+			}()
+			return nil
+		},
+		OnStop: func(ctx context.Context) error {
+			log.Printf("Stopping GRPC server...")
+			grpcServer.GracefulStop()
+			log.Printf("GRPC server stopped.")
+			return nil
+		},
+	})
+}
+
+// registerScheduler запускает фоновые задачи после старта приложения и останавливает их до закрытия пула БД
+func registerScheduler(lc fx.Lifecycle, s *scheduler.Scheduler) {
+	lc.Append(fx.Hook{
+		OnStart: func(ctx context.Context) error {
+			s.Start()
+			return nil
+		},
+		OnStop: func(ctx context.Context) error {
+			return s.Stop(ctx)
+		},
+	})
+}
+
+// registerEventHub запускается после gRPC сервера и останавливается раньше него:
+// закрытые подписки завершают WatchCardEvents, иначе GracefulStop ждал бы их вечно
+func registerEventHub(lc fx.Lifecycle, hub *events.Hub) {
+	lc.Append(fx.Hook{
+		OnStart: func(ctx context.Context) error {
+			return hub.Start(ctx)
+		},
+		OnStop: func(ctx context.Context) error {
+			return hub.Stop(ctx)
+		},
+	})
+}
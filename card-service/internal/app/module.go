@@ -0,0 +1,156 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/mrevds/pizza-app/card-service/internal/auth"
+	"github.com/mrevds/pizza-app/card-service/internal/config"
+	"github.com/mrevds/pizza-app/card-service/internal/encryption"
+	"github.com/mrevds/pizza-app/card-service/internal/events"
+	"github.com/mrevds/pizza-app/card-service/internal/handler"
+	"github.com/mrevds/pizza-app/card-service/internal/notify"
+	"github.com/mrevds/pizza-app/card-service/internal/outbox"
+	"github.com/mrevds/pizza-app/card-service/internal/repository"
+	"github.com/mrevds/pizza-app/card-service/internal/repository/pg"
+	"github.com/mrevds/pizza-app/card-service/internal/risk"
+	"github.com/mrevds/pizza-app/card-service/internal/service"
+	"github.com/mrevds/pizza-app/card-service/internal/userdir"
+
+	"go.uber.org/fx"
+	"google.golang.org/grpc"
+)
+
+// newGRPCServer включает аутентификацию: access-токены user-service для методов
+// пользователя, сервисные токены с областями для handler.ServiceMethods и токены
+// админов для handler.AdminMethods
+func newGRPCServer(cfg *config.Config, svc service.CardService) (*grpc.Server, error) {
+	var tokens auth.Tokens
+	var err error
+	if tokens.Services, err = auth.ParseTokens(cfg.JWT.ServiceTokens); err != nil {
+		return nil, fmt.Errorf("CARD_SERVICE_TOKENS: %w", err)
+	}
+	if tokens.Admins, err = auth.ParseTokens(cfg.JWT.AdminTokens); err != nil {
+		return nil, fmt.Errorf("CARD_ADMIN_TOKENS: %w", err)
+	}
+	if len(tokens.Services) == 0 {
+		log.Printf("CARD_SERVICE_TOKENS is empty, internal methods will reject all calls")
+	}
+	if len(tokens.Admins) == 0 {
+		log.Printf("CARD_ADMIN_TOKENS is empty, admin methods will reject all calls")
+	}
+	for name := range tokens.Services {
+		if len(cfg.JWT.ServiceScopes[name]) == 0 {
+			log.Printf("service %s has no jwt.service_scopes, its calls will be rejected", name)
+		}
+	}
+	interceptor, err := auth.NewInterceptor(auth.NewTokenValidator(cfg.JWT.SecretKey), svc, tokens, auth.Access{
+		ServiceMethods: handler.ServiceMethods,
+		ServiceScopes:  cfg.JWT.ServiceScopes,
+		AdminMethods:   handler.AdminMethods,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return grpc.NewServer(
+		grpc.UnaryInterceptor(interceptor.Unary()),
+		grpc.StreamInterceptor(interceptor.Stream()),
+	), nil
+}
+
+func newEventHub(repo repository.CardRepository, listener events.Listener, cfg *config.Config) *events.Hub {
+	return events.New(repo, listener, events.Config{
+		PollInterval: cfg.Events.PollInterval,
+		GapTimeout:   cfg.Events.GapTimeout,
+	})
+}
+
+// newEventPublisher выбирает, куда relay публикует события outbox. Файл закрывается
+// при остановке, после планировщика (хуки останавливаются в обратном порядке).
+func newEventPublisher(lc fx.Lifecycle, cfg *config.Config) (outbox.EventPublisher, error) {
+	switch cfg.Outbox.Publisher {
+	case "", "log":
+		return outbox.NewLogPublisher(), nil
+	case "file":
+		if cfg.Outbox.FilePath == "" {
+			return nil, fmt.Errorf("outbox.file_path is required for publisher file")
+		}
+		p, err := outbox.NewFilePublisher(cfg.Outbox.FilePath)
+		if err != nil {
+			return nil, err
+		}
+		lc.Append(fx.Hook{OnStop: func(ctx context.Context) error { return p.Close() }})
+		return p, nil
+	}
+	return nil, fmt.Errorf("unknown outbox.publisher %q", cfg.Outbox.Publisher)
+}
+
+func newOutboxRelay(repo repository.CardRepository, publisher outbox.EventPublisher, cfg *config.Config) *outbox.Relay {
+	return outbox.NewRelay(repo, publisher, cfg.Outbox.BatchSize)
+}
+
+// newUserDirectory - клиент user-service для переводов по номеру телефона.
+// Без адреса возвращает nil, и переводы по телефону отключены.
+func newUserDirectory(lc fx.Lifecycle, cfg *config.Config) (userdir.Directory, error) {
+	uc := cfg.UserService
+	if uc.Address == "" {
+		log.Printf("user_service.address is empty, transfers by phone number are disabled")
+		return nil, nil
+	}
+	if uc.Token == "" {
+		log.Printf("USER_SERVICE_TOKEN is empty, user-service will reject recipient lookups")
+	}
+	client, err := userdir.New(uc.Address, uc.Token, uc.Timeout)
+	if err != nil {
+		return nil, err
+	}
+	lc.Append(fx.Hook{OnStop: func(ctx context.Context) error { return client.Close() }})
+	return client, nil
+}
+
+// newRiskEngine собирает правила оценки риска из конфига. nil - оценка отключена.
+func newRiskEngine(cfg *config.Config) *risk.Engine {
+	rc := cfg.Risk
+	if !rc.Enabled {
+		return nil
+	}
+	var rules []risk.Rule
+	if rc.VelocityScore > 0 {
+		rules = append(rules, risk.Velocity{Max: rc.VelocityMax, Window: rc.VelocityWindow, Score: rc.VelocityScore})
+	}
+	if rc.OutlierScore > 0 {
+		rules = append(rules, risk.AmountOutlier{
+			Factor:     rc.OutlierFactor,
+			MinHistory: rc.OutlierMinHistory,
+			Lookback:   rc.OutlierLookback,
+			Score:      rc.OutlierScore,
+		})
+	}
+	if rc.NewCardScore > 0 {
+		rules = append(rules, risk.NewCard{MaxAge: rc.NewCardAge, Amount: rc.NewCardAmount, Score: rc.NewCardScore})
+	}
+	if rc.ManyCardsScore > 0 {
+		rules = append(rules, risk.ManyCards{Max: rc.ManyCardsMax, Window: rc.ManyCardsWindow, Score: rc.ManyCardsScore})
+	}
+	return risk.NewEngine(rc.ReviewScore, rc.DenyScore, rules...)
+}
+
+var Module = fx.Module("app",
+	fx.Provide(pg.NewCardRepo),
+	fx.Provide(pg.NewAdvisoryLocker),
+	fx.Provide(notify.NewLogNotifier),
+	fx.Provide(pg.NewNotifyListener),
+	fx.Provide(newEventHub),
+	fx.Provide(newRiskEngine),
+	fx.Provide(newUserDirectory),
+	fx.Provide(newEventPublisher),
+	fx.Provide(newOutboxRelay),
+	fx.Provide(encryption.NewEnvelope),
+	fx.Provide(service.NewCardService),
+	fx.Provide(service.NewKeyRotator),
+	fx.Provide(handler.NewGRPCHandler),
+	fx.Provide(handler.NewGRPCHandlerV2),
+	fx.Provide(newGRPCServer),
+	fx.Provide(newScheduler),
+)
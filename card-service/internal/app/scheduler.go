@@ -0,0 +1,97 @@
+package app
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/mrevds/pizza-app/card-service/internal/config"
+	"github.com/mrevds/pizza-app/card-service/internal/outbox"
+	"github.com/mrevds/pizza-app/card-service/internal/scheduler"
+	"github.com/mrevds/pizza-app/card-service/internal/service"
+)
+
+// newScheduler собирает фоновые задачи card-service
+func newScheduler(locker scheduler.Locker, svc service.CardService, relay *outbox.Relay, cfg *config.Config) *scheduler.Scheduler {
+	return scheduler.New(locker,
+		scheduler.Job{
+			Name:     "expire_authorizations",
+			Interval: cfg.Card.AuthorizationSweepTick,
+			Run: func(ctx context.Context) error {
+				n, err := svc.ExpireAuthorizations(ctx)
+				logProcessed("expired %d authorizations", n)
+				return err
+			},
+		},
+		scheduler.Job{
+			Name:     "expire_cards",
+			Interval: cfg.Scheduler.CardExpiryInterval,
+			Run: func(ctx context.Context) error {
+				n, err := svc.ExpireCards(ctx)
+				logProcessed("expired %d cards", int64(n))
+				return err
+			},
+		},
+		scheduler.Job{
+			Name:     "notify_expiring_cards",
+			Interval: cfg.Scheduler.ExpiryNoticeInterval,
+			Run: func(ctx context.Context) error {
+				n, err := svc.NotifyExpiringCards(ctx)
+				logProcessed("sent %d card expiry notices", int64(n))
+				return err
+			},
+		},
+		scheduler.Job{
+			Name:     "scheduled_transfers",
+			Interval: cfg.Scheduler.ScheduledTransferInterval,
+			Run: func(ctx context.Context) error {
+				n, err := svc.RunScheduledTransfers(ctx)
+				logProcessed("ran %d scheduled transfers", int64(n))
+				return err
+			},
+		},
+		scheduler.Job{
+			Name:     "purge_card_events",
+			Interval: cfg.Events.PurgeInterval,
+			Run: func(ctx context.Context) error {
+				n, err := svc.PurgeCardEvents(ctx)
+				logProcessed("purged %d card events", n)
+				return err
+			},
+		},
+		scheduler.Job{
+			// Одна реплика публикует outbox - события карты уходят по порядку
+			Name:     "outbox_relay",
+			Interval: cfg.Outbox.RelayInterval,
+			Run: func(ctx context.Context) error {
+				n, err := relay.Run(ctx)
+				logProcessed("published %d outbox events", n)
+				return err
+			},
+		},
+		scheduler.Job{
+			Name:     "purge_outbox",
+			Interval: cfg.Outbox.PurgeInterval,
+			Run: func(ctx context.Context) error {
+				n, err := relay.Purge(ctx, time.Now().Add(-cfg.Outbox.Retention))
+				logProcessed("purged %d published outbox events", n)
+				return err
+			},
+		},
+		scheduler.Job{
+			Name:     "fail_stale_pending",
+			Interval: cfg.Scheduler.PendingSweepInterval,
+			Run: func(ctx context.Context) error {
+				n, err := svc.FailStalePendingTransactions(ctx)
+				logProcessed("failed %d stale pending transactions", n)
+				return err
+			},
+		},
+	)
+}
+
+func logProcessed(format string, n int64) {
+	if n > 0 {
+		log.Printf(format, n)
+	}
+}
@@ -0,0 +1,238 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	testSecret  = "test-secret"
+	testSubject = "6f1c2a9e-3b4d-4e5f-8a7b-9c0d1e2f3a4b"
+	testMethod  = "/card_v2.CardV2/GetCard"
+	testService = "/card_v2.CardV2/ProcessPayment"
+	testAdmin   = "/card_v2.CardV2/SetCardStatus"
+)
+
+var testNow = time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+// sign подписывает токен так же, как user-service
+func sign(t *testing.T, alg, secret string, claims map[string]interface{}) string {
+	t.Helper()
+	var key interface{} = []byte(secret)
+	if alg == "none" {
+		key = jwt.UnsafeAllowNoneSignatureType
+	}
+	token, err := jwt.NewWithClaims(jwt.GetSigningMethod(alg), jwt.MapClaims(claims)).SignedString(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return token
+}
+
+func accessClaims() map[string]interface{} {
+	return map[string]interface{}{
+		"user_id": testSubject,
+		"type":    "access",
+		"exp":     testNow.Add(15 * time.Minute).Unix(),
+		"iat":     testNow.Unix(),
+		"nbf":     testNow.Unix(),
+	}
+}
+
+func newTestValidator() *TokenValidator {
+	v := NewTokenValidator(testSecret)
+	v.now = func() time.Time { return testNow }
+	return v
+}
+
+func TestValidate(t *testing.T) {
+	with := func(key string, value interface{}) map[string]interface{} {
+		c := accessClaims()
+		if value == nil {
+			delete(c, key)
+		} else {
+			c[key] = value
+		}
+		return c
+	}
+	tests := []struct {
+		name    string
+		token   string
+		wantErr error
+	}{
+		{"valid", sign(t, "HS256", testSecret, accessClaims()), nil},
+		{"wrong secret", sign(t, "HS256", "other", accessClaims()), ErrInvalidToken},
+		{"alg none", sign(t, "none", testSecret, accessClaims()), ErrInvalidToken},
+		{"alg HS512", sign(t, "HS512", testSecret, accessClaims()), ErrInvalidToken},
+		{"expired", sign(t, "HS256", testSecret, with("exp", testNow.Add(-time.Second).Unix())), ErrTokenExpired},
+		{"no exp", sign(t, "HS256", testSecret, with("exp", nil)), ErrInvalidToken},
+		{"not yet valid", sign(t, "HS256", testSecret, with("nbf", testNow.Add(time.Minute).Unix())), ErrInvalidToken},
+		{"refresh token", sign(t, "HS256", testSecret, with("type", "refresh")), ErrInvalidToken},
+		{"user id not uuid", sign(t, "HS256", testSecret, with("user_id", "42")), ErrInvalidToken},
+		{"malformed", "abc.def", ErrInvalidToken},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims, err := newTestValidator().Validate(tt.token)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if err == nil && claims.UserID != testSubject {
+				t.Errorf("UserID = %q, want %q", claims.UserID, testSubject)
+			}
+		})
+	}
+}
+
+type fakeResolver map[string]int64
+
+func (r fakeResolver) ResolveUser(ctx context.Context, externalID string) (int64, error) {
+	return r[externalID], nil
+}
+
+func newTestInterceptor(t *testing.T) *Interceptor {
+	t.Helper()
+	i, err := NewInterceptor(newTestValidator(), fakeResolver{testSubject: 42}, Tokens{
+		Services: map[string]string{"order-service": "order-token", "report-service": "report-token"},
+		Admins:   map[string]string{"alice": "admin-token"},
+	}, Access{
+		ServiceMethods: map[string]string{testService: "payments"},
+		ServiceScopes:  map[string][]string{"order-service": {"payments"}},
+		AdminMethods:   []string{testAdmin},
+	})
+	if err != nil {
+		t.Fatalf("NewInterceptor: %v", err)
+	}
+	return i
+}
+
+func TestNewInterceptorUnknownScope(t *testing.T) {
+	_, err := NewInterceptor(newTestValidator(), fakeResolver{}, Tokens{}, Access{
+		ServiceMethods: map[string]string{testService: "payments"},
+		ServiceScopes:  map[string][]string{"order-service": {"payment"}},
+	})
+	if err == nil {
+		t.Error("unknown scope want error")
+	}
+}
+
+func callUnary(t *testing.T, i *Interceptor, method string, md metadata.MD) (Identity, error) {
+	t.Helper()
+	ctx := metadata.NewIncomingContext(context.Background(), md)
+	var got Identity
+	_, err := i.Unary()(ctx, nil, &grpc.UnaryServerInfo{FullMethod: method}, func(ctx context.Context, req interface{}) (interface{}, error) {
+		got, _ = FromContext(ctx)
+		return nil, nil
+	})
+	return got, err
+}
+
+func TestInterceptor(t *testing.T) {
+	bearer := "Bearer " + sign(t, "HS256", testSecret, accessClaims())
+	tests := []struct {
+		name     string
+		method   string
+		md       metadata.MD
+		wantCode codes.Code
+		want     Identity
+	}{
+		{"user token", testMethod, metadata.Pairs(AuthorizationHeader, bearer), codes.OK, Identity{UserID: 42, Subject: testSubject}},
+		{"no token", testMethod, metadata.MD{}, codes.Unauthenticated, Identity{}},
+		{"without bearer scheme", testMethod, metadata.Pairs(AuthorizationHeader, bearer[len("Bearer "):]), codes.Unauthenticated, Identity{}},
+		{"service token on user method", testMethod, metadata.Pairs(ServiceTokenHeader, "order-token"), codes.Unauthenticated, Identity{}},
+		{"service token", testService, metadata.Pairs(ServiceTokenHeader, "order-token"), codes.OK, Identity{Service: "order-service"}},
+		{"wrong service token", testService, metadata.Pairs(ServiceTokenHeader, "order-token2"), codes.Unauthenticated, Identity{}},
+		{"user token on service method", testService, metadata.Pairs(AuthorizationHeader, bearer), codes.Unauthenticated, Identity{}},
+		{"service without scope", testService, metadata.Pairs(ServiceTokenHeader, "report-token"), codes.PermissionDenied, Identity{}},
+		{"admin token", testAdmin, metadata.Pairs(AdminTokenHeader, "admin-token"), codes.OK, Identity{Admin: "alice"}},
+		{"service token on admin method", testAdmin, metadata.Pairs(ServiceTokenHeader, "order-token"), codes.Unauthenticated, Identity{}},
+		{"admin token in service header", testAdmin, metadata.Pairs(ServiceTokenHeader, "admin-token"), codes.Unauthenticated, Identity{}},
+		{"user token on admin method", testAdmin, metadata.Pairs(AuthorizationHeader, bearer), codes.Unauthenticated, Identity{}},
+		{"admin token on service method", testService, metadata.Pairs(ServiceTokenHeader, "admin-token"), codes.Unauthenticated, Identity{}},
+		{"reflection", "/grpc.reflection.v1.ServerReflection/ServerReflectionInfo", metadata.MD{}, codes.OK, Identity{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := callUnary(t, newTestInterceptor(t), tt.method, tt.md)
+			if code := status.Code(err); code != tt.wantCode {
+				t.Fatalf("code = %v, want %v (err %v)", code, tt.wantCode, err)
+			}
+			if got != tt.want {
+				t.Errorf("identity = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+type fakeStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *fakeStream) Context() context.Context { return s.ctx }
+
+func TestInterceptorStream(t *testing.T) {
+	md := metadata.Pairs(AuthorizationHeader, "Bearer "+sign(t, "HS256", testSecret, accessClaims()))
+	ss := &fakeStream{ctx: metadata.NewIncomingContext(context.Background(), md)}
+	var userID int64
+	err := newTestInterceptor(t).Stream()(nil, ss, &grpc.StreamServerInfo{FullMethod: "/card_v1.CardV1/WatchCardEvents"},
+		func(srv interface{}, stream grpc.ServerStream) error {
+			userID = UserID(stream.Context())
+			return nil
+		})
+	if err != nil {
+		t.Fatalf("stream: %v", err)
+	}
+	if userID != 42 {
+		t.Errorf("UserID = %d, want 42", userID)
+	}
+}
+
+func TestParseTokens(t *testing.T) {
+	tokens, err := ParseTokens(" order-service:abc, admin:xyz ,")
+	if err != nil {
+		t.Fatalf("ParseTokens: %v", err)
+	}
+	if len(tokens) != 2 || tokens["order-service"] != "abc" || tokens["admin"] != "xyz" {
+		t.Errorf("tokens = %v", tokens)
+	}
+	if tokens, err := ParseTokens(""); err != nil || len(tokens) != 0 {
+		t.Errorf("ParseTokens(\"\") = %v, %v, want no tokens", tokens, err)
+	}
+	for _, bad := range []string{"order-service", "order-service:", ":abc"} {
+		if _, err := ParseTokens(bad); err == nil {
+			t.Errorf("ParseTokens(%q) want error", bad)
+		}
+	}
+}
+
+func TestMatchToken(t *testing.T) {
+	tokens := map[string]string{"order-service": "abc", "card-service": "xyz"}
+	tests := []struct {
+		token string
+		want  string
+	}{
+		{"abc", "order-service"},
+		{"xyz", "card-service"},
+		{"abcd", ""},
+		{"ab", ""},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		name, ok := matchToken(tokens, tt.token)
+		if name != tt.want || ok != (tt.want != "") {
+			t.Errorf("matchToken(%q) = %q, %v, want %q", tt.token, name, ok, tt.want)
+		}
+	}
+	if _, ok := matchToken(map[string]string{}, ""); ok {
+		t.Error("empty token matched empty token set")
+	}
+}
@@ -0,0 +1,29 @@
+package auth
+
+import "context"
+
+// Identity - проверенный вызывающий. У пользователя заполнены UserID и Subject,
+// у внутреннего сервиса - Service, у админа - Admin.
+type Identity struct {
+	UserID  int64  // id пользователя в card-service
+	Subject string // UUID пользователя в user-service
+	Service string // имя сервиса из CARD_SERVICE_TOKENS
+	Admin   string // имя админа из CARD_ADMIN_TOKENS
+}
+
+type identityKey struct{}
+
+func WithIdentity(ctx context.Context, id Identity) context.Context {
+	return context.WithValue(ctx, identityKey{}, id)
+}
+
+func FromContext(ctx context.Context) (Identity, bool) {
+	id, ok := ctx.Value(identityKey{}).(Identity)
+	return id, ok
+}
+
+// UserID - id пользователя из контекста, 0 если вызов не от пользователя
+func UserID(ctx context.Context) int64 {
+	id, _ := FromContext(ctx)
+	return id.UserID
+}
@@ -0,0 +1,196 @@
+package auth
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// Заголовки метаданных gRPC
+const (
+	AuthorizationHeader = "authorization"   // "Bearer <access-токен user-service>"
+	ServiceTokenHeader  = "x-service-token" // токен внутреннего сервиса
+	AdminTokenHeader    = "x-admin-token"   // токен админа
+)
+
+// UserResolver переводит UUID пользователя user-service в id card-service
+type UserResolver interface {
+	ResolveUser(ctx context.Context, externalID string) (int64, error)
+}
+
+// ParseTokens разбирает CARD_SERVICE_TOKENS и CARD_ADMIN_TOKENS: "<name>:<token>"
+// через запятую. Результат - токены по имени сервиса или админа.
+func ParseTokens(s string) (map[string]string, error) {
+	tokens := make(map[string]string)
+	for _, item := range strings.Split(s, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		name, token, ok := strings.Cut(item, ":")
+		if !ok || name == "" || token == "" {
+			return nil, fmt.Errorf("invalid service token %q, want <name>:<token>", name)
+		}
+		tokens[name] = token
+	}
+	return tokens, nil
+}
+
+// Tokens - сервисные токены и токены админов по имени
+type Tokens struct {
+	Services map[string]string
+	Admins   map[string]string
+}
+
+// Access - методы, которые вызывает не пользователь. ServiceMethods - область каждого
+// метода для сервисов, ServiceScopes - области, выданные сервису по имени из Tokens.Services:
+// сервис вызывает только методы своих областей. AdminMethods - только с токеном админа.
+type Access struct {
+	ServiceMethods map[string]string
+	ServiceScopes  map[string][]string
+	AdminMethods   []string
+}
+
+// Interceptor аутентифицирует каждый вызов. Методы сервисов принимают только сервисный
+// токен с нужной областью, методы админов - только токен админа, остальные - только
+// access-токен пользователя. Reflection открыт.
+type Interceptor struct {
+	validator      *TokenValidator
+	resolver       UserResolver
+	tokens         Tokens
+	serviceMethods map[string]string
+	serviceScopes  map[string]map[string]bool
+	adminMethods   map[string]bool
+}
+
+// NewInterceptor проверяет, что каждая выданная область есть у какого-то метода:
+// опечатка в конфиге иначе молча оставила бы сервис без доступа
+func NewInterceptor(validator *TokenValidator, resolver UserResolver, tokens Tokens, access Access) (*Interceptor, error) {
+	known := make(map[string]bool)
+	for _, scope := range access.ServiceMethods {
+		known[scope] = true
+	}
+	scopes := make(map[string]map[string]bool, len(access.ServiceScopes))
+	for name, list := range access.ServiceScopes {
+		scopes[name] = make(map[string]bool, len(list))
+		for _, scope := range list {
+			if !known[scope] {
+				return nil, fmt.Errorf("unknown scope %q for service %s", scope, name)
+			}
+			scopes[name][scope] = true
+		}
+	}
+	admin := make(map[string]bool, len(access.AdminMethods))
+	for _, m := range access.AdminMethods {
+		admin[m] = true
+	}
+	return &Interceptor{
+		validator:      validator,
+		resolver:       resolver,
+		tokens:         tokens,
+		serviceMethods: access.ServiceMethods,
+		serviceScopes:  scopes,
+		adminMethods:   admin,
+	}, nil
+}
+
+func (i *Interceptor) Unary() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		ctx, err := i.authenticate(ctx, info.FullMethod)
+		if err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+func (i *Interceptor) Stream() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, err := i.authenticate(ss.Context(), info.FullMethod)
+		if err != nil {
+			return err
+		}
+		return handler(srv, &identityStream{ServerStream: ss, ctx: ctx})
+	}
+}
+
+// identityStream подменяет контекст потока на контекст с Identity
+type identityStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *identityStream) Context() context.Context {
+	return s.ctx
+}
+
+func (i *Interceptor) authenticate(ctx context.Context, method string) (context.Context, error) {
+	if strings.HasPrefix(method, "/grpc.reflection.") {
+		return ctx, nil
+	}
+	md, _ := metadata.FromIncomingContext(ctx)
+	if scope, ok := i.serviceMethods[method]; ok {
+		name, ok := matchToken(i.tokens.Services, firstValue(md, ServiceTokenHeader))
+		if !ok {
+			return nil, status.Error(codes.Unauthenticated, "service token is required")
+		}
+		if !i.serviceScopes[name][scope] {
+			return nil, status.Errorf(codes.PermissionDenied, "service %s may not call %s", name, method)
+		}
+		return WithIdentity(ctx, Identity{Service: name}), nil
+	}
+	if i.adminMethods[method] {
+		name, ok := matchToken(i.tokens.Admins, firstValue(md, AdminTokenHeader))
+		if !ok {
+			return nil, status.Error(codes.Unauthenticated, "admin token is required")
+		}
+		return WithIdentity(ctx, Identity{Admin: name}), nil
+	}
+
+	token, ok := strings.CutPrefix(firstValue(md, AuthorizationHeader), "Bearer ")
+	if !ok || token == "" {
+		return nil, status.Error(codes.Unauthenticated, "authorization token is required")
+	}
+	claims, err := i.validator.Validate(token)
+	if err != nil {
+		return nil, status.Error(codes.Unauthenticated, err.Error())
+	}
+	userID, err := i.resolver.ResolveUser(ctx, claims.UserID)
+	if err != nil {
+		if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+			return nil, status.FromContextError(err).Err()
+		}
+		log.Printf("auth: resolve user %s: %v", claims.UserID, err)
+		return nil, status.Error(codes.Internal, "failed to resolve user")
+	}
+	return WithIdentity(ctx, Identity{UserID: userID, Subject: claims.UserID}), nil
+}
+
+// matchToken сравнивает со всеми токенами за постоянное время
+func matchToken(tokens map[string]string, token string) (string, bool) {
+	if token == "" {
+		return "", false
+	}
+	name := ""
+	for n, t := range tokens {
+		if subtle.ConstantTimeCompare([]byte(token), []byte(t)) == 1 {
+			name = n
+		}
+	}
+	return name, name != ""
+}
+
+func firstValue(md metadata.MD, key string) string {
+	if v := md.Get(key); len(v) > 0 {
+		return v[0]
+	}
+	return ""
+}
@@ -0,0 +1,62 @@
+// Package auth проверяет вызывающего card-service: пользователя по access-токену
+// user-service или внутренний сервис по сервисному токену.
+package auth
+
+import (
+	"errors"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"github.com/mrevds/pizza-app/card-service/internal/utils"
+)
+
+var (
+	ErrInvalidToken = errors.New("invalid token")
+	ErrTokenExpired = errors.New("token is expired")
+)
+
+// TokenTypeAccess - тип access-токена в claims user-service
+const TokenTypeAccess = "access"
+
+// Claims - claims токена user-service, повторяет utils.Claims там: user_id - UUID
+// пользователя, type - access или refresh
+type Claims struct {
+	UserID string `json:"user_id"`
+	Type   string `json:"type"`
+	jwt.RegisteredClaims
+}
+
+// TokenValidator проверяет токены user-service общим секретом той же библиотекой
+// golang-jwt, которой они выпускаются
+type TokenValidator struct {
+	secret []byte
+	now    func() time.Time
+}
+
+func NewTokenValidator(secret string) *TokenValidator {
+	return &TokenValidator{secret: []byte(secret), now: time.Now}
+}
+
+// Validate проверяет подпись, срок действия и тип access. Принимается только HS256:
+// токены с другим алгоритмом (в том числе none) и без exp отклоняются.
+func (v *TokenValidator) Validate(token string) (*Claims, error) {
+	var claims Claims
+	_, err := jwt.ParseWithClaims(token, &claims, func(*jwt.Token) (interface{}, error) {
+		return v.secret, nil
+	},
+		jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}),
+		jwt.WithExpirationRequired(),
+		jwt.WithTimeFunc(v.now),
+	)
+	if errors.Is(err, jwt.ErrTokenExpired) {
+		return nil, ErrTokenExpired
+	}
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+	if claims.Type != TokenTypeAccess || !utils.IsUUID(claims.UserID) {
+		return nil, ErrInvalidToken
+	}
+	return &claims, nil
+}
@@ -2,15 +2,25 @@ package config
 
 import (
 	"fmt"
+	"os"
 	"time"
 
 	"github.com/spf13/viper"
 )
 
 type Config struct {
-	Server   ServerConfig
-	DataBase DatabaseConfig
-	JWT      JWTConfig
+	Server      ServerConfig
+	DataBase    DatabaseConfig
+	JWT         JWTConfig
+	Card        CardConfig
+	Encryption  EncryptionConfig
+	Scheduler   SchedulerConfig
+	Events      EventsConfig
+	Risk        RiskConfig
+	Outbox      OutboxConfig
+	P2P         P2PConfig
+	UserService UserServiceConfig
+	GiftCard    GiftCardConfig
 }
 
 type ServerConfig struct {
@@ -27,10 +37,113 @@ type DatabaseConfig struct {
 	MaxConns int
 	MinConns int
 }
+
+// JWTConfig - SecretKey общий с user-service, им проверяются access-токены пользователей.
+// Сервисные токены и токены админов, как и мастер-ключи, задаются только переменной окружения.
+// ServiceScopes не секретны: области методов (handler.ServiceMethods) по имени сервиса.
 type JWTConfig struct {
 	SecretKey       string
-	AccessTokenTTL  int // in minutes
-	RefreshTokenTTL int // in minutes
+	AccessTokenTTL  int    // in minutes
+	RefreshTokenTTL int    // in minutes
+	ServiceTokens   string // CARD_SERVICE_TOKENS: "<name>:<token>" через запятую
+	ServiceScopes   map[string][]string
+	AdminTokens     string // CARD_ADMIN_TOKENS: "<admin>:<token>" через запятую
+}
+
+type CardConfig struct {
+	DefaultCurrency string
+	IdempotencyTTL  time.Duration // сколько хранится ответ на запрос с idempotency_key
+
+	AuthorizationTTL       time.Duration // срок жизни холда
+	AuthorizationSweepTick time.Duration // как часто истекшие холды переводятся в expired
+}
+
+// SchedulerConfig - фоновые задачи. Нулевой интервал отключает задачу.
+type SchedulerConfig struct {
+	CardExpiryInterval   time.Duration // перевод просроченных карт в expired
+	ExpiryNoticeInterval time.Duration // уведомления о скором окончании срока карты
+	ExpiryNoticeWindow   time.Duration // за сколько до окончания срока предупреждать
+	PendingSweepInterval time.Duration // перевод зависших pending транзакций в failed
+	PendingTimeout       time.Duration // сколько транзакция может быть в pending
+
+	ScheduledTransferInterval   time.Duration // запуск запланированных переводов
+	ScheduledTransferRetries    int           // сколько раз повторять запуск при нехватке средств или лимите
+	ScheduledTransferRetryDelay time.Duration // через сколько повторять
+}
+
+// EventsConfig - события карт для WatchCardEvents
+type EventsConfig struct {
+	Retention     time.Duration // сколько хранятся события, столько можно возобновлять подписку
+	PurgeInterval time.Duration // удаление старых событий, 0 отключает
+	PollInterval  time.Duration // опрос базы на случай потерянного NOTIFY
+	GapTimeout    time.Duration // сколько ждать коммита события с пропущенным id
+}
+
+// OutboxConfig - публикация доменных событий из outbox для других сервисов
+type OutboxConfig struct {
+	RelayInterval time.Duration // как часто публикуются новые сообщения, 0 отключает
+	BatchSize     int
+	Retention     time.Duration // сколько хранятся опубликованные сообщения
+	PurgeInterval time.Duration // удаление опубликованных, 0 отключает
+	Publisher     string        // log или file
+	FilePath      string        // файл для publisher file, события дописываются строками JSON
+}
+
+// P2PConfig - переводы другому пользователю по номеру телефона
+type P2PConfig struct {
+	LookupLimit     int           // сколько поисков получателя по телефону разрешено отправителю за LookupWindow
+	LookupWindow    time.Duration // окно лимита, защищает от перебора номеров
+	ConfirmationTTL time.Duration // сколько действует подтверждение перевода
+}
+
+// UserServiceConfig - клиент user-service. Token - сервисный токен card-service
+// для внутренних методов user-service, задается только переменной окружения.
+// Пустой Address отключает переводы по номеру телефона.
+type UserServiceConfig struct {
+	Address string
+	Timeout time.Duration
+	Token   string // USER_SERVICE_TOKEN
+}
+
+// GiftCardConfig - подарочные карты. После MaxPINAttempts неверных PIN подряд код
+// перестает приниматься, 0 - без ограничения.
+type GiftCardConfig struct {
+	MaxPINAttempts int
+}
+
+// RiskConfig - оценка списаний перед проведением. Баллы сработавших правил складываются:
+// от ReviewScore списание уходит на разбор, от DenyScore отклоняется с блокировкой карты.
+// Правило с нулевыми баллами отключено.
+type RiskConfig struct {
+	Enabled     bool
+	ReviewScore int
+	DenyScore   int
+
+	VelocityMax    int // сколько списаний по карте допустимо за VelocityWindow
+	VelocityWindow time.Duration
+	VelocityScore  int
+
+	OutlierFactor     float64       // во сколько раз сумма больше средней по карте
+	OutlierMinHistory int           // сколько списаний нужно, чтобы считать среднее
+	OutlierLookback   time.Duration // за какой период считается среднее
+	OutlierScore      int
+
+	NewCardAge    time.Duration // карта моложе - новая
+	NewCardAmount float64       // крупная сумма для новой карты, в основных единицах валюты
+	NewCardScore  int
+
+	ManyCardsMax    int // сколько карт пользователь может добавить за ManyCardsWindow
+	ManyCardsWindow time.Duration
+	ManyCardsScore  int
+}
+
+// EncryptionConfig - мастер-ключи задаются только файлом или переменными окружения,
+// в config.yaml их нет: он копируется в образ вместе с сервисом
+type EncryptionConfig struct {
+	ActiveKeyVersion int
+	MasterKeyFile    string // файл со строками "<version>:<base64 key>"
+	MasterKeys       string // ENCRYPTION_MASTER_KEYS: "<version>:<base64 key>" через запятую
+	FingerprintKey   string // base64 ключ HMAC для отпечатков номеров карт
 }
 
 //type RateLimiterConfig struct {
@@ -77,10 +190,155 @@ func Load() (*Config, error) {
 	v.SetDefault("jwt.access_token_duration", "15m")
 	v.SetDefault("jwt.refresh_token_duration", "168h") // 7 дней
 
+	v.BindEnv("encryption.active_key_version", "ENCRYPTION_ACTIVE_KEY_VERSION")
+	v.BindEnv("encryption.master_key_file", "ENCRYPTION_MASTER_KEY_FILE")
+
+	v.SetDefault("card.default_currency", "RUB")
+	v.SetDefault("card.idempotency_ttl", "24h")
+	v.SetDefault("card.authorization_ttl", "24h")
+	v.SetDefault("card.authorization_sweep_interval", "1m")
+	v.SetDefault("encryption.active_key_version", 1)
+
+	v.SetDefault("scheduler.card_expiry_interval", "1h")
+	v.SetDefault("scheduler.expiry_notice_interval", "1h")
+	v.SetDefault("scheduler.expiry_notice_window", "720h") // 30 дней
+	v.SetDefault("scheduler.pending_sweep_interval", "1m")
+	v.SetDefault("scheduler.pending_timeout", "15m")
+	v.SetDefault("scheduler.scheduled_transfer_interval", "1m")
+	v.SetDefault("scheduler.scheduled_transfer_retries", 3)
+	v.SetDefault("scheduler.scheduled_transfer_retry_delay", "1h")
+	v.SetDefault("events.retention", "72h")
+	v.SetDefault("events.purge_interval", "1h")
+	v.SetDefault("events.poll_interval", "5s")
+	v.SetDefault("events.gap_timeout", "10s")
+
+	v.SetDefault("outbox.relay_interval", "1s")
+	v.SetDefault("outbox.batch_size", 100)
+	v.SetDefault("outbox.retention", "168h")
+	v.SetDefault("outbox.purge_interval", "1h")
+	v.SetDefault("outbox.publisher", "log")
+	v.BindEnv("outbox.publisher", "OUTBOX_PUBLISHER")
+	v.BindEnv("outbox.file_path", "OUTBOX_FILE_PATH")
+
+	v.SetDefault("risk.enabled", true)
+	v.SetDefault("risk.review_score", 50)
+	v.SetDefault("risk.deny_score", 100)
+	v.SetDefault("risk.velocity_max", 5)
+	v.SetDefault("risk.velocity_window", "1m")
+	v.SetDefault("risk.velocity_score", 60)
+	v.SetDefault("risk.outlier_factor", 5)
+	v.SetDefault("risk.outlier_min_history", 5)
+	v.SetDefault("risk.outlier_lookback", "2160h") // 90 дней
+	v.SetDefault("risk.outlier_score", 50)
+	v.SetDefault("risk.new_card_age", "24h")
+	v.SetDefault("risk.new_card_amount", 10000)
+	v.SetDefault("risk.new_card_score", 50)
+	v.SetDefault("risk.many_cards_max", 5)
+	v.SetDefault("risk.many_cards_window", "24h")
+	v.SetDefault("risk.many_cards_score", 50)
+
+	v.SetDefault("p2p.lookup_limit", 10)
+	v.SetDefault("p2p.lookup_window", "1h")
+	v.SetDefault("p2p.confirmation_ttl", "5m")
+	v.SetDefault("user_service.timeout", "3s")
+	v.BindEnv("user_service.address", "USER_SERVICE_ADDR")
+	v.SetDefault("gift_card.max_pin_attempts", 5)
+
 	v.SetDefault("rate_limit.requests_per_second", 100)
 	if err := v.ReadInConfig(); err != nil {
 		if _, ok := err.(viper.ConfigFileNotFoundError); !ok {
-			return nil, fmt.Errorf("Error reading config file:", err)
+			return nil, fmt.Errorf("error reading config file: %w", err)
+		}
+	}
+	idempotencyTTL, err := time.ParseDuration(v.GetString("card.idempotency_ttl"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid idempotency ttl: %v", err)
+	}
+	authorizationTTL, err := time.ParseDuration(v.GetString("card.authorization_ttl"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid authorization ttl: %v", err)
+	}
+	authorizationSweep, err := time.ParseDuration(v.GetString("card.authorization_sweep_interval"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid authorization sweep interval: %v", err)
+	}
+	scheduler := SchedulerConfig{
+		ScheduledTransferRetries: v.GetInt("scheduler.scheduled_transfer_retries"),
+	}
+	for key, dst := range map[string]*time.Duration{
+		"scheduler.card_expiry_interval":           &scheduler.CardExpiryInterval,
+		"scheduler.expiry_notice_interval":         &scheduler.ExpiryNoticeInterval,
+		"scheduler.expiry_notice_window":           &scheduler.ExpiryNoticeWindow,
+		"scheduler.pending_sweep_interval":         &scheduler.PendingSweepInterval,
+		"scheduler.pending_timeout":                &scheduler.PendingTimeout,
+		"scheduler.scheduled_transfer_interval":    &scheduler.ScheduledTransferInterval,
+		"scheduler.scheduled_transfer_retry_delay": &scheduler.ScheduledTransferRetryDelay,
+	} {
+		if *dst, err = time.ParseDuration(v.GetString(key)); err != nil {
+			return nil, fmt.Errorf("invalid %s: %v", key, err)
+		}
+	}
+	var events EventsConfig
+	for key, dst := range map[string]*time.Duration{
+		"events.retention":      &events.Retention,
+		"events.purge_interval": &events.PurgeInterval,
+		"events.poll_interval":  &events.PollInterval,
+		"events.gap_timeout":    &events.GapTimeout,
+	} {
+		if *dst, err = time.ParseDuration(v.GetString(key)); err != nil {
+			return nil, fmt.Errorf("invalid %s: %v", key, err)
+		}
+	}
+	outbox := OutboxConfig{
+		BatchSize: v.GetInt("outbox.batch_size"),
+		Publisher: v.GetString("outbox.publisher"),
+		FilePath:  v.GetString("outbox.file_path"),
+	}
+	for key, dst := range map[string]*time.Duration{
+		"outbox.relay_interval": &outbox.RelayInterval,
+		"outbox.retention":      &outbox.Retention,
+		"outbox.purge_interval": &outbox.PurgeInterval,
+	} {
+		if *dst, err = time.ParseDuration(v.GetString(key)); err != nil {
+			return nil, fmt.Errorf("invalid %s: %v", key, err)
+		}
+	}
+	risk := RiskConfig{
+		Enabled:           v.GetBool("risk.enabled"),
+		ReviewScore:       v.GetInt("risk.review_score"),
+		DenyScore:         v.GetInt("risk.deny_score"),
+		VelocityMax:       v.GetInt("risk.velocity_max"),
+		VelocityScore:     v.GetInt("risk.velocity_score"),
+		OutlierFactor:     v.GetFloat64("risk.outlier_factor"),
+		OutlierMinHistory: v.GetInt("risk.outlier_min_history"),
+		OutlierScore:      v.GetInt("risk.outlier_score"),
+		NewCardAmount:     v.GetFloat64("risk.new_card_amount"),
+		NewCardScore:      v.GetInt("risk.new_card_score"),
+		ManyCardsMax:      v.GetInt("risk.many_cards_max"),
+		ManyCardsScore:    v.GetInt("risk.many_cards_score"),
+	}
+	for key, dst := range map[string]*time.Duration{
+		"risk.velocity_window":   &risk.VelocityWindow,
+		"risk.outlier_lookback":  &risk.OutlierLookback,
+		"risk.new_card_age":      &risk.NewCardAge,
+		"risk.many_cards_window": &risk.ManyCardsWindow,
+	} {
+		if *dst, err = time.ParseDuration(v.GetString(key)); err != nil {
+			return nil, fmt.Errorf("invalid %s: %v", key, err)
+		}
+	}
+	p2p := P2PConfig{LookupLimit: v.GetInt("p2p.lookup_limit")}
+	userSvc := UserServiceConfig{
+		Address: v.GetString("user_service.address"),
+		Token:   os.Getenv("USER_SERVICE_TOKEN"),
+	}
+	for key, dst := range map[string]*time.Duration{
+		"p2p.lookup_window":    &p2p.LookupWindow,
+		"p2p.confirmation_ttl": &p2p.ConfirmationTTL,
+		"user_service.timeout": &userSvc.Timeout,
+	} {
+		if *dst, err = time.ParseDuration(v.GetString(key)); err != nil {
+			return nil, fmt.Errorf("invalid %s: %v", key, err)
 		}
 	}
 	accessDuration, err := time.ParseDuration(v.GetString("jwt.access_token_duration"))
@@ -111,7 +369,30 @@ func Load() (*Config, error) {
 			SecretKey:       v.GetString("jwt.secret_key"),
 			AccessTokenTTL:  int(accessDuration.Minutes()),
 			RefreshTokenTTL: int(refreshDuration.Minutes()),
+			ServiceTokens:   os.Getenv("CARD_SERVICE_TOKENS"),
+			ServiceScopes:   v.GetStringMapStringSlice("jwt.service_scopes"),
+			AdminTokens:     os.Getenv("CARD_ADMIN_TOKENS"),
+		},
+		Card: CardConfig{
+			DefaultCurrency: v.GetString("card.default_currency"),
+			IdempotencyTTL:  idempotencyTTL,
+
+			AuthorizationTTL:       authorizationTTL,
+			AuthorizationSweepTick: authorizationSweep,
+		},
+		Encryption: EncryptionConfig{
+			ActiveKeyVersion: v.GetInt("encryption.active_key_version"),
+			MasterKeyFile:    v.GetString("encryption.master_key_file"),
+			MasterKeys:       os.Getenv("ENCRYPTION_MASTER_KEYS"),
+			FingerprintKey:   os.Getenv("ENCRYPTION_FINGERPRINT_KEY"),
 		},
+		Scheduler:   scheduler,
+		Events:      events,
+		Risk:        risk,
+		Outbox:      outbox,
+		P2P:         p2p,
+		UserService: userSvc,
+		GiftCard:    GiftCardConfig{MaxPINAttempts: v.GetInt("gift_card.max_pin_attempts")},
 	}
 	return cfg, nil
 }
@@ -0,0 +1,168 @@
+// Package cron разбирает расписания в формате cron из пяти полей
+// (минута, час, день месяца, месяц, день недели) и считает следующий запуск.
+package cron
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var ErrInvalidSpec = errors.New("invalid cron expression")
+
+// Сокращения вместо пяти полей
+var descriptors = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// searchLimit - насколько вперед ищется запуск: расписание вроде "0 0 30 2 *" не сработает никогда
+const searchLimit = 5 // лет
+
+type field struct {
+	name     string
+	min, max int
+	names    map[string]int
+}
+
+var (
+	minuteField = field{name: "minute", min: 0, max: 59}
+	hourField   = field{name: "hour", min: 0, max: 23}
+	domField    = field{name: "day of month", min: 1, max: 31}
+	monthField  = field{name: "month", min: 1, max: 12, names: map[string]int{
+		"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6,
+		"jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12,
+	}}
+	// 0 и 7 - воскресенье
+	dowField = field{name: "day of week", min: 0, max: 7, names: map[string]int{
+		"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6,
+	}}
+)
+
+// Schedule - разобранное расписание. Значения полей хранятся битовыми масками.
+type Schedule struct {
+	minute, hour, dom, month, dow uint64
+	// Как в Vixie cron: если заданы и день месяца, и день недели,
+	// подходит день, совпавший хотя бы с одним из них
+	domAny, dowAny bool
+}
+
+// Parse разбирает выражение вида "0 9 * * MON" или сокращение (@daily, @weekly, ...)
+func Parse(spec string) (*Schedule, error) {
+	spec = strings.TrimSpace(spec)
+	if d, ok := descriptors[strings.ToLower(spec)]; ok {
+		spec = d
+	}
+	parts := strings.Fields(spec)
+	if len(parts) != 5 {
+		return nil, fmt.Errorf("%w: want 5 fields, got %d", ErrInvalidSpec, len(parts))
+	}
+	s := &Schedule{domAny: parts[2] == "*", dowAny: parts[4] == "*"}
+	var err error
+	for i, dst := range []*uint64{&s.minute, &s.hour, &s.dom, &s.month, &s.dow} {
+		f := []field{minuteField, hourField, domField, monthField, dowField}[i]
+		if *dst, err = f.parse(parts[i]); err != nil {
+			return nil, err
+		}
+	}
+	if s.dow&(1<<7) != 0 {
+		s.dow |= 1 // 7 - тоже воскресенье
+	}
+	return s, nil
+}
+
+// parse разбирает поле: список через запятую из *, N, N-M, каждое с необязательным /шагом
+func (f field) parse(expr string) (uint64, error) {
+	var bits uint64
+	for _, item := range strings.Split(expr, ",") {
+		rng, step := item, 1
+		if i := strings.IndexByte(item, '/'); i >= 0 {
+			n, err := strconv.Atoi(item[i+1:])
+			if err != nil || n <= 0 {
+				return 0, fmt.Errorf("%w: %s: bad step in %q", ErrInvalidSpec, f.name, item)
+			}
+			rng, step = item[:i], n
+		}
+		lo, hi := f.min, f.max
+		switch i := strings.IndexByte(rng, '-'); {
+		case rng == "*":
+		case i > 0:
+			var err error
+			if lo, err = f.value(rng[:i]); err != nil {
+				return 0, err
+			}
+			if hi, err = f.value(rng[i+1:]); err != nil {
+				return 0, err
+			}
+			if lo > hi {
+				return 0, fmt.Errorf("%w: %s: empty range %q", ErrInvalidSpec, f.name, rng)
+			}
+		default:
+			v, err := f.value(rng)
+			if err != nil {
+				return 0, err
+			}
+			lo = v
+			if step == 1 {
+				hi = v // "5/15" - с 5 до конца диапазона, "5" - только 5
+			}
+		}
+		for v := lo; v <= hi; v += step {
+			bits |= 1 << uint(v)
+		}
+	}
+	return bits, nil
+}
+
+func (f field) value(s string) (int, error) {
+	if v, ok := f.names[strings.ToLower(s)]; ok {
+		return v, nil
+	}
+	v, err := strconv.Atoi(s)
+	if err != nil || v < f.min || v > f.max {
+		return 0, fmt.Errorf("%w: %s: %q is out of range %d-%d", ErrInvalidSpec, f.name, s, f.min, f.max)
+	}
+	return v, nil
+}
+
+// Next возвращает первый запуск строго после after в часовом поясе after.
+// Нулевое время - расписание не срабатывает в ближайшие годы.
+func (s *Schedule) Next(after time.Time) time.Time {
+	loc := after.Location()
+	t := after.Truncate(time.Minute).Add(time.Minute)
+	limit := t.AddDate(searchLimit, 0, 0)
+	for t.Before(limit) {
+		switch {
+		case !has(s.month, int(t.Month())):
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, loc)
+		case !s.dayMatches(t):
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, loc)
+		case !has(s.hour, t.Hour()):
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, loc)
+		case !has(s.minute, t.Minute()):
+			t = t.Add(time.Minute)
+		default:
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+func (s *Schedule) dayMatches(t time.Time) bool {
+	dom, dow := has(s.dom, t.Day()), has(s.dow, int(t.Weekday()))
+	if s.domAny || s.dowAny {
+		return dom && dow
+	}
+	return dom || dow
+}
+
+func has(bits uint64, v int) bool {
+	return bits&(1<<uint(v)) != 0
+}
@@ -0,0 +1,53 @@
+package cron
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestNext(t *testing.T) {
+	moscow := time.FixedZone("MSK", 3*60*60)
+	// 2026-03-02 - понедельник
+	base := time.Date(2026, 3, 2, 10, 30, 15, 0, moscow)
+	tests := []struct {
+		spec string
+		want time.Time
+	}{
+		{"* * * * *", time.Date(2026, 3, 2, 10, 31, 0, 0, moscow)},
+		{"0 9 * * MON", time.Date(2026, 3, 9, 9, 0, 0, 0, moscow)},
+		{"0 12 * * 1", time.Date(2026, 3, 2, 12, 0, 0, 0, moscow)},
+		{"*/15 * * * *", time.Date(2026, 3, 2, 10, 45, 0, 0, moscow)},
+		{"5/20 10 * * *", time.Date(2026, 3, 2, 10, 45, 0, 0, moscow)},
+		{"0 0 1 * *", time.Date(2026, 4, 1, 0, 0, 0, 0, moscow)},
+		{"0 0 31 * *", time.Date(2026, 3, 31, 0, 0, 0, 0, moscow)},
+		{"0 0 29 2 *", time.Date(2028, 2, 29, 0, 0, 0, 0, moscow)},
+		{"0 8 * * sat,7", time.Date(2026, 3, 7, 8, 0, 0, 0, moscow)},
+		{"0 8-10 * * 1-5", time.Date(2026, 3, 3, 8, 0, 0, 0, moscow)},
+		// День месяца или день недели: 15-е число или пятница
+		{"0 0 15 * 5", time.Date(2026, 3, 6, 0, 0, 0, 0, moscow)},
+		{"@daily", time.Date(2026, 3, 3, 0, 0, 0, 0, moscow)},
+		{"@weekly", time.Date(2026, 3, 8, 0, 0, 0, 0, moscow)},
+		{"0 0 30 2 *", time.Time{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			s, err := Parse(tt.spec)
+			if err != nil {
+				t.Fatalf("Parse: %v", err)
+			}
+			if got := s.Next(base); !got.Equal(tt.want) {
+				t.Errorf("Next = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	for _, spec := range []string{"", "* * * *", "60 * * * *", "* 24 * * *", "* * 0 * *", "* * * 13 *",
+		"* * * * 8", "*/0 * * * *", "10-5 * * * *", "* * * * funday", "@every 5m"} {
+		if _, err := Parse(spec); !errors.Is(err, ErrInvalidSpec) {
+			t.Errorf("Parse(%q) err = %v, want ErrInvalidSpec", spec, err)
+		}
+	}
+}
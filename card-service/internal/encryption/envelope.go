@@ -0,0 +1,251 @@
+package encryption
+
+import (
+	"bufio"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/mrevds/pizza-app/card-service/internal/config"
+)
+
+const keySize = 32 // AES-256
+
+var ErrUnknownKeyVersion = errors.New("unknown master key version")
+
+// Sealed - зашифрованные данные вместе с обернутым ключом данных.
+// Ciphertext шифруется ключом данных (DEK), WrappedKey - это DEK,
+// зашифрованный мастер-ключом версии KeyVersion.
+type Sealed struct {
+	Ciphertext []byte
+	WrappedKey []byte
+	KeyVersion int
+}
+
+// Envelope реализует envelope encryption: у каждой записи свой ключ данных,
+// который хранится рядом с данными в зашифрованном мастер-ключом виде.
+// Ротация мастер-ключа требует только переобернуть ключи данных.
+type Envelope struct {
+	masterKeys     map[int][]byte
+	activeVersion  int
+	fingerprintKey []byte
+}
+
+// NewEnvelope берет мастер-ключи из файла ENCRYPTION_MASTER_KEY_FILE или из
+// ENCRYPTION_MASTER_KEYS. Без ключей сервис не запускается: встроенных ключей нет.
+func NewEnvelope(cfg *config.Config) (*Envelope, error) {
+	var (
+		keys map[int][]byte
+		err  error
+	)
+	switch {
+	case cfg.Encryption.MasterKeyFile != "" && cfg.Encryption.MasterKeys != "":
+		return nil, errors.New("master keys are set both in ENCRYPTION_MASTER_KEY_FILE and ENCRYPTION_MASTER_KEYS, use one of them")
+	case cfg.Encryption.MasterKeyFile != "":
+		keys, err = loadKeyFile(cfg.Encryption.MasterKeyFile)
+	case cfg.Encryption.MasterKeys != "":
+		keys, err = parseKeys(strings.NewReader(strings.ReplaceAll(cfg.Encryption.MasterKeys, ",", "\n")))
+		if err != nil {
+			err = fmt.Errorf("ENCRYPTION_MASTER_KEYS: %w", err)
+		}
+	default:
+		return nil, errors.New("master keys are not configured: set ENCRYPTION_MASTER_KEY_FILE or ENCRYPTION_MASTER_KEYS")
+	}
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := keys[cfg.Encryption.ActiveKeyVersion]; !ok {
+		return nil, fmt.Errorf("active master key v%d is not configured", cfg.Encryption.ActiveKeyVersion)
+	}
+
+	if cfg.Encryption.FingerprintKey == "" {
+		return nil, errors.New("fingerprint key is not configured: set ENCRYPTION_FINGERPRINT_KEY")
+	}
+	fingerprintKey, err := decodeKey(cfg.Encryption.FingerprintKey)
+	if err != nil {
+		return nil, fmt.Errorf("fingerprint key: %w", err)
+	}
+
+	return &Envelope{
+		masterKeys:     keys,
+		activeVersion:  cfg.Encryption.ActiveKeyVersion,
+		fingerprintKey: fingerprintKey,
+	}, nil
+}
+
+// ActiveVersion - версия мастер-ключа, которой оборачиваются новые ключи данных
+func (e *Envelope) ActiveVersion() int {
+	return e.activeVersion
+}
+
+// Encrypt шифрует plaintext новым ключом данных и оборачивает его активным мастер-ключом
+func (e *Envelope) Encrypt(plaintext []byte) (*Sealed, error) {
+	dek := make([]byte, keySize)
+	if _, err := io.ReadFull(rand.Reader, dek); err != nil {
+		return nil, fmt.Errorf("failed to generate data key: %w", err)
+	}
+
+	ciphertext, err := seal(dek, plaintext, nil)
+	if err != nil {
+		return nil, err
+	}
+	wrapped, err := e.wrap(dek, e.activeVersion)
+	if err != nil {
+		return nil, err
+	}
+	return &Sealed{Ciphertext: ciphertext, WrappedKey: wrapped, KeyVersion: e.activeVersion}, nil
+}
+
+// Decrypt расшифровывает данные, предварительно развернув ключ данных
+func (e *Envelope) Decrypt(s *Sealed) ([]byte, error) {
+	dek, err := e.unwrap(s.WrappedKey, s.KeyVersion)
+	if err != nil {
+		return nil, err
+	}
+	return open(dek, s.Ciphertext, nil)
+}
+
+// Rewrap переоборачивает ключ данных активным мастер-ключом.
+// Сами данные при этом не перешифровываются.
+func (e *Envelope) Rewrap(wrappedKey []byte, version int) ([]byte, error) {
+	dek, err := e.unwrap(wrappedKey, version)
+	if err != nil {
+		return nil, err
+	}
+	return e.wrap(dek, e.activeVersion)
+}
+
+// Fingerprint - детерминированный keyed-HMAC отпечаток, позволяющий искать
+// одинаковые значения без хранения их в открытом виде
+func (e *Envelope) Fingerprint(value string) string {
+	mac := hmac.New(sha256.New, e.fingerprintKey)
+	mac.Write([]byte(value))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func (e *Envelope) wrap(dek []byte, version int) ([]byte, error) {
+	master, ok := e.masterKeys[version]
+	if !ok {
+		return nil, ErrUnknownKeyVersion
+	}
+	return seal(master, dek, versionAAD(version))
+}
+
+func (e *Envelope) unwrap(wrapped []byte, version int) ([]byte, error) {
+	master, ok := e.masterKeys[version]
+	if !ok {
+		return nil, ErrUnknownKeyVersion
+	}
+	return open(master, wrapped, versionAAD(version))
+}
+
+// versionAAD привязывает обернутый ключ к версии мастер-ключа
+func versionAAD(version int) []byte {
+	aad := make([]byte, 8)
+	binary.BigEndian.PutUint64(aad, uint64(version))
+	return aad
+}
+
+// seal шифрует AES-GCM и возвращает nonce||ciphertext
+func seal(key, plaintext, aad []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	return gcm.Seal(nonce, nonce, plaintext, aad), nil
+}
+
+func open(key, data, aad []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < gcm.NonceSize() {
+		return nil, errors.New("ciphertext too short")
+	}
+	nonce, ciphertext := data[:gcm.NonceSize()], data[gcm.NonceSize():]
+	plaintext, err := gcm.Open(nil, nonce, ciphertext, aad)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt: %w", err)
+	}
+	return plaintext, nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+func decodeKey(encoded string) ([]byte, error) {
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+	if err != nil {
+		return nil, fmt.Errorf("key must be base64: %w", err)
+	}
+	if len(key) != keySize {
+		return nil, fmt.Errorf("key must be %d bytes, got %d", keySize, len(key))
+	}
+	return key, nil
+}
+
+// loadKeyFile читает файл мастер-ключей
+func loadKeyFile(path string) (map[int][]byte, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open master key file: %w", err)
+	}
+	defer f.Close()
+
+	keys, err := parseKeys(f)
+	if err != nil {
+		return nil, fmt.Errorf("master key file: %w", err)
+	}
+	return keys, nil
+}
+
+// parseKeys разбирает строки вида "<version>:<base64 key>".
+// Пустые строки и строки, начинающиеся с #, пропускаются.
+func parseKeys(r io.Reader) (map[int][]byte, error) {
+	keys := make(map[int][]byte)
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		versionStr, encoded, ok := strings.Cut(text, ":")
+		if !ok {
+			return nil, fmt.Errorf("line %d: expected <version>:<key>", line)
+		}
+		version, err := strconv.Atoi(strings.TrimSpace(versionStr))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid version: %w", line, err)
+		}
+		key, err := decodeKey(encoded)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		keys[version] = key
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read master keys: %w", err)
+	}
+	return keys, nil
+}
@@ -0,0 +1,167 @@
+package encryption
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mrevds/pizza-app/card-service/internal/config"
+)
+
+func testKey(b byte) string {
+	return base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{b}, keySize))
+}
+
+func newTestEnvelope(t *testing.T, active int, keys string) *Envelope {
+	t.Helper()
+	cfg := &config.Config{Encryption: config.EncryptionConfig{
+		ActiveKeyVersion: active,
+		MasterKeys:       keys,
+		FingerprintKey:   testKey(0xf0),
+	}}
+	e, err := NewEnvelope(cfg)
+	if err != nil {
+		t.Fatalf("NewEnvelope: %v", err)
+	}
+	return e
+}
+
+func TestEnvelopeRoundTrip(t *testing.T) {
+	e := newTestEnvelope(t, 1, "1:"+testKey(1))
+	for _, plaintext := range [][]byte{
+		[]byte("4111111111111111"),
+		[]byte(""),
+		bytes.Repeat([]byte("x"), 4096),
+	} {
+		sealed, err := e.Encrypt(plaintext)
+		if err != nil {
+			t.Fatalf("Encrypt(%d bytes): %v", len(plaintext), err)
+		}
+		if sealed.KeyVersion != 1 {
+			t.Fatalf("Encrypt: key version %d, want 1", sealed.KeyVersion)
+		}
+		if len(plaintext) > 0 && bytes.Contains(sealed.Ciphertext, plaintext) {
+			t.Fatalf("Encrypt: ciphertext contains plaintext")
+		}
+		got, err := e.Decrypt(sealed)
+		if err != nil {
+			t.Fatalf("Decrypt(%d bytes): %v", len(plaintext), err)
+		}
+		if !bytes.Equal(got, plaintext) {
+			t.Fatalf("Decrypt = %q, want %q", got, plaintext)
+		}
+	}
+
+	// У каждой записи свой ключ данных и nonce
+	a, _ := e.Encrypt([]byte("4111111111111111"))
+	b, _ := e.Encrypt([]byte("4111111111111111"))
+	if bytes.Equal(a.Ciphertext, b.Ciphertext) || bytes.Equal(a.WrappedKey, b.WrappedKey) {
+		t.Fatalf("Encrypt of the same plaintext produced the same ciphertext or wrapped key")
+	}
+}
+
+func TestEnvelopeTamper(t *testing.T) {
+	e := newTestEnvelope(t, 1, "1:"+testKey(1)+",2:"+testKey(2))
+	sealed, err := e.Encrypt([]byte("4111111111111111"))
+	if err != nil {
+		t.Fatalf("Encrypt: %v", err)
+	}
+	flip := func(b []byte, i int) []byte {
+		c := append([]byte(nil), b...)
+		c[i] ^= 0x01
+		return c
+	}
+
+	cases := []struct {
+		name   string
+		sealed Sealed
+	}{
+		{"ciphertext bit", Sealed{Ciphertext: flip(sealed.Ciphertext, len(sealed.Ciphertext)-1), WrappedKey: sealed.WrappedKey, KeyVersion: 1}},
+		{"nonce bit", Sealed{Ciphertext: flip(sealed.Ciphertext, 0), WrappedKey: sealed.WrappedKey, KeyVersion: 1}},
+		{"wrapped key bit", Sealed{Ciphertext: sealed.Ciphertext, WrappedKey: flip(sealed.WrappedKey, len(sealed.WrappedKey)-1), KeyVersion: 1}},
+		// Обернутый ключ привязан к версии мастер-ключа через AAD
+		{"other key version", Sealed{Ciphertext: sealed.Ciphertext, WrappedKey: sealed.WrappedKey, KeyVersion: 2}},
+		{"truncated", Sealed{Ciphertext: sealed.Ciphertext[:4], WrappedKey: sealed.WrappedKey, KeyVersion: 1}},
+	}
+	for _, tc := range cases {
+		if _, err := e.Decrypt(&tc.sealed); err == nil {
+			t.Fatalf("Decrypt with %s: no error", tc.name)
+		}
+	}
+
+	if _, err := e.Decrypt(&Sealed{Ciphertext: sealed.Ciphertext, WrappedKey: sealed.WrappedKey, KeyVersion: 3}); !errors.Is(err, ErrUnknownKeyVersion) {
+		t.Fatalf("Decrypt with unknown version: %v, want ErrUnknownKeyVersion", err)
+	}
+}
+
+func TestEnvelopeRewrap(t *testing.T) {
+	keys := "1:" + testKey(1) + ",2:" + testKey(2)
+	old := newTestEnvelope(t, 1, keys)
+	sealed, err := old.Encrypt([]byte("5555555555554444"))
+	if err != nil {
+		t.Fatalf("Encrypt: %v", err)
+	}
+
+	rotated := newTestEnvelope(t, 2, keys)
+	wrapped, err := rotated.Rewrap(sealed.WrappedKey, sealed.KeyVersion)
+	if err != nil {
+		t.Fatalf("Rewrap: %v", err)
+	}
+	got, err := rotated.Decrypt(&Sealed{Ciphertext: sealed.Ciphertext, WrappedKey: wrapped, KeyVersion: 2})
+	if err != nil {
+		t.Fatalf("Decrypt after rewrap: %v", err)
+	}
+	if string(got) != "5555555555554444" {
+		t.Fatalf("Decrypt after rewrap = %q", got)
+	}
+}
+
+func TestFingerprint(t *testing.T) {
+	e := newTestEnvelope(t, 1, "1:"+testKey(1))
+	if e.Fingerprint("4111111111111111") != e.Fingerprint("4111111111111111") {
+		t.Fatalf("Fingerprint is not deterministic")
+	}
+	if e.Fingerprint("4111111111111111") == e.Fingerprint("4111111111111112") {
+		t.Fatalf("Fingerprint of different values is equal")
+	}
+}
+
+func TestNewEnvelopeKeys(t *testing.T) {
+	dir := t.TempDir()
+	keyFile := filepath.Join(dir, "keys")
+	content := "# ключи\n\n2:" + testKey(2) + "\n"
+	if err := os.WriteFile(keyFile, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		name    string
+		cfg     config.EncryptionConfig
+		wantErr bool
+	}{
+		{"env keys", config.EncryptionConfig{ActiveKeyVersion: 2, MasterKeys: "1:" + testKey(1) + ", 2:" + testKey(2)}, false},
+		{"key file", config.EncryptionConfig{ActiveKeyVersion: 2, MasterKeyFile: keyFile}, false},
+		// Встроенных ключей нет, без файла и переменной сервис не запускается
+		{"no keys", config.EncryptionConfig{ActiveKeyVersion: 1}, true},
+		{"file and env", config.EncryptionConfig{ActiveKeyVersion: 2, MasterKeyFile: keyFile, MasterKeys: "2:" + testKey(2)}, true},
+		{"missing active key", config.EncryptionConfig{ActiveKeyVersion: 2, MasterKeys: "1:" + testKey(1)}, true},
+		{"short key", config.EncryptionConfig{ActiveKeyVersion: 1, MasterKeys: "1:" + base64.StdEncoding.EncodeToString([]byte("short"))}, true},
+		{"not base64", config.EncryptionConfig{ActiveKeyVersion: 1, MasterKeys: "1:not base64!"}, true},
+		{"no version", config.EncryptionConfig{ActiveKeyVersion: 1, MasterKeys: testKey(1)}, true},
+		{"missing key file", config.EncryptionConfig{ActiveKeyVersion: 1, MasterKeyFile: filepath.Join(dir, "none")}, true},
+	}
+	for _, tc := range cases {
+		tc.cfg.FingerprintKey = testKey(0xf0)
+		_, err := NewEnvelope(&config.Config{Encryption: tc.cfg})
+		if (err != nil) != tc.wantErr {
+			t.Fatalf("NewEnvelope(%s): err = %v, want error %v", tc.name, err, tc.wantErr)
+		}
+	}
+
+	if _, err := NewEnvelope(&config.Config{Encryption: config.EncryptionConfig{ActiveKeyVersion: 1, MasterKeys: "1:" + testKey(1)}}); err == nil {
+		t.Fatalf("NewEnvelope without fingerprint key: no error")
+	}
+}
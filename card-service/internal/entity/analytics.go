@@ -0,0 +1,28 @@
+package entity
+
+import "time"
+
+// DailyTotal - сумма и число успешных транзакций карты одного типа и категории за день (UTC).
+// В выборке по нескольким картам CardID = 0.
+type DailyTotal struct {
+	CardID          int64     `json:"card_id" db:"card_id"`
+	Day             time.Time `json:"day" db:"day"`
+	TransactionType string    `json:"transaction_type" db:"transaction_type"`
+	Category        string    `json:"category" db:"category"`
+	Currency        string    `json:"currency" db:"currency"`
+	Amount          int64     `json:"amount" db:"amount_minor"`
+	Count           int       `json:"count" db:"tx_count"`
+}
+
+// DailyBalance - баланс карты на конец дня (UTC)
+type DailyBalance struct {
+	CardID            int64     `json:"card_id" db:"card_id"`
+	Day               time.Time `json:"day" db:"day"`
+	Balance           int64     `json:"balance" db:"balance_minor"`
+	LastTransactionID int64     `json:"last_transaction_id" db:"last_transaction_id"` // транзакция, давшая баланс
+}
+
+// DayOf - день UTC, к которому относится момент t
+func DayOf(t time.Time) time.Time {
+	return t.UTC().Truncate(24 * time.Hour)
+}
@@ -0,0 +1,34 @@
+package entity
+
+import "time"
+
+// Authorization - холд под заказ: резервирует сумму на карте до списания (capture)
+// или снятия (void). Уменьшает доступный баланс, в журнал не попадает.
+type Authorization struct {
+	ID          int64     `json:"id" db:"id"`
+	CardID      int64     `json:"card_id" db:"card_id"`
+	UserID      int64     `json:"user_id" db:"user_id"`
+	OrderID     string    `json:"order_id" db:"order_id"`
+	Amount      int64     `json:"amount" db:"amount_minor"`
+	Captured    int64     `json:"captured" db:"captured_minor"`
+	Currency    string    `json:"currency" db:"currency"`
+	Status      string    `json:"status" db:"status"`
+	Description string    `json:"description" db:"description"`
+	Category    string    `json:"category" db:"category"`
+	ExpiresAt   time.Time `json:"expires_at" db:"expires_at"`
+	CreatedAt   time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// Статусы холда
+const (
+	AuthorizationStatusActive   = "active"
+	AuthorizationStatusCaptured = "captured"
+	AuthorizationStatusVoided   = "voided"
+	AuthorizationStatusExpired  = "expired"
+)
+
+// IsActive - холд действует: не списан, не снят и не истек
+func (a *Authorization) IsActive(now time.Time) bool {
+	return a.Status == AuthorizationStatusActive && now.Before(a.ExpiresAt)
+}
@@ -1 +1,115 @@
 package entity
+
+import "time"
+
+type Card struct {
+	ID               int64      `json:"id" db:"id"`
+	UserID           int64      `json:"user_id" db:"user_id"`
+	CardNumberMasked string     `json:"card_number_masked" db:"card_number_masked"`
+	CardHolderName   string     `json:"card_holder_name" db:"card_holder_name"`
+	ExpiryDate       string     `json:"expiry_date" db:"expiry_date"`
+	CardType         string     `json:"card_type" db:"card_type"`
+	Balance          int64      `json:"balance" db:"balance_minor"` // в минимальных единицах валюты
+	Held             int64      `json:"held" db:"-"`                // сумма активных холдов, считается запросом
+	Currency         string     `json:"currency" db:"currency"`
+	Status           string     `json:"status" db:"status"`
+	IsActive         bool       `json:"is_active" db:"is_active"`
+	IsBlocked        bool       `json:"is_blocked" db:"is_blocked"`
+	BlockReason      string     `json:"block_reason" db:"block_reason"`
+	CreatedAt        time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt        time.Time  `json:"updated_at" db:"updated_at"`
+	DeletedAt        *time.Time `json:"-" db:"deleted_at"`
+
+	// Зашифрованный номер карты, наружу не отдается
+	PAN *EncryptedPAN `json:"-"`
+}
+
+// Available - баланс, доступный для списаний: баланс за вычетом холдов
+func (c *Card) Available() int64 {
+	return c.Balance - c.Held
+}
+
+// EncryptedPAN - полный номер карты в зашифрованном виде (envelope encryption)
+type EncryptedPAN struct {
+	Ciphertext  []byte `db:"pan_ciphertext"`
+	WrappedKey  []byte `db:"pan_wrapped_key"`
+	KeyVersion  int    `db:"pan_key_version"`
+	Fingerprint string `db:"pan_fingerprint"`
+}
+
+// CardKey - обернутый ключ данных карты, нужен для ротации мастер-ключа
+type CardKey struct {
+	CardID     int64
+	WrappedKey []byte
+	KeyVersion int
+}
+
+type Transaction struct {
+	ID              int64     `json:"id" db:"id"`
+	CardID          int64     `json:"card_id" db:"card_id"`
+	TransactionType string    `json:"transaction_type" db:"transaction_type"`
+	Amount          int64     `json:"amount" db:"amount_minor"` // суммы в минимальных единицах валюты
+	BalanceBefore   int64     `json:"balance_before" db:"balance_before_minor"`
+	BalanceAfter    int64     `json:"balance_after" db:"balance_after_minor"`
+	Currency        string    `json:"currency" db:"currency"`
+	Description     string    `json:"description" db:"description"`
+	Status          string    `json:"status" db:"status"`
+	OrderID         string    `json:"order_id" db:"order_id"`
+	Category        string    `json:"category" db:"category"`                               // категория заказа или мерчант оплаты, у возврата - оплаты
+	JournalEntryID  int64     `json:"journal_entry_id" db:"journal_entry_id"`               // запись главной книги, 0 у старых транзакций
+	OriginalTxID    int64     `json:"original_transaction_id" db:"original_transaction_id"` // у refund - исходная оплата
+	CreatedAt       time.Time `json:"created_at" db:"created_at"`
+}
+
+// Типы транзакций
+const (
+	TransactionTypeDeposit     = "deposit"
+	TransactionTypeWithdraw    = "withdraw"
+	TransactionTypeTransferIn  = "transfer_in"
+	TransactionTypeTransferOut = "transfer_out"
+	TransactionTypePayment     = "payment"
+	TransactionTypeRefund      = "refund"
+
+	// Исправления сверки: приводят историю карты к главной книге
+	TransactionTypeAdjustmentCredit = "adjustment_credit"
+	TransactionTypeAdjustmentDebit  = "adjustment_debit"
+)
+
+// Статусы транзакций
+const (
+	TransactionStatusPending  = "pending"
+	TransactionStatusSuccess  = "success"
+	TransactionStatusFailed   = "failed"
+	TransactionStatusReversed = "reversed" // операция отменена, возврат по ней невозможен
+)
+
+// IsTransactionType проверяет что t - известный тип транзакции
+func IsTransactionType(t string) bool {
+	switch t {
+	case TransactionTypeDeposit, TransactionTypeWithdraw, TransactionTypeTransferIn,
+		TransactionTypeTransferOut, TransactionTypePayment, TransactionTypeRefund,
+		TransactionTypeAdjustmentCredit, TransactionTypeAdjustmentDebit:
+		return true
+	}
+	return false
+}
+
+// IsTransactionStatus проверяет что status - известный статус транзакции
+func IsTransactionStatus(status string) bool {
+	switch status {
+	case TransactionStatusPending, TransactionStatusSuccess, TransactionStatusFailed, TransactionStatusReversed:
+		return true
+	}
+	return false
+}
+
+// IdempotencyRecord - сохраненный результат операции по ключу идемпотентности пользователя
+type IdempotencyRecord struct {
+	UserID      int64     `db:"user_id"`
+	Key         string    `db:"key"`
+	Operation   string    `db:"operation"`
+	Fingerprint string    `db:"fingerprint"` // sha256 запроса без ключа
+	Response    []byte    `db:"response"`    // JSON результата операции
+	CreatedAt   time.Time `db:"created_at"`
+	ExpiresAt   time.Time `db:"expires_at"`
+}
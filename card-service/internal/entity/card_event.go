@@ -0,0 +1,38 @@
+package entity
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Типы событий карты для WatchCardEvents
+const (
+	CardEventTransactionCreated = "transaction_created" // payload - Transaction
+	CardEventBalanceChanged     = "balance_changed"     // payload - BalanceEvent
+	CardEventStatusChanged      = "card_status_changed" // payload - StatusEvent, в том числе блокировка
+)
+
+// CardEvent - событие по карте пользователя. Пишется в той же транзакции, что и
+// изменение, id растет и служит точкой возобновления подписки.
+type CardEvent struct {
+	ID        int64           `json:"id" db:"id"`
+	UserID    int64           `json:"user_id" db:"user_id"`
+	CardID    int64           `json:"card_id" db:"card_id"`
+	Type      string          `json:"type" db:"event_type"`
+	Payload   json.RawMessage `json:"payload" db:"payload"`
+	CreatedAt time.Time       `json:"created_at" db:"created_at"`
+}
+
+// BalanceEvent - баланс карты после изменения, суммы в минимальных единицах
+type BalanceEvent struct {
+	Balance  int64  `json:"balance"`
+	Held     int64  `json:"held"`
+	Currency string `json:"currency"`
+}
+
+// StatusEvent - новый статус карты
+type StatusEvent struct {
+	Status      string `json:"status"`
+	BlockReason string `json:"block_reason,omitempty"`
+	Actor       string `json:"actor"`
+}
@@ -0,0 +1,63 @@
+package entity
+
+import "time"
+
+// Статусы карты. is_active/is_blocked в таблице cards выводятся из статуса.
+const (
+	CardStatusActive         = "active"
+	CardStatusBlockedByUser  = "blocked_by_user"
+	CardStatusBlockedByFraud = "blocked_by_fraud" // блокировка банком: админом или автоматикой
+	CardStatusExpired        = "expired"
+	CardStatusClosed         = "closed"
+)
+
+// Кто меняет статус карты
+const (
+	ActorUser   = "user"
+	ActorAdmin  = "admin"
+	ActorSystem = "system"
+)
+
+// cardStatusTransitions - допустимые переходы статусов. Из closed выхода нет,
+// expired можно только закрыть.
+var cardStatusTransitions = map[string][]string{
+	CardStatusActive:         {CardStatusBlockedByUser, CardStatusBlockedByFraud, CardStatusExpired, CardStatusClosed},
+	CardStatusBlockedByUser:  {CardStatusActive, CardStatusBlockedByFraud, CardStatusExpired, CardStatusClosed},
+	CardStatusBlockedByFraud: {CardStatusActive, CardStatusExpired, CardStatusClosed},
+	CardStatusExpired:        {CardStatusClosed},
+}
+
+// IsCardStatus проверяет что status - известный статус карты
+func IsCardStatus(status string) bool {
+	_, ok := cardStatusTransitions[status]
+	return ok || status == CardStatusClosed
+}
+
+// CanTransition проверяет допустимость перехода статуса карты from -> to
+func CanTransition(from, to string) bool {
+	for _, s := range cardStatusTransitions[from] {
+		if s == to {
+			return true
+		}
+	}
+	return false
+}
+
+// IsBlockedStatus - карта заблокирована пользователем или банком
+func IsBlockedStatus(status string) bool {
+	return status == CardStatusBlockedByUser || status == CardStatusBlockedByFraud
+}
+
+// CardStatusChange - запись истории статусов карты. История только дописывается.
+// FromStatus пустой у записи о добавлении карты.
+type CardStatusChange struct {
+	ID         int64     `json:"id" db:"id"`
+	CardID     int64     `json:"card_id" db:"card_id"`
+	FromStatus string    `json:"from_status" db:"from_status"`
+	ToStatus   string    `json:"to_status" db:"to_status"`
+	Actor      string    `json:"actor" db:"actor"`
+	ActorID    int64     `json:"actor_id" db:"actor_id"`     // id пользователя, 0 у admin и system
+	ActorName  string    `json:"actor_name" db:"actor_name"` // имя админа из его токена
+	Reason     string    `json:"reason" db:"reason"`
+	CreatedAt  time.Time `json:"created_at" db:"created_at"`
+}
@@ -0,0 +1,28 @@
+package entity
+
+import "testing"
+
+func TestCardStatusTransitions(t *testing.T) {
+	cases := []struct {
+		from, to string
+		want     bool
+	}{
+		{CardStatusActive, CardStatusBlockedByUser, true},
+		{CardStatusBlockedByUser, CardStatusActive, true},
+		{CardStatusBlockedByFraud, CardStatusActive, true},
+		{CardStatusBlockedByFraud, CardStatusBlockedByUser, false},
+		{CardStatusExpired, CardStatusActive, false},
+		{CardStatusExpired, CardStatusClosed, true},
+		{CardStatusClosed, CardStatusActive, false},
+		{CardStatusActive, CardStatusActive, false},
+		{"", CardStatusActive, false},
+	}
+	for _, tc := range cases {
+		if got := CanTransition(tc.from, tc.to); got != tc.want {
+			t.Errorf("CanTransition(%q, %q) = %v, want %v", tc.from, tc.to, got, tc.want)
+		}
+	}
+	if !IsCardStatus(CardStatusClosed) || IsCardStatus("frozen") {
+		t.Error("IsCardStatus: unexpected result")
+	}
+}
@@ -0,0 +1,25 @@
+package entity
+
+import "time"
+
+// CardTypeGift - подарочная карта: номера нет, оплачивается кодом и PIN или,
+// после погашения, как карта пользователя
+const CardTypeGift = "gift"
+
+// GiftCard - код и PIN подарочной карты CardID. Код и PIN в открытом виде
+// показываются только при выпуске. До погашения у карты нет владельца (user_id = 0).
+type GiftCard struct {
+	CardID            int64      `json:"card_id" db:"card_id"`
+	BatchID           string     `json:"batch_id" db:"batch_id"` // партия выпуска для партнера
+	CodeFingerprint   string     `json:"-" db:"code_fingerprint"`
+	PINHash           string     `json:"-" db:"pin_hash"`
+	FailedPINAttempts int        `json:"failed_pin_attempts" db:"failed_pin_attempts"` // неверных PIN подряд
+	RedeemedBy        int64      `json:"redeemed_by" db:"redeemed_by"`
+	RedeemedAt        *time.Time `json:"redeemed_at" db:"redeemed_at"`
+	CreatedAt         time.Time  `json:"created_at" db:"created_at"`
+}
+
+// Redeemed - карта уже привязана к пользователю
+func (g *GiftCard) Redeemed() bool {
+	return g.RedeemedAt != nil
+}
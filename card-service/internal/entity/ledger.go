@@ -0,0 +1,87 @@
+package entity
+
+import "time"
+
+// Виды счетов главной книги. У каждой карты свой счет, системные счета - по одному на валюту.
+const (
+	AccountKindCard               = "card"
+	AccountKindCashIn             = "cash_in"             // внешние деньги: пополнения и снятия
+	AccountKindMerchantSettlement = "merchant_settlement" // расчеты с мерчантами по оплатам заказов
+	AccountKindFees               = "fees"                // комиссии сервиса
+	AccountKindGiftCardSales      = "gift_card_sales"     // деньги за проданные подарочные карты
+)
+
+// Стороны проводки
+const (
+	PostingDebit  = "debit"
+	PostingCredit = "credit"
+)
+
+// Операции журнала
+const (
+	JournalOperationDeposit        = "deposit"
+	JournalOperationWithdraw       = "withdraw"
+	JournalOperationTransfer       = "transfer"
+	JournalOperationPayment        = "payment"
+	JournalOperationRefund         = "refund"
+	JournalOperationOpeningBalance = "opening_balance"
+	JournalOperationGiftCardIssue  = "gift_card_issue"
+)
+
+// LedgerAccount - счет главной книги. CardID = 0 у системных счетов.
+// Баланс счета - сумма кредитов минус сумма дебетов: для карты это деньги клиента.
+type LedgerAccount struct {
+	ID        int64     `db:"id"`
+	Kind      string    `db:"kind"`
+	CardID    int64     `db:"card_id"`
+	Currency  string    `db:"currency"`
+	Balance   int64     `db:"balance_minor"` // текущий баланс, ведется только у счетов карт
+	CreatedAt time.Time `db:"created_at"`
+}
+
+// JournalEntry - запись журнала: набор проводок одной операции, дебет равен кредиту.
+// Записи журнала и проводки не изменяются и не удаляются.
+type JournalEntry struct {
+	ID          int64     `db:"id"`
+	Operation   string    `db:"operation"`
+	Description string    `db:"description"`
+	CreatedAt   time.Time `db:"created_at"`
+	Postings    []*Posting
+}
+
+// Posting - проводка по одному счету, Amount всегда положительный
+type Posting struct {
+	ID        int64  `db:"id"`
+	EntryID   int64  `db:"entry_id"`
+	AccountID int64  `db:"account_id"`
+	Direction string `db:"direction"`
+	Amount    int64  `db:"amount_minor"`
+	Currency  string `db:"currency"`
+}
+
+// Balanced проверяет что в каждой валюте сумма дебетов равна сумме кредитов
+func (e *JournalEntry) Balanced() bool {
+	if len(e.Postings) < 2 {
+		return false
+	}
+	totals := make(map[string]int64)
+	for _, p := range e.Postings {
+		if p.Amount <= 0 {
+			return false
+		}
+		switch p.Direction {
+		case PostingDebit:
+			totals[p.Currency] += p.Amount
+		case PostingCredit:
+			totals[p.Currency] -= p.Amount
+		default:
+			return false
+		}
+	}
+	for _, t := range totals {
+		if t != 0 {
+			return false
+		}
+	}
+	return true
+}
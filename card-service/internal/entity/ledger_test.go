@@ -0,0 +1,49 @@
+package entity
+
+import "testing"
+
+func TestJournalEntryBalanced(t *testing.T) {
+	posting := func(account int64, direction string, amount int64, currency string) *Posting {
+		return &Posting{AccountID: account, Direction: direction, Amount: amount, Currency: currency}
+	}
+	cases := []struct {
+		name     string
+		postings []*Posting
+		want     bool
+	}{
+		{"debit equals credit", []*Posting{
+			posting(1, PostingDebit, 1000, "RUB"),
+			posting(2, PostingCredit, 1000, "RUB"),
+		}, true},
+		{"split credit with fee", []*Posting{
+			posting(1, PostingDebit, 1000, "RUB"),
+			posting(2, PostingCredit, 990, "RUB"),
+			posting(3, PostingCredit, 10, "RUB"),
+		}, true},
+		{"amounts differ", []*Posting{
+			posting(1, PostingDebit, 1000, "RUB"),
+			posting(2, PostingCredit, 999, "RUB"),
+		}, false},
+		{"currencies differ", []*Posting{
+			posting(1, PostingDebit, 1000, "RUB"),
+			posting(2, PostingCredit, 1000, "USD"),
+		}, false},
+		{"single posting", []*Posting{
+			posting(1, PostingDebit, 1000, "RUB"),
+		}, false},
+		{"non-positive amount", []*Posting{
+			posting(1, PostingDebit, 0, "RUB"),
+			posting(2, PostingCredit, 0, "RUB"),
+		}, false},
+		{"unknown direction", []*Posting{
+			posting(1, "debet", 1000, "RUB"),
+			posting(2, PostingCredit, 1000, "RUB"),
+		}, false},
+	}
+	for _, tc := range cases {
+		e := &JournalEntry{Postings: tc.postings}
+		if got := e.Balanced(); got != tc.want {
+			t.Fatalf("%s: Balanced() = %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,56 @@
+package entity
+
+import "time"
+
+// CardLimit - лимиты списаний по карте. TransactionType = "" - лимит на все списания
+// вместе, иначе только на указанный тип. Нулевая сумма - без ограничения.
+type CardLimit struct {
+	CardID          int64     `json:"card_id" db:"card_id"`
+	TransactionType string    `json:"transaction_type" db:"transaction_type"`
+	PerTransaction  int64     `json:"per_transaction" db:"per_transaction_minor"`
+	Daily           int64     `json:"daily" db:"daily_minor"`
+	Monthly         int64     `json:"monthly" db:"monthly_minor"`
+	SetBy           string    `json:"set_by" db:"set_by"` // user или admin, лимит админа владелец не меняет
+	UpdatedAt       time.Time `json:"updated_at" db:"updated_at"`
+
+	// Потрачено за окна лимита, считается по транзакциям
+	DailySpent   int64 `json:"daily_spent" db:"-"`
+	MonthlySpent int64 `json:"monthly_spent" db:"-"`
+}
+
+// CardLimitChange - замена лимитов карты: кто заменил и на какой набор.
+// Пустой Limits - лимиты сняты.
+type CardLimitChange struct {
+	ID        int64        `json:"id" db:"id"`
+	CardID    int64        `json:"card_id" db:"card_id"`
+	Actor     string       `json:"actor" db:"actor"`           // user или admin
+	ActorID   int64        `json:"actor_id" db:"actor_id"`     // id пользователя, 0 у admin
+	ActorName string       `json:"actor_name" db:"actor_name"` // имя админа из его токена
+	Reason    string       `json:"reason" db:"reason"`
+	Limits    []*CardLimit `json:"limits" db:"limits"`
+	CreatedAt time.Time    `json:"created_at" db:"created_at"`
+}
+
+// Окна лимитов скользящие: последние сутки и последние 30 дней
+const (
+	LimitDailyWindow   = 24 * time.Hour
+	LimitMonthlyWindow = 30 * 24 * time.Hour
+)
+
+// Виды лимитов
+const (
+	LimitPerTransaction = "per_transaction"
+	LimitDaily          = "daily"
+	LimitMonthly        = "monthly"
+)
+
+// DebitTransactionTypes - типы транзакций, которые учитываются в лимитах
+var DebitTransactionTypes = []string{TransactionTypeWithdraw, TransactionTypeTransferOut, TransactionTypePayment}
+
+// LimitedTypes - типы транзакций, на которые действует лимит
+func (l *CardLimit) LimitedTypes() []string {
+	if l.TransactionType == "" {
+		return DebitTransactionTypes
+	}
+	return []string{l.TransactionType}
+}
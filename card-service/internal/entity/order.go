@@ -0,0 +1,10 @@
+package entity
+
+// Итоговый статус оплаты заказа по его транзакциям
+const (
+	OrderPaymentStatusUnpaid            = "unpaid"  // списаний нет
+	OrderPaymentStatusPending           = "pending" // есть только незавершенные оплаты
+	OrderPaymentStatusPaid              = "paid"
+	OrderPaymentStatusPartiallyRefunded = "partially_refunded"
+	OrderPaymentStatusRefunded          = "refunded" // возвращено все списанное
+)
@@ -0,0 +1,27 @@
+package entity
+
+import "time"
+
+// Типы доменных событий outbox, схема - card_events_v1.DomainEvent
+const (
+	OutboxPaymentCompleted  = "payment.completed"
+	OutboxPaymentRefunded   = "payment.refunded"
+	OutboxCardStatusChanged = "card.status_changed"
+)
+
+// OutboxSchemaVersion - версия схемы событий (пакет card_events_v1)
+const OutboxSchemaVersion = 1
+
+// OutboxMessage - доменное событие, записанное в транзакции изменения и еще не
+// обязательно опубликованное. Payload - сериализованный card_events_v1.DomainEvent.
+type OutboxMessage struct {
+	ID            int64      `db:"id"`
+	CardID        int64      `db:"card_id"` // ключ упорядочивания
+	EventType     string     `db:"event_type"`
+	SchemaVersion int        `db:"schema_version"`
+	Payload       []byte     `db:"payload"`
+	Attempts      int        `db:"attempts"`   // неудачные попытки публикации
+	LastError     string     `db:"last_error"` // ошибка последней неудачной попытки
+	CreatedAt     time.Time  `db:"created_at"`
+	PublishedAt   *time.Time `db:"published_at"`
+}
@@ -0,0 +1,30 @@
+package entity
+
+import "time"
+
+// Статусы перевода по номеру телефона
+const (
+	P2PStatusPending   = "pending"   // подтверждение показано, перевод не выполнен
+	P2PStatusCompleted = "completed" // перевод выполнен, токен больше не действует
+)
+
+// P2PTransfer - перевод другому пользователю по номеру телефона. Создается подтверждением
+// с замаскированным именем получателя и выполняется один раз по Token до ExpiresAt.
+// Карта получателя выбирается при подтверждении и отправителю не показывается.
+type P2PTransfer struct {
+	ID              int64      `json:"id" db:"id"`
+	Token           string     `json:"token" db:"token"`
+	UserID          int64      `json:"user_id" db:"user_id"` // отправитель
+	FromCardID      int64      `json:"from_card_id" db:"from_card_id"`
+	RecipientUserID int64      `json:"recipient_user_id" db:"recipient_user_id"`
+	RecipientCardID int64      `json:"recipient_card_id" db:"recipient_card_id"`
+	RecipientName   string     `json:"recipient_name" db:"recipient_name"` // "Иван П." из user-service
+	Amount          int64      `json:"amount" db:"amount_minor"`
+	Currency        string     `json:"currency" db:"currency"`
+	Description     string     `json:"description" db:"description"`
+	Status          string     `json:"status" db:"status"`
+	TransactionID   int64      `json:"transaction_id" db:"transaction_id"` // transfer_out отправителя
+	ExpiresAt       time.Time  `json:"expires_at" db:"expires_at"`
+	CreatedAt       time.Time  `json:"created_at" db:"created_at"`
+	CompletedAt     *time.Time `json:"completed_at" db:"completed_at"`
+}
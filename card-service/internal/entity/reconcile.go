@@ -0,0 +1,54 @@
+package entity
+
+import "time"
+
+// Расхождения, которые находит сверка балансов
+const (
+	ReconcileIssueChainGap       = "chain_gap"       // balance_before не равен balance_after предыдущей транзакции
+	ReconcileIssueAmountMismatch = "amount_mismatch" // balance_after - balance_before не равно сумме транзакции
+	ReconcileIssueHistoryDrift   = "history_drift"   // баланс по истории транзакций не равен главной книге
+	ReconcileIssueStoredDrift    = "stored_drift"    // хранимый баланс карты не равен главной книге
+	ReconcileIssueAccountDrift   = "account_drift"   // текущий баланс счета карты не равен сумме его проводок
+)
+
+// ReconcileReport - отчет сверки балансов карт с историей транзакций и главной книгой
+type ReconcileReport struct {
+	StartedAt      time.Time             `json:"started_at"`
+	FinishedAt     time.Time             `json:"finished_at"`
+	Fix            bool                  `json:"fix"`
+	Reason         string                `json:"reason,omitempty"`
+	CardsChecked   int                   `json:"cards_checked"`
+	CardsWithDrift int                   `json:"cards_with_drift"`
+	CardsFixed     int                   `json:"cards_fixed"`
+	Cards          []*CardReconciliation `json:"cards"` // только карты с расхождениями
+}
+
+// CardReconciliation - сверка одной карты. Суммы в минимальных единицах Currency.
+type CardReconciliation struct {
+	CardID          int64            `json:"card_id"`
+	UserID          int64            `json:"user_id"`
+	Currency        string           `json:"currency"`
+	StoredBalance   int64            `json:"stored_balance"`   // cards.balance_minor
+	LedgerBalance   int64            `json:"ledger_balance"`   // сумма проводок счета карты в главной книге
+	AccountBalance  int64            `json:"account_balance"`  // ledger_accounts.balance_minor
+	ComputedBalance int64            `json:"computed_balance"` // сумма успешных транзакций
+	Transactions    int              `json:"transactions"`
+	Issues          []ReconcileIssue `json:"issues"`
+	Fixed           bool             `json:"fixed"`
+	// AdjustmentTransactionID - исправляющая транзакция, записанная в режиме fix
+	AdjustmentTransactionID int64 `json:"adjustment_transaction_id,omitempty"`
+}
+
+// HasDrift - баланс карты, текущий баланс её счета или её история расходится с главной книгой.
+// Разрывы в цепочке balance_before/after сами по себе расхождением не считаются:
+// историю нельзя переписать, они только попадают в отчет.
+func (c *CardReconciliation) HasDrift() bool {
+	return c.StoredBalance != c.LedgerBalance || c.ComputedBalance != c.LedgerBalance || c.AccountBalance != c.LedgerBalance
+}
+
+type ReconcileIssue struct {
+	Type          string `json:"type"`
+	TransactionID int64  `json:"transaction_id,omitempty"`
+	Expected      int64  `json:"expected"`
+	Actual        int64  `json:"actual"`
+}
@@ -0,0 +1,36 @@
+package entity
+
+import "time"
+
+// BlockReasonFraud - причина автоматической блокировки карты по оценке риска
+const BlockReasonFraud = "fraud"
+
+// RiskDecision - решение оценки риска по списанию. Пишется на каждое оцененное
+// списание, в том числе отклоненное, и служит журналом для ручного разбора.
+type RiskDecision struct {
+	ID              int64     `json:"id" db:"id"`
+	CardID          int64     `json:"card_id" db:"card_id"`
+	UserID          int64     `json:"user_id" db:"user_id"`
+	TransactionType string    `json:"transaction_type" db:"transaction_type"`
+	Amount          int64     `json:"amount" db:"amount_minor"`
+	Currency        string    `json:"currency" db:"currency"`
+	OrderID         string    `json:"order_id" db:"order_id"`
+	Decision        string    `json:"decision" db:"decision"` // allow, review, deny
+	Score           int       `json:"score" db:"score"`
+	Hits            []RiskHit `json:"hits" db:"hits"`                     // сработавшие правила
+	TransactionID   int64     `json:"transaction_id" db:"transaction_id"` // 0 у отклоненных и холдов
+	CreatedAt       time.Time `json:"created_at" db:"created_at"`
+}
+
+// RiskHit - сработавшее правило оценки риска
+type RiskHit struct {
+	Rule   string `json:"rule"`
+	Score  int    `json:"score"`
+	Reason string `json:"reason"`
+}
+
+// DebitStats - успешные списания карты за период
+type DebitStats struct {
+	Count   int
+	Average int64 // средняя сумма в минимальных единицах
+}
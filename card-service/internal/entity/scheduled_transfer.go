@@ -0,0 +1,81 @@
+package entity
+
+import "time"
+
+// Статусы запланированного перевода
+const (
+	ScheduledStatusActive    = "active"
+	ScheduledStatusCompleted = "completed" // разовый перевод выполнен
+	ScheduledStatusCancelled = "cancelled" // отменен пользователем
+	ScheduledStatusFailed    = "failed"    // разовый перевод не удался или карта больше недоступна
+)
+
+// Результаты запуска
+const (
+	ScheduledRunSuccess = "success"
+	ScheduledRunRetry   = "retry"  // не удался, будет повторен через scheduler.scheduled_transfer_retry_delay
+	ScheduledRunFailed  = "failed" // не удался, запуск пропущен
+)
+
+// Причины неудачного запуска
+const (
+	ScheduledErrorInsufficientFunds = "insufficient_funds"
+	ScheduledErrorLimitExceeded     = "limit_exceeded"
+	ScheduledErrorCardBlocked       = "card_blocked"
+	ScheduledErrorRiskDenied        = "risk_denied"
+	ScheduledErrorCardUnavailable   = "card_unavailable" // карта закрыта, удалена, истекла или сменила валюту
+)
+
+// ScheduledTransfer - перевод с карты на карту или пополнение карты (FromCardID = 0)
+// по расписанию: разовый в NextRunAt (Cron пустой) или повторяющийся по Cron в поясе Timezone.
+// Amount - в минимальных единицах Currency.
+type ScheduledTransfer struct {
+	ID          int64      `json:"id" db:"id"`
+	UserID      int64      `json:"user_id" db:"user_id"`
+	FromCardID  int64      `json:"from_card_id" db:"from_card_id"`
+	ToCardID    int64      `json:"to_card_id" db:"to_card_id"`
+	Amount      int64      `json:"amount" db:"amount_minor"`
+	Currency    string     `json:"currency" db:"currency"`
+	Description string     `json:"description" db:"description"`
+	Cron        string     `json:"cron" db:"cron"`
+	Timezone    string     `json:"timezone" db:"timezone"`
+	Status      string     `json:"status" db:"status"`
+	NextRunAt   time.Time  `json:"next_run_at" db:"next_run_at"`
+	LastRunAt   *time.Time `json:"last_run_at" db:"last_run_at"`
+	Attempt     int        `json:"attempt" db:"attempt"` // сколько раз текущий запуск уже не удался
+	CreatedAt   time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at" db:"updated_at"`
+
+	// Внутренняя ошибка запуска (не про карты и деньги): запуск не записан и повторится
+	// в RetryAt с тем же NextRunAt, то есть с тем же ключом идемпотентности.
+	// ErrorCount - сколько таких ошибок подряд, от него растет пауза до RetryAt.
+	LastError  string     `json:"last_error" db:"last_error"`
+	ErrorCount int        `json:"error_count" db:"error_count"`
+	RetryAt    *time.Time `json:"retry_at" db:"retry_at"`
+
+	// Runs - последние запуски, от новых к старым
+	Runs []*ScheduledTransferRun `json:"runs,omitempty" db:"-"`
+}
+
+// IsDeposit - пополнение карты, а не перевод с карты пользователя
+func (t *ScheduledTransfer) IsDeposit() bool {
+	return t.FromCardID == 0
+}
+
+// IsRecurring - перевод повторяется по расписанию
+func (t *ScheduledTransfer) IsRecurring() bool {
+	return t.Cron != ""
+}
+
+// ScheduledTransferRun - результат одного запуска запланированного перевода
+type ScheduledTransferRun struct {
+	ID                  int64     `json:"id" db:"id"`
+	ScheduledTransferID int64     `json:"scheduled_transfer_id" db:"scheduled_transfer_id"`
+	ScheduledFor        time.Time `json:"scheduled_for" db:"scheduled_for"`
+	Attempt             int       `json:"attempt" db:"attempt"` // 1 - первая попытка
+	Status              string    `json:"status" db:"status"`
+	ErrorCode           string    `json:"error_code" db:"error_code"`
+	Error               string    `json:"error" db:"error"`
+	TransactionID       int64     `json:"transaction_id" db:"transaction_id"` // списание или пополнение, 0 у неудачных
+	CreatedAt           time.Time `json:"created_at" db:"created_at"`
+}
@@ -0,0 +1,284 @@
+// Package events раздает подписчикам события карт из таблицы card_events.
+// На каждой реплике один Hub слушает канал Postgres (LISTEN/NOTIFY), дочитывает
+// новые события из базы и рассылает их подписчикам по user_id.
+package events
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/mrevds/pizza-app/card-service/internal/entity"
+)
+
+// Channel - канал NOTIFY, в который триггер card_events пишет id нового события
+const Channel = "card_events"
+
+const (
+	fetchBatch       = 500
+	subscriberBuffer = 64
+	reconnectDelay   = time.Second
+)
+
+var (
+	// ErrSubscriberLagging - подписчик не успевал читать и был отключен,
+	// продолжить можно с последнего полученного id
+	ErrSubscriberLagging = errors.New("subscriber is too slow, resume from the last received event")
+	ErrHubStopped        = errors.New("event hub is stopped")
+)
+
+// Store - чтение событий из базы
+type Store interface {
+	ListCardEvents(ctx context.Context, afterID, userID int64, limit int) ([]*entity.CardEvent, error)
+	LastCardEventID(ctx context.Context) (int64, error)
+}
+
+// Listener ждет уведомлений канала и вызывает notify на каждое. Первый notify - сразу
+// после подписки, чтобы дочитать пропущенное за время переподключения.
+// Блокируется до отмены ctx или разрыва соединения.
+type Listener interface {
+	Listen(ctx context.Context, channel string, notify func()) error
+}
+
+// Config - параметры Hub. PollInterval - страховочный опрос базы на случай потерянных
+// уведомлений, GapTimeout - сколько ждать коммита события с пропущенным id.
+type Config struct {
+	PollInterval time.Duration
+	GapTimeout   time.Duration
+}
+
+// Subscription - живой поток событий одного пользователя
+type Subscription struct {
+	userID int64
+	c      chan *entity.CardEvent
+	err    error // причина закрытия c, пишется до close
+}
+
+// C - канал событий. Закрывается при отключении, причина - Err.
+func (s *Subscription) C() <-chan *entity.CardEvent {
+	return s.c
+}
+
+func (s *Subscription) Err() error {
+	return s.err
+}
+
+type Hub struct {
+	store    Store
+	listener Listener
+	cfg      Config
+
+	mu   sync.Mutex
+	subs map[*Subscription]struct{}
+	stop bool
+
+	wake   chan struct{}
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+
+	// Состояние чтения, меняется только в горутине run. Все id <= cursor разосланы
+	// или пропущены, delivered - разосланные id выше cursor (после пропуска в id).
+	cursor    int64
+	delivered map[int64]bool
+	gapSince  time.Time
+}
+
+func New(store Store, listener Listener, cfg Config) *Hub {
+	return &Hub{
+		store:     store,
+		listener:  listener,
+		cfg:       cfg,
+		subs:      make(map[*Subscription]struct{}),
+		wake:      make(chan struct{}, 1),
+		delivered: make(map[int64]bool),
+	}
+}
+
+// Start запоминает последний id события и начинает слушать новые
+func (h *Hub) Start(ctx context.Context) error {
+	cursor, err := h.store.LastCardEventID(ctx)
+	if err != nil {
+		return err
+	}
+	h.cursor = cursor
+
+	runCtx, cancel := context.WithCancel(context.Background())
+	h.cancel = cancel
+	h.wg.Add(2)
+	go h.listen(runCtx)
+	go h.run(runCtx)
+	return nil
+}
+
+// Stop закрывает все подписки с ErrHubStopped и ждет остановки горутин, но не дольше ctx
+func (h *Hub) Stop(ctx context.Context) error {
+	h.mu.Lock()
+	h.stop = true
+	for sub := range h.subs {
+		h.closeLocked(sub, ErrHubStopped)
+	}
+	h.mu.Unlock()
+
+	if h.cancel == nil {
+		return nil
+	}
+	h.cancel()
+	done := make(chan struct{})
+	go func() {
+		h.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Subscribe подписывает на события пользователя, начиная с ближайших новых
+func (h *Hub) Subscribe(userID int64) *Subscription {
+	sub := &Subscription{userID: userID, c: make(chan *entity.CardEvent, subscriberBuffer)}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.stop {
+		sub.err = ErrHubStopped
+		close(sub.c)
+		return sub
+	}
+	h.subs[sub] = struct{}{}
+	return sub
+}
+
+func (h *Hub) Unsubscribe(sub *Subscription) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.closeLocked(sub, nil)
+}
+
+// Notify будит чтение событий, вызывается на каждое уведомление канала
+func (h *Hub) Notify() {
+	select {
+	case h.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (h *Hub) closeLocked(sub *Subscription, err error) {
+	if _, ok := h.subs[sub]; !ok {
+		return
+	}
+	delete(h.subs, sub)
+	sub.err = err
+	close(sub.c)
+}
+
+// listen держит подписку на канал и переподключается после разрыва
+func (h *Hub) listen(ctx context.Context) {
+	defer h.wg.Done()
+	for {
+		err := h.listener.Listen(ctx, Channel, h.Notify)
+		if ctx.Err() != nil {
+			return
+		}
+		log.Printf("events: listen %s: %v, reconnecting", Channel, err)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(reconnectDelay):
+		}
+	}
+}
+
+func (h *Hub) run(ctx context.Context) {
+	defer h.wg.Done()
+	var poll <-chan time.Time
+	if h.cfg.PollInterval > 0 {
+		ticker := time.NewTicker(h.cfg.PollInterval)
+		defer ticker.Stop()
+		poll = ticker.C
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-h.wake:
+		case <-poll:
+		}
+		if err := h.fetch(ctx); err != nil && ctx.Err() == nil {
+			log.Printf("events: fetch: %v", err)
+		}
+	}
+}
+
+// fetch дочитывает события после cursor и рассылает их. id выдаются последовательностью
+// до коммита, поэтому событие с меньшим id может появиться позже большего: такой
+// пропуск ждем GapTimeout, затем считаем откатившейся транзакцией.
+func (h *Hub) fetch(ctx context.Context) error {
+	for {
+		before := h.cursor
+		events, err := h.store.ListCardEvents(ctx, h.cursor, 0, fetchBatch)
+		if err != nil {
+			return err
+		}
+		for _, e := range events {
+			if h.delivered[e.ID] {
+				continue
+			}
+			h.dispatch(e)
+			h.delivered[e.ID] = true
+		}
+		h.advance()
+		// Курсор стоит на пропуске - дальше читать те же события бессмысленно
+		if len(events) < fetchBatch || h.cursor == before {
+			return nil
+		}
+	}
+}
+
+func (h *Hub) advance() {
+	for h.delivered[h.cursor+1] {
+		delete(h.delivered, h.cursor+1)
+		h.cursor++
+	}
+	if len(h.delivered) == 0 {
+		h.gapSince = time.Time{}
+		return
+	}
+	if h.gapSince.IsZero() {
+		h.gapSince = time.Now()
+		return
+	}
+	if time.Since(h.gapSince) < h.cfg.GapTimeout {
+		return
+	}
+	// Пропуск не заполнился: перескакиваем до следующего разосланного id
+	next := int64(0)
+	for id := range h.delivered {
+		if next == 0 || id < next {
+			next = id
+		}
+	}
+	h.cursor = next - 1
+	h.gapSince = time.Time{}
+	h.advance()
+}
+
+// dispatch отправляет событие подписчикам пользователя. Подписчик с полным буфером
+// отключается, а не тормозит остальных.
+func (h *Hub) dispatch(e *entity.CardEvent) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for sub := range h.subs {
+		if sub.userID != e.UserID {
+			continue
+		}
+		select {
+		case sub.c <- e:
+		default:
+			h.closeLocked(sub, ErrSubscriberLagging)
+		}
+	}
+}
@@ -0,0 +1,140 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mrevds/pizza-app/card-service/internal/entity"
+)
+
+// memStore - события в памяти. Коммит события с меньшим id позже большего
+// моделируется порядком вызовов commit.
+type memStore struct {
+	mu     sync.Mutex
+	events []*entity.CardEvent
+}
+
+func (s *memStore) commit(id, userID int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.events = append(s.events, &entity.CardEvent{ID: id, UserID: userID, CardID: 1, Type: entity.CardEventBalanceChanged})
+	sort.Slice(s.events, func(i, j int) bool { return s.events[i].ID < s.events[j].ID })
+}
+
+func (s *memStore) ListCardEvents(ctx context.Context, afterID, userID int64, limit int) ([]*entity.CardEvent, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var out []*entity.CardEvent
+	for _, e := range s.events {
+		if e.ID > afterID && (userID == 0 || e.UserID == userID) && len(out) < limit {
+			out = append(out, e)
+		}
+	}
+	return out, nil
+}
+
+func (s *memStore) LastCardEventID(ctx context.Context) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.events) == 0 {
+		return 0, nil
+	}
+	return s.events[len(s.events)-1].ID, nil
+}
+
+func receive(t *testing.T, sub *Subscription) []int64 {
+	t.Helper()
+	var ids []int64
+	for {
+		select {
+		case e, ok := <-sub.C():
+			if !ok {
+				return ids
+			}
+			ids = append(ids, e.ID)
+		default:
+			return ids
+		}
+	}
+}
+
+func TestHubDeliversLateCommittedEvents(t *testing.T) {
+	store := &memStore{}
+	hub := New(store, nil, Config{GapTimeout: time.Hour})
+	sub := hub.Subscribe(1)
+	other := hub.Subscribe(2)
+	ctx := context.Background()
+
+	// Событие 2 закоммичено раньше 1: курсор стоит на пропуске, но 2 уже разослано
+	store.commit(2, 1)
+	store.commit(3, 2)
+	if err := hub.fetch(ctx); err != nil {
+		t.Fatalf("fetch: %v", err)
+	}
+	if got := receive(t, sub); len(got) != 1 || got[0] != 2 {
+		t.Fatalf("user 1 got %v, want [2]", got)
+	}
+	if hub.cursor != 0 {
+		t.Fatalf("cursor moved over the gap: %d", hub.cursor)
+	}
+
+	store.commit(1, 1)
+	if err := hub.fetch(ctx); err != nil {
+		t.Fatalf("fetch: %v", err)
+	}
+	if got := receive(t, sub); len(got) != 1 || got[0] != 1 {
+		t.Fatalf("user 1 got %v after gap was filled, want [1]", got)
+	}
+	if got := receive(t, other); len(got) != 1 || got[0] != 3 {
+		t.Fatalf("user 2 got %v, want [3]", got)
+	}
+	if hub.cursor != 3 || len(hub.delivered) != 0 {
+		t.Fatalf("cursor %d, delivered %v", hub.cursor, hub.delivered)
+	}
+}
+
+func TestHubSkipsGapAfterTimeout(t *testing.T) {
+	store := &memStore{}
+	hub := New(store, nil, Config{GapTimeout: 0})
+	ctx := context.Background()
+
+	// id 1 откатился и не появится никогда
+	store.commit(2, 1)
+	for i := 0; i < 2; i++ {
+		if err := hub.fetch(ctx); err != nil {
+			t.Fatalf("fetch: %v", err)
+		}
+	}
+	if hub.cursor != 2 {
+		t.Fatalf("cursor %d, want 2 after gap timeout", hub.cursor)
+	}
+}
+
+func TestHubDropsLaggingSubscriber(t *testing.T) {
+	store := &memStore{}
+	hub := New(store, nil, Config{})
+	sub := hub.Subscribe(1)
+	for id := int64(1); id <= subscriberBuffer+1; id++ {
+		store.commit(id, 1)
+	}
+	if err := hub.fetch(context.Background()); err != nil {
+		t.Fatalf("fetch: %v", err)
+	}
+	if got := receive(t, sub); len(got) != subscriberBuffer {
+		t.Fatalf("got %d events, want %d", len(got), subscriberBuffer)
+	}
+	if !errors.Is(sub.Err(), ErrSubscriberLagging) {
+		t.Fatalf("lagging subscriber is not closed: %v", sub.Err())
+	}
+
+	if err := hub.Stop(context.Background()); err != nil {
+		t.Fatalf("Stop: %v", err)
+	}
+	if late := hub.Subscribe(1); !errors.Is(late.Err(), ErrHubStopped) {
+		t.Fatalf("subscribe after stop: %v", late.Err())
+	}
+}
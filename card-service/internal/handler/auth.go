@@ -0,0 +1,65 @@
+package handler
+
+import (
+	"context"
+
+	"github.com/mrevds/pizza-app/card-service/internal/auth"
+	"github.com/mrevds/pizza-app/card-service/internal/service"
+	cardGRPC "github.com/mrevds/pizza-app/card-service/pkg/user-card_v1"
+	cardV2 "github.com/mrevds/pizza-app/card-service/pkg/user-card_v2"
+)
+
+// ScopePayments - область сервисных методов оплаты заказа. Области выдаются сервисам
+// в jwt.service_scopes конфига.
+const ScopePayments = "payments"
+
+var (
+	v1 = "/" + cardGRPC.CardV1_ServiceDesc.ServiceName + "/"
+	v2 = "/" + cardV2.CardV2_ServiceDesc.ServiceName + "/"
+)
+
+// ServiceMethods - методы для других сервисов и их области. Их вызывают с сервисным
+// токеном, пользователь передается в запросе. Сервис без области метода получает
+// PERMISSION_DENIED.
+var ServiceMethods = map[string]string{
+	v1 + "ProcessPayment":      ScopePayments,
+	v1 + "ValidateCard":        ScopePayments,
+	v2 + "ProcessPayment":      ScopePayments,
+	v2 + "ProcessSplitPayment": ScopePayments,
+	v2 + "GetPaymentsByOrder":  ScopePayments,
+	v2 + "ValidateCard":        ScopePayments,
+	v2 + "AuthorizePayment":    ScopePayments,
+	v2 + "CapturePayment":      ScopePayments,
+	v2 + "VoidAuthorization":   ScopePayments,
+	v2 + "RefundPayment":       ScopePayments,
+}
+
+// AdminMethods - операции поддержки: смена статуса и лимитов любой карты, исправление
+// балансов, выпуск подарочных карт. Вызываются только с токеном админа, сервисные токены не подходят.
+var AdminMethods = []string{
+	v2 + "SetCardStatus",
+	v2 + "ReconcileBalances",
+	v2 + "IssueGiftCard",
+	v2 + "AdminSetCardLimits",
+}
+
+// currentUser - пользователь из access-токена, проверенного auth.Interceptor.
+// user_id из запроса методов пользователя не используется.
+func currentUser(ctx context.Context) int64 {
+	return auth.UserID(ctx)
+}
+
+// currentAdmin - имя админа из токена, проверенного auth.Interceptor для AdminMethods
+func currentAdmin(ctx context.Context) string {
+	id, _ := auth.FromContext(ctx)
+	return id.Admin
+}
+
+// requestUser - пользователь в запросе метода для сервисов по UUID из user-service.
+// Внутренний id card-service (user_id) не принимается: сервис не может его проверить.
+func requestUser(ctx context.Context, s service.CardService, userUUID string) (int64, error) {
+	if userUUID == "" {
+		return 0, service.ErrUserUUIDRequired
+	}
+	return s.ResolveUser(ctx, userUUID)
+}
@@ -0,0 +1,156 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"strconv"
+
+	"github.com/mrevds/pizza-app/card-service/internal/events"
+	"github.com/mrevds/pizza-app/card-service/internal/service"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// toGRPCError переводит ошибки сервисного слоя в gRPC статусы
+func toGRPCError(err error) error {
+	if err == nil {
+		return nil
+	}
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+
+	var verr *service.ValidationError
+	if errors.As(err, &verr) {
+		return validationStatus(verr)
+	}
+	var lerr *service.LimitExceededError
+	if errors.As(err, &lerr) {
+		return limitStatus(lerr)
+	}
+
+	switch {
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		return status.FromContextError(err).Err()
+	case errors.Is(err, events.ErrSubscriberLagging):
+		return status.Error(codes.Aborted, err.Error())
+	case errors.Is(err, events.ErrHubStopped):
+		return status.Error(codes.Unavailable, err.Error())
+	case errors.Is(err, service.ErrCardNotFound),
+		errors.Is(err, service.ErrTransactionNotFound),
+		errors.Is(err, service.ErrAuthorizationNotFound),
+		errors.Is(err, service.ErrScheduledTransferNotFound),
+		errors.Is(err, service.ErrRecipientNotFound),
+		errors.Is(err, service.ErrP2PTransferNotFound):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, service.ErrInvalidAmount),
+		errors.Is(err, service.ErrSameCard),
+		errors.Is(err, service.ErrInvalidCardData),
+		errors.Is(err, service.ErrInvalidUserID),
+		errors.Is(err, service.ErrUserUUIDRequired),
+		errors.Is(err, service.ErrUnsupportedCurrency),
+		errors.Is(err, service.ErrInvalidIdempotencyKey),
+		errors.Is(err, service.ErrOrderIDRequired),
+		errors.Is(err, service.ErrCaptureExceedsAuthorization),
+		errors.Is(err, service.ErrRefundTargetRequired),
+		errors.Is(err, service.ErrInvalidCardStatus),
+		errors.Is(err, service.ErrAmbiguousOrderPayment),
+		errors.Is(err, service.ErrInvalidPageToken),
+		errors.Is(err, service.ErrReconcileReasonRequired),
+		errors.Is(err, service.ErrInvalidSchedule),
+		errors.Is(err, service.ErrPhoneRequired),
+		errors.Is(err, service.ErrSelfTransfer),
+		errors.Is(err, service.ErrConfirmationRequired),
+		errors.Is(err, service.ErrGiftCardCodeRequired),
+		errors.Is(err, service.ErrGiftCardInvalid),
+		errors.Is(err, service.ErrPaymentCardConflict),
+		errors.Is(err, service.ErrSplitLegsInvalid),
+		errors.Is(err, service.ErrSplitCurrencyMismatch),
+		errors.Is(err, service.ErrSplitDuplicateCard),
+		errors.Is(err, service.ErrRefundExceedsPayment):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, service.ErrCardBlocked),
+		errors.Is(err, service.ErrCardInactive),
+		errors.Is(err, service.ErrCardExpired),
+		errors.Is(err, service.ErrCardNotBlocked),
+		errors.Is(err, service.ErrStatusTransition),
+		errors.Is(err, service.ErrInsufficientFunds),
+		errors.Is(err, service.ErrCurrencyMismatch),
+		errors.Is(err, service.ErrIdempotencyKeyReused),
+		errors.Is(err, service.ErrAuthorizationNotActive),
+		errors.Is(err, service.ErrNotRefundable),
+		errors.Is(err, service.ErrPaymentReversed),
+		errors.Is(err, service.ErrPaymentFullyRefunded),
+		errors.Is(err, service.ErrRiskDenied),
+		errors.Is(err, service.ErrScheduledTransferNotActive),
+		errors.Is(err, service.ErrRecipientNoCard),
+		errors.Is(err, service.ErrP2PTransferExpired),
+		errors.Is(err, service.ErrP2PTransferCompleted),
+		errors.Is(err, service.ErrGiftCardLocked),
+		errors.Is(err, service.ErrGiftCardRedeemed),
+		errors.Is(err, service.ErrGiftCardEmpty),
+		errors.Is(err, service.ErrGiftCardReadOnly),
+		errors.Is(err, service.ErrResumeTooOld):
+		return status.Error(codes.FailedPrecondition, err.Error())
+	case errors.Is(err, service.ErrTooManyLookups):
+		return status.Error(codes.ResourceExhausted, err.Error())
+	case errors.Is(err, service.ErrP2PUnavailable):
+		return status.Error(codes.Unavailable, err.Error())
+	case errors.Is(err, service.ErrUnblockForbidden),
+		errors.Is(err, service.ErrLimitSetByAdmin):
+		return status.Error(codes.PermissionDenied, err.Error())
+	case errors.Is(err, service.ErrCardAlreadyExists),
+		errors.Is(err, service.ErrAuthorizationExists),
+		errors.Is(err, service.ErrOrderAlreadyCharged):
+		return status.Error(codes.AlreadyExists, err.Error())
+	default:
+		return status.Error(codes.Internal, "internal error")
+	}
+}
+
+// validationStatus возвращает InvalidArgument с перечнем ошибочных полей в BadRequest
+func validationStatus(verr *service.ValidationError) error {
+	br := &errdetails.BadRequest{}
+	for _, v := range verr.Violations {
+		br.FieldViolations = append(br.FieldViolations, &errdetails.BadRequest_FieldViolation{
+			Field:       v.Field,
+			Description: v.Description,
+		})
+	}
+	st, err := status.New(codes.InvalidArgument, verr.Error()).WithDetails(br)
+	if err != nil {
+		return status.Error(codes.InvalidArgument, verr.Error())
+	}
+	return st.Err()
+}
+
+// limitStatus возвращает FailedPrecondition, в ErrorInfo - какой лимит превышен и остаток
+func limitStatus(lerr *service.LimitExceededError) error {
+	info := &errdetails.ErrorInfo{
+		Reason: "CARD_LIMIT_EXCEEDED",
+		Domain: "card-service",
+		Metadata: map[string]string{
+			"limit":            lerr.Kind,
+			"transaction_type": lerr.TransactionType,
+			"limit_minor":      strconv.FormatInt(lerr.Limit.UnitsMinor, 10),
+			"remaining_minor":  strconv.FormatInt(lerr.Remaining.UnitsMinor, 10),
+			"currency":         lerr.Limit.Currency,
+		},
+	}
+	st, err := status.New(codes.FailedPrecondition, lerr.Error()).WithDetails(info)
+	if err != nil {
+		return status.Error(codes.FailedPrecondition, lerr.Error())
+	}
+	return st.Err()
+}
+
+// isBusinessError - ошибки, о которых внутренние RPC сообщают полем message, а не gRPC статусом
+func isBusinessError(err error) bool {
+	return errors.Is(err, service.ErrCardBlocked) ||
+		errors.Is(err, service.ErrCardInactive) ||
+		errors.Is(err, service.ErrCardExpired) ||
+		errors.Is(err, service.ErrInsufficientFunds) ||
+		errors.Is(err, service.ErrLimitExceeded) ||
+		errors.Is(err, service.ErrRiskDenied)
+}
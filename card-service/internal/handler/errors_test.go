@@ -0,0 +1,89 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/mrevds/pizza-app/card-service/internal/money"
+	"github.com/mrevds/pizza-app/card-service/internal/service"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestToGRPCError(t *testing.T) {
+	verr := &service.ValidationError{Violations: []service.FieldViolation{
+		{Field: "card_number", Description: "failed Luhn check"},
+		{Field: "limits[1].daily", Description: "currency must match card currency RUB"},
+	}}
+	badRequest := &errdetails.BadRequest{FieldViolations: []*errdetails.BadRequest_FieldViolation{
+		{Field: "card_number", Description: "failed Luhn check"},
+		{Field: "limits[1].daily", Description: "currency must match card currency RUB"},
+	}}
+	lerr := &service.LimitExceededError{
+		Kind:            "daily",
+		TransactionType: "payment",
+		Limit:           money.Money{UnitsMinor: 100_000, Currency: "RUB"},
+		Remaining:       money.Money{UnitsMinor: 2_500, Currency: "RUB"},
+	}
+	limitInfo := &errdetails.ErrorInfo{
+		Reason: "CARD_LIMIT_EXCEEDED",
+		Domain: "card-service",
+		Metadata: map[string]string{
+			"limit":            "daily",
+			"transaction_type": "payment",
+			"limit_minor":      "100000",
+			"remaining_minor":  "2500",
+			"currency":         "RUB",
+		},
+	}
+
+	tests := []struct {
+		name    string
+		err     error
+		code    codes.Code
+		message string // пусто - не проверяется
+		details []proto.Message
+	}{
+		{"validation", verr, codes.InvalidArgument, verr.Error(), []proto.Message{badRequest}},
+		{"wrapped validation", fmt.Errorf("add card: %w", verr), codes.InvalidArgument, "", []proto.Message{badRequest}},
+		{"limit", lerr, codes.FailedPrecondition, lerr.Error(), []proto.Message{limitInfo}},
+		{"wrapped limit", fmt.Errorf("withdraw: %w", lerr), codes.FailedPrecondition, "", []proto.Message{limitInfo}},
+		{"not found", service.ErrCardNotFound, codes.NotFound, service.ErrCardNotFound.Error(), nil},
+		{"invalid argument", service.ErrUserUUIDRequired, codes.InvalidArgument, "", nil},
+		{"failed precondition", service.ErrInsufficientFunds, codes.FailedPrecondition, "", nil},
+		{"permission denied", service.ErrLimitSetByAdmin, codes.PermissionDenied, "", nil},
+		{"already exists", service.ErrOrderAlreadyCharged, codes.AlreadyExists, "", nil},
+		{"canceled", context.Canceled, codes.Canceled, "", nil},
+		{"grpc status", status.Error(codes.Unauthenticated, "token expired"), codes.Unauthenticated, "token expired", nil},
+		{"unknown", errors.New("pq: connection refused"), codes.Internal, "internal error", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			st := status.Convert(toGRPCError(tt.err))
+			if st.Code() != tt.code {
+				t.Fatalf("code = %v, want %v", st.Code(), tt.code)
+			}
+			if tt.message != "" && st.Message() != tt.message {
+				t.Errorf("message = %q, want %q", st.Message(), tt.message)
+			}
+			details := st.Details()
+			if len(details) != len(tt.details) {
+				t.Fatalf("details = %v, want %v", details, tt.details)
+			}
+			for i, d := range details {
+				msg, ok := d.(proto.Message)
+				if !ok || !proto.Equal(msg, tt.details[i]) {
+					t.Errorf("details[%d] = %v, want %v", i, d, tt.details[i])
+				}
+			}
+		})
+	}
+
+	if err := toGRPCError(nil); err != nil {
+		t.Errorf("toGRPCError(nil) = %v", err)
+	}
+}
@@ -0,0 +1,569 @@
+package handler
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/mrevds/pizza-app/card-service/internal/entity"
+	"github.com/mrevds/pizza-app/card-service/internal/money"
+	"github.com/mrevds/pizza-app/card-service/internal/service"
+	cardGRPC "github.com/mrevds/pizza-app/card-service/pkg/user-card_v1"
+
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// grpcHandler - CardV1 API, оставлен для совместимости. Суммы в нем double в основных
+// единицах валюты карты и переводятся в минимальные единицы на входе и выходе.
+type grpcHandler struct {
+	cardGRPC.UnimplementedCardV1Server
+	cardService service.CardService
+}
+
+func NewGRPCHandler(s service.CardService) cardGRPC.CardV1Server {
+	return &grpcHandler{cardService: s}
+}
+
+func (h *grpcHandler) AddCard(ctx context.Context, req *cardGRPC.AddCardRequest) (*cardGRPC.AddCardResponse, error) {
+	card, err := h.cardService.AddCard(ctx, service.AddCardInput{
+		UserID:         currentUser(ctx),
+		CardNumber:     req.GetCardNumber(),
+		CardHolderName: req.GetCardHolderName(),
+		ExpiryDate:     req.GetExpiryDate(),
+		CVV:            req.GetCvv(),
+	})
+	if err != nil {
+		return nil, toGRPCError(err)
+	}
+	pc, err := toProtoCard(card)
+	if err != nil {
+		return nil, toGRPCError(err)
+	}
+	return &cardGRPC.AddCardResponse{Card: pc}, nil
+}
+
+func (h *grpcHandler) GetCard(ctx context.Context, req *cardGRPC.GetCardRequest) (*cardGRPC.GetCardResponse, error) {
+	card, err := h.cardService.GetCard(ctx, currentUser(ctx), req.GetCardId())
+	if err != nil {
+		return nil, toGRPCError(err)
+	}
+	pc, err := toProtoCard(card)
+	if err != nil {
+		return nil, toGRPCError(err)
+	}
+	return &cardGRPC.GetCardResponse{Card: pc}, nil
+}
+
+func (h *grpcHandler) GetUserCards(ctx context.Context, req *cardGRPC.GetUserCardsRequest) (*cardGRPC.GetUserCardsResponse, error) {
+	cards, err := h.cardService.GetUserCards(ctx, currentUser(ctx))
+	if err != nil {
+		return nil, toGRPCError(err)
+	}
+	resp := &cardGRPC.GetUserCardsResponse{Cards: make([]*cardGRPC.Card, 0, len(cards))}
+	for _, c := range cards {
+		pc, err := toProtoCard(c)
+		if err != nil {
+			return nil, toGRPCError(err)
+		}
+		resp.Cards = append(resp.Cards, pc)
+	}
+	return resp, nil
+}
+
+func (h *grpcHandler) UpdateCard(ctx context.Context, req *cardGRPC.UpdateCardRequest) (*cardGRPC.UpdateCardResponse, error) {
+	card, err := h.cardService.UpdateCard(ctx, service.UpdateCardInput{
+		UserID:         currentUser(ctx),
+		CardID:         req.GetCardId(),
+		CardHolderName: req.GetCardHolderName(),
+		ExpiryDate:     req.GetExpiryDate(),
+	})
+	if err != nil {
+		return nil, toGRPCError(err)
+	}
+	pc, err := toProtoCard(card)
+	if err != nil {
+		return nil, toGRPCError(err)
+	}
+	return &cardGRPC.UpdateCardResponse{Card: pc}, nil
+}
+
+func (h *grpcHandler) DeleteCard(ctx context.Context, req *cardGRPC.DeleteCardRequest) (*emptypb.Empty, error) {
+	if err := h.cardService.DeleteCard(ctx, currentUser(ctx), req.GetCardId()); err != nil {
+		return nil, toGRPCError(err)
+	}
+	return &emptypb.Empty{}, nil
+}
+
+func (h *grpcHandler) BlockCard(ctx context.Context, req *cardGRPC.BlockCardRequest) (*emptypb.Empty, error) {
+	if err := h.cardService.BlockCard(ctx, currentUser(ctx), req.GetCardId(), req.GetReason()); err != nil {
+		return nil, toGRPCError(err)
+	}
+	return &emptypb.Empty{}, nil
+}
+
+func (h *grpcHandler) UnblockCard(ctx context.Context, req *cardGRPC.UnblockCardRequest) (*emptypb.Empty, error) {
+	if err := h.cardService.UnblockCard(ctx, currentUser(ctx), req.GetCardId()); err != nil {
+		return nil, toGRPCError(err)
+	}
+	return &emptypb.Empty{}, nil
+}
+
+func (h *grpcHandler) GetBalance(ctx context.Context, req *cardGRPC.GetBalanceRequest) (*cardGRPC.GetBalanceResponse, error) {
+	card, err := h.cardService.GetBalance(ctx, currentUser(ctx), req.GetCardId())
+	if err != nil {
+		return nil, toGRPCError(err)
+	}
+	var amounts [3]float64
+	for i, units := range []int64{card.Balance, card.Available(), card.Held} {
+		if amounts[i], err = toMajor(units, card.Currency); err != nil {
+			return nil, toGRPCError(err)
+		}
+	}
+	return &cardGRPC.GetBalanceResponse{
+		Balance:   amounts[0],
+		Currency:  card.Currency,
+		Available: amounts[1],
+		Held:      amounts[2],
+	}, nil
+}
+
+func (h *grpcHandler) Deposit(ctx context.Context, req *cardGRPC.DepositRequest) (*cardGRPC.DepositResponse, error) {
+	key, err := idempotencyKey(ctx, req.GetIdempotencyKey())
+	if err != nil {
+		return nil, err
+	}
+	amount, err := h.cardAmount(ctx, currentUser(ctx), req.GetCardId(), req.GetAmount())
+	if err != nil {
+		return nil, toGRPCError(err)
+	}
+	txn, err := h.cardService.Deposit(ctx, service.OperationInput{
+		UserID:         currentUser(ctx),
+		CardID:         req.GetCardId(),
+		Amount:         amount,
+		Description:    req.GetDescription(),
+		IdempotencyKey: key,
+	})
+	if err != nil {
+		return nil, toGRPCError(err)
+	}
+	pt, err := toProtoTransaction(txn)
+	if err != nil {
+		return nil, toGRPCError(err)
+	}
+	return &cardGRPC.DepositResponse{
+		Transaction: pt,
+		NewBalance:  pt.BalanceAfter,
+	}, nil
+}
+
+func (h *grpcHandler) Withdraw(ctx context.Context, req *cardGRPC.WithdrawRequest) (*cardGRPC.WithdrawResponse, error) {
+	key, err := idempotencyKey(ctx, req.GetIdempotencyKey())
+	if err != nil {
+		return nil, err
+	}
+	amount, err := h.cardAmount(ctx, currentUser(ctx), req.GetCardId(), req.GetAmount())
+	if err != nil {
+		return nil, toGRPCError(err)
+	}
+	txn, err := h.cardService.Withdraw(ctx, service.OperationInput{
+		UserID:         currentUser(ctx),
+		CardID:         req.GetCardId(),
+		Amount:         amount,
+		Description:    req.GetDescription(),
+		IdempotencyKey: key,
+	})
+	if err != nil {
+		return nil, toGRPCError(err)
+	}
+	pt, err := toProtoTransaction(txn)
+	if err != nil {
+		return nil, toGRPCError(err)
+	}
+	return &cardGRPC.WithdrawResponse{
+		Transaction: pt,
+		NewBalance:  pt.BalanceAfter,
+	}, nil
+}
+
+func (h *grpcHandler) Transfer(ctx context.Context, req *cardGRPC.TransferRequest) (*cardGRPC.TransferResponse, error) {
+	key, err := idempotencyKey(ctx, req.GetIdempotencyKey())
+	if err != nil {
+		return nil, err
+	}
+	// Валюта карт перевода совпадает, сумму переводим по карте списания
+	amount, err := h.cardAmount(ctx, currentUser(ctx), req.GetFromCardId(), req.GetAmount())
+	if err != nil {
+		return nil, toGRPCError(err)
+	}
+	from, to, err := h.cardService.Transfer(ctx, service.TransferInput{
+		UserID:         currentUser(ctx),
+		FromCardID:     req.GetFromCardId(),
+		ToCardID:       req.GetToCardId(),
+		Amount:         amount,
+		Description:    req.GetDescription(),
+		IdempotencyKey: key,
+	})
+	if err != nil {
+		return nil, toGRPCError(err)
+	}
+	fromTxn, err := toProtoTransaction(from)
+	if err != nil {
+		return nil, toGRPCError(err)
+	}
+	toTxn, err := toProtoTransaction(to)
+	if err != nil {
+		return nil, toGRPCError(err)
+	}
+	return &cardGRPC.TransferResponse{
+		FromTransaction: fromTxn,
+		ToTransaction:   toTxn,
+		NewBalanceFrom:  fromTxn.BalanceAfter,
+		NewBalanceTo:    toTxn.BalanceAfter,
+	}, nil
+}
+
+func (h *grpcHandler) GetTransactions(ctx context.Context, req *cardGRPC.GetTransactionsRequest) (*cardGRPC.GetTransactionsResponse, error) {
+	txs, total, err := h.cardService.GetTransactions(ctx, currentUser(ctx), req.GetCardId(), int(req.GetLimit()), int(req.GetOffset()))
+	if err != nil {
+		return nil, toGRPCError(err)
+	}
+	resp := &cardGRPC.GetTransactionsResponse{
+		Transactions: make([]*cardGRPC.Transaction, 0, len(txs)),
+		Total:        int32(total),
+	}
+	for _, t := range txs {
+		pt, err := toProtoTransaction(t)
+		if err != nil {
+			return nil, toGRPCError(err)
+		}
+		resp.Transactions = append(resp.Transactions, pt)
+	}
+	return resp, nil
+}
+
+func (h *grpcHandler) GetTransaction(ctx context.Context, req *cardGRPC.GetTransactionRequest) (*cardGRPC.GetTransactionResponse, error) {
+	txn, err := h.cardService.GetTransaction(ctx, currentUser(ctx), req.GetTransactionId())
+	if err != nil {
+		return nil, toGRPCError(err)
+	}
+	pt, err := toProtoTransaction(txn)
+	if err != nil {
+		return nil, toGRPCError(err)
+	}
+	return &cardGRPC.GetTransactionResponse{Transaction: pt}, nil
+}
+
+// statementChunkSize - размер сообщения выписки, столько данных буферизуется перед отправкой
+const statementChunkSize = 32 << 10
+
+func (h *grpcHandler) ExportStatement(req *cardGRPC.ExportStatementRequest, stream cardGRPC.CardV1_ExportStatementServer) error {
+	input := service.StatementInput{
+		UserID: currentUser(stream.Context()),
+		CardID: req.GetCardId(),
+		Format: req.GetFormat(),
+	}
+	if req.GetFrom() != nil {
+		input.From = req.GetFrom().AsTime()
+	}
+	if req.GetTo() != nil {
+		input.To = req.GetTo().AsTime()
+	}
+
+	cw := &statementChunkWriter{stream: stream}
+	buf := bufio.NewWriterSize(cw, statementChunkSize)
+	begin := func(f service.StatementFile) error {
+		cw.first = &cardGRPC.StatementChunk{FileName: f.Name, ContentType: f.ContentType}
+		return nil
+	}
+	if err := h.cardService.ExportStatement(stream.Context(), input, begin, buf); err != nil {
+		return toGRPCError(err)
+	}
+	return toGRPCError(buf.Flush())
+}
+
+// statementChunkWriter отправляет каждую запись отдельным StatementChunk,
+// первое сообщение дополнительно несет имя и тип файла
+type statementChunkWriter struct {
+	stream cardGRPC.CardV1_ExportStatementServer
+	first  *cardGRPC.StatementChunk
+}
+
+func (w *statementChunkWriter) Write(p []byte) (int, error) {
+	chunk := &cardGRPC.StatementChunk{}
+	if w.first != nil {
+		chunk, w.first = w.first, nil
+	}
+	chunk.Data = p
+	if err := w.stream.Send(chunk); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+func (h *grpcHandler) GetSpendingSummary(ctx context.Context, req *cardGRPC.GetSpendingSummaryRequest) (*cardGRPC.GetSpendingSummaryResponse, error) {
+	query := service.SpendingQuery{
+		UserID: currentUser(ctx),
+		CardID: req.GetCardId(),
+		Period: req.GetPeriod(),
+	}
+	if req.GetFrom() != nil {
+		query.From = req.GetFrom().AsTime()
+	}
+	if req.GetTo() != nil {
+		query.To = req.GetTo().AsTime()
+	}
+	summary, err := h.cardService.GetSpendingSummary(ctx, query)
+	if err != nil {
+		return nil, toGRPCError(err)
+	}
+
+	resp := &cardGRPC.GetSpendingSummaryResponse{
+		FromDate: summary.From.Format(time.DateOnly),
+		ToDate:   summary.To.Format(time.DateOnly),
+	}
+	for _, group := range []struct {
+		totals []*service.SpendingTotal
+		dst    *[]*cardGRPC.SpendingTotal
+	}{
+		{summary.ByType, &resp.ByType},
+		{summary.ByPeriod, &resp.ByPeriod},
+		{summary.ByCategory, &resp.ByCategory},
+	} {
+		for _, t := range group.totals {
+			amount, err := toMajor(t.Amount, t.Currency)
+			if err != nil {
+				return nil, toGRPCError(err)
+			}
+			*group.dst = append(*group.dst, &cardGRPC.SpendingTotal{
+				Key:      t.Key,
+				Amount:   amount,
+				Currency: t.Currency,
+				Count:    int32(t.Count),
+			})
+		}
+	}
+	return resp, nil
+}
+
+func (h *grpcHandler) GetBalanceHistory(ctx context.Context, req *cardGRPC.GetBalanceHistoryRequest) (*cardGRPC.GetBalanceHistoryResponse, error) {
+	query := service.BalanceHistoryQuery{
+		UserID: currentUser(ctx),
+		CardID: req.GetCardId(),
+	}
+	if req.GetFrom() != nil {
+		query.From = req.GetFrom().AsTime()
+	}
+	if req.GetTo() != nil {
+		query.To = req.GetTo().AsTime()
+	}
+	history, err := h.cardService.GetBalanceHistory(ctx, query)
+	if err != nil {
+		return nil, toGRPCError(err)
+	}
+	resp := &cardGRPC.GetBalanceHistoryResponse{
+		Currency: history.Currency,
+		Days:     make([]*cardGRPC.DailyBalance, 0, len(history.Days)),
+	}
+	for _, b := range history.Days {
+		balance, err := toMajor(b.Balance, history.Currency)
+		if err != nil {
+			return nil, toGRPCError(err)
+		}
+		resp.Days = append(resp.Days, &cardGRPC.DailyBalance{Date: b.Day.Format(time.DateOnly), Balance: balance})
+	}
+	return resp, nil
+}
+
+func (h *grpcHandler) WatchCardEvents(req *cardGRPC.WatchCardEventsRequest, stream cardGRPC.CardV1_WatchCardEventsServer) error {
+	err := h.cardService.WatchCardEvents(stream.Context(), service.WatchInput{
+		UserID:       currentUser(stream.Context()),
+		CardID:       req.GetCardId(),
+		AfterEventID: req.GetAfterEventId(),
+	}, func(e *entity.CardEvent) error {
+		pe, err := toProtoCardEvent(e)
+		if err != nil {
+			return err
+		}
+		return stream.Send(pe)
+	})
+	return toGRPCError(err)
+}
+
+func toProtoCardEvent(e *entity.CardEvent) (*cardGRPC.CardEvent, error) {
+	pe := &cardGRPC.CardEvent{
+		Id:        e.ID,
+		CardId:    e.CardID,
+		Type:      e.Type,
+		CreatedAt: timestamppb.New(e.CreatedAt),
+	}
+	switch e.Type {
+	case entity.CardEventTransactionCreated:
+		var t entity.Transaction
+		if err := json.Unmarshal(e.Payload, &t); err != nil {
+			return nil, err
+		}
+		pt, err := toProtoTransaction(&t)
+		if err != nil {
+			return nil, err
+		}
+		pe.Payload = &cardGRPC.CardEvent_Transaction{Transaction: pt}
+	case entity.CardEventBalanceChanged:
+		var b entity.BalanceEvent
+		if err := json.Unmarshal(e.Payload, &b); err != nil {
+			return nil, err
+		}
+		var amounts [3]float64
+		for i, units := range []int64{b.Balance, b.Balance - b.Held, b.Held} {
+			v, err := toMajor(units, b.Currency)
+			if err != nil {
+				return nil, err
+			}
+			amounts[i] = v
+		}
+		pe.Payload = &cardGRPC.CardEvent_Balance{Balance: &cardGRPC.GetBalanceResponse{
+			Balance:   amounts[0],
+			Currency:  b.Currency,
+			Available: amounts[1],
+			Held:      amounts[2],
+		}}
+	case entity.CardEventStatusChanged:
+		var st entity.StatusEvent
+		if err := json.Unmarshal(e.Payload, &st); err != nil {
+			return nil, err
+		}
+		pe.Payload = &cardGRPC.CardEvent_Status{Status: &cardGRPC.CardStatusUpdate{
+			Status:      st.Status,
+			BlockReason: st.BlockReason,
+			Actor:       st.Actor,
+		}}
+	}
+	return pe, nil
+}
+
+func (h *grpcHandler) ProcessPayment(ctx context.Context, req *cardGRPC.ProcessPaymentRequest) (*cardGRPC.ProcessPaymentResponse, error) {
+	key, err := idempotencyKey(ctx, req.GetIdempotencyKey())
+	if err != nil {
+		return nil, err
+	}
+	userID, err := requestUser(ctx, h.cardService, req.GetUserUuid())
+	if err != nil {
+		return nil, toGRPCError(err)
+	}
+	amount, err := h.cardAmount(ctx, userID, req.GetCardId(), req.GetAmount())
+	if err != nil {
+		return nil, toGRPCError(err)
+	}
+	txn, err := h.cardService.ProcessPayment(ctx, service.PaymentInput{
+		UserID:           userID,
+		CardID:           req.GetCardId(),
+		Amount:           amount,
+		OrderID:          req.GetOrderId(),
+		Category:         req.GetCategory(),
+		AdditionalCharge: req.GetAdditionalCharge(),
+		Description:      req.GetDescription(),
+		IdempotencyKey:   key,
+	})
+	if err != nil {
+		// Отказ в оплате - штатный ответ для Order Service, а не ошибка
+		if isBusinessError(err) {
+			log.Printf("payment declined: order_id=%s card_id=%d: %v", req.GetOrderId(), req.GetCardId(), err)
+			return &cardGRPC.ProcessPaymentResponse{Success: false, Message: err.Error()}, nil
+		}
+		return nil, toGRPCError(err)
+	}
+	pt, err := toProtoTransaction(txn)
+	if err != nil {
+		return nil, toGRPCError(err)
+	}
+	return &cardGRPC.ProcessPaymentResponse{
+		Success:     true,
+		Message:     "payment processed",
+		Transaction: pt,
+	}, nil
+}
+
+func (h *grpcHandler) ValidateCard(ctx context.Context, req *cardGRPC.ValidateCardRequest) (*cardGRPC.ValidateCardResponse, error) {
+	userID, err := requestUser(ctx, h.cardService, req.GetUserUuid())
+	if err != nil {
+		return nil, toGRPCError(err)
+	}
+	amount, err := h.cardAmount(ctx, userID, req.GetCardId(), req.GetAmount())
+	if err == nil {
+		err = h.cardService.ValidateCard(ctx, userID, req.GetCardId(), amount)
+	}
+	if err != nil {
+		if isBusinessError(err) {
+			return &cardGRPC.ValidateCardResponse{IsValid: false, Message: err.Error()}, nil
+		}
+		return nil, toGRPCError(err)
+	}
+	return &cardGRPC.ValidateCardResponse{IsValid: true, Message: "card is valid"}, nil
+}
+
+// cardAmount переводит сумму v1 (double в валюте карты) в минимальные единицы
+func (h *grpcHandler) cardAmount(ctx context.Context, userID, cardID int64, amount float64) (money.Money, error) {
+	card, err := h.cardService.GetCard(ctx, userID, cardID)
+	if err != nil {
+		return money.Money{}, err
+	}
+	units, err := money.ToMinor(amount, card.Currency)
+	if err != nil {
+		return money.Money{}, service.ErrInvalidAmount
+	}
+	return money.Money{UnitsMinor: units, Currency: card.Currency}, nil
+}
+
+func toMajor(units int64, currency string) (float64, error) {
+	v, err := money.ToMajor(units, currency)
+	if err != nil {
+		return 0, fmt.Errorf("convert %d minor units of %q: %w", units, currency, err)
+	}
+	return v, nil
+}
+
+func toProtoCard(c *entity.Card) (*cardGRPC.Card, error) {
+	balance, err := toMajor(c.Balance, c.Currency)
+	if err != nil {
+		return nil, err
+	}
+	return &cardGRPC.Card{
+		Id:               c.ID,
+		UserId:           c.UserID,
+		CardNumberMasked: c.CardNumberMasked,
+		CardHolderName:   c.CardHolderName,
+		ExpiryDate:       c.ExpiryDate,
+		CardType:         c.CardType,
+		Balance:          balance,
+		Currency:         c.Currency,
+		IsActive:         c.IsActive,
+		IsBlocked:        c.IsBlocked,
+		CreatedAt:        timestamppb.New(c.CreatedAt),
+		UpdatedAt:        timestamppb.New(c.UpdatedAt),
+	}, nil
+}
+
+func toProtoTransaction(t *entity.Transaction) (*cardGRPC.Transaction, error) {
+	var amounts [3]float64
+	for i, units := range []int64{t.Amount, t.BalanceBefore, t.BalanceAfter} {
+		v, err := toMajor(units, t.Currency)
+		if err != nil {
+			return nil, err
+		}
+		amounts[i] = v
+	}
+	return &cardGRPC.Transaction{
+		Id:              t.ID,
+		CardId:          t.CardID,
+		TransactionType: t.TransactionType,
+		Amount:          amounts[0],
+		BalanceBefore:   amounts[1],
+		BalanceAfter:    amounts[2],
+		Description:     t.Description,
+		Status:          t.Status,
+		CreatedAt:       timestamppb.New(t.CreatedAt),
+	}, nil
+}
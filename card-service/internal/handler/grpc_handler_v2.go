@@ -0,0 +1,876 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"time"
+
+	"github.com/mrevds/pizza-app/card-service/internal/entity"
+	"github.com/mrevds/pizza-app/card-service/internal/money"
+	"github.com/mrevds/pizza-app/card-service/internal/service"
+	cardV2 "github.com/mrevds/pizza-app/card-service/pkg/user-card_v2"
+
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+type grpcHandlerV2 struct {
+	cardV2.UnimplementedCardV2Server
+	cardService service.CardService
+}
+
+func NewGRPCHandlerV2(s service.CardService) cardV2.CardV2Server {
+	return &grpcHandlerV2{cardService: s}
+}
+
+func (h *grpcHandlerV2) AddCard(ctx context.Context, req *cardV2.AddCardRequest) (*cardV2.AddCardResponse, error) {
+	card, err := h.cardService.AddCard(ctx, service.AddCardInput{
+		UserID:         currentUser(ctx),
+		CardNumber:     req.GetCardNumber(),
+		CardHolderName: req.GetCardHolderName(),
+		ExpiryDate:     req.GetExpiryDate(),
+		CVV:            req.GetCvv(),
+	})
+	if err != nil {
+		return nil, toGRPCError(err)
+	}
+	return &cardV2.AddCardResponse{Card: toProtoCardV2(card)}, nil
+}
+
+func (h *grpcHandlerV2) GetCard(ctx context.Context, req *cardV2.GetCardRequest) (*cardV2.GetCardResponse, error) {
+	card, err := h.cardService.GetCard(ctx, currentUser(ctx), req.GetCardId())
+	if err != nil {
+		return nil, toGRPCError(err)
+	}
+	return &cardV2.GetCardResponse{Card: toProtoCardV2(card)}, nil
+}
+
+func (h *grpcHandlerV2) GetUserCards(ctx context.Context, req *cardV2.GetUserCardsRequest) (*cardV2.GetUserCardsResponse, error) {
+	cards, err := h.cardService.GetUserCards(ctx, currentUser(ctx))
+	if err != nil {
+		return nil, toGRPCError(err)
+	}
+	resp := &cardV2.GetUserCardsResponse{Cards: make([]*cardV2.Card, 0, len(cards))}
+	for _, c := range cards {
+		resp.Cards = append(resp.Cards, toProtoCardV2(c))
+	}
+	return resp, nil
+}
+
+func (h *grpcHandlerV2) UpdateCard(ctx context.Context, req *cardV2.UpdateCardRequest) (*cardV2.UpdateCardResponse, error) {
+	card, err := h.cardService.UpdateCard(ctx, service.UpdateCardInput{
+		UserID:         currentUser(ctx),
+		CardID:         req.GetCardId(),
+		CardHolderName: req.GetCardHolderName(),
+		ExpiryDate:     req.GetExpiryDate(),
+	})
+	if err != nil {
+		return nil, toGRPCError(err)
+	}
+	return &cardV2.UpdateCardResponse{Card: toProtoCardV2(card)}, nil
+}
+
+func (h *grpcHandlerV2) DeleteCard(ctx context.Context, req *cardV2.DeleteCardRequest) (*emptypb.Empty, error) {
+	if err := h.cardService.DeleteCard(ctx, currentUser(ctx), req.GetCardId()); err != nil {
+		return nil, toGRPCError(err)
+	}
+	return &emptypb.Empty{}, nil
+}
+
+func (h *grpcHandlerV2) BlockCard(ctx context.Context, req *cardV2.BlockCardRequest) (*emptypb.Empty, error) {
+	if err := h.cardService.BlockCard(ctx, currentUser(ctx), req.GetCardId(), req.GetReason()); err != nil {
+		return nil, toGRPCError(err)
+	}
+	return &emptypb.Empty{}, nil
+}
+
+func (h *grpcHandlerV2) UnblockCard(ctx context.Context, req *cardV2.UnblockCardRequest) (*emptypb.Empty, error) {
+	if err := h.cardService.UnblockCard(ctx, currentUser(ctx), req.GetCardId()); err != nil {
+		return nil, toGRPCError(err)
+	}
+	return &emptypb.Empty{}, nil
+}
+
+func (h *grpcHandlerV2) GetBalance(ctx context.Context, req *cardV2.GetBalanceRequest) (*cardV2.GetBalanceResponse, error) {
+	card, err := h.cardService.GetBalance(ctx, currentUser(ctx), req.GetCardId())
+	if err != nil {
+		return nil, toGRPCError(err)
+	}
+	return &cardV2.GetBalanceResponse{
+		Balance:   toProtoMoney(card.Balance, card.Currency),
+		Available: toProtoMoney(card.Available(), card.Currency),
+		Held:      toProtoMoney(card.Held, card.Currency),
+	}, nil
+}
+
+func (h *grpcHandlerV2) Deposit(ctx context.Context, req *cardV2.DepositRequest) (*cardV2.DepositResponse, error) {
+	key, err := idempotencyKey(ctx, req.GetIdempotencyKey())
+	if err != nil {
+		return nil, err
+	}
+	txn, err := h.cardService.Deposit(ctx, service.OperationInput{
+		UserID:         currentUser(ctx),
+		CardID:         req.GetCardId(),
+		Amount:         fromProtoMoney(req.GetAmount()),
+		Description:    req.GetDescription(),
+		IdempotencyKey: key,
+	})
+	if err != nil {
+		return nil, toGRPCError(err)
+	}
+	return &cardV2.DepositResponse{
+		Transaction: toProtoTransactionV2(txn),
+		NewBalance:  toProtoMoney(txn.BalanceAfter, txn.Currency),
+	}, nil
+}
+
+func (h *grpcHandlerV2) Withdraw(ctx context.Context, req *cardV2.WithdrawRequest) (*cardV2.WithdrawResponse, error) {
+	key, err := idempotencyKey(ctx, req.GetIdempotencyKey())
+	if err != nil {
+		return nil, err
+	}
+	txn, err := h.cardService.Withdraw(ctx, service.OperationInput{
+		UserID:         currentUser(ctx),
+		CardID:         req.GetCardId(),
+		Amount:         fromProtoMoney(req.GetAmount()),
+		Description:    req.GetDescription(),
+		IdempotencyKey: key,
+	})
+	if err != nil {
+		return nil, toGRPCError(err)
+	}
+	return &cardV2.WithdrawResponse{
+		Transaction: toProtoTransactionV2(txn),
+		NewBalance:  toProtoMoney(txn.BalanceAfter, txn.Currency),
+	}, nil
+}
+
+func (h *grpcHandlerV2) Transfer(ctx context.Context, req *cardV2.TransferRequest) (*cardV2.TransferResponse, error) {
+	key, err := idempotencyKey(ctx, req.GetIdempotencyKey())
+	if err != nil {
+		return nil, err
+	}
+	from, to, err := h.cardService.Transfer(ctx, service.TransferInput{
+		UserID:         currentUser(ctx),
+		FromCardID:     req.GetFromCardId(),
+		ToCardID:       req.GetToCardId(),
+		Amount:         fromProtoMoney(req.GetAmount()),
+		Description:    req.GetDescription(),
+		IdempotencyKey: key,
+	})
+	if err != nil {
+		return nil, toGRPCError(err)
+	}
+	return &cardV2.TransferResponse{
+		FromTransaction: toProtoTransactionV2(from),
+		ToTransaction:   toProtoTransactionV2(to),
+		NewBalanceFrom:  toProtoMoney(from.BalanceAfter, from.Currency),
+		NewBalanceTo:    toProtoMoney(to.BalanceAfter, to.Currency),
+	}, nil
+}
+
+func (h *grpcHandlerV2) GetTransactions(ctx context.Context, req *cardV2.GetTransactionsRequest) (*cardV2.GetTransactionsResponse, error) {
+	// Старые клиенты с offset получают прежнюю пагинацию по одной карте
+	if req.GetOffset() > 0 {
+		txs, total, err := h.cardService.GetTransactions(ctx, currentUser(ctx), req.GetCardId(), int(req.GetLimit()), int(req.GetOffset()))
+		if err != nil {
+			return nil, toGRPCError(err)
+		}
+		return toProtoTransactionsV2(txs, total, ""), nil
+	}
+
+	query := service.TransactionQuery{
+		UserID:    currentUser(ctx),
+		CardID:    req.GetCardId(),
+		Types:     req.GetTransactionTypes(),
+		Statuses:  req.GetStatuses(),
+		MinAmount: fromProtoMoney(req.GetMinAmount()),
+		MaxAmount: fromProtoMoney(req.GetMaxAmount()),
+		Text:      req.GetQuery(),
+		PageSize:  int(req.GetLimit()),
+		PageToken: req.GetPageToken(),
+	}
+	if req.GetFrom() != nil {
+		query.From = req.GetFrom().AsTime()
+	}
+	if req.GetTo() != nil {
+		query.To = req.GetTo().AsTime()
+	}
+	page, err := h.cardService.ListTransactions(ctx, query)
+	if err != nil {
+		return nil, toGRPCError(err)
+	}
+	resp := toProtoTransactionsV2(page.Transactions, page.Total, page.NextPageToken)
+	for _, o := range page.Orders {
+		order := &cardV2.OrderPayments{
+			OrderId: o.OrderID,
+			Amount:  toProtoMoney(o.Amount, o.Currency),
+			Legs:    make([]*cardV2.Transaction, 0, len(o.Legs)),
+		}
+		for _, t := range o.Legs {
+			order.Legs = append(order.Legs, toProtoTransactionV2(t))
+		}
+		resp.Orders = append(resp.Orders, order)
+	}
+	return resp, nil
+}
+
+func toProtoTransactionsV2(txs []*entity.Transaction, total int, nextPageToken string) *cardV2.GetTransactionsResponse {
+	resp := &cardV2.GetTransactionsResponse{
+		Transactions:  make([]*cardV2.Transaction, 0, len(txs)),
+		Total:         int32(total),
+		NextPageToken: nextPageToken,
+	}
+	for _, t := range txs {
+		resp.Transactions = append(resp.Transactions, toProtoTransactionV2(t))
+	}
+	return resp
+}
+
+func (h *grpcHandlerV2) GetTransaction(ctx context.Context, req *cardV2.GetTransactionRequest) (*cardV2.GetTransactionResponse, error) {
+	txn, err := h.cardService.GetTransaction(ctx, currentUser(ctx), req.GetTransactionId())
+	if err != nil {
+		return nil, toGRPCError(err)
+	}
+	return &cardV2.GetTransactionResponse{Transaction: toProtoTransactionV2(txn)}, nil
+}
+
+func (h *grpcHandlerV2) GetSpendingSummary(ctx context.Context, req *cardV2.GetSpendingSummaryRequest) (*cardV2.GetSpendingSummaryResponse, error) {
+	query := service.SpendingQuery{
+		UserID: currentUser(ctx),
+		CardID: req.GetCardId(),
+		Period: req.GetPeriod(),
+	}
+	if req.GetFrom() != nil {
+		query.From = req.GetFrom().AsTime()
+	}
+	if req.GetTo() != nil {
+		query.To = req.GetTo().AsTime()
+	}
+	summary, err := h.cardService.GetSpendingSummary(ctx, query)
+	if err != nil {
+		return nil, toGRPCError(err)
+	}
+	return &cardV2.GetSpendingSummaryResponse{
+		FromDate:   summary.From.Format(time.DateOnly),
+		ToDate:     summary.To.Format(time.DateOnly),
+		ByType:     toProtoSpendingTotals(summary.ByType),
+		ByPeriod:   toProtoSpendingTotals(summary.ByPeriod),
+		ByCategory: toProtoSpendingTotals(summary.ByCategory),
+	}, nil
+}
+
+func (h *grpcHandlerV2) GetBalanceHistory(ctx context.Context, req *cardV2.GetBalanceHistoryRequest) (*cardV2.GetBalanceHistoryResponse, error) {
+	query := service.BalanceHistoryQuery{
+		UserID: currentUser(ctx),
+		CardID: req.GetCardId(),
+	}
+	if req.GetFrom() != nil {
+		query.From = req.GetFrom().AsTime()
+	}
+	if req.GetTo() != nil {
+		query.To = req.GetTo().AsTime()
+	}
+	history, err := h.cardService.GetBalanceHistory(ctx, query)
+	if err != nil {
+		return nil, toGRPCError(err)
+	}
+	resp := &cardV2.GetBalanceHistoryResponse{Days: make([]*cardV2.DailyBalance, 0, len(history.Days))}
+	for _, b := range history.Days {
+		resp.Days = append(resp.Days, &cardV2.DailyBalance{
+			Date:    b.Day.Format(time.DateOnly),
+			Balance: toProtoMoney(b.Balance, history.Currency),
+		})
+	}
+	return resp, nil
+}
+
+func (h *grpcHandlerV2) ProcessPayment(ctx context.Context, req *cardV2.ProcessPaymentRequest) (*cardV2.ProcessPaymentResponse, error) {
+	key, err := idempotencyKey(ctx, req.GetIdempotencyKey())
+	if err != nil {
+		return nil, err
+	}
+	userID, err := requestUser(ctx, h.cardService, req.GetUserUuid())
+	if err != nil {
+		return nil, toGRPCError(err)
+	}
+	txn, err := h.cardService.ProcessPayment(ctx, service.PaymentInput{
+		UserID:           userID,
+		CardID:           req.GetCardId(),
+		GiftCode:         req.GetGiftCode(),
+		GiftPIN:          req.GetGiftPin(),
+		Amount:           fromProtoMoney(req.GetAmount()),
+		OrderID:          req.GetOrderId(),
+		Category:         req.GetCategory(),
+		AdditionalCharge: req.GetAdditionalCharge(),
+		Description:      req.GetDescription(),
+		IdempotencyKey:   key,
+	})
+	if err != nil {
+		// Отказ в оплате - штатный ответ для Order Service, а не ошибка
+		if isBusinessError(err) {
+			log.Printf("payment declined: order_id=%s card_id=%d: %v", req.GetOrderId(), req.GetCardId(), err)
+			return &cardV2.ProcessPaymentResponse{Success: false, Message: err.Error()}, nil
+		}
+		return nil, toGRPCError(err)
+	}
+	return &cardV2.ProcessPaymentResponse{
+		Success:     true,
+		Message:     "payment processed",
+		Transaction: toProtoTransactionV2(txn),
+	}, nil
+}
+
+func (h *grpcHandlerV2) ProcessSplitPayment(ctx context.Context, req *cardV2.ProcessSplitPaymentRequest) (*cardV2.ProcessSplitPaymentResponse, error) {
+	key, err := idempotencyKey(ctx, req.GetIdempotencyKey())
+	if err != nil {
+		return nil, err
+	}
+	userID, err := requestUser(ctx, h.cardService, req.GetUserUuid())
+	if err != nil {
+		return nil, toGRPCError(err)
+	}
+	legs := make([]service.PaymentLeg, 0, len(req.GetLegs()))
+	for _, l := range req.GetLegs() {
+		legs = append(legs, service.PaymentLeg{
+			CardID:   l.GetCardId(),
+			GiftCode: l.GetGiftCode(),
+			GiftPIN:  l.GetGiftPin(),
+			Amount:   fromProtoMoney(l.GetAmount()),
+		})
+	}
+	txns, err := h.cardService.ProcessSplitPayment(ctx, service.SplitPaymentInput{
+		UserID:           userID,
+		OrderID:          req.GetOrderId(),
+		Legs:             legs,
+		Category:         req.GetCategory(),
+		AdditionalCharge: req.GetAdditionalCharge(),
+		Description:      req.GetDescription(),
+		IdempotencyKey:   key,
+	})
+	if err != nil {
+		if isBusinessError(err) {
+			log.Printf("split payment declined: order_id=%s: %v", req.GetOrderId(), err)
+			return &cardV2.ProcessSplitPaymentResponse{Success: false, Message: err.Error()}, nil
+		}
+		return nil, toGRPCError(err)
+	}
+	resp := &cardV2.ProcessSplitPaymentResponse{
+		Success:      true,
+		Message:      "payment processed",
+		Transactions: make([]*cardV2.Transaction, 0, len(txns)),
+	}
+	for _, t := range txns {
+		resp.Transactions = append(resp.Transactions, toProtoTransactionV2(t))
+	}
+	return resp, nil
+}
+
+func (h *grpcHandlerV2) GetPaymentsByOrder(ctx context.Context, req *cardV2.GetPaymentsByOrderRequest) (*cardV2.GetPaymentsByOrderResponse, error) {
+	userID, err := requestUser(ctx, h.cardService, req.GetUserUuid())
+	if err != nil {
+		return nil, toGRPCError(err)
+	}
+	summary, err := h.cardService.GetPaymentsByOrder(ctx, userID, req.GetOrderId())
+	if err != nil {
+		return nil, toGRPCError(err)
+	}
+	resp := &cardV2.GetPaymentsByOrderResponse{
+		OrderId:      summary.OrderID,
+		Status:       summary.Status,
+		Paid:         toProtoMoney(summary.Paid, summary.Currency),
+		Refunded:     toProtoMoney(summary.Refunded, summary.Currency),
+		Adjusted:     toProtoMoney(summary.Adjusted, summary.Currency),
+		Net:          toProtoMoney(summary.Net, summary.Currency),
+		Transactions: make([]*cardV2.Transaction, 0, len(summary.Transactions)),
+	}
+	for _, t := range summary.Transactions {
+		resp.Transactions = append(resp.Transactions, toProtoTransactionV2(t))
+	}
+	return resp, nil
+}
+
+func (h *grpcHandlerV2) ValidateCard(ctx context.Context, req *cardV2.ValidateCardRequest) (*cardV2.ValidateCardResponse, error) {
+	userID, err := requestUser(ctx, h.cardService, req.GetUserUuid())
+	if err == nil {
+		err = h.cardService.ValidateCard(ctx, userID, req.GetCardId(), fromProtoMoney(req.GetAmount()))
+	}
+	if err != nil {
+		if isBusinessError(err) {
+			return &cardV2.ValidateCardResponse{IsValid: false, Message: err.Error()}, nil
+		}
+		return nil, toGRPCError(err)
+	}
+	return &cardV2.ValidateCardResponse{IsValid: true, Message: "card is valid"}, nil
+}
+
+func (h *grpcHandlerV2) AuthorizePayment(ctx context.Context, req *cardV2.AuthorizePaymentRequest) (*cardV2.AuthorizePaymentResponse, error) {
+	key, err := idempotencyKey(ctx, req.GetIdempotencyKey())
+	if err != nil {
+		return nil, err
+	}
+	userID, err := requestUser(ctx, h.cardService, req.GetUserUuid())
+	if err != nil {
+		return nil, toGRPCError(err)
+	}
+	auth, err := h.cardService.AuthorizePayment(ctx, service.AuthorizeInput{
+		UserID:         userID,
+		CardID:         req.GetCardId(),
+		Amount:         fromProtoMoney(req.GetAmount()),
+		OrderID:        req.GetOrderId(),
+		Category:       req.GetCategory(),
+		Description:    req.GetDescription(),
+		IdempotencyKey: key,
+	})
+	if err != nil {
+		// Как и в ProcessPayment, отказ - штатный ответ для Order Service
+		if isBusinessError(err) {
+			log.Printf("authorization declined: order_id=%s card_id=%d: %v", req.GetOrderId(), req.GetCardId(), err)
+			return &cardV2.AuthorizePaymentResponse{Success: false, Message: err.Error()}, nil
+		}
+		return nil, toGRPCError(err)
+	}
+	return &cardV2.AuthorizePaymentResponse{
+		Success:       true,
+		Message:       "payment authorized",
+		Authorization: toProtoAuthorization(auth),
+	}, nil
+}
+
+func (h *grpcHandlerV2) CapturePayment(ctx context.Context, req *cardV2.CapturePaymentRequest) (*cardV2.CapturePaymentResponse, error) {
+	key, err := idempotencyKey(ctx, req.GetIdempotencyKey())
+	if err != nil {
+		return nil, err
+	}
+	userID, err := requestUser(ctx, h.cardService, req.GetUserUuid())
+	if err != nil {
+		return nil, toGRPCError(err)
+	}
+	auth, txn, err := h.cardService.CapturePayment(ctx, service.CaptureInput{
+		UserID:           userID,
+		AuthorizationID:  req.GetAuthorizationId(),
+		OrderID:          req.GetOrderId(),
+		Amount:           fromProtoMoney(req.GetAmount()),
+		AdditionalCharge: req.GetAdditionalCharge(),
+		IdempotencyKey:   key,
+	})
+	if err != nil {
+		return nil, toGRPCError(err)
+	}
+	return &cardV2.CapturePaymentResponse{
+		Authorization: toProtoAuthorization(auth),
+		Transaction:   toProtoTransactionV2(txn),
+	}, nil
+}
+
+func (h *grpcHandlerV2) VoidAuthorization(ctx context.Context, req *cardV2.VoidAuthorizationRequest) (*cardV2.VoidAuthorizationResponse, error) {
+	userID, err := requestUser(ctx, h.cardService, req.GetUserUuid())
+	if err != nil {
+		return nil, toGRPCError(err)
+	}
+	auth, err := h.cardService.VoidAuthorization(ctx, userID, req.GetAuthorizationId(), req.GetOrderId())
+	if err != nil {
+		return nil, toGRPCError(err)
+	}
+	return &cardV2.VoidAuthorizationResponse{Authorization: toProtoAuthorization(auth)}, nil
+}
+
+func (h *grpcHandlerV2) RefundPayment(ctx context.Context, req *cardV2.RefundPaymentRequest) (*cardV2.RefundPaymentResponse, error) {
+	key, err := idempotencyKey(ctx, req.GetIdempotencyKey())
+	if err != nil {
+		return nil, err
+	}
+	userID, err := requestUser(ctx, h.cardService, req.GetUserUuid())
+	if err != nil {
+		return nil, toGRPCError(err)
+	}
+	res, err := h.cardService.RefundPayment(ctx, service.RefundInput{
+		UserID:         userID,
+		TransactionID:  req.GetTransactionId(),
+		OrderID:        req.GetOrderId(),
+		Amount:         fromProtoMoney(req.GetAmount()),
+		Reason:         req.GetReason(),
+		IdempotencyKey: key,
+	})
+	if err != nil {
+		return nil, toGRPCError(err)
+	}
+	return &cardV2.RefundPaymentResponse{
+		Refund:        toProtoTransactionV2(res.Refund),
+		RefundedTotal: toProtoMoney(res.Refunded, res.Payment.Currency),
+		Refundable:    toProtoMoney(res.Refundable, res.Payment.Currency),
+	}, nil
+}
+
+func (h *grpcHandlerV2) SetCardLimits(ctx context.Context, req *cardV2.SetCardLimitsRequest) (*cardV2.SetCardLimitsResponse, error) {
+	input := service.SetLimitsInput{UserID: currentUser(ctx), CardID: req.GetCardId(), Limits: fromProtoCardLimits(req.GetLimits())}
+	limits, err := h.cardService.SetCardLimits(ctx, input)
+	if err != nil {
+		return nil, toGRPCError(err)
+	}
+	card, err := h.cardService.GetCard(ctx, currentUser(ctx), req.GetCardId())
+	if err != nil {
+		return nil, toGRPCError(err)
+	}
+	return &cardV2.SetCardLimitsResponse{Limits: toProtoCardLimits(limits, card.Currency)}, nil
+}
+
+func (h *grpcHandlerV2) GetCardLimits(ctx context.Context, req *cardV2.GetCardLimitsRequest) (*cardV2.GetCardLimitsResponse, error) {
+	card, err := h.cardService.GetCard(ctx, currentUser(ctx), req.GetCardId())
+	if err != nil {
+		return nil, toGRPCError(err)
+	}
+	limits, err := h.cardService.GetCardLimits(ctx, currentUser(ctx), req.GetCardId())
+	if err != nil {
+		return nil, toGRPCError(err)
+	}
+	return &cardV2.GetCardLimitsResponse{Limits: toProtoCardLimits(limits, card.Currency)}, nil
+}
+
+func (h *grpcHandlerV2) CreateScheduledTransfer(ctx context.Context, req *cardV2.CreateScheduledTransferRequest) (*cardV2.CreateScheduledTransferResponse, error) {
+	input := service.ScheduledTransferInput{
+		UserID:      currentUser(ctx),
+		FromCardID:  req.GetFromCardId(),
+		ToCardID:    req.GetToCardId(),
+		Amount:      fromProtoMoney(req.GetAmount()),
+		Description: req.GetDescription(),
+		Cron:        req.GetCron(),
+		Timezone:    req.GetTimezone(),
+	}
+	if req.GetRunAt() != nil {
+		input.RunAt = req.GetRunAt().AsTime()
+	}
+	st, err := h.cardService.CreateScheduledTransfer(ctx, input)
+	if err != nil {
+		return nil, toGRPCError(err)
+	}
+	return &cardV2.CreateScheduledTransferResponse{Transfer: toProtoScheduledTransfer(st)}, nil
+}
+
+func (h *grpcHandlerV2) ListScheduledTransfers(ctx context.Context, req *cardV2.ListScheduledTransfersRequest) (*cardV2.ListScheduledTransfersResponse, error) {
+	transfers, err := h.cardService.ListScheduledTransfers(ctx, currentUser(ctx), req.GetIncludeFinished())
+	if err != nil {
+		return nil, toGRPCError(err)
+	}
+	res := make([]*cardV2.ScheduledTransfer, 0, len(transfers))
+	for _, st := range transfers {
+		res = append(res, toProtoScheduledTransfer(st))
+	}
+	return &cardV2.ListScheduledTransfersResponse{Transfers: res}, nil
+}
+
+func (h *grpcHandlerV2) CancelScheduledTransfer(ctx context.Context, req *cardV2.CancelScheduledTransferRequest) (*cardV2.CancelScheduledTransferResponse, error) {
+	st, err := h.cardService.CancelScheduledTransfer(ctx, currentUser(ctx), req.GetId())
+	if err != nil {
+		return nil, toGRPCError(err)
+	}
+	return &cardV2.CancelScheduledTransferResponse{Transfer: toProtoScheduledTransfer(st)}, nil
+}
+
+func (h *grpcHandlerV2) SetDefaultCard(ctx context.Context, req *cardV2.SetDefaultCardRequest) (*cardV2.SetDefaultCardResponse, error) {
+	card, err := h.cardService.SetDefaultCard(ctx, currentUser(ctx), req.GetCardId())
+	if err != nil {
+		return nil, toGRPCError(err)
+	}
+	return &cardV2.SetDefaultCardResponse{Card: toProtoCardV2(card)}, nil
+}
+
+func (h *grpcHandlerV2) PreviewTransferToUser(ctx context.Context, req *cardV2.PreviewTransferToUserRequest) (*cardV2.PreviewTransferToUserResponse, error) {
+	t, err := h.cardService.PreviewTransferToUser(ctx, service.TransferToUserPreviewInput{
+		UserID:      currentUser(ctx),
+		FromCardID:  req.GetFromCardId(),
+		PhoneNumber: req.GetPhoneNumber(),
+		Amount:      fromProtoMoney(req.GetAmount()),
+		Description: req.GetDescription(),
+	})
+	if err != nil {
+		return nil, toGRPCError(err)
+	}
+	return &cardV2.PreviewTransferToUserResponse{
+		ConfirmationToken: t.Token,
+		RecipientName:     t.RecipientName,
+		FromCardId:        t.FromCardID,
+		Amount:            toProtoMoney(t.Amount, t.Currency),
+		ExpiresAt:         timestamppb.New(t.ExpiresAt),
+	}, nil
+}
+
+func (h *grpcHandlerV2) TransferToUser(ctx context.Context, req *cardV2.TransferToUserRequest) (*cardV2.TransferToUserResponse, error) {
+	key, err := idempotencyKey(ctx, req.GetIdempotencyKey())
+	if err != nil {
+		return nil, err
+	}
+	t, txn, err := h.cardService.TransferToUser(ctx, service.TransferToUserInput{
+		UserID:            currentUser(ctx),
+		ConfirmationToken: req.GetConfirmationToken(),
+		IdempotencyKey:    key,
+	})
+	if err != nil {
+		return nil, toGRPCError(err)
+	}
+	return &cardV2.TransferToUserResponse{
+		Transaction:   toProtoTransactionV2(txn),
+		RecipientName: t.RecipientName,
+		NewBalance:    toProtoMoney(txn.BalanceAfter, txn.Currency),
+	}, nil
+}
+
+func (h *grpcHandlerV2) RedeemGiftCard(ctx context.Context, req *cardV2.RedeemGiftCardRequest) (*cardV2.RedeemGiftCardResponse, error) {
+	key, err := idempotencyKey(ctx, req.GetIdempotencyKey())
+	if err != nil {
+		return nil, err
+	}
+	res, err := h.cardService.RedeemGiftCard(ctx, service.GiftCardRedeemInput{
+		UserID:         currentUser(ctx),
+		Code:           req.GetCode(),
+		PIN:            req.GetPin(),
+		ToCardID:       req.GetToCardId(),
+		IdempotencyKey: key,
+	})
+	if err != nil {
+		return nil, toGRPCError(err)
+	}
+	resp := &cardV2.RedeemGiftCardResponse{GiftCard: toProtoCardV2(res.Card)}
+	if res.Transaction != nil {
+		resp.Transaction = toProtoTransactionV2(res.Transaction)
+	}
+	return resp, nil
+}
+
+func (h *grpcHandlerV2) GetGiftCardBalance(ctx context.Context, req *cardV2.GetGiftCardBalanceRequest) (*cardV2.GetGiftCardBalanceResponse, error) {
+	gift, card, err := h.cardService.GetGiftCardBalance(ctx, req.GetCode(), req.GetPin())
+	if err != nil {
+		return nil, toGRPCError(err)
+	}
+	return &cardV2.GetGiftCardBalanceResponse{
+		Balance:    toProtoMoney(card.Available(), card.Currency),
+		ExpiryDate: card.ExpiryDate,
+		Status:     card.Status,
+		Redeemed:   gift.Redeemed(),
+	}, nil
+}
+
+func (h *grpcHandlerV2) GetCardStatusHistory(ctx context.Context, req *cardV2.GetCardStatusHistoryRequest) (*cardV2.GetCardStatusHistoryResponse, error) {
+	history, err := h.cardService.GetCardStatusHistory(ctx, currentUser(ctx), req.GetCardId())
+	if err != nil {
+		return nil, toGRPCError(err)
+	}
+	changes := make([]*cardV2.CardStatusChange, 0, len(history))
+	for _, c := range history {
+		changes = append(changes, &cardV2.CardStatusChange{
+			Id:         c.ID,
+			FromStatus: c.FromStatus,
+			ToStatus:   c.ToStatus,
+			Actor:      c.Actor,
+			ActorId:    c.ActorID,
+			ActorName:  c.ActorName,
+			Reason:     c.Reason,
+			CreatedAt:  timestamppb.New(c.CreatedAt),
+		})
+	}
+	return &cardV2.GetCardStatusHistoryResponse{Changes: changes}, nil
+}
+
+func (h *grpcHandlerV2) SetCardStatus(ctx context.Context, req *cardV2.SetCardStatusRequest) (*cardV2.SetCardStatusResponse, error) {
+	card, err := h.cardService.ChangeCardStatus(ctx, service.StatusChangeInput{
+		CardID:    req.GetCardId(),
+		Status:    req.GetStatus(),
+		Actor:     entity.ActorAdmin,
+		ActorName: currentAdmin(ctx),
+		Reason:    req.GetReason(),
+	})
+	if err != nil {
+		return nil, toGRPCError(err)
+	}
+	return &cardV2.SetCardStatusResponse{Card: toProtoCardV2(card)}, nil
+}
+
+func (h *grpcHandlerV2) AdminSetCardLimits(ctx context.Context, req *cardV2.AdminSetCardLimitsRequest) (*cardV2.AdminSetCardLimitsResponse, error) {
+	limits, card, err := h.cardService.AdminSetCardLimits(ctx, service.AdminSetLimitsInput{
+		CardID:    req.GetCardId(),
+		ActorName: currentAdmin(ctx),
+		Reason:    req.GetReason(),
+		Limits:    fromProtoCardLimits(req.GetLimits()),
+	})
+	if err != nil {
+		return nil, toGRPCError(err)
+	}
+	return &cardV2.AdminSetCardLimitsResponse{Limits: toProtoCardLimits(limits, card.Currency)}, nil
+}
+
+func (h *grpcHandlerV2) ReconcileBalances(ctx context.Context, req *cardV2.ReconcileBalancesRequest) (*cardV2.ReconcileBalancesResponse, error) {
+	report, err := h.cardService.Reconcile(ctx, service.ReconcileInput{
+		CardID: req.GetCardId(),
+		Fix:    req.GetFix(),
+		Reason: req.GetReason(),
+	})
+	if err != nil {
+		return nil, toGRPCError(err)
+	}
+	data, err := json.Marshal(report)
+	if err != nil {
+		return nil, toGRPCError(err)
+	}
+	return &cardV2.ReconcileBalancesResponse{
+		Report:         data,
+		CardsChecked:   int32(report.CardsChecked),
+		CardsWithDrift: int32(report.CardsWithDrift),
+		CardsFixed:     int32(report.CardsFixed),
+	}, nil
+}
+
+func (h *grpcHandlerV2) IssueGiftCard(ctx context.Context, req *cardV2.IssueGiftCardRequest) (*cardV2.IssueGiftCardResponse, error) {
+	issued, err := h.cardService.IssueGiftCard(ctx, service.GiftCardIssueInput{
+		Amount:      fromProtoMoney(req.GetAmount()),
+		ExpiryDate:  req.GetExpiryDate(),
+		BatchID:     req.GetBatchId(),
+		Description: req.GetDescription(),
+	})
+	if err != nil {
+		return nil, toGRPCError(err)
+	}
+	return &cardV2.IssueGiftCardResponse{
+		Card: toProtoCardV2(issued.Card),
+		Code: issued.Code,
+		Pin:  issued.PIN,
+	}, nil
+}
+
+func fromProtoMoney(m *cardV2.Money) money.Money {
+	return money.Money{UnitsMinor: m.GetUnitsMinor(), Currency: m.GetCurrency()}
+}
+
+func toProtoMoney(units int64, currency string) *cardV2.Money {
+	return &cardV2.Money{UnitsMinor: units, Currency: currency}
+}
+
+func toProtoSpendingTotals(totals []*service.SpendingTotal) []*cardV2.SpendingTotal {
+	res := make([]*cardV2.SpendingTotal, 0, len(totals))
+	for _, t := range totals {
+		res = append(res, &cardV2.SpendingTotal{
+			Key:    t.Key,
+			Amount: toProtoMoney(t.Amount, t.Currency),
+			Count:  int32(t.Count),
+		})
+	}
+	return res
+}
+
+func toProtoCardV2(c *entity.Card) *cardV2.Card {
+	return &cardV2.Card{
+		Id:               c.ID,
+		UserId:           c.UserID,
+		CardNumberMasked: c.CardNumberMasked,
+		CardHolderName:   c.CardHolderName,
+		ExpiryDate:       c.ExpiryDate,
+		CardType:         c.CardType,
+		Balance:          toProtoMoney(c.Balance, c.Currency),
+		IsActive:         c.IsActive,
+		IsBlocked:        c.IsBlocked,
+		Status:           c.Status,
+		BlockReason:      c.BlockReason,
+		CreatedAt:        timestamppb.New(c.CreatedAt),
+		UpdatedAt:        timestamppb.New(c.UpdatedAt),
+	}
+}
+
+func toProtoTransactionV2(t *entity.Transaction) *cardV2.Transaction {
+	return &cardV2.Transaction{
+		Id:                    t.ID,
+		CardId:                t.CardID,
+		TransactionType:       t.TransactionType,
+		Amount:                toProtoMoney(t.Amount, t.Currency),
+		BalanceBefore:         toProtoMoney(t.BalanceBefore, t.Currency),
+		BalanceAfter:          toProtoMoney(t.BalanceAfter, t.Currency),
+		Description:           t.Description,
+		Status:                t.Status,
+		CreatedAt:             timestamppb.New(t.CreatedAt),
+		OrderId:               t.OrderID,
+		OriginalTransactionId: t.OriginalTxID,
+		Category:              t.Category,
+	}
+}
+
+func toProtoAuthorization(a *entity.Authorization) *cardV2.Authorization {
+	return &cardV2.Authorization{
+		Id:             a.ID,
+		CardId:         a.CardID,
+		OrderId:        a.OrderID,
+		Amount:         toProtoMoney(a.Amount, a.Currency),
+		CapturedAmount: toProtoMoney(a.Captured, a.Currency),
+		Status:         a.Status,
+		Description:    a.Description,
+		ExpiresAt:      timestamppb.New(a.ExpiresAt),
+		CreatedAt:      timestamppb.New(a.CreatedAt),
+	}
+}
+
+// fromProtoCardLimits - лимиты из запроса, суммы проверяет сервис
+func fromProtoCardLimits(limits []*cardV2.CardLimit) []service.LimitInput {
+	inputs := make([]service.LimitInput, 0, len(limits))
+	for _, l := range limits {
+		inputs = append(inputs, service.LimitInput{
+			TransactionType: l.GetTransactionType(),
+			PerTransaction:  fromProtoMoney(l.GetPerTransaction()),
+			Daily:           fromProtoMoney(l.GetDaily()),
+			Monthly:         fromProtoMoney(l.GetMonthly()),
+		})
+	}
+	return inputs
+}
+
+// toProtoCardLimits - лимиты карты, суммы в валюте карты, нулевой лимит - без ограничения (пусто)
+func toProtoCardLimits(limits []*entity.CardLimit, currency string) []*cardV2.CardLimit {
+	optional := func(units int64) *cardV2.Money {
+		if units == 0 {
+			return nil
+		}
+		return toProtoMoney(units, currency)
+	}
+	res := make([]*cardV2.CardLimit, 0, len(limits))
+	for _, l := range limits {
+		res = append(res, &cardV2.CardLimit{
+			TransactionType: l.TransactionType,
+			PerTransaction:  optional(l.PerTransaction),
+			Daily:           optional(l.Daily),
+			Monthly:         optional(l.Monthly),
+			DailySpent:      toProtoMoney(l.DailySpent, currency),
+			MonthlySpent:    toProtoMoney(l.MonthlySpent, currency),
+			SetBy:           l.SetBy,
+		})
+	}
+	return res
+}
+
+func toProtoScheduledTransfer(st *entity.ScheduledTransfer) *cardV2.ScheduledTransfer {
+	res := &cardV2.ScheduledTransfer{
+		Id:          st.ID,
+		FromCardId:  st.FromCardID,
+		ToCardId:    st.ToCardID,
+		Amount:      toProtoMoney(st.Amount, st.Currency),
+		Description: st.Description,
+		Cron:        st.Cron,
+		Timezone:    st.Timezone,
+		Status:      st.Status,
+		NextRunAt:   timestamppb.New(st.NextRunAt),
+		Attempt:     int32(st.Attempt),
+		CreatedAt:   timestamppb.New(st.CreatedAt),
+	}
+	if st.LastRunAt != nil {
+		res.LastRunAt = timestamppb.New(*st.LastRunAt)
+	}
+	for _, run := range st.Runs {
+		res.Runs = append(res.Runs, &cardV2.ScheduledTransferRun{
+			Id:            run.ID,
+			ScheduledFor:  timestamppb.New(run.ScheduledFor),
+			Attempt:       int32(run.Attempt),
+			Status:        run.Status,
+			ErrorCode:     run.ErrorCode,
+			Error:         run.Error,
+			TransactionId: run.TransactionID,
+			CreatedAt:     timestamppb.New(run.CreatedAt),
+		})
+	}
+	return res
+}
@@ -0,0 +1,31 @@
+package handler
+
+import (
+	"context"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// idempotencyKeyHeader - ключ идемпотентности в метаданных, если клиент не передал поле запроса
+const idempotencyKeyHeader = "idempotency-key"
+
+// idempotencyKey возвращает ключ из поля запроса или из метаданных gRPC.
+// Разные ключи в поле и в метаданных - ошибка клиента.
+func idempotencyKey(ctx context.Context, field string) (string, error) {
+	var header string
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if values := md.Get(idempotencyKeyHeader); len(values) > 0 {
+			header = values[0]
+		}
+	}
+	switch {
+	case field == "":
+		return header, nil
+	case header == "" || header == field:
+		return field, nil
+	default:
+		return "", status.Error(codes.InvalidArgument, "idempotency_key field and idempotency-key metadata differ")
+	}
+}
@@ -0,0 +1,43 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS cards (
+    id BIGSERIAL PRIMARY KEY,
+    user_id BIGINT NOT NULL,
+    card_number_masked VARCHAR(32) NOT NULL,
+    card_holder_name VARCHAR(100) NOT NULL,
+    expiry_date VARCHAR(5) NOT NULL,
+    card_type VARCHAR(20) NOT NULL,
+    balance NUMERIC(15, 2) NOT NULL DEFAULT 0 CHECK (balance >= 0),
+    currency VARCHAR(3) NOT NULL,
+    is_active BOOLEAN NOT NULL DEFAULT TRUE,
+    is_blocked BOOLEAN NOT NULL DEFAULT FALSE,
+    block_reason TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    deleted_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_cards_user_id ON cards(user_id) WHERE deleted_at IS NULL;
+
+CREATE TABLE IF NOT EXISTS transactions (
+    id BIGSERIAL PRIMARY KEY,
+    card_id BIGINT NOT NULL REFERENCES cards(id),
+    transaction_type VARCHAR(20) NOT NULL,
+    amount NUMERIC(15, 2) NOT NULL CHECK (amount > 0),
+    balance_before NUMERIC(15, 2) NOT NULL,
+    balance_after NUMERIC(15, 2) NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    status VARCHAR(20) NOT NULL,
+    order_id VARCHAR(64) NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_transactions_card_id_created_at ON transactions(card_id, created_at DESC, id DESC);
+CREATE INDEX IF NOT EXISTS idx_transactions_order_id ON transactions(order_id) WHERE order_id <> '';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS transactions;
+DROP TABLE IF EXISTS cards;
+-- +goose StatementEnd
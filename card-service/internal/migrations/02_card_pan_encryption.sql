@@ -0,0 +1,24 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Полный номер карты хранится только в зашифрованном виде (envelope encryption):
+-- pan_ciphertext зашифрован ключом данных, pan_wrapped_key - ключ данных,
+-- зашифрованный мастер-ключом версии pan_key_version.
+ALTER TABLE cards ADD COLUMN IF NOT EXISTS pan_ciphertext BYTEA;
+ALTER TABLE cards ADD COLUMN IF NOT EXISTS pan_wrapped_key BYTEA;
+ALTER TABLE cards ADD COLUMN IF NOT EXISTS pan_key_version INT;
+-- HMAC-отпечаток номера для поиска дубликатов без расшифровки
+ALTER TABLE cards ADD COLUMN IF NOT EXISTS pan_fingerprint VARCHAR(64);
+
+CREATE UNIQUE INDEX IF NOT EXISTS uq_cards_pan_fingerprint ON cards(pan_fingerprint) WHERE deleted_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_cards_pan_key_version ON cards(pan_key_version);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_cards_pan_key_version;
+DROP INDEX IF EXISTS uq_cards_pan_fingerprint;
+ALTER TABLE cards DROP COLUMN IF EXISTS pan_fingerprint;
+ALTER TABLE cards DROP COLUMN IF EXISTS pan_key_version;
+ALTER TABLE cards DROP COLUMN IF EXISTS pan_wrapped_key;
+ALTER TABLE cards DROP COLUMN IF EXISTS pan_ciphertext;
+-- +goose StatementEnd
@@ -0,0 +1,47 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Суммы хранятся целым числом минимальных единиц валюты (копейки, центы).
+-- Колонки переименованы, чтобы старая версия сервиса не записала рубли в колонку копеек.
+-- Множитель зависит от числа знаков валюты (ISO 4217): 0, 2 или 3.
+CREATE OR REPLACE FUNCTION currency_minor_factor(code VARCHAR) RETURNS NUMERIC AS $$
+    SELECT CASE
+        WHEN code IN ('JPY', 'KRW', 'VND', 'CLP') THEN 1
+        WHEN code IN ('BHD', 'KWD', 'OMR', 'JOD', 'TND') THEN 1000
+        ELSE 100
+    END::NUMERIC
+$$ LANGUAGE SQL IMMUTABLE;
+
+ALTER TABLE cards ALTER COLUMN balance DROP DEFAULT;
+ALTER TABLE cards ALTER COLUMN balance TYPE BIGINT USING round(balance * currency_minor_factor(currency))::BIGINT;
+ALTER TABLE cards ALTER COLUMN balance SET DEFAULT 0;
+ALTER TABLE cards RENAME COLUMN balance TO balance_minor;
+
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS currency VARCHAR(3);
+UPDATE transactions t SET currency = c.currency FROM cards c WHERE c.id = t.card_id;
+ALTER TABLE transactions ALTER COLUMN currency SET NOT NULL;
+
+ALTER TABLE transactions ALTER COLUMN amount TYPE BIGINT USING round(amount * currency_minor_factor(currency))::BIGINT;
+ALTER TABLE transactions ALTER COLUMN balance_before TYPE BIGINT USING round(balance_before * currency_minor_factor(currency))::BIGINT;
+ALTER TABLE transactions ALTER COLUMN balance_after TYPE BIGINT USING round(balance_after * currency_minor_factor(currency))::BIGINT;
+ALTER TABLE transactions RENAME COLUMN amount TO amount_minor;
+ALTER TABLE transactions RENAME COLUMN balance_before TO balance_before_minor;
+ALTER TABLE transactions RENAME COLUMN balance_after TO balance_after_minor;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE transactions RENAME COLUMN balance_after_minor TO balance_after;
+ALTER TABLE transactions RENAME COLUMN balance_before_minor TO balance_before;
+ALTER TABLE transactions RENAME COLUMN amount_minor TO amount;
+ALTER TABLE transactions ALTER COLUMN balance_after TYPE NUMERIC(15, 2) USING balance_after / currency_minor_factor(currency);
+ALTER TABLE transactions ALTER COLUMN balance_before TYPE NUMERIC(15, 2) USING balance_before / currency_minor_factor(currency);
+ALTER TABLE transactions ALTER COLUMN amount TYPE NUMERIC(15, 2) USING amount / currency_minor_factor(currency);
+ALTER TABLE transactions DROP COLUMN IF EXISTS currency;
+
+ALTER TABLE cards RENAME COLUMN balance_minor TO balance;
+ALTER TABLE cards ALTER COLUMN balance DROP DEFAULT;
+ALTER TABLE cards ALTER COLUMN balance TYPE NUMERIC(15, 2) USING balance / currency_minor_factor(currency);
+ALTER TABLE cards ALTER COLUMN balance SET DEFAULT 0;
+
+DROP FUNCTION IF EXISTS currency_minor_factor(VARCHAR);
+-- +goose StatementEnd
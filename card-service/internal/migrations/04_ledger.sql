@@ -0,0 +1,86 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Главная книга с двойной записью. cards.balance_minor - проекция баланса счета карты.
+CREATE TABLE IF NOT EXISTS ledger_accounts (
+    id BIGSERIAL PRIMARY KEY,
+    kind VARCHAR(32) NOT NULL,
+    card_id BIGINT REFERENCES cards(id),
+    currency VARCHAR(3) NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    CHECK ((kind = 'card') = (card_id IS NOT NULL))
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS uq_ledger_accounts_card_id ON ledger_accounts(card_id) WHERE card_id IS NOT NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS uq_ledger_accounts_system ON ledger_accounts(kind, currency) WHERE card_id IS NULL;
+
+CREATE TABLE IF NOT EXISTS ledger_entries (
+    id BIGSERIAL PRIMARY KEY,
+    operation VARCHAR(32) NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE TABLE IF NOT EXISTS ledger_postings (
+    id BIGSERIAL PRIMARY KEY,
+    entry_id BIGINT NOT NULL REFERENCES ledger_entries(id),
+    account_id BIGINT NOT NULL REFERENCES ledger_accounts(id),
+    direction VARCHAR(6) NOT NULL CHECK (direction IN ('debit', 'credit')),
+    amount_minor BIGINT NOT NULL CHECK (amount_minor > 0),
+    currency VARCHAR(3) NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_ledger_postings_entry_id ON ledger_postings(entry_id);
+CREATE INDEX IF NOT EXISTS idx_ledger_postings_account_id ON ledger_postings(account_id) INCLUDE (direction, amount_minor);
+
+-- Журнал только дописывается: исправления делаются новыми записями
+CREATE OR REPLACE FUNCTION ledger_append_only() RETURNS TRIGGER AS $$
+BEGIN
+    RAISE EXCEPTION '% is append-only', TG_TABLE_NAME;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER trg_ledger_entries_append_only BEFORE UPDATE OR DELETE ON ledger_entries
+    FOR EACH ROW EXECUTE FUNCTION ledger_append_only();
+CREATE TRIGGER trg_ledger_postings_append_only BEFORE UPDATE OR DELETE ON ledger_postings
+    FOR EACH ROW EXECUTE FUNCTION ledger_append_only();
+
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS journal_entry_id BIGINT REFERENCES ledger_entries(id);
+
+-- Счета для существующих карт и входящие остатки: дебет cash_in, кредит карты
+INSERT INTO ledger_accounts (kind, card_id, currency)
+SELECT 'card', id, currency FROM cards
+ON CONFLICT DO NOTHING;
+
+INSERT INTO ledger_accounts (kind, currency)
+SELECT DISTINCT 'cash_in', currency FROM cards WHERE balance_minor > 0
+ON CONFLICT DO NOTHING;
+
+DO $$
+DECLARE
+    c RECORD;
+    entry_id BIGINT;
+BEGIN
+    FOR c IN SELECT id, currency, balance_minor FROM cards WHERE balance_minor > 0 LOOP
+        INSERT INTO ledger_entries (operation, description) VALUES ('opening_balance', 'opening balance')
+        RETURNING id INTO entry_id;
+
+        INSERT INTO ledger_postings (entry_id, account_id, direction, amount_minor, currency)
+        SELECT entry_id, a.id, 'debit', c.balance_minor, c.currency
+        FROM ledger_accounts a WHERE a.kind = 'cash_in' AND a.card_id IS NULL AND a.currency = c.currency;
+
+        INSERT INTO ledger_postings (entry_id, account_id, direction, amount_minor, currency)
+        SELECT entry_id, a.id, 'credit', c.balance_minor, c.currency
+        FROM ledger_accounts a WHERE a.card_id = c.id;
+    END LOOP;
+END;
+$$;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE transactions DROP COLUMN IF EXISTS journal_entry_id;
+DROP TABLE IF EXISTS ledger_postings;
+DROP TABLE IF EXISTS ledger_entries;
+DROP TABLE IF EXISTS ledger_accounts;
+DROP FUNCTION IF EXISTS ledger_append_only();
+-- +goose StatementEnd
@@ -0,0 +1,22 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Результаты операций с idempotency_key. Запись создается в той же транзакции,
+-- что и движение денег, поэтому повтор запроса не может провести операцию дважды.
+CREATE TABLE IF NOT EXISTS idempotency_keys (
+    user_id BIGINT NOT NULL,
+    key VARCHAR(128) NOT NULL,
+    operation VARCHAR(32) NOT NULL,
+    fingerprint VARCHAR(64) NOT NULL,
+    response JSONB NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    expires_at TIMESTAMPTZ NOT NULL,
+    PRIMARY KEY (user_id, key)
+);
+
+CREATE INDEX IF NOT EXISTS idx_idempotency_keys_expires_at ON idempotency_keys(expires_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS idempotency_keys;
+-- +goose StatementEnd
@@ -0,0 +1,29 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Холды двухфазной оплаты заказов. Активный холд уменьшает доступный баланс карты,
+-- истекший (expires_at <= now()) не учитывается, даже если фоновая задача еще не сменила статус.
+CREATE TABLE IF NOT EXISTS payment_authorizations (
+    id BIGSERIAL PRIMARY KEY,
+    card_id BIGINT NOT NULL REFERENCES cards(id),
+    user_id BIGINT NOT NULL,
+    order_id VARCHAR(64) NOT NULL,
+    amount_minor BIGINT NOT NULL CHECK (amount_minor > 0),
+    captured_minor BIGINT NOT NULL DEFAULT 0 CHECK (captured_minor >= 0 AND captured_minor <= amount_minor),
+    currency VARCHAR(3) NOT NULL,
+    status VARCHAR(20) NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    expires_at TIMESTAMPTZ NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_payment_authorizations_active ON payment_authorizations(card_id, expires_at) WHERE status = 'active';
+CREATE INDEX IF NOT EXISTS idx_payment_authorizations_order_id ON payment_authorizations(order_id);
+-- Один активный холд на заказ по карте
+CREATE UNIQUE INDEX IF NOT EXISTS uq_payment_authorizations_active_order ON payment_authorizations(card_id, order_id) WHERE status = 'active';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS payment_authorizations;
+-- +goose StatementEnd
@@ -0,0 +1,14 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Возврат - транзакция refund со ссылкой на исходную оплату. Сумма возвратов по оплате
+-- считается по этим ссылкам под блокировкой карты.
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS original_transaction_id BIGINT REFERENCES transactions(id);
+
+CREATE INDEX IF NOT EXISTS idx_transactions_original_transaction_id ON transactions(original_transaction_id)
+    WHERE original_transaction_id IS NOT NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE transactions DROP COLUMN IF EXISTS original_transaction_id;
+-- +goose StatementEnd
@@ -0,0 +1,19 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Лимиты списаний по карте. transaction_type = '' - лимит на все списания вместе.
+-- 0 - без ограничения. Потраченное считается по transactions за скользящее окно.
+CREATE TABLE IF NOT EXISTS card_limits (
+    card_id BIGINT NOT NULL REFERENCES cards(id),
+    transaction_type VARCHAR(20) NOT NULL DEFAULT '',
+    per_transaction_minor BIGINT NOT NULL DEFAULT 0 CHECK (per_transaction_minor >= 0),
+    daily_minor BIGINT NOT NULL DEFAULT 0 CHECK (daily_minor >= 0),
+    monthly_minor BIGINT NOT NULL DEFAULT 0 CHECK (monthly_minor >= 0),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (card_id, transaction_type)
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS card_limits;
+-- +goose StatementEnd
@@ -0,0 +1,44 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Статус карты вместо пары is_active/is_blocked. Флаги остаются и обновляются вместе со статусом.
+ALTER TABLE cards ADD COLUMN IF NOT EXISTS status VARCHAR(20) NOT NULL DEFAULT 'active'
+    CHECK (status IN ('active', 'blocked_by_user', 'blocked_by_fraud', 'expired', 'closed'));
+
+-- До статусов блокировать карту мог только владелец
+UPDATE cards SET status = CASE
+    WHEN deleted_at IS NOT NULL OR NOT is_active THEN 'closed'
+    WHEN is_blocked THEN 'blocked_by_user'
+    ELSE 'active'
+END;
+
+CREATE TABLE IF NOT EXISTS card_status_history (
+    id BIGSERIAL PRIMARY KEY,
+    card_id BIGINT NOT NULL REFERENCES cards(id),
+    from_status VARCHAR(20) NOT NULL DEFAULT '',
+    to_status VARCHAR(20) NOT NULL,
+    actor VARCHAR(10) NOT NULL CHECK (actor IN ('user', 'admin', 'system')),
+    actor_id BIGINT,
+    reason TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_card_status_history_card_id ON card_status_history(card_id, id);
+
+CREATE TRIGGER trg_card_status_history_append_only BEFORE UPDATE OR DELETE ON card_status_history
+    FOR EACH ROW EXECUTE FUNCTION ledger_append_only();
+
+-- Текущий статус существующих карт - первая запись истории
+INSERT INTO card_status_history (card_id, to_status, actor, actor_id, reason, created_at)
+SELECT id, status,
+       CASE WHEN status = 'active' THEN 'system' ELSE 'user' END,
+       CASE WHEN status = 'active' THEN NULL ELSE user_id END,
+       CASE WHEN status = 'blocked_by_user' THEN block_reason ELSE 'status backfill' END,
+       updated_at
+FROM cards;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS card_status_history;
+ALTER TABLE cards DROP COLUMN IF EXISTS status;
+-- +goose StatementEnd
@@ -0,0 +1,25 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Момент, с которого карта просрочена: начало месяца после MM/YY, UTC (как utils.ParseExpiry).
+-- NULL для некорректного срока.
+CREATE OR REPLACE FUNCTION card_expires_at(expiry VARCHAR) RETURNS TIMESTAMPTZ AS $$
+    SELECT CASE WHEN expiry ~ '^(0[1-9]|1[0-2])/[0-9]{2}$' THEN
+        (make_date(2000 + substr(expiry, 4, 2)::INT, substr(expiry, 1, 2)::INT, 1) + INTERVAL '1 month')::TIMESTAMP AT TIME ZONE 'UTC'
+    END
+$$ LANGUAGE SQL IMMUTABLE;
+
+-- Срок, о котором владелец уже предупрежден. После смены expiry_date предупреждение придет снова.
+ALTER TABLE cards ADD COLUMN IF NOT EXISTS expiry_notified_for VARCHAR(5) NOT NULL DEFAULT '';
+
+CREATE INDEX IF NOT EXISTS idx_cards_expires_at ON cards(card_expires_at(expiry_date))
+    WHERE deleted_at IS NULL AND status IN ('active', 'blocked_by_user', 'blocked_by_fraud');
+CREATE INDEX IF NOT EXISTS idx_transactions_pending ON transactions(created_at) WHERE status = 'pending';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_transactions_pending;
+DROP INDEX IF EXISTS idx_cards_expires_at;
+ALTER TABLE cards DROP COLUMN IF EXISTS expiry_notified_for;
+DROP FUNCTION IF EXISTS card_expires_at(VARCHAR);
+-- +goose StatementEnd
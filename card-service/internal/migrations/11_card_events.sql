@@ -0,0 +1,34 @@
+-- +goose Up
+-- +goose StatementBegin
+-- События карт для WatchCardEvents. Пишутся в транзакции изменения, хранятся
+-- events.retention и удаляются фоновой задачей.
+CREATE TABLE IF NOT EXISTS card_events (
+    id BIGSERIAL PRIMARY KEY,
+    user_id BIGINT NOT NULL,
+    card_id BIGINT NOT NULL REFERENCES cards(id),
+    event_type VARCHAR(32) NOT NULL,
+    payload JSONB NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_card_events_user_id ON card_events(user_id, id);
+CREATE INDEX IF NOT EXISTS idx_card_events_created_at ON card_events(created_at);
+
+-- Реплики узнают о новых событиях через LISTEN card_events. NOTIFY доставляется
+-- при коммите, так что подписчики не увидят событие откатившейся транзакции.
+CREATE OR REPLACE FUNCTION notify_card_event() RETURNS TRIGGER AS $$
+BEGIN
+    PERFORM pg_notify('card_events', NEW.id::TEXT);
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER trg_card_events_notify AFTER INSERT ON card_events
+    FOR EACH ROW EXECUTE FUNCTION notify_card_event();
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS card_events;
+DROP FUNCTION IF EXISTS notify_card_event();
+-- +goose StatementEnd
@@ -0,0 +1,29 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Решения оценки риска по списаниям, в том числе отклоненным. Журнал для ручного
+-- разбора: review и deny ищутся по decision, history карты - по card_id.
+CREATE TABLE IF NOT EXISTS risk_decisions (
+    id BIGSERIAL PRIMARY KEY,
+    card_id BIGINT NOT NULL REFERENCES cards(id),
+    user_id BIGINT NOT NULL,
+    transaction_type VARCHAR(20) NOT NULL,
+    amount_minor BIGINT NOT NULL,
+    currency VARCHAR(3) NOT NULL,
+    order_id VARCHAR(64) NOT NULL DEFAULT '',
+    decision VARCHAR(10) NOT NULL CHECK (decision IN ('allow', 'review', 'deny')),
+    score INT NOT NULL,
+    hits JSONB NOT NULL DEFAULT '[]',
+    transaction_id BIGINT REFERENCES transactions(id),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_risk_decisions_card_id_created_at ON risk_decisions(card_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_risk_decisions_review ON risk_decisions(created_at) WHERE decision <> 'allow';
+CREATE INDEX IF NOT EXISTS idx_cards_user_id_created_at ON cards(user_id, created_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_cards_user_id_created_at;
+DROP TABLE IF EXISTS risk_decisions;
+-- +goose StatementEnd
@@ -0,0 +1,25 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Outbox доменных событий для других сервисов. Строка пишется в транзакции изменения,
+-- relay публикует неопубликованные по возрастанию id и отмечает published_at.
+-- События одной карты пишутся под её блокировкой, поэтому их id растут в порядке коммитов.
+CREATE TABLE IF NOT EXISTS outbox (
+    id BIGSERIAL PRIMARY KEY,
+    card_id BIGINT NOT NULL,
+    event_type VARCHAR(64) NOT NULL,
+    schema_version INT NOT NULL,
+    payload BYTEA NOT NULL,
+    attempts INT NOT NULL DEFAULT 0,
+    last_error TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    published_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_outbox_pending ON outbox(id) WHERE published_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_outbox_published_at ON outbox(published_at) WHERE published_at IS NOT NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS outbox;
+-- +goose StatementEnd
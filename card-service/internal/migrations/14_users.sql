@@ -0,0 +1,19 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Пользователи user-service (UUID) и их id в card-service. user_id в cards и остальных
+-- таблицах остается BIGINT: UUID из токена переводится в id при аутентификации.
+-- Новые id выдаются после уже занятых в cards, чтобы не совпасть с пользователями,
+-- которые раньше передавали user_id сами.
+CREATE TABLE IF NOT EXISTS users (
+    id BIGSERIAL PRIMARY KEY,
+    external_id VARCHAR(36) NOT NULL UNIQUE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+SELECT setval(pg_get_serial_sequence('users', 'id'), COALESCE((SELECT MAX(user_id) FROM cards), 0) + 1, false);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS users;
+-- +goose StatementEnd
@@ -0,0 +1,49 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Запланированные переводы: from_card_id NULL - пополнение to_card_id.
+-- cron пустой - разовый перевод в next_run_at. next_run_at - ближайший запуск
+-- или повтор неудачного, attempt - сколько раз текущий запуск уже не удался.
+CREATE TABLE IF NOT EXISTS scheduled_transfers (
+    id BIGSERIAL PRIMARY KEY,
+    user_id BIGINT NOT NULL,
+    from_card_id BIGINT REFERENCES cards(id),
+    to_card_id BIGINT NOT NULL REFERENCES cards(id),
+    amount_minor BIGINT NOT NULL CHECK (amount_minor > 0),
+    currency VARCHAR(3) NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    cron VARCHAR(100) NOT NULL DEFAULT '',
+    timezone VARCHAR(64) NOT NULL DEFAULT 'UTC',
+    status VARCHAR(20) NOT NULL DEFAULT 'active'
+        CHECK (status IN ('active', 'completed', 'cancelled', 'failed')),
+    next_run_at TIMESTAMPTZ NOT NULL,
+    last_run_at TIMESTAMPTZ,
+    attempt INT NOT NULL DEFAULT 0,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    CHECK (from_card_id IS NULL OR from_card_id <> to_card_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_scheduled_transfers_due ON scheduled_transfers(next_run_at, id) WHERE status = 'active';
+CREATE INDEX IF NOT EXISTS idx_scheduled_transfers_user ON scheduled_transfers(user_id, id);
+
+-- Результат каждого запуска, в том числе неудачного
+CREATE TABLE IF NOT EXISTS scheduled_transfer_runs (
+    id BIGSERIAL PRIMARY KEY,
+    scheduled_transfer_id BIGINT NOT NULL REFERENCES scheduled_transfers(id),
+    scheduled_for TIMESTAMPTZ NOT NULL,
+    attempt INT NOT NULL,
+    status VARCHAR(20) NOT NULL CHECK (status IN ('success', 'retry', 'failed')),
+    error_code VARCHAR(50) NOT NULL DEFAULT '',
+    error TEXT NOT NULL DEFAULT '',
+    transaction_id BIGINT REFERENCES transactions(id),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_scheduled_transfer_runs_transfer ON scheduled_transfer_runs(scheduled_transfer_id, id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS scheduled_transfer_runs;
+DROP TABLE IF EXISTS scheduled_transfers;
+-- +goose StatementEnd
@@ -0,0 +1,45 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Карта для переводов по номеру телефона. NULL - зачисляется на первую
+-- действующую карту пользователя в валюте перевода.
+ALTER TABLE users ADD COLUMN IF NOT EXISTS default_card_id BIGINT REFERENCES cards(id);
+
+-- Поиски получателя по телефону, по ним ограничивается частота поиска отправителя.
+-- Сам номер не хранится.
+CREATE TABLE IF NOT EXISTS phone_lookups (
+    id BIGSERIAL PRIMARY KEY,
+    user_id BIGINT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_phone_lookups_user ON phone_lookups(user_id, created_at);
+
+-- Переводы по номеру телефона: строка создается подтверждением с замаскированным
+-- именем получателя, перевод выполняется по token не позже expires_at
+CREATE TABLE IF NOT EXISTS p2p_transfers (
+    id BIGSERIAL PRIMARY KEY,
+    token VARCHAR(64) NOT NULL UNIQUE,
+    user_id BIGINT NOT NULL,
+    from_card_id BIGINT NOT NULL REFERENCES cards(id),
+    recipient_user_id BIGINT NOT NULL REFERENCES users(id),
+    recipient_card_id BIGINT NOT NULL REFERENCES cards(id),
+    recipient_name VARCHAR(255) NOT NULL DEFAULT '',
+    amount_minor BIGINT NOT NULL CHECK (amount_minor > 0),
+    currency VARCHAR(3) NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    status VARCHAR(20) NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'completed')),
+    transaction_id BIGINT REFERENCES transactions(id),
+    expires_at TIMESTAMPTZ NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    completed_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_p2p_transfers_user ON p2p_transfers(user_id, id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS p2p_transfers;
+DROP TABLE IF EXISTS phone_lookups;
+ALTER TABLE users DROP COLUMN IF EXISTS default_card_id;
+-- +goose StatementEnd
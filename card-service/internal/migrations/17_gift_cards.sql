@@ -0,0 +1,24 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Подарочные карты. Сама карта - строка cards с card_type = 'gift' без номера:
+-- баланс, срок и статус ведутся как у обычной карты. До погашения user_id = 0,
+-- при погашении карта переходит к пользователю.
+-- Код и PIN хранятся только HMAC-отпечатками (ключ отпечатков номеров карт).
+CREATE TABLE IF NOT EXISTS gift_cards (
+    card_id BIGINT PRIMARY KEY REFERENCES cards(id),
+    batch_id VARCHAR(64) NOT NULL DEFAULT '',
+    code_fingerprint VARCHAR(64) NOT NULL UNIQUE,
+    pin_hash VARCHAR(64) NOT NULL,
+    failed_pin_attempts INT NOT NULL DEFAULT 0,
+    redeemed_by BIGINT,
+    redeemed_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_gift_cards_batch ON gift_cards(batch_id) WHERE batch_id <> '';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS gift_cards;
+-- +goose StatementEnd
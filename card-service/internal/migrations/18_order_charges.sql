@@ -0,0 +1,27 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Заказы, по которым было списание. Строка вставляется в транзакции первого списания
+-- по заказу: первичный ключ не дает списать заказ дважды, в том числе параллельно.
+-- Повторное списание проходит только с явным additional_charge и строку не меняет.
+-- order_id уникален в пределах пользователя, как и в выборках платежей по заказу.
+CREATE TABLE IF NOT EXISTS order_charges (
+    user_id BIGINT NOT NULL,
+    order_id VARCHAR(64) NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (user_id, order_id)
+);
+
+-- Заказы, оплаченные до появления таблицы
+INSERT INTO order_charges (user_id, order_id, created_at)
+SELECT DISTINCT ON (c.user_id, t.order_id) c.user_id, t.order_id, t.created_at
+FROM transactions t
+JOIN cards c ON c.id = t.card_id
+WHERE t.order_id <> '' AND t.transaction_type = 'payment' AND t.status IN ('pending', 'success')
+ORDER BY c.user_id, t.order_id, t.id
+ON CONFLICT DO NOTHING;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS order_charges;
+-- +goose StatementEnd
@@ -0,0 +1,55 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Категория заказа или мерчант оплаты, задается Order Service. Возврат наследует
+-- категорию оплаты, холд передает свою в capture.
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS category VARCHAR(64) NOT NULL DEFAULT '';
+ALTER TABLE payment_authorizations ADD COLUMN IF NOT EXISTS category VARCHAR(64) NOT NULL DEFAULT '';
+
+-- Дневные агрегаты для аналитики трат: обновляются в транзакции каждой успешной
+-- операции, так что отчет не читает историю карты. Дни - по UTC.
+CREATE TABLE IF NOT EXISTS card_daily_totals (
+    card_id BIGINT NOT NULL REFERENCES cards(id),
+    day DATE NOT NULL,
+    transaction_type VARCHAR(20) NOT NULL,
+    category VARCHAR(64) NOT NULL DEFAULT '',
+    currency VARCHAR(3) NOT NULL,
+    amount_minor BIGINT NOT NULL,
+    tx_count INT NOT NULL,
+    PRIMARY KEY (card_id, day, transaction_type, category)
+);
+
+-- Баланс карты на конец дня - balance_after последней транзакции дня. Дни без
+-- операций не хранятся, баланс в них равен балансу предыдущего дня.
+CREATE TABLE IF NOT EXISTS card_daily_balances (
+    card_id BIGINT NOT NULL REFERENCES cards(id),
+    day DATE NOT NULL,
+    balance_minor BIGINT NOT NULL,
+    last_transaction_id BIGINT NOT NULL,
+    PRIMARY KEY (card_id, day)
+);
+
+-- Агрегаты по существующей истории
+INSERT INTO card_daily_totals (card_id, day, transaction_type, category, currency, amount_minor, tx_count)
+SELECT card_id, (created_at AT TIME ZONE 'UTC')::DATE, transaction_type, category, MIN(currency),
+       SUM(amount_minor), COUNT(*)
+FROM transactions
+WHERE status = 'success'
+GROUP BY 1, 2, 3, 4
+ON CONFLICT DO NOTHING;
+
+INSERT INTO card_daily_balances (card_id, day, balance_minor, last_transaction_id)
+SELECT DISTINCT ON (card_id, (created_at AT TIME ZONE 'UTC')::DATE)
+       card_id, (created_at AT TIME ZONE 'UTC')::DATE, balance_after_minor, id
+FROM transactions
+WHERE status = 'success'
+ORDER BY card_id, (created_at AT TIME ZONE 'UTC')::DATE, created_at DESC, id DESC
+ON CONFLICT DO NOTHING;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS card_daily_balances;
+DROP TABLE IF EXISTS card_daily_totals;
+ALTER TABLE payment_authorizations DROP COLUMN IF EXISTS category;
+ALTER TABLE transactions DROP COLUMN IF EXISTS category;
+-- +goose StatementEnd
@@ -0,0 +1,11 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Админ в истории статусов - имя из его токена (CARD_ADMIN_TOKENS), а не id из запроса.
+-- actor_id старых записей админов задавал вызывающий и не проверялся.
+ALTER TABLE card_status_history ADD COLUMN IF NOT EXISTS actor_name VARCHAR(64) NOT NULL DEFAULT '';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE card_status_history DROP COLUMN IF EXISTS actor_name;
+-- +goose StatementEnd
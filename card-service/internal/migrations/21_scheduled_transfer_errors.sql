@@ -0,0 +1,16 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Внутренние ошибки запуска (база, шифрование и т.п.) не пишутся в scheduled_transfer_runs:
+-- запуск повторяется с тем же next_run_at, а ошибка и время повтора хранятся здесь.
+-- retry_at растет с error_count, чтобы одна сломанная запись не занимала каждый тик.
+ALTER TABLE scheduled_transfers ADD COLUMN IF NOT EXISTS last_error TEXT NOT NULL DEFAULT '';
+ALTER TABLE scheduled_transfers ADD COLUMN IF NOT EXISTS error_count INT NOT NULL DEFAULT 0;
+ALTER TABLE scheduled_transfers ADD COLUMN IF NOT EXISTS retry_at TIMESTAMPTZ;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE scheduled_transfers DROP COLUMN IF EXISTS retry_at;
+ALTER TABLE scheduled_transfers DROP COLUMN IF EXISTS error_count;
+ALTER TABLE scheduled_transfers DROP COLUMN IF EXISTS last_error;
+-- +goose StatementEnd
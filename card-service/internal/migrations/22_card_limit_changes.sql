@@ -0,0 +1,25 @@
+-- +goose Up
+-- +goose StatementBegin
+-- История замены лимитов: владелец карты или админ (имя из его токена CARD_ADMIN_TOKENS).
+-- limits - новый набор в том виде, в каком его вернул SetCardLimits, [] - лимиты сняты.
+CREATE TABLE IF NOT EXISTS card_limit_changes (
+    id BIGSERIAL PRIMARY KEY,
+    card_id BIGINT NOT NULL REFERENCES cards(id),
+    actor VARCHAR(10) NOT NULL CHECK (actor IN ('user', 'admin')),
+    actor_id BIGINT,
+    actor_name VARCHAR(64) NOT NULL DEFAULT '',
+    reason TEXT NOT NULL DEFAULT '',
+    limits JSONB NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_card_limit_changes_card_id ON card_limit_changes(card_id, id);
+
+CREATE TRIGGER trg_card_limit_changes_append_only BEFORE UPDATE OR DELETE ON card_limit_changes
+    FOR EACH ROW EXECUTE FUNCTION ledger_append_only();
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS card_limit_changes;
+-- +goose StatementEnd
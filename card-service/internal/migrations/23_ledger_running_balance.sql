@@ -0,0 +1,20 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Текущий баланс счета карты (кредиты минус дебеты) обновляется вместе с проводками,
+-- чтобы операция не суммировала все проводки счета. У системных счетов не ведется:
+-- их строку обновляла бы каждая операция в валюте. Сверка по-прежнему считает сумму проводок.
+ALTER TABLE ledger_accounts ADD COLUMN IF NOT EXISTS balance_minor BIGINT NOT NULL DEFAULT 0;
+
+UPDATE ledger_accounts a SET balance_minor = p.balance
+FROM (
+    SELECT account_id, SUM(CASE WHEN direction = 'credit' THEN amount_minor ELSE -amount_minor END)::BIGINT AS balance
+    FROM ledger_postings
+    GROUP BY account_id
+) p
+WHERE p.account_id = a.id AND a.kind = 'card';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE ledger_accounts DROP COLUMN IF EXISTS balance_minor;
+-- +goose StatementEnd
@@ -0,0 +1,17 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Кто задал лимит: user - владелец, admin - поддержка через AdminSetCardLimits.
+-- Лимиты админа владелец не меняет и не снимает.
+ALTER TABLE card_limits ADD COLUMN IF NOT EXISTS set_by VARCHAR(10) NOT NULL DEFAULT 'user'
+    CHECK (set_by IN ('user', 'admin'));
+
+-- Лимиты, последним заменял которые админ
+UPDATE card_limits l SET set_by = 'admin'
+FROM (SELECT DISTINCT ON (card_id) card_id, actor FROM card_limit_changes ORDER BY card_id, id DESC) c
+WHERE c.card_id = l.card_id AND c.actor = 'admin';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE card_limits DROP COLUMN IF EXISTS set_by;
+-- +goose StatementEnd
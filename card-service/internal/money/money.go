@@ -0,0 +1,127 @@
+package money
+
+import (
+	"errors"
+	"math"
+	"strconv"
+	"strings"
+)
+
+var (
+	ErrUnsupportedCurrency = errors.New("unsupported currency")
+	ErrInvalidAmount       = errors.New("invalid amount")
+)
+
+// exponents - число знаков после запятой (ISO 4217) для поддерживаемых валют
+var exponents = map[string]int{
+	// 0 знаков
+	"JPY": 0,
+	"KRW": 0,
+	"VND": 0,
+	"CLP": 0,
+	// 2 знака
+	"RUB": 2,
+	"USD": 2,
+	"EUR": 2,
+	"GBP": 2,
+	"CNY": 2,
+	"KZT": 2,
+	"UZS": 2,
+	"BYN": 2,
+	"TRY": 2,
+	"AED": 2,
+	// 3 знака
+	"BHD": 3,
+	"KWD": 3,
+	"OMR": 3,
+	"JOD": 3,
+	"TND": 3,
+}
+
+// Money - сумма в минимальных единицах валюты (копейки, центы, филсы)
+type Money struct {
+	UnitsMinor int64
+	Currency   string
+}
+
+// NormalizeCurrency приводит код валюты к виду "RUB"
+func NormalizeCurrency(currency string) string {
+	return strings.ToUpper(strings.TrimSpace(currency))
+}
+
+// Exponent возвращает число знаков после запятой для валюты
+func Exponent(currency string) (int, error) {
+	exp, ok := exponents[NormalizeCurrency(currency)]
+	if !ok {
+		return 0, ErrUnsupportedCurrency
+	}
+	return exp, nil
+}
+
+// IsSupported проверяет что валюта есть в таблице exponents
+func IsSupported(currency string) bool {
+	_, err := Exponent(currency)
+	return err == nil
+}
+
+// ToMinor переводит сумму в основных единицах (рублях) в минимальные (копейки)
+// с округлением до ближайшей минимальной единицы. Нужна только для v1 API с double.
+func ToMinor(amount float64, currency string) (int64, error) {
+	exp, err := Exponent(currency)
+	if err != nil {
+		return 0, err
+	}
+	if math.IsNaN(amount) || math.IsInf(amount, 0) {
+		return 0, ErrInvalidAmount
+	}
+	// Через десятичную строку, а не amount*10^exp: 1.005*100 = 100.49999...
+	scaled, err := strconv.ParseFloat(strconv.FormatFloat(amount, 'f', -1, 64)+"e"+strconv.Itoa(exp), 64)
+	if err != nil {
+		return 0, ErrInvalidAmount
+	}
+	scaled = math.Round(scaled)
+	if scaled >= math.MaxInt64 || scaled <= math.MinInt64 {
+		return 0, ErrInvalidAmount
+	}
+	return int64(scaled), nil
+}
+
+// ToMajor переводит минимальные единицы в основные. Нужна только для v1 API с double.
+func ToMajor(units int64, currency string) (float64, error) {
+	exp, err := Exponent(currency)
+	if err != nil {
+		return 0, err
+	}
+	f, err := strconv.ParseFloat(Format(units, exp), 64)
+	if err != nil {
+		return 0, err
+	}
+	return f, nil
+}
+
+// Format печатает сумму с exp знаками после запятой без потери точности: Format(-1050, 2) = "-10.50"
+func Format(units int64, exp int) string {
+	sign := ""
+	u := uint64(units)
+	if units < 0 {
+		sign = "-"
+		u = uint64(-units)
+	}
+	digits := strconv.FormatUint(u, 10)
+	if exp == 0 {
+		return sign + digits
+	}
+	if len(digits) <= exp {
+		digits = strings.Repeat("0", exp-len(digits)+1) + digits
+	}
+	return sign + digits[:len(digits)-exp] + "." + digits[len(digits)-exp:]
+}
+
+// String печатает сумму вместе с валютой: "10.50 RUB"
+func (m Money) String() string {
+	exp, err := Exponent(m.Currency)
+	if err != nil {
+		return strconv.FormatInt(m.UnitsMinor, 10) + " " + m.Currency + " (minor units)"
+	}
+	return Format(m.UnitsMinor, exp) + " " + m.Currency
+}
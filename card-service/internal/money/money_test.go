@@ -0,0 +1,97 @@
+package money
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestToMinor(t *testing.T) {
+	cases := []struct {
+		amount   float64
+		currency string
+		want     int64
+	}{
+		{10, "RUB", 1000},
+		{10.5, "rub", 1050},
+		{0.1 + 0.2, "USD", 30},
+		{1.005, "EUR", 101},
+		{19.99, "USD", 1999},
+		{1234.4, "JPY", 1234},
+		{1234.5, "JPY", 1235},
+		{1.2345, "KWD", 1235},
+		{0.001, "BHD", 1},
+		{-2.5, "RUB", -250},
+		{0, "RUB", 0},
+	}
+	for _, tc := range cases {
+		got, err := ToMinor(tc.amount, tc.currency)
+		if err != nil {
+			t.Fatalf("ToMinor(%v, %q): %v", tc.amount, tc.currency, err)
+		}
+		if got != tc.want {
+			t.Fatalf("ToMinor(%v, %q) = %d, want %d", tc.amount, tc.currency, got, tc.want)
+		}
+	}
+}
+
+func TestToMinorErrors(t *testing.T) {
+	if _, err := ToMinor(1, "XXX"); !errors.Is(err, ErrUnsupportedCurrency) {
+		t.Fatalf("unknown currency: got %v", err)
+	}
+	for _, amount := range []float64{math.NaN(), math.Inf(1), math.Inf(-1), 1e30} {
+		if _, err := ToMinor(amount, "RUB"); !errors.Is(err, ErrInvalidAmount) {
+			t.Fatalf("ToMinor(%v): got %v, want ErrInvalidAmount", amount, err)
+		}
+	}
+}
+
+func TestToMajor(t *testing.T) {
+	cases := []struct {
+		units    int64
+		currency string
+		want     float64
+	}{
+		{1050, "RUB", 10.5},
+		{1999, "USD", 19.99},
+		{1235, "JPY", 1235},
+		{1235, "KWD", 1.235},
+		{-5, "RUB", -0.05},
+	}
+	for _, tc := range cases {
+		got, err := ToMajor(tc.units, tc.currency)
+		if err != nil {
+			t.Fatalf("ToMajor(%d, %q): %v", tc.units, tc.currency, err)
+		}
+		if got != tc.want {
+			t.Fatalf("ToMajor(%d, %q) = %v, want %v", tc.units, tc.currency, got, tc.want)
+		}
+	}
+}
+
+func TestFormat(t *testing.T) {
+	cases := []struct {
+		units int64
+		exp   int
+		want  string
+	}{
+		{1050, 2, "10.50"},
+		{5, 2, "0.05"},
+		{-5, 2, "-0.05"},
+		{0, 2, "0.00"},
+		{1235, 0, "1235"},
+		{1, 3, "0.001"},
+		{math.MinInt64, 2, "-92233720368547758.08"},
+	}
+	for _, tc := range cases {
+		if got := Format(tc.units, tc.exp); got != tc.want {
+			t.Fatalf("Format(%d, %d) = %q, want %q", tc.units, tc.exp, got, tc.want)
+		}
+	}
+}
+
+func TestMoneyString(t *testing.T) {
+	if got := (Money{UnitsMinor: 1050, Currency: "RUB"}).String(); got != "10.50 RUB" {
+		t.Fatalf("String() = %q", got)
+	}
+}
@@ -0,0 +1,38 @@
+package notify
+
+import (
+	"context"
+	"log"
+)
+
+// Виды уведомлений
+const (
+	KindCardExpiring = "card_expiring"
+
+	KindScheduledTransferCompleted = "scheduled_transfer_completed"
+	KindScheduledTransferFailed    = "scheduled_transfer_failed"
+)
+
+// Notification - уведомление владельцу карты
+type Notification struct {
+	Kind   string
+	CardID int64
+	Text   string
+}
+
+// Notifier доставляет уведомления пользователям
+type Notifier interface {
+	Notify(ctx context.Context, userID int64, n Notification) error
+}
+
+// logNotifier пишет уведомления в лог, пока в системе нет сервиса уведомлений
+type logNotifier struct{}
+
+func NewLogNotifier() Notifier {
+	return logNotifier{}
+}
+
+func (logNotifier) Notify(ctx context.Context, userID int64, n Notification) error {
+	log.Printf("notification to user %d: kind=%s card_id=%d: %s", userID, n.Kind, n.CardID, n.Text)
+	return nil
+}
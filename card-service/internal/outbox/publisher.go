@@ -0,0 +1,107 @@
+// Package outbox публикует доменные события card-service, записанные в таблицу outbox
+// в транзакциях изменений. Доставка не реже одного раза: сообщение отмечается
+// опубликованным только после успешного Publish, повтор возможен после сбоя.
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+)
+
+// Message - событие для публикации. Key - ключ упорядочивания (id карты): брокер
+// с разделами (Kafka, NATS JetStream) должен отправлять сообщения одного ключа
+// в один раздел, чтобы потребитель получал события карты по порядку.
+type Message struct {
+	ID            int64     `json:"id"` // id в outbox, возрастает в пределах ключа
+	Key           string    `json:"key"`
+	Type          string    `json:"type"`
+	SchemaVersion int       `json:"schema_version"`
+	Payload       []byte    `json:"payload"` // card_events_v1.DomainEvent
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// EventPublisher доставляет сообщение во внешнюю систему. nil означает, что
+// сообщение принято и повторно его отправлять не нужно.
+type EventPublisher interface {
+	Publish(ctx context.Context, msg Message) error
+}
+
+// logPublisher пишет события в лог, пока в системе нет брокера сообщений
+type logPublisher struct{}
+
+func NewLogPublisher() EventPublisher {
+	return logPublisher{}
+}
+
+func (logPublisher) Publish(ctx context.Context, msg Message) error {
+	log.Printf("outbox event: id=%d key=%s type=%s v%d payload=%d bytes",
+		msg.ID, msg.Key, msg.Type, msg.SchemaVersion, len(msg.Payload))
+	return nil
+}
+
+// FilePublisher дописывает события в файл строками JSON. Строка сбрасывается на диск
+// до возврата из Publish.
+type FilePublisher struct {
+	mu   sync.Mutex
+	file *os.File
+	enc  *json.Encoder
+}
+
+func NewFilePublisher(path string) (*FilePublisher, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("open outbox file: %w", err)
+	}
+	return &FilePublisher{file: f, enc: json.NewEncoder(f)}, nil
+}
+
+func (p *FilePublisher) Publish(ctx context.Context, msg Message) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if err := p.enc.Encode(msg); err != nil {
+		return err
+	}
+	return p.file.Sync()
+}
+
+func (p *FilePublisher) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.file.Close()
+}
+
+// MemoryBus хранит опубликованные события в памяти, для тестов. Fail, если задан,
+// вызывается перед публикацией и может отклонить сообщение.
+type MemoryBus struct {
+	mu       sync.Mutex
+	messages []Message
+	Fail     func(msg Message) error
+}
+
+func NewMemoryBus() *MemoryBus {
+	return &MemoryBus{}
+}
+
+func (b *MemoryBus) Publish(ctx context.Context, msg Message) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.Fail != nil {
+		if err := b.Fail(msg); err != nil {
+			return err
+		}
+	}
+	b.messages = append(b.messages, msg)
+	return nil
+}
+
+// Messages - опубликованные сообщения в порядке публикации
+func (b *MemoryBus) Messages() []Message {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return append([]Message(nil), b.messages...)
+}
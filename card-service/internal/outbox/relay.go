@@ -0,0 +1,104 @@
+package outbox
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/mrevds/pizza-app/card-service/internal/entity"
+)
+
+// Store - чтение и отметки outbox. Реализуется репозиторием.
+type Store interface {
+	ListPendingOutbox(ctx context.Context, afterID int64, limit int) ([]*entity.OutboxMessage, error)
+	MarkOutboxPublished(ctx context.Context, ids []int64) error
+	MarkOutboxFailed(ctx context.Context, id int64, lastError string) error
+	DeletePublishedOutboxBefore(ctx context.Context, before time.Time) (int64, error)
+}
+
+// Relay переносит сообщения из outbox в EventPublisher. Одновременно должен работать
+// один Relay (задача планировщика с выбором лидера), иначе порядок не гарантирован.
+type Relay struct {
+	store     Store
+	publisher EventPublisher
+	batch     int
+}
+
+func NewRelay(store Store, publisher EventPublisher, batch int) *Relay {
+	if batch <= 0 {
+		batch = 100
+	}
+	return &Relay{store: store, publisher: publisher, batch: batch}
+}
+
+// Run публикует неопубликованные сообщения по возрастанию id. После ошибки публикации
+// остальные сообщения той же карты до следующего запуска не отправляются, чтобы не
+// нарушить порядок, сообщения других карт публикуются дальше.
+func (r *Relay) Run(ctx context.Context) (int64, error) {
+	var (
+		published int64
+		afterID   int64
+		failed    int
+		lastErr   error
+		stopped   = make(map[int64]bool)
+	)
+	for {
+		messages, err := r.store.ListPendingOutbox(ctx, afterID, r.batch)
+		if err != nil {
+			return published, err
+		}
+		var ids []int64
+		for _, m := range messages {
+			afterID = m.ID
+			if stopped[m.CardID] {
+				continue
+			}
+			if err := r.publisher.Publish(ctx, toMessage(m)); err != nil {
+				if ctx.Err() != nil {
+					break
+				}
+				stopped[m.CardID] = true
+				failed++
+				lastErr = err
+				if err := r.store.MarkOutboxFailed(ctx, m.ID, err.Error()); err != nil {
+					return published, err
+				}
+				continue
+			}
+			ids = append(ids, m.ID)
+		}
+		if len(ids) > 0 {
+			if err := r.store.MarkOutboxPublished(ctx, ids); err != nil {
+				return published, err
+			}
+			published += int64(len(ids))
+		}
+		if err := ctx.Err(); err != nil {
+			return published, err
+		}
+		if len(messages) < r.batch {
+			break
+		}
+	}
+	if lastErr != nil {
+		return published, fmt.Errorf("failed to publish %d outbox messages: %w", failed, lastErr)
+	}
+	return published, nil
+}
+
+// Purge удаляет сообщения, опубликованные раньше before
+func (r *Relay) Purge(ctx context.Context, before time.Time) (int64, error) {
+	return r.store.DeletePublishedOutboxBefore(ctx, before)
+}
+
+func toMessage(m *entity.OutboxMessage) Message {
+	return Message{
+		ID:            m.ID,
+		Key:           strconv.FormatInt(m.CardID, 10),
+		Type:          m.EventType,
+		SchemaVersion: m.SchemaVersion,
+		Payload:       m.Payload,
+		CreatedAt:     m.CreatedAt,
+	}
+}
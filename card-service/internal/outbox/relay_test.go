@@ -0,0 +1,124 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mrevds/pizza-app/card-service/internal/entity"
+)
+
+type memStore struct {
+	mu       sync.Mutex
+	messages []*entity.OutboxMessage
+}
+
+func (s *memStore) add(cardID int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.messages = append(s.messages, &entity.OutboxMessage{ID: int64(len(s.messages) + 1), CardID: cardID, EventType: "test"})
+}
+
+func (s *memStore) ListPendingOutbox(ctx context.Context, afterID int64, limit int) ([]*entity.OutboxMessage, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var out []*entity.OutboxMessage
+	for _, m := range s.messages {
+		if m.PublishedAt == nil && m.ID > afterID && len(out) < limit {
+			cp := *m
+			out = append(out, &cp)
+		}
+	}
+	return out, nil
+}
+
+func (s *memStore) MarkOutboxPublished(ctx context.Context, ids []int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	for _, id := range ids {
+		s.messages[id-1].PublishedAt = &now
+	}
+	return nil
+}
+
+func (s *memStore) MarkOutboxFailed(ctx context.Context, id int64, lastError string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.messages[id-1].Attempts++
+	s.messages[id-1].LastError = lastError
+	return nil
+}
+
+func (s *memStore) DeletePublishedOutboxBefore(ctx context.Context, before time.Time) (int64, error) {
+	return 0, nil
+}
+
+func TestRelayKeepsOrderPerCard(t *testing.T) {
+	store := &memStore{}
+	// Карта 1: сообщения 1, 3, 5; карта 2: 2, 4, 6. Батч меньше числа сообщений.
+	for i := 0; i < 6; i++ {
+		store.add(int64(i%2 + 1))
+	}
+	bus := NewMemoryBus()
+	bus.Fail = func(msg Message) error {
+		if msg.ID == 3 {
+			return errors.New("broker unavailable")
+		}
+		return nil
+	}
+	relay := NewRelay(store, bus, 4)
+	ctx := context.Background()
+
+	n, err := relay.Run(ctx)
+	if err == nil || n != 4 {
+		t.Fatalf("first run: published %d, err %v", n, err)
+	}
+	// После сбоя на 3 остальные сообщения карты 1 ждут, карта 2 публикуется целиком
+	if got := ids(bus.Messages()); !equal(got, []int64{1, 2, 4, 6}) {
+		t.Fatalf("first run published %v", got)
+	}
+	if m := store.messages[2]; m.Attempts != 1 || m.LastError == "" || m.PublishedAt != nil {
+		t.Fatalf("failed message: %+v", m)
+	}
+
+	bus.Fail = nil
+	if n, err := relay.Run(ctx); err != nil || n != 2 {
+		t.Fatalf("second run: published %d, err %v", n, err)
+	}
+	var card1 []int64
+	for _, msg := range bus.Messages() {
+		if msg.Key == "1" {
+			card1 = append(card1, msg.ID)
+		}
+	}
+	if !sort.SliceIsSorted(card1, func(i, j int) bool { return card1[i] < card1[j] }) || len(card1) != 3 {
+		t.Fatalf("card 1 events out of order: %v", card1)
+	}
+	if n, err := relay.Run(ctx); err != nil || n != 0 {
+		t.Fatalf("idle run: published %d, err %v", n, err)
+	}
+}
+
+func ids(messages []Message) []int64 {
+	out := make([]int64, 0, len(messages))
+	for _, m := range messages {
+		out = append(out, m.ID)
+	}
+	return out
+}
+
+func equal(a, b []int64) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -1 +1,219 @@
 package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/mrevds/pizza-app/card-service/internal/entity"
+)
+
+var (
+	ErrNotFound      = errors.New("not found")
+	ErrAlreadyExists = errors.New("already exists")
+)
+
+// TransactionFilter - условия выборки истории. CardID != 0 - одна карта, иначе все
+// неудаленные карты UserID. Пустые поля не ограничивают выборку.
+type TransactionFilter struct {
+	CardID    int64
+	UserID    int64
+	From      time.Time // created_at >= From
+	To        time.Time // created_at < To
+	Types     []string
+	Statuses  []string
+	Currency  string // обязателен вместе с MinAmount/MaxAmount
+	MinAmount int64
+	MaxAmount int64
+	Text      string // подстрока description без учета регистра
+
+	// Порядок - (created_at, id) по убыванию, Ascending - по возрастанию.
+	// After - последняя запись предыдущей страницы.
+	Ascending bool
+	After     *TransactionCursor
+	Limit     int
+	Offset    int
+}
+
+type TransactionCursor struct {
+	CreatedAt time.Time
+	ID        int64
+}
+
+// DailyTotalFilter - дневные агрегаты за дни From <= day < To. CardID != 0 - одна карта,
+// иначе все неудаленные карты UserID.
+type DailyTotalFilter struct {
+	CardID int64
+	UserID int64
+	From   time.Time
+	To     time.Time
+}
+
+type CardRepository interface {
+	// RunInTx выполняет fn в одной транзакции БД. Репозиторий, переданный в fn,
+	// работает внутри этой транзакции.
+	RunInTx(ctx context.Context, fn func(repo CardRepository) error) error
+
+	// ResolveUser возвращает id пользователя по UUID из user-service, при первом
+	// обращении выдает новый
+	ResolveUser(ctx context.Context, externalID string) (int64, error)
+	// LockUser блокирует строку пользователя до конца транзакции, ErrNotFound если её нет
+	LockUser(ctx context.Context, userID int64) error
+	// GetDefaultCardID - карта для переводов по телефону, 0 если не выбрана
+	GetDefaultCardID(ctx context.Context, userID int64) (int64, error)
+	// SetDefaultCard возвращает ErrNotFound, если пользователя нет в users
+	SetDefaultCard(ctx context.Context, userID, cardID int64) error
+
+	CreateCard(ctx context.Context, card *entity.Card) error
+	GetCard(ctx context.Context, cardID int64) (*entity.Card, error)
+	GetCardForUpdate(ctx context.Context, cardID int64) (*entity.Card, error)
+	GetUserCards(ctx context.Context, userID int64) ([]*entity.Card, error)
+	UpdateCard(ctx context.Context, card *entity.Card) error
+	DeleteCard(ctx context.Context, cardID int64) error
+	// SetCardOwner передает карту пользователю: так погашается подарочная карта
+	SetCardOwner(ctx context.Context, cardID, userID int64) error
+	// UpdateCardStatus меняет статус карты, флаги is_active/is_blocked выводятся из статуса
+	UpdateCardStatus(ctx context.Context, cardID int64, status, blockReason string) error
+	CreateStatusChange(ctx context.Context, change *entity.CardStatusChange) error
+	// GetCardStatusHistory возвращает историю статусов карты от старых к новым
+	GetCardStatusHistory(ctx context.Context, cardID int64) ([]*entity.CardStatusChange, error)
+	UpdateBalance(ctx context.Context, cardID int64, balance int64) error
+
+	// ListCardsToExpire возвращает id не закрытых карт, срок которых истек к now
+	ListCardsToExpire(ctx context.Context, now time.Time, limit int) ([]int64, error)
+	// ListCardsExpiringBefore возвращает действующие карты со сроком до before,
+	// владельца которых еще не предупредили об этом сроке
+	ListCardsExpiringBefore(ctx context.Context, now, before time.Time, limit int) ([]*entity.Card, error)
+	MarkExpiryNotified(ctx context.Context, cardID int64, expiryDate string) error
+	// ListCardIDs возвращает id неудаленных карт больше afterID по возрастанию
+	ListCardIDs(ctx context.Context, afterID int64, limit int) ([]int64, error)
+
+	// ListCardKeysToRotate возвращает ключи карт, обернутые не активной версией мастер-ключа
+	ListCardKeysToRotate(ctx context.Context, activeVersion int, afterID int64, limit int) ([]*entity.CardKey, error)
+	UpdateCardKey(ctx context.Context, key *entity.CardKey, previousVersion int) error
+
+	// GetOrCreateAccount возвращает счет главной книги карты (cardID != 0) или системный счет вида kind
+	GetOrCreateAccount(ctx context.Context, kind string, cardID int64, currency string) (*entity.LedgerAccount, error)
+	// CreateJournalEntry записывает запись журнала вместе с проводками и обновляет
+	// текущий баланс счетов карт в той же транзакции
+	CreateJournalEntry(ctx context.Context, entry *entity.JournalEntry) error
+	// GetAccountBalance - текущий баланс счета карты (ledger_accounts.balance_minor),
+	// без суммирования проводок. У системных счетов не ведется
+	GetAccountBalance(ctx context.Context, accountID int64) (int64, error)
+	// GetCardLedgerBalance считает баланс счета карты по всем проводкам: кредиты минус дебеты,
+	// 0 если счета еще нет. Для сверки
+	GetCardLedgerBalance(ctx context.Context, cardID int64) (int64, error)
+	// GetCardAccountBalance - текущий баланс счета карты, 0 если счета еще нет
+	GetCardAccountBalance(ctx context.Context, cardID int64) (int64, error)
+	// SetCardAccountBalance переписывает текущий баланс счета карты, только для исправления сверкой
+	SetCardAccountBalance(ctx context.Context, cardID, balance int64) error
+
+	// GetIdempotencyRecord возвращает неистекшую запись по ключу пользователя
+	GetIdempotencyRecord(ctx context.Context, userID int64, key string) (*entity.IdempotencyRecord, error)
+	// CreateIdempotencyRecord возвращает ErrAlreadyExists, если по ключу есть неистекшая запись
+	CreateIdempotencyRecord(ctx context.Context, rec *entity.IdempotencyRecord) error
+
+	// CreateAuthorization возвращает ErrAlreadyExists, если по карте уже есть активный холд заказа
+	CreateAuthorization(ctx context.Context, a *entity.Authorization) error
+	GetAuthorizationForUpdate(ctx context.Context, id int64) (*entity.Authorization, error)
+	UpdateAuthorization(ctx context.Context, a *entity.Authorization) error
+	// ExpireAuthorizations переводит истекшие активные холды в expired и возвращает их карты,
+	// по карте на каждый холд
+	ExpireAuthorizations(ctx context.Context) ([]int64, error)
+
+	GetCardLimits(ctx context.Context, cardID int64) ([]*entity.CardLimit, error)
+	// ReplaceCardLimits заменяет все лимиты карты, вызывать в транзакции. У лимитов
+	// с UpdatedAt он сохраняется, остальным ставится текущее время
+	ReplaceCardLimits(ctx context.Context, cardID int64, limits []*entity.CardLimit) error
+	// CreateLimitChange пишет замену лимитов в историю, вызывать в транзакции замены
+	CreateLimitChange(ctx context.Context, c *entity.CardLimitChange) error
+	// GetSpentSince - сумма успешных транзакций карты указанных типов начиная с since
+	// и активных холдов, если среди типов есть payment
+	GetSpentSince(ctx context.Context, cardID int64, txTypes []string, since time.Time) (int64, error)
+
+	CreateScheduledTransfer(ctx context.Context, t *entity.ScheduledTransfer) error
+	GetScheduledTransfer(ctx context.Context, id int64) (*entity.ScheduledTransfer, error)
+	GetScheduledTransferForUpdate(ctx context.Context, id int64) (*entity.ScheduledTransfer, error)
+	// ListUserScheduledTransfers - переводы пользователя от новых к старым, activeOnly - только активные
+	ListUserScheduledTransfers(ctx context.Context, userID int64, activeOnly bool) ([]*entity.ScheduledTransfer, error)
+	// ListDueScheduledTransfers - активные переводы с next_run_at <= now и id > afterID по возрастанию id
+	ListDueScheduledTransfers(ctx context.Context, now time.Time, afterID int64, limit int) ([]*entity.ScheduledTransfer, error)
+	// UpdateScheduledTransfer сохраняет статус, next_run_at, last_run_at и attempt
+	UpdateScheduledTransfer(ctx context.Context, t *entity.ScheduledTransfer) error
+	CreateScheduledTransferRun(ctx context.Context, run *entity.ScheduledTransferRun) error
+	// ListScheduledTransferRuns - последние limit запусков перевода от новых к старым
+	ListScheduledTransferRuns(ctx context.Context, scheduledTransferID int64, limit int) ([]*entity.ScheduledTransferRun, error)
+
+	CreatePhoneLookup(ctx context.Context, userID int64) error
+	// CountPhoneLookupsSince - сколько раз пользователь искал получателя по телефону начиная с since
+	CountPhoneLookupsSince(ctx context.Context, userID int64, since time.Time) (int, error)
+	CreateP2PTransfer(ctx context.Context, t *entity.P2PTransfer) error
+	GetP2PTransferByTokenForUpdate(ctx context.Context, token string) (*entity.P2PTransfer, error)
+	// UpdateP2PTransfer сохраняет статус, transaction_id и completed_at
+	UpdateP2PTransfer(ctx context.Context, t *entity.P2PTransfer) error
+
+	// CreateGiftCard возвращает ErrAlreadyExists, если карта с таким кодом уже выпущена
+	CreateGiftCard(ctx context.Context, g *entity.GiftCard) error
+	// GetGiftCardByCodeForUpdate ищет подарочную карту по отпечатку кода и блокирует строку
+	GetGiftCardByCodeForUpdate(ctx context.Context, codeFingerprint string) (*entity.GiftCard, error)
+	GetGiftCardForUpdate(ctx context.Context, cardID int64) (*entity.GiftCard, error)
+	// UpdateGiftCard сохраняет failed_pin_attempts, redeemed_by и redeemed_at
+	UpdateGiftCard(ctx context.Context, g *entity.GiftCard) error
+
+	CreateRiskDecision(ctx context.Context, d *entity.RiskDecision) error
+	// CountRiskDecisionsSince - сколько списаний по карте оценено начиная с since
+	CountRiskDecisionsSince(ctx context.Context, cardID int64, since time.Time) (int, error)
+	// GetDebitStats - число и средняя сумма успешных списаний карты начиная с since
+	GetDebitStats(ctx context.Context, cardID int64, since time.Time) (entity.DebitStats, error)
+	// CountUserCardsSince - сколько карт пользователь добавил начиная с since, включая удаленные
+	CountUserCardsSince(ctx context.Context, userID int64, since time.Time) (int, error)
+
+	CreateTransaction(ctx context.Context, tx *entity.Transaction) error
+	// FailPendingTransactions переводит транзакции в pending, созданные до before, в failed
+	FailPendingTransactions(ctx context.Context, before time.Time) (int64, error)
+	GetTransaction(ctx context.Context, transactionID int64) (*entity.Transaction, error)
+	GetTransactions(ctx context.Context, cardID int64, limit, offset int) ([]*entity.Transaction, int, error)
+	ListTransactions(ctx context.Context, filter TransactionFilter) ([]*entity.Transaction, error)
+	// ListCardTransactions - транзакции карты с id > afterID по возрастанию id. Транзакции
+	// карты пишутся под её блокировкой, поэтому порядок id - порядок изменения баланса.
+	ListCardTransactions(ctx context.Context, cardID, afterID int64, limit int) ([]*entity.Transaction, error)
+	// CountTransactions считает записи по фильтру без учета After, Limit и Offset
+	CountTransactions(ctx context.Context, filter TransactionFilter) (int, error)
+	// GetBalanceBefore - balance_after последней успешной транзакции карты до момента at, 0 если их не было
+	GetBalanceBefore(ctx context.Context, cardID int64, at time.Time) (int64, error)
+	// GetOrderPayments возвращает оплаты заказа по картам пользователя
+	GetOrderPayments(ctx context.Context, userID int64, orderID string) ([]*entity.Transaction, error)
+	// ListOrderPayments - оплаты нескольких заказов по картам пользователя, по возрастанию id
+	ListOrderPayments(ctx context.Context, userID int64, orderIDs []string) ([]*entity.Transaction, error)
+	// GetOrderTransactions - оплаты, возвраты и исправления сверки заказа по картам пользователя, по возрастанию id
+	GetOrderTransactions(ctx context.Context, userID int64, orderID string) ([]*entity.Transaction, error)
+	// CreateOrderCharge отмечает первое списание по заказу пользователя, ErrAlreadyExists - заказ уже списывался
+	CreateOrderCharge(ctx context.Context, orderID string, userID int64) error
+	// GetRefundedAmount - сумма успешных возвратов по оплате
+	GetRefundedAmount(ctx context.Context, paymentID int64) (int64, error)
+
+	// AddDailyTotal прибавляет Amount и Count к агрегату дня карты
+	AddDailyTotal(ctx context.Context, t *entity.DailyTotal) error
+	// SetDailyBalance сохраняет баланс на конец дня, если транзакция позже уже учтенной
+	SetDailyBalance(ctx context.Context, b *entity.DailyBalance) error
+	// ListDailyTotals - агрегаты по фильтру, сложенные по картам (CardID = 0), по возрастанию дня
+	ListDailyTotals(ctx context.Context, filter DailyTotalFilter) ([]*entity.DailyTotal, error)
+	// ListDailyBalances - балансы карты на конец дней from <= day < to, по возрастанию дня
+	ListDailyBalances(ctx context.Context, cardID int64, from, to time.Time) ([]*entity.DailyBalance, error)
+	// GetDailyBalanceBefore - баланс на конец последнего дня с операциями до day, 0 если их не было
+	GetDailyBalanceBefore(ctx context.Context, cardID int64, day time.Time) (int64, error)
+
+	CreateOutboxMessage(ctx context.Context, m *entity.OutboxMessage) error
+	// ListPendingOutbox - неопубликованные сообщения с id > afterID по возрастанию id
+	ListPendingOutbox(ctx context.Context, afterID int64, limit int) ([]*entity.OutboxMessage, error)
+	MarkOutboxPublished(ctx context.Context, ids []int64) error
+	// MarkOutboxFailed увеличивает attempts и запоминает ошибку публикации
+	MarkOutboxFailed(ctx context.Context, id int64, lastError string) error
+	DeletePublishedOutboxBefore(ctx context.Context, before time.Time) (int64, error)
+
+	CreateCardEvent(ctx context.Context, e *entity.CardEvent) error
+	// ListCardEvents - события с id > afterID по возрастанию id, userID = 0 - всех пользователей
+	ListCardEvents(ctx context.Context, afterID, userID int64, limit int) ([]*entity.CardEvent, error)
+	LastCardEventID(ctx context.Context) (int64, error)
+	DeleteCardEventsBefore(ctx context.Context, before time.Time) (int64, error)
+}
@@ -0,0 +1,43 @@
+package pg
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v4"
+
+	"github.com/mrevds/pizza-app/card-service/client"
+	"github.com/mrevds/pizza-app/card-service/internal/events"
+)
+
+// notifyListener держит LISTEN на выделенном соединении из пула
+type notifyListener struct {
+	db *client.DB
+}
+
+func NewNotifyListener(db *client.DB) events.Listener {
+	return &notifyListener{db: db}
+}
+
+func (l *notifyListener) Listen(ctx context.Context, channel string, notify func()) error {
+	conn, err := l.db.Pool.Acquire(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to acquire connection: %w", err)
+	}
+	// Соединение с активным LISTEN в пул не возвращаем
+	defer func() {
+		_ = conn.Conn().Close(context.Background())
+		conn.Release()
+	}()
+
+	if _, err := conn.Exec(ctx, "LISTEN "+pgx.Identifier{channel}.Sanitize()); err != nil {
+		return err
+	}
+	notify()
+	for {
+		if _, err := conn.Conn().WaitForNotification(ctx); err != nil {
+			return err
+		}
+		notify()
+	}
+}
@@ -0,0 +1,47 @@
+package pg
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/mrevds/pizza-app/card-service/client"
+	"github.com/mrevds/pizza-app/card-service/internal/scheduler"
+)
+
+// advisoryLocker - выбор лидера через advisory lock Postgres. Блокировка сессионная:
+// держится на выделенном соединении из пула и снимается сама, если реплика упала.
+type advisoryLocker struct {
+	db *client.DB
+}
+
+func NewAdvisoryLocker(db *client.DB) scheduler.Locker {
+	return &advisoryLocker{db: db}
+}
+
+func (l *advisoryLocker) TryLock(ctx context.Context, name string) (func(), bool, error) {
+	conn, err := l.db.Pool.Acquire(ctx)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to acquire connection: %w", err)
+	}
+	key := "card-service:" + name
+	var acquired bool
+	if err := conn.QueryRow(ctx, `SELECT pg_try_advisory_lock(hashtext($1))`, key).Scan(&acquired); err != nil {
+		conn.Release()
+		return nil, false, err
+	}
+	if !acquired {
+		conn.Release()
+		return nil, false, nil
+	}
+	unlock := func() {
+		// Контекст задачи может быть уже отменен, блокировку снимаем в любом случае
+		if _, err := conn.Exec(context.Background(), `SELECT pg_advisory_unlock(hashtext($1))`, key); err != nil {
+			log.Printf("failed to release advisory lock %s: %v", key, err)
+			// Соединение с висящей блокировкой в пул не возвращаем
+			_ = conn.Conn().Close(context.Background())
+		}
+		conn.Release()
+	}
+	return unlock, true, nil
+}
@@ -1 +1,1356 @@
 package pg
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgx/v4"
+	"github.com/mrevds/pizza-app/card-service/client"
+	"github.com/mrevds/pizza-app/card-service/internal/entity"
+	"github.com/mrevds/pizza-app/card-service/internal/repository"
+)
+
+// querier - общий интерфейс для пула соединений и транзакции
+type querier interface {
+	Exec(ctx context.Context, sql string, args ...interface{}) (pgconn.CommandTag, error)
+	Query(ctx context.Context, sql string, args ...interface{}) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...interface{}) pgx.Row
+}
+
+type cardRepo struct {
+	db *client.DB
+	q  querier
+	tx pgx.Tx
+}
+
+func NewCardRepo(db *client.DB) repository.CardRepository {
+	return &cardRepo{db: db}
+}
+
+// conn возвращает текущую транзакцию, если она открыта, иначе пул
+func (r *cardRepo) conn() querier {
+	if r.q != nil {
+		return r.q
+	}
+	return r.db.Pool
+}
+
+func (r *cardRepo) RunInTx(ctx context.Context, fn func(repo repository.CardRepository) error) error {
+	// Уже внутри транзакции - переиспользуем её
+	if r.tx != nil {
+		return fn(r)
+	}
+
+	// Уровень изоляции по умолчанию (READ COMMITTED): сервис сериализует операции
+	// блокировкой строк карт, см. cardService.Transfer
+	tx, err := r.db.Pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	if err := fn(&cardRepo{db: r.db, q: tx, tx: tx}); err != nil {
+		return err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// cardColumns включает сумму активных холдов: истекшие по времени холды не учитываются,
+// даже если их статус еще не сменился на expired
+const cardColumns = `id, user_id, card_number_masked, card_holder_name, expiry_date, card_type,
+	balance_minor, currency, status, is_active, is_blocked, block_reason, created_at, updated_at,
+	COALESCE((SELECT SUM(h.amount_minor) FROM payment_authorizations h
+	          WHERE h.card_id = cards.id AND h.status = 'active' AND h.expires_at > now()), 0)::BIGINT`
+
+func scanCard(row pgx.Row) (*entity.Card, error) {
+	var c entity.Card
+	err := row.Scan(&c.ID, &c.UserID, &c.CardNumberMasked, &c.CardHolderName, &c.ExpiryDate, &c.CardType,
+		&c.Balance, &c.Currency, &c.Status, &c.IsActive, &c.IsBlocked, &c.BlockReason, &c.CreatedAt, &c.UpdatedAt, &c.Held)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, repository.ErrNotFound
+		}
+		return nil, err
+	}
+	return &c, nil
+}
+
+func (r *cardRepo) ResolveUser(ctx context.Context, externalID string) (int64, error) {
+	var id int64
+	err := r.conn().QueryRow(ctx, `SELECT id FROM users WHERE external_id = $1`, externalID).Scan(&id)
+	if err == nil || !errors.Is(err, pgx.ErrNoRows) {
+		return id, err
+	}
+	// Пользователя могли добавить параллельно: тогда INSERT ничего не вернет, читаем еще раз
+	err = r.conn().QueryRow(ctx, `
+	  INSERT INTO users (external_id) VALUES ($1)
+	  ON CONFLICT (external_id) DO NOTHING
+	  RETURNING id`, externalID).Scan(&id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		err = r.conn().QueryRow(ctx, `SELECT id FROM users WHERE external_id = $1`, externalID).Scan(&id)
+	}
+	return id, err
+}
+
+func (r *cardRepo) LockUser(ctx context.Context, userID int64) error {
+	var id int64
+	err := r.conn().QueryRow(ctx, `SELECT id FROM users WHERE id = $1 FOR UPDATE`, userID).Scan(&id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return repository.ErrNotFound
+	}
+	return err
+}
+
+func (r *cardRepo) GetDefaultCardID(ctx context.Context, userID int64) (int64, error) {
+	var cardID int64
+	err := r.conn().QueryRow(ctx, `
+	  SELECT COALESCE(default_card_id, 0) FROM users WHERE id = $1`, userID).Scan(&cardID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return 0, nil
+	}
+	return cardID, err
+}
+
+func (r *cardRepo) SetDefaultCard(ctx context.Context, userID, cardID int64) error {
+	tag, err := r.conn().Exec(ctx, `UPDATE users SET default_card_id = $1 WHERE id = $2`, cardID, userID)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return repository.ErrNotFound
+	}
+	return nil
+}
+
+func (r *cardRepo) CreateCard(ctx context.Context, c *entity.Card) error {
+	var pan entity.EncryptedPAN
+	if c.PAN != nil {
+		pan = *c.PAN
+	}
+	err := r.conn().QueryRow(ctx, `
+  INSERT INTO cards (user_id, card_number_masked, card_holder_name, expiry_date, card_type, balance_minor, currency, status,
+                     is_active, is_blocked, pan_ciphertext, pan_wrapped_key, pan_key_version, pan_fingerprint)
+  VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, NULLIF($13, 0), NULLIF($14, ''))
+  RETURNING id, created_at, updated_at
+ `, c.UserID, c.CardNumberMasked, c.CardHolderName, c.ExpiryDate, c.CardType, c.Balance, c.Currency, c.Status, c.IsActive, c.IsBlocked,
+		pan.Ciphertext, pan.WrappedKey, pan.KeyVersion, pan.Fingerprint).
+		Scan(&c.ID, &c.CreatedAt, &c.UpdatedAt)
+	if isUniqueViolation(err) {
+		return repository.ErrAlreadyExists
+	}
+	return err
+}
+
+func (r *cardRepo) GetCard(ctx context.Context, cardID int64) (*entity.Card, error) {
+	return scanCard(r.conn().QueryRow(ctx, `
+	  SELECT `+cardColumns+` FROM cards WHERE id = $1 AND deleted_at IS NULL`, cardID))
+}
+
+func (r *cardRepo) GetCardForUpdate(ctx context.Context, cardID int64) (*entity.Card, error) {
+	return scanCard(r.conn().QueryRow(ctx, `
+	  SELECT `+cardColumns+` FROM cards WHERE id = $1 AND deleted_at IS NULL FOR UPDATE`, cardID))
+}
+
+func (r *cardRepo) GetUserCards(ctx context.Context, userID int64) ([]*entity.Card, error) {
+	rows, err := r.conn().Query(ctx, `
+	  SELECT `+cardColumns+` FROM cards WHERE user_id = $1 AND deleted_at IS NULL ORDER BY id`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	cards := make([]*entity.Card, 0)
+	for rows.Next() {
+		c, err := scanCard(rows)
+		if err != nil {
+			return nil, err
+		}
+		cards = append(cards, c)
+	}
+	return cards, rows.Err()
+}
+
+func (r *cardRepo) UpdateCard(ctx context.Context, c *entity.Card) error {
+	return r.conn().QueryRow(ctx, `
+  UPDATE cards SET card_holder_name = $1, expiry_date = $2, updated_at = now()
+  WHERE id = $3 AND deleted_at IS NULL
+  RETURNING updated_at
+ `, c.CardHolderName, c.ExpiryDate, c.ID).Scan(&c.UpdatedAt)
+}
+
+func (r *cardRepo) DeleteCard(ctx context.Context, cardID int64) error {
+	tag, err := r.conn().Exec(ctx, `
+        UPDATE cards SET deleted_at = now(), status = 'closed', is_active = false WHERE id = $1 AND deleted_at IS NULL
+    `, cardID)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return repository.ErrNotFound
+	}
+	return nil
+}
+
+func (r *cardRepo) SetCardOwner(ctx context.Context, cardID, userID int64) error {
+	tag, err := r.conn().Exec(ctx, `
+        UPDATE cards SET user_id = $1, updated_at = now() WHERE id = $2 AND deleted_at IS NULL
+    `, userID, cardID)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return repository.ErrNotFound
+	}
+	return nil
+}
+
+func (r *cardRepo) UpdateCardStatus(ctx context.Context, cardID int64, status, blockReason string) error {
+	tag, err := r.conn().Exec(ctx, `
+        UPDATE cards SET status = $1, is_active = $1 <> 'closed', is_blocked = $1 IN ('blocked_by_user', 'blocked_by_fraud'),
+                         block_reason = $2, updated_at = now()
+        WHERE id = $3 AND deleted_at IS NULL
+    `, status, blockReason, cardID)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return repository.ErrNotFound
+	}
+	return nil
+}
+
+func (r *cardRepo) CreateStatusChange(ctx context.Context, c *entity.CardStatusChange) error {
+	return r.conn().QueryRow(ctx, `
+  INSERT INTO card_status_history (card_id, from_status, to_status, actor, actor_id, actor_name, reason)
+  VALUES ($1, $2, $3, $4, NULLIF($5, 0), $6, $7)
+  RETURNING id, created_at
+ `, c.CardID, c.FromStatus, c.ToStatus, c.Actor, c.ActorID, c.ActorName, c.Reason).Scan(&c.ID, &c.CreatedAt)
+}
+
+func (r *cardRepo) GetCardStatusHistory(ctx context.Context, cardID int64) ([]*entity.CardStatusChange, error) {
+	rows, err := r.conn().Query(ctx, `
+	  SELECT id, card_id, from_status, to_status, actor, COALESCE(actor_id, 0), actor_name, reason, created_at
+	  FROM card_status_history WHERE card_id = $1
+	  ORDER BY id`, cardID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var changes []*entity.CardStatusChange
+	for rows.Next() {
+		var c entity.CardStatusChange
+		if err := rows.Scan(&c.ID, &c.CardID, &c.FromStatus, &c.ToStatus, &c.Actor, &c.ActorID, &c.ActorName, &c.Reason, &c.CreatedAt); err != nil {
+			return nil, err
+		}
+		changes = append(changes, &c)
+	}
+	return changes, rows.Err()
+}
+
+func (r *cardRepo) CreateOutboxMessage(ctx context.Context, m *entity.OutboxMessage) error {
+	return r.conn().QueryRow(ctx, `
+	  INSERT INTO outbox (card_id, event_type, schema_version, payload)
+	  VALUES ($1, $2, $3, $4)
+	  RETURNING id, created_at`,
+		m.CardID, m.EventType, m.SchemaVersion, m.Payload,
+	).Scan(&m.ID, &m.CreatedAt)
+}
+
+func (r *cardRepo) ListPendingOutbox(ctx context.Context, afterID int64, limit int) ([]*entity.OutboxMessage, error) {
+	rows, err := r.conn().Query(ctx, `
+	  SELECT id, card_id, event_type, schema_version, payload, attempts, last_error, created_at
+	  FROM outbox
+	  WHERE published_at IS NULL AND id > $1
+	  ORDER BY id
+	  LIMIT $2`, afterID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var messages []*entity.OutboxMessage
+	for rows.Next() {
+		var m entity.OutboxMessage
+		if err := rows.Scan(&m.ID, &m.CardID, &m.EventType, &m.SchemaVersion, &m.Payload,
+			&m.Attempts, &m.LastError, &m.CreatedAt); err != nil {
+			return nil, err
+		}
+		messages = append(messages, &m)
+	}
+	return messages, rows.Err()
+}
+
+func (r *cardRepo) MarkOutboxPublished(ctx context.Context, ids []int64) error {
+	_, err := r.conn().Exec(ctx, `UPDATE outbox SET published_at = now() WHERE id = ANY($1)`, ids)
+	return err
+}
+
+func (r *cardRepo) MarkOutboxFailed(ctx context.Context, id int64, lastError string) error {
+	_, err := r.conn().Exec(ctx, `
+	  UPDATE outbox SET attempts = attempts + 1, last_error = $2 WHERE id = $1`, id, lastError)
+	return err
+}
+
+func (r *cardRepo) DeletePublishedOutboxBefore(ctx context.Context, before time.Time) (int64, error) {
+	tag, err := r.conn().Exec(ctx, `DELETE FROM outbox WHERE published_at < $1`, before)
+	if err != nil {
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
+
+func (r *cardRepo) CreateCardEvent(ctx context.Context, e *entity.CardEvent) error {
+	return r.conn().QueryRow(ctx, `
+	  INSERT INTO card_events (user_id, card_id, event_type, payload)
+	  VALUES ($1, $2, $3, $4)
+	  RETURNING id, created_at`,
+		e.UserID, e.CardID, e.Type, e.Payload,
+	).Scan(&e.ID, &e.CreatedAt)
+}
+
+func (r *cardRepo) ListCardEvents(ctx context.Context, afterID, userID int64, limit int) ([]*entity.CardEvent, error) {
+	rows, err := r.conn().Query(ctx, `
+	  SELECT id, user_id, card_id, event_type, payload, created_at
+	  FROM card_events
+	  WHERE id > $1 AND ($2 = 0 OR user_id = $2)
+	  ORDER BY id
+	  LIMIT $3`, afterID, userID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var events []*entity.CardEvent
+	for rows.Next() {
+		var e entity.CardEvent
+		if err := rows.Scan(&e.ID, &e.UserID, &e.CardID, &e.Type, &e.Payload, &e.CreatedAt); err != nil {
+			return nil, err
+		}
+		events = append(events, &e)
+	}
+	return events, rows.Err()
+}
+
+func (r *cardRepo) LastCardEventID(ctx context.Context) (int64, error) {
+	var id int64
+	err := r.conn().QueryRow(ctx, `SELECT COALESCE(max(id), 0) FROM card_events`).Scan(&id)
+	return id, err
+}
+
+func (r *cardRepo) DeleteCardEventsBefore(ctx context.Context, before time.Time) (int64, error) {
+	tag, err := r.conn().Exec(ctx, `DELETE FROM card_events WHERE created_at < $1`, before)
+	if err != nil {
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
+
+func (r *cardRepo) ListCardsToExpire(ctx context.Context, now time.Time, limit int) ([]int64, error) {
+	rows, err := r.conn().Query(ctx, `
+	  SELECT id FROM cards
+	  WHERE deleted_at IS NULL AND status IN ('active', 'blocked_by_user', 'blocked_by_fraud')
+	    AND card_expires_at(expiry_date) <= $1
+	  ORDER BY id
+	  LIMIT $2`, now, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+func (r *cardRepo) ListCardsExpiringBefore(ctx context.Context, now, before time.Time, limit int) ([]*entity.Card, error) {
+	rows, err := r.conn().Query(ctx, `
+	  SELECT `+cardColumns+` FROM cards
+	  WHERE deleted_at IS NULL AND status IN ('active', 'blocked_by_user', 'blocked_by_fraud')
+	    AND card_expires_at(expiry_date) > $1 AND card_expires_at(expiry_date) <= $2
+	    AND expiry_notified_for <> expiry_date AND user_id <> 0
+	  ORDER BY id
+	  LIMIT $3`, now, before, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var cards []*entity.Card
+	for rows.Next() {
+		c, err := scanCard(rows)
+		if err != nil {
+			return nil, err
+		}
+		cards = append(cards, c)
+	}
+	return cards, rows.Err()
+}
+
+func (r *cardRepo) MarkExpiryNotified(ctx context.Context, cardID int64, expiryDate string) error {
+	_, err := r.conn().Exec(ctx, `
+        UPDATE cards SET expiry_notified_for = $1 WHERE id = $2
+    `, expiryDate, cardID)
+	return err
+}
+
+func (r *cardRepo) UpdateBalance(ctx context.Context, cardID int64, balance int64) error {
+	_, err := r.conn().Exec(ctx, `
+        UPDATE cards SET balance_minor = $1, updated_at = now() WHERE id = $2
+    `, balance, cardID)
+	return err
+}
+
+func (r *cardRepo) ListCardIDs(ctx context.Context, afterID int64, limit int) ([]int64, error) {
+	rows, err := r.conn().Query(ctx, `
+	  SELECT id FROM cards WHERE deleted_at IS NULL AND id > $1 ORDER BY id LIMIT $2`, afterID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ids := make([]int64, 0, limit)
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+func (r *cardRepo) ListCardKeysToRotate(ctx context.Context, activeVersion int, afterID int64, limit int) ([]*entity.CardKey, error) {
+	rows, err := r.conn().Query(ctx, `
+	  SELECT id, pan_wrapped_key, pan_key_version FROM cards
+	  WHERE pan_wrapped_key IS NOT NULL AND pan_key_version <> $1 AND id > $2
+	  ORDER BY id
+	  LIMIT $3`, activeVersion, afterID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	keys := make([]*entity.CardKey, 0, limit)
+	for rows.Next() {
+		var k entity.CardKey
+		if err := rows.Scan(&k.CardID, &k.WrappedKey, &k.KeyVersion); err != nil {
+			return nil, err
+		}
+		keys = append(keys, &k)
+	}
+	return keys, rows.Err()
+}
+
+// UpdateCardKey сохраняет переобернутый ключ. previousVersion защищает от гонки
+// двух одновременных ротаций: ключ обновится только если его не успели поменять.
+func (r *cardRepo) UpdateCardKey(ctx context.Context, key *entity.CardKey, previousVersion int) error {
+	tag, err := r.conn().Exec(ctx, `
+        UPDATE cards SET pan_wrapped_key = $1, pan_key_version = $2 WHERE id = $3 AND pan_key_version = $4
+    `, key.WrappedKey, key.KeyVersion, key.CardID, previousVersion)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return repository.ErrNotFound
+	}
+	return nil
+}
+
+func (r *cardRepo) GetOrCreateAccount(ctx context.Context, kind string, cardID int64, currency string) (*entity.LedgerAccount, error) {
+	// Счет создается при первой операции. ON CONFLICT DO NOTHING + повторный SELECT
+	// безопасны при гонке двух транзакций за один и тот же счет.
+	if _, err := r.conn().Exec(ctx, `
+        INSERT INTO ledger_accounts (kind, card_id, currency) VALUES ($1, NULLIF($2, 0), $3)
+        ON CONFLICT DO NOTHING
+    `, kind, cardID, currency); err != nil {
+		return nil, err
+	}
+
+	var a entity.LedgerAccount
+	err := r.conn().QueryRow(ctx, `
+	  SELECT id, kind, COALESCE(card_id, 0), currency, balance_minor, created_at FROM ledger_accounts
+	  WHERE kind = $1 AND COALESCE(card_id, 0) = $2 AND currency = $3`, kind, cardID, currency).
+		Scan(&a.ID, &a.Kind, &a.CardID, &a.Currency, &a.Balance, &a.CreatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, repository.ErrNotFound
+		}
+		return nil, err
+	}
+	return &a, nil
+}
+
+func (r *cardRepo) CreateJournalEntry(ctx context.Context, e *entity.JournalEntry) error {
+	err := r.conn().QueryRow(ctx, `
+  INSERT INTO ledger_entries (operation, description) VALUES ($1, $2)
+  RETURNING id, created_at
+ `, e.Operation, e.Description).Scan(&e.ID, &e.CreatedAt)
+	if err != nil {
+		return err
+	}
+	for _, p := range e.Postings {
+		p.EntryID = e.ID
+		err := r.conn().QueryRow(ctx, `
+  INSERT INTO ledger_postings (entry_id, account_id, direction, amount_minor, currency)
+  VALUES ($1, $2, $3, $4, $5)
+  RETURNING id
+ `, p.EntryID, p.AccountID, p.Direction, p.Amount, p.Currency).Scan(&p.ID)
+		if err != nil {
+			return err
+		}
+		delta := p.Amount
+		if p.Direction == entity.PostingDebit {
+			delta = -delta
+		}
+		// Строку счета карты уже держит блокировка карты в той же транзакции
+		if _, err := r.conn().Exec(ctx, `
+  UPDATE ledger_accounts SET balance_minor = balance_minor + $1 WHERE id = $2 AND kind = 'card'
+ `, delta, p.AccountID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *cardRepo) GetAccountBalance(ctx context.Context, accountID int64) (int64, error) {
+	var balance int64
+	err := r.conn().QueryRow(ctx, `SELECT balance_minor FROM ledger_accounts WHERE id = $1`, accountID).Scan(&balance)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return 0, repository.ErrNotFound
+	}
+	return balance, err
+}
+
+func (r *cardRepo) GetCardLedgerBalance(ctx context.Context, cardID int64) (int64, error) {
+	var balance int64
+	err := r.conn().QueryRow(ctx, `
+	  SELECT COALESCE(SUM(CASE WHEN p.direction = 'credit' THEN p.amount_minor ELSE -p.amount_minor END), 0)::BIGINT
+	  FROM ledger_postings p
+	  JOIN ledger_accounts a ON a.id = p.account_id
+	  WHERE a.card_id = $1`, cardID).Scan(&balance)
+	return balance, err
+}
+
+func (r *cardRepo) GetCardAccountBalance(ctx context.Context, cardID int64) (int64, error) {
+	var balance int64
+	err := r.conn().QueryRow(ctx, `
+	  SELECT COALESCE(SUM(balance_minor), 0)::BIGINT FROM ledger_accounts WHERE card_id = $1`, cardID).Scan(&balance)
+	return balance, err
+}
+
+func (r *cardRepo) SetCardAccountBalance(ctx context.Context, cardID, balance int64) error {
+	_, err := r.conn().Exec(ctx, `UPDATE ledger_accounts SET balance_minor = $1 WHERE card_id = $2`, balance, cardID)
+	return err
+}
+
+func (r *cardRepo) GetIdempotencyRecord(ctx context.Context, userID int64, key string) (*entity.IdempotencyRecord, error) {
+	var rec entity.IdempotencyRecord
+	err := r.conn().QueryRow(ctx, `
+	  SELECT user_id, key, operation, fingerprint, response, created_at, expires_at FROM idempotency_keys
+	  WHERE user_id = $1 AND key = $2 AND expires_at > now()`, userID, key).
+		Scan(&rec.UserID, &rec.Key, &rec.Operation, &rec.Fingerprint, &rec.Response, &rec.CreatedAt, &rec.ExpiresAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, repository.ErrNotFound
+		}
+		return nil, err
+	}
+	return &rec, nil
+}
+
+func (r *cardRepo) CreateIdempotencyRecord(ctx context.Context, rec *entity.IdempotencyRecord) error {
+	// Истекшая запись перезаписывается, живая - нет
+	tag, err := r.conn().Exec(ctx, `
+        INSERT INTO idempotency_keys (user_id, key, operation, fingerprint, response, expires_at)
+        VALUES ($1, $2, $3, $4, $5, $6)
+        ON CONFLICT (user_id, key) DO UPDATE SET
+            operation = EXCLUDED.operation,
+            fingerprint = EXCLUDED.fingerprint,
+            response = EXCLUDED.response,
+            created_at = now(),
+            expires_at = EXCLUDED.expires_at
+        WHERE idempotency_keys.expires_at <= now()
+    `, rec.UserID, rec.Key, rec.Operation, rec.Fingerprint, rec.Response, rec.ExpiresAt)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return repository.ErrAlreadyExists
+	}
+	return nil
+}
+
+const authorizationColumns = `id, card_id, user_id, order_id, amount_minor, captured_minor, currency, status,
+	description, category, expires_at, created_at, updated_at`
+
+func scanAuthorization(row pgx.Row) (*entity.Authorization, error) {
+	var a entity.Authorization
+	err := row.Scan(&a.ID, &a.CardID, &a.UserID, &a.OrderID, &a.Amount, &a.Captured, &a.Currency, &a.Status,
+		&a.Description, &a.Category, &a.ExpiresAt, &a.CreatedAt, &a.UpdatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, repository.ErrNotFound
+		}
+		return nil, err
+	}
+	return &a, nil
+}
+
+func (r *cardRepo) CreateAuthorization(ctx context.Context, a *entity.Authorization) error {
+	err := r.conn().QueryRow(ctx, `
+  INSERT INTO payment_authorizations (card_id, user_id, order_id, amount_minor, currency, status, description, category, expires_at)
+  VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+  RETURNING id, created_at, updated_at
+ `, a.CardID, a.UserID, a.OrderID, a.Amount, a.Currency, a.Status, a.Description, a.Category, a.ExpiresAt).
+		Scan(&a.ID, &a.CreatedAt, &a.UpdatedAt)
+	if isUniqueViolation(err) {
+		return repository.ErrAlreadyExists
+	}
+	return err
+}
+
+func (r *cardRepo) GetAuthorizationForUpdate(ctx context.Context, id int64) (*entity.Authorization, error) {
+	return scanAuthorization(r.conn().QueryRow(ctx, `
+	  SELECT `+authorizationColumns+` FROM payment_authorizations WHERE id = $1 FOR UPDATE`, id))
+}
+
+func (r *cardRepo) UpdateAuthorization(ctx context.Context, a *entity.Authorization) error {
+	return r.conn().QueryRow(ctx, `
+  UPDATE payment_authorizations SET status = $1, captured_minor = $2, updated_at = now()
+  WHERE id = $3
+  RETURNING updated_at
+ `, a.Status, a.Captured, a.ID).Scan(&a.UpdatedAt)
+}
+
+func (r *cardRepo) ExpireAuthorizations(ctx context.Context) ([]int64, error) {
+	rows, err := r.conn().Query(ctx, `
+        UPDATE payment_authorizations SET status = 'expired', updated_at = now()
+        WHERE status = 'active' AND expires_at <= now()
+        RETURNING card_id
+    `)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var cardIDs []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		cardIDs = append(cardIDs, id)
+	}
+	return cardIDs, rows.Err()
+}
+
+func (r *cardRepo) GetCardLimits(ctx context.Context, cardID int64) ([]*entity.CardLimit, error) {
+	rows, err := r.conn().Query(ctx, `
+	  SELECT card_id, transaction_type, per_transaction_minor, daily_minor, monthly_minor, set_by, updated_at
+	  FROM card_limits WHERE card_id = $1
+	  ORDER BY transaction_type`, cardID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var limits []*entity.CardLimit
+	for rows.Next() {
+		var l entity.CardLimit
+		if err := rows.Scan(&l.CardID, &l.TransactionType, &l.PerTransaction, &l.Daily, &l.Monthly, &l.SetBy, &l.UpdatedAt); err != nil {
+			return nil, err
+		}
+		limits = append(limits, &l)
+	}
+	return limits, rows.Err()
+}
+
+func (r *cardRepo) ReplaceCardLimits(ctx context.Context, cardID int64, limits []*entity.CardLimit) error {
+	if _, err := r.conn().Exec(ctx, `DELETE FROM card_limits WHERE card_id = $1`, cardID); err != nil {
+		return err
+	}
+	for _, l := range limits {
+		// Сохраненный лимит вставляется снова со своим updated_at
+		var updatedAt *time.Time
+		if !l.UpdatedAt.IsZero() {
+			updatedAt = &l.UpdatedAt
+		}
+		err := r.conn().QueryRow(ctx, `
+  INSERT INTO card_limits (card_id, transaction_type, per_transaction_minor, daily_minor, monthly_minor, set_by, updated_at)
+  VALUES ($1, $2, $3, $4, $5, $6, COALESCE($7, now()))
+  RETURNING updated_at
+ `, cardID, l.TransactionType, l.PerTransaction, l.Daily, l.Monthly, l.SetBy, updatedAt).Scan(&l.UpdatedAt)
+		if err != nil {
+			return err
+		}
+		l.CardID = cardID
+	}
+	return nil
+}
+
+func (r *cardRepo) CreateLimitChange(ctx context.Context, c *entity.CardLimitChange) error {
+	limits, err := json.Marshal(c.Limits)
+	if err != nil {
+		return err
+	}
+	return r.conn().QueryRow(ctx, `
+  INSERT INTO card_limit_changes (card_id, actor, actor_id, actor_name, reason, limits)
+  VALUES ($1, $2, NULLIF($3, 0), $4, $5, $6)
+  RETURNING id, created_at
+ `, c.CardID, c.Actor, c.ActorID, c.ActorName, c.Reason, limits).Scan(&c.ID, &c.CreatedAt)
+}
+
+func (r *cardRepo) GetSpentSince(ctx context.Context, cardID int64, txTypes []string, since time.Time) (int64, error) {
+	var spent int64
+	// Активный холд - будущая оплата: учитывается целиком, независимо от since,
+	// иначе холды и списания в сумме обходят лимит
+	err := r.conn().QueryRow(ctx, `
+	  SELECT (SELECT COALESCE(SUM(amount_minor), 0) FROM transactions
+	          WHERE card_id = $1 AND transaction_type = ANY($2) AND status = 'success' AND created_at > $3)
+	       + (SELECT COALESCE(SUM(amount_minor), 0) FROM payment_authorizations
+	          WHERE card_id = $1 AND 'payment' = ANY($2) AND status = 'active' AND expires_at > now())`,
+		cardID, txTypes, since).Scan(&spent)
+	return spent, err
+}
+
+const scheduledTransferColumns = `id, user_id, COALESCE(from_card_id, 0), to_card_id, amount_minor, currency,
+	description, cron, timezone, status, next_run_at, last_run_at, attempt, created_at, updated_at,
+	last_error, error_count, retry_at`
+
+func scanScheduledTransfer(row pgx.Row) (*entity.ScheduledTransfer, error) {
+	var t entity.ScheduledTransfer
+	err := row.Scan(&t.ID, &t.UserID, &t.FromCardID, &t.ToCardID, &t.Amount, &t.Currency,
+		&t.Description, &t.Cron, &t.Timezone, &t.Status, &t.NextRunAt, &t.LastRunAt, &t.Attempt, &t.CreatedAt, &t.UpdatedAt,
+		&t.LastError, &t.ErrorCount, &t.RetryAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, repository.ErrNotFound
+		}
+		return nil, err
+	}
+	return &t, nil
+}
+
+func (r *cardRepo) queryScheduledTransfers(ctx context.Context, sql string, args ...interface{}) ([]*entity.ScheduledTransfer, error) {
+	rows, err := r.conn().Query(ctx, sql, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var transfers []*entity.ScheduledTransfer
+	for rows.Next() {
+		t, err := scanScheduledTransfer(rows)
+		if err != nil {
+			return nil, err
+		}
+		transfers = append(transfers, t)
+	}
+	return transfers, rows.Err()
+}
+
+func (r *cardRepo) CreateScheduledTransfer(ctx context.Context, t *entity.ScheduledTransfer) error {
+	return r.conn().QueryRow(ctx, `
+  INSERT INTO scheduled_transfers (user_id, from_card_id, to_card_id, amount_minor, currency, description,
+                                   cron, timezone, status, next_run_at)
+  VALUES ($1, NULLIF($2, 0), $3, $4, $5, $6, $7, $8, $9, $10)
+  RETURNING id, created_at, updated_at
+ `, t.UserID, t.FromCardID, t.ToCardID, t.Amount, t.Currency, t.Description,
+		t.Cron, t.Timezone, t.Status, t.NextRunAt).Scan(&t.ID, &t.CreatedAt, &t.UpdatedAt)
+}
+
+func (r *cardRepo) GetScheduledTransfer(ctx context.Context, id int64) (*entity.ScheduledTransfer, error) {
+	return scanScheduledTransfer(r.conn().QueryRow(ctx, `
+	  SELECT `+scheduledTransferColumns+` FROM scheduled_transfers WHERE id = $1`, id))
+}
+
+func (r *cardRepo) GetScheduledTransferForUpdate(ctx context.Context, id int64) (*entity.ScheduledTransfer, error) {
+	return scanScheduledTransfer(r.conn().QueryRow(ctx, `
+	  SELECT `+scheduledTransferColumns+` FROM scheduled_transfers WHERE id = $1 FOR UPDATE`, id))
+}
+
+func (r *cardRepo) ListUserScheduledTransfers(ctx context.Context, userID int64, activeOnly bool) ([]*entity.ScheduledTransfer, error) {
+	return r.queryScheduledTransfers(ctx, `
+	  SELECT `+scheduledTransferColumns+` FROM scheduled_transfers
+	  WHERE user_id = $1 AND (NOT $2 OR status = 'active')
+	  ORDER BY id DESC`, userID, activeOnly)
+}
+
+func (r *cardRepo) ListDueScheduledTransfers(ctx context.Context, now time.Time, afterID int64, limit int) ([]*entity.ScheduledTransfer, error) {
+	return r.queryScheduledTransfers(ctx, `
+	  SELECT `+scheduledTransferColumns+` FROM scheduled_transfers
+	  WHERE status = 'active' AND next_run_at <= $1 AND (retry_at IS NULL OR retry_at <= $1) AND id > $2
+	  ORDER BY id
+	  LIMIT $3`, now, afterID, limit)
+}
+
+func (r *cardRepo) UpdateScheduledTransfer(ctx context.Context, t *entity.ScheduledTransfer) error {
+	return r.conn().QueryRow(ctx, `
+  UPDATE scheduled_transfers SET status = $1, next_run_at = $2, last_run_at = $3, attempt = $4,
+                                 last_error = $5, error_count = $6, retry_at = $7, updated_at = now()
+  WHERE id = $8
+  RETURNING updated_at
+ `, t.Status, t.NextRunAt, t.LastRunAt, t.Attempt, t.LastError, t.ErrorCount, t.RetryAt, t.ID).Scan(&t.UpdatedAt)
+}
+
+func (r *cardRepo) CreateScheduledTransferRun(ctx context.Context, run *entity.ScheduledTransferRun) error {
+	return r.conn().QueryRow(ctx, `
+  INSERT INTO scheduled_transfer_runs (scheduled_transfer_id, scheduled_for, attempt, status, error_code, error, transaction_id)
+  VALUES ($1, $2, $3, $4, $5, $6, NULLIF($7, 0))
+  RETURNING id, created_at
+ `, run.ScheduledTransferID, run.ScheduledFor, run.Attempt, run.Status, run.ErrorCode, run.Error, run.TransactionID).
+		Scan(&run.ID, &run.CreatedAt)
+}
+
+func (r *cardRepo) ListScheduledTransferRuns(ctx context.Context, scheduledTransferID int64, limit int) ([]*entity.ScheduledTransferRun, error) {
+	rows, err := r.conn().Query(ctx, `
+	  SELECT id, scheduled_transfer_id, scheduled_for, attempt, status, error_code, error,
+	         COALESCE(transaction_id, 0), created_at
+	  FROM scheduled_transfer_runs WHERE scheduled_transfer_id = $1
+	  ORDER BY id DESC
+	  LIMIT $2`, scheduledTransferID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var runs []*entity.ScheduledTransferRun
+	for rows.Next() {
+		var run entity.ScheduledTransferRun
+		if err := rows.Scan(&run.ID, &run.ScheduledTransferID, &run.ScheduledFor, &run.Attempt, &run.Status,
+			&run.ErrorCode, &run.Error, &run.TransactionID, &run.CreatedAt); err != nil {
+			return nil, err
+		}
+		runs = append(runs, &run)
+	}
+	return runs, rows.Err()
+}
+
+func (r *cardRepo) CreatePhoneLookup(ctx context.Context, userID int64) error {
+	_, err := r.conn().Exec(ctx, `INSERT INTO phone_lookups (user_id) VALUES ($1)`, userID)
+	return err
+}
+
+func (r *cardRepo) CountPhoneLookupsSince(ctx context.Context, userID int64, since time.Time) (int, error) {
+	var n int
+	err := r.conn().QueryRow(ctx, `
+	  SELECT count(*) FROM phone_lookups WHERE user_id = $1 AND created_at > $2`,
+		userID, since).Scan(&n)
+	return n, err
+}
+
+func (r *cardRepo) CreateP2PTransfer(ctx context.Context, t *entity.P2PTransfer) error {
+	return r.conn().QueryRow(ctx, `
+  INSERT INTO p2p_transfers (token, user_id, from_card_id, recipient_user_id, recipient_card_id, recipient_name,
+                             amount_minor, currency, description, status, expires_at)
+  VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+  RETURNING id, created_at
+ `, t.Token, t.UserID, t.FromCardID, t.RecipientUserID, t.RecipientCardID, t.RecipientName,
+		t.Amount, t.Currency, t.Description, t.Status, t.ExpiresAt).Scan(&t.ID, &t.CreatedAt)
+}
+
+func (r *cardRepo) GetP2PTransferByTokenForUpdate(ctx context.Context, token string) (*entity.P2PTransfer, error) {
+	var t entity.P2PTransfer
+	err := r.conn().QueryRow(ctx, `
+	  SELECT id, token, user_id, from_card_id, recipient_user_id, recipient_card_id, recipient_name,
+	         amount_minor, currency, description, status, COALESCE(transaction_id, 0), expires_at, created_at, completed_at
+	  FROM p2p_transfers WHERE token = $1 FOR UPDATE`, token).Scan(
+		&t.ID, &t.Token, &t.UserID, &t.FromCardID, &t.RecipientUserID, &t.RecipientCardID, &t.RecipientName,
+		&t.Amount, &t.Currency, &t.Description, &t.Status, &t.TransactionID, &t.ExpiresAt, &t.CreatedAt, &t.CompletedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, repository.ErrNotFound
+		}
+		return nil, err
+	}
+	return &t, nil
+}
+
+func (r *cardRepo) UpdateP2PTransfer(ctx context.Context, t *entity.P2PTransfer) error {
+	_, err := r.conn().Exec(ctx, `
+  UPDATE p2p_transfers SET status = $1, transaction_id = NULLIF($2, 0), completed_at = $3
+  WHERE id = $4
+ `, t.Status, t.TransactionID, t.CompletedAt, t.ID)
+	return err
+}
+
+func (r *cardRepo) CreateGiftCard(ctx context.Context, g *entity.GiftCard) error {
+	err := r.conn().QueryRow(ctx, `
+  INSERT INTO gift_cards (card_id, batch_id, code_fingerprint, pin_hash)
+  VALUES ($1, $2, $3, $4)
+  RETURNING created_at
+ `, g.CardID, g.BatchID, g.CodeFingerprint, g.PINHash).Scan(&g.CreatedAt)
+	if isUniqueViolation(err) {
+		return repository.ErrAlreadyExists
+	}
+	return err
+}
+
+const giftCardColumns = `card_id, batch_id, code_fingerprint, pin_hash, failed_pin_attempts,
+	COALESCE(redeemed_by, 0), redeemed_at, created_at`
+
+func scanGiftCard(row pgx.Row) (*entity.GiftCard, error) {
+	var g entity.GiftCard
+	err := row.Scan(&g.CardID, &g.BatchID, &g.CodeFingerprint, &g.PINHash, &g.FailedPINAttempts,
+		&g.RedeemedBy, &g.RedeemedAt, &g.CreatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, repository.ErrNotFound
+		}
+		return nil, err
+	}
+	return &g, nil
+}
+
+func (r *cardRepo) GetGiftCardByCodeForUpdate(ctx context.Context, codeFingerprint string) (*entity.GiftCard, error) {
+	return scanGiftCard(r.conn().QueryRow(ctx, `
+	  SELECT `+giftCardColumns+` FROM gift_cards WHERE code_fingerprint = $1 FOR UPDATE`, codeFingerprint))
+}
+
+func (r *cardRepo) GetGiftCardForUpdate(ctx context.Context, cardID int64) (*entity.GiftCard, error) {
+	return scanGiftCard(r.conn().QueryRow(ctx, `
+	  SELECT `+giftCardColumns+` FROM gift_cards WHERE card_id = $1 FOR UPDATE`, cardID))
+}
+
+func (r *cardRepo) UpdateGiftCard(ctx context.Context, g *entity.GiftCard) error {
+	_, err := r.conn().Exec(ctx, `
+  UPDATE gift_cards SET failed_pin_attempts = $1, redeemed_by = NULLIF($2, 0), redeemed_at = $3
+  WHERE card_id = $4
+ `, g.FailedPINAttempts, g.RedeemedBy, g.RedeemedAt, g.CardID)
+	return err
+}
+
+func (r *cardRepo) CreateRiskDecision(ctx context.Context, d *entity.RiskDecision) error {
+	hits, err := json.Marshal(d.Hits)
+	if err != nil {
+		return fmt.Errorf("failed to marshal risk hits: %w", err)
+	}
+	return r.conn().QueryRow(ctx, `
+	  INSERT INTO risk_decisions (card_id, user_id, transaction_type, amount_minor, currency, order_id,
+	                              decision, score, hits, transaction_id)
+	  VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, NULLIF($10, 0))
+	  RETURNING id, created_at`,
+		d.CardID, d.UserID, d.TransactionType, d.Amount, d.Currency, d.OrderID,
+		d.Decision, d.Score, hits, d.TransactionID,
+	).Scan(&d.ID, &d.CreatedAt)
+}
+
+func (r *cardRepo) CountRiskDecisionsSince(ctx context.Context, cardID int64, since time.Time) (int, error) {
+	var n int
+	err := r.conn().QueryRow(ctx, `
+	  SELECT count(*) FROM risk_decisions WHERE card_id = $1 AND created_at > $2`,
+		cardID, since).Scan(&n)
+	return n, err
+}
+
+func (r *cardRepo) GetDebitStats(ctx context.Context, cardID int64, since time.Time) (entity.DebitStats, error) {
+	var st entity.DebitStats
+	err := r.conn().QueryRow(ctx, `
+	  SELECT count(*), COALESCE(round(avg(amount_minor)), 0)::BIGINT FROM transactions
+	  WHERE card_id = $1 AND transaction_type = ANY($2) AND status = 'success' AND created_at > $3`,
+		cardID, entity.DebitTransactionTypes, since).Scan(&st.Count, &st.Average)
+	return st, err
+}
+
+func (r *cardRepo) CountUserCardsSince(ctx context.Context, userID int64, since time.Time) (int, error) {
+	var n int
+	err := r.conn().QueryRow(ctx, `
+	  SELECT count(*) FROM cards WHERE user_id = $1 AND created_at > $2`,
+		userID, since).Scan(&n)
+	return n, err
+}
+
+const transactionColumns = `id, card_id, transaction_type, amount_minor, balance_before_minor, balance_after_minor,
+	currency, description, status, order_id, category, COALESCE(journal_entry_id, 0), COALESCE(original_transaction_id, 0), created_at`
+
+func scanTransaction(row pgx.Row) (*entity.Transaction, error) {
+	var t entity.Transaction
+	err := row.Scan(&t.ID, &t.CardID, &t.TransactionType, &t.Amount, &t.BalanceBefore, &t.BalanceAfter,
+		&t.Currency, &t.Description, &t.Status, &t.OrderID, &t.Category, &t.JournalEntryID, &t.OriginalTxID, &t.CreatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, repository.ErrNotFound
+		}
+		return nil, err
+	}
+	return &t, nil
+}
+
+func (r *cardRepo) CreateTransaction(ctx context.Context, t *entity.Transaction) error {
+	return r.conn().QueryRow(ctx, `
+  INSERT INTO transactions (card_id, transaction_type, amount_minor, balance_before_minor, balance_after_minor,
+                            currency, description, status, order_id, category, journal_entry_id, original_transaction_id)
+  VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, NULLIF($11, 0), NULLIF($12, 0))
+  RETURNING id, created_at
+ `, t.CardID, t.TransactionType, t.Amount, t.BalanceBefore, t.BalanceAfter, t.Currency, t.Description, t.Status, t.OrderID,
+		t.Category, t.JournalEntryID, t.OriginalTxID).
+		Scan(&t.ID, &t.CreatedAt)
+}
+
+func (r *cardRepo) FailPendingTransactions(ctx context.Context, before time.Time) (int64, error) {
+	tag, err := r.conn().Exec(ctx, `
+        UPDATE transactions SET status = 'failed' WHERE status = 'pending' AND created_at < $1
+    `, before)
+	if err != nil {
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
+
+func (r *cardRepo) GetTransaction(ctx context.Context, transactionID int64) (*entity.Transaction, error) {
+	return scanTransaction(r.conn().QueryRow(ctx, `
+	  SELECT `+transactionColumns+` FROM transactions WHERE id = $1`, transactionID))
+}
+
+func (r *cardRepo) GetTransactions(ctx context.Context, cardID int64, limit, offset int) ([]*entity.Transaction, int, error) {
+	var total int
+	if err := r.conn().QueryRow(ctx, `SELECT count(*) FROM transactions WHERE card_id = $1`, cardID).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	rows, err := r.conn().Query(ctx, `
+	  SELECT `+transactionColumns+` FROM transactions
+	  WHERE card_id = $1
+	  ORDER BY created_at DESC, id DESC
+	  LIMIT $2 OFFSET $3`, cardID, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	txs := make([]*entity.Transaction, 0, limit)
+	for rows.Next() {
+		t, err := scanTransaction(rows)
+		if err != nil {
+			return nil, 0, err
+		}
+		txs = append(txs, t)
+	}
+	return txs, total, rows.Err()
+}
+
+func (r *cardRepo) ListCardTransactions(ctx context.Context, cardID, afterID int64, limit int) ([]*entity.Transaction, error) {
+	rows, err := r.conn().Query(ctx, `
+	  SELECT `+transactionColumns+` FROM transactions
+	  WHERE card_id = $1 AND id > $2
+	  ORDER BY id
+	  LIMIT $3`, cardID, afterID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	txs := make([]*entity.Transaction, 0, limit)
+	for rows.Next() {
+		t, err := scanTransaction(rows)
+		if err != nil {
+			return nil, err
+		}
+		txs = append(txs, t)
+	}
+	return txs, rows.Err()
+}
+
+func (r *cardRepo) ListTransactions(ctx context.Context, f repository.TransactionFilter) ([]*entity.Transaction, error) {
+	where, args := transactionWhere(f, true)
+	order := ` ORDER BY created_at DESC, id DESC`
+	if f.Ascending {
+		order = ` ORDER BY created_at, id`
+	}
+	query := `SELECT ` + transactionColumns + ` FROM transactions WHERE ` + where + order
+	if f.Limit > 0 {
+		args = append(args, f.Limit)
+		query += fmt.Sprintf(" LIMIT $%d", len(args))
+	}
+	if f.Offset > 0 {
+		args = append(args, f.Offset)
+		query += fmt.Sprintf(" OFFSET $%d", len(args))
+	}
+
+	rows, err := r.conn().Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	txs := make([]*entity.Transaction, 0, f.Limit)
+	for rows.Next() {
+		t, err := scanTransaction(rows)
+		if err != nil {
+			return nil, err
+		}
+		txs = append(txs, t)
+	}
+	return txs, rows.Err()
+}
+
+func (r *cardRepo) CountTransactions(ctx context.Context, f repository.TransactionFilter) (int, error) {
+	where, args := transactionWhere(f, false)
+	var total int
+	err := r.conn().QueryRow(ctx, `SELECT count(*) FROM transactions WHERE `+where, args...).Scan(&total)
+	return total, err
+}
+
+func (r *cardRepo) GetBalanceBefore(ctx context.Context, cardID int64, at time.Time) (int64, error) {
+	var balance int64
+	err := r.conn().QueryRow(ctx, `
+		SELECT balance_after_minor FROM transactions
+		WHERE card_id = $1 AND created_at < $2 AND status = $3
+		ORDER BY created_at DESC, id DESC
+		LIMIT 1`, cardID, at, entity.TransactionStatusSuccess).Scan(&balance)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return 0, nil
+	}
+	return balance, err
+}
+
+// transactionWhere собирает условие WHERE по фильтру. withCursor - учитывать After.
+func transactionWhere(f repository.TransactionFilter, withCursor bool) (string, []interface{}) {
+	var conds []string
+	var args []interface{}
+	add := func(cond string, arg interface{}) {
+		args = append(args, arg)
+		conds = append(conds, fmt.Sprintf(cond, len(args)))
+	}
+
+	if f.CardID != 0 {
+		add("card_id = $%d", f.CardID)
+	} else {
+		add("card_id IN (SELECT id FROM cards WHERE user_id = $%d AND deleted_at IS NULL)", f.UserID)
+	}
+	if !f.From.IsZero() {
+		add("created_at >= $%d", f.From)
+	}
+	if !f.To.IsZero() {
+		add("created_at < $%d", f.To)
+	}
+	if len(f.Types) > 0 {
+		add("transaction_type = ANY($%d)", f.Types)
+	}
+	if len(f.Statuses) > 0 {
+		add("status = ANY($%d)", f.Statuses)
+	}
+	if f.Currency != "" {
+		add("currency = $%d", f.Currency)
+	}
+	if f.MinAmount > 0 {
+		add("amount_minor >= $%d", f.MinAmount)
+	}
+	if f.MaxAmount > 0 {
+		add("amount_minor <= $%d", f.MaxAmount)
+	}
+	if f.Text != "" {
+		add("description ILIKE '%%' || $%d || '%%'", escapeLike(f.Text))
+	}
+	if withCursor && f.After != nil {
+		args = append(args, f.After.CreatedAt, f.After.ID)
+		op := "<"
+		if f.Ascending {
+			op = ">"
+		}
+		conds = append(conds, fmt.Sprintf("(created_at, id) %s ($%d, $%d)", op, len(args)-1, len(args)))
+	}
+	return strings.Join(conds, " AND "), args
+}
+
+// escapeLike экранирует спецсимволы LIKE, чтобы строка искалась как есть
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s)
+}
+
+func (r *cardRepo) GetOrderPayments(ctx context.Context, userID int64, orderID string) ([]*entity.Transaction, error) {
+	rows, err := r.conn().Query(ctx, `
+	  SELECT `+transactionColumns+` FROM transactions
+	  WHERE order_id = $1 AND transaction_type = 'payment'
+	    AND card_id IN (SELECT id FROM cards WHERE user_id = $2)
+	  ORDER BY id`, orderID, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var txs []*entity.Transaction
+	for rows.Next() {
+		t, err := scanTransaction(rows)
+		if err != nil {
+			return nil, err
+		}
+		txs = append(txs, t)
+	}
+	return txs, rows.Err()
+}
+
+func (r *cardRepo) ListOrderPayments(ctx context.Context, userID int64, orderIDs []string) ([]*entity.Transaction, error) {
+	rows, err := r.conn().Query(ctx, `
+	  SELECT `+transactionColumns+` FROM transactions
+	  WHERE order_id = ANY($1) AND transaction_type = 'payment'
+	    AND card_id IN (SELECT id FROM cards WHERE user_id = $2)
+	  ORDER BY id`, orderIDs, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var txs []*entity.Transaction
+	for rows.Next() {
+		t, err := scanTransaction(rows)
+		if err != nil {
+			return nil, err
+		}
+		txs = append(txs, t)
+	}
+	return txs, rows.Err()
+}
+
+func (r *cardRepo) GetOrderTransactions(ctx context.Context, userID int64, orderID string) ([]*entity.Transaction, error) {
+	rows, err := r.conn().Query(ctx, `
+	  SELECT `+transactionColumns+` FROM transactions
+	  WHERE order_id = $1
+	    AND transaction_type IN ('payment', 'refund', 'adjustment_credit', 'adjustment_debit')
+	    AND card_id IN (SELECT id FROM cards WHERE user_id = $2)
+	  ORDER BY id`, orderID, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var txs []*entity.Transaction
+	for rows.Next() {
+		t, err := scanTransaction(rows)
+		if err != nil {
+			return nil, err
+		}
+		txs = append(txs, t)
+	}
+	return txs, rows.Err()
+}
+
+func (r *cardRepo) CreateOrderCharge(ctx context.Context, orderID string, userID int64) error {
+	// Параллельная вставка того же заказа ждет коммита первой и не вставляет ничего
+	tag, err := r.conn().Exec(ctx, `
+        INSERT INTO order_charges (user_id, order_id) VALUES ($1, $2)
+        ON CONFLICT (user_id, order_id) DO NOTHING
+    `, userID, orderID)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return repository.ErrAlreadyExists
+	}
+	return nil
+}
+
+func (r *cardRepo) GetRefundedAmount(ctx context.Context, paymentID int64) (int64, error) {
+	var refunded int64
+	err := r.conn().QueryRow(ctx, `
+	  SELECT COALESCE(SUM(amount_minor), 0)::BIGINT FROM transactions
+	  WHERE original_transaction_id = $1 AND transaction_type = 'refund' AND status = 'success'`, paymentID).
+		Scan(&refunded)
+	return refunded, err
+}
+
+func (r *cardRepo) AddDailyTotal(ctx context.Context, t *entity.DailyTotal) error {
+	_, err := r.conn().Exec(ctx, `
+        INSERT INTO card_daily_totals (card_id, day, transaction_type, category, currency, amount_minor, tx_count)
+        VALUES ($1, $2, $3, $4, $5, $6, $7)
+        ON CONFLICT (card_id, day, transaction_type, category) DO UPDATE SET
+            amount_minor = card_daily_totals.amount_minor + EXCLUDED.amount_minor,
+            tx_count = card_daily_totals.tx_count + EXCLUDED.tx_count
+    `, t.CardID, t.Day, t.TransactionType, t.Category, t.Currency, t.Amount, t.Count)
+	return err
+}
+
+func (r *cardRepo) SetDailyBalance(ctx context.Context, b *entity.DailyBalance) error {
+	_, err := r.conn().Exec(ctx, `
+        INSERT INTO card_daily_balances (card_id, day, balance_minor, last_transaction_id)
+        VALUES ($1, $2, $3, $4)
+        ON CONFLICT (card_id, day) DO UPDATE SET
+            balance_minor = EXCLUDED.balance_minor,
+            last_transaction_id = EXCLUDED.last_transaction_id
+        WHERE card_daily_balances.last_transaction_id < EXCLUDED.last_transaction_id
+    `, b.CardID, b.Day, b.Balance, b.LastTransactionID)
+	return err
+}
+
+func (r *cardRepo) ListDailyTotals(ctx context.Context, f repository.DailyTotalFilter) ([]*entity.DailyTotal, error) {
+	cards, arg := "card_id = $1", f.CardID
+	if f.CardID == 0 {
+		cards, arg = "card_id IN (SELECT id FROM cards WHERE user_id = $1 AND deleted_at IS NULL)", f.UserID
+	}
+	rows, err := r.conn().Query(ctx, `
+	  SELECT day, transaction_type, category, currency, SUM(amount_minor)::BIGINT, SUM(tx_count)::INT
+	  FROM card_daily_totals
+	  WHERE `+cards+` AND day >= $2 AND day < $3
+	  GROUP BY day, transaction_type, category, currency
+	  ORDER BY day, transaction_type, category`, arg, f.From, f.To)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var totals []*entity.DailyTotal
+	for rows.Next() {
+		var t entity.DailyTotal
+		if err := rows.Scan(&t.Day, &t.TransactionType, &t.Category, &t.Currency, &t.Amount, &t.Count); err != nil {
+			return nil, err
+		}
+		totals = append(totals, &t)
+	}
+	return totals, rows.Err()
+}
+
+func (r *cardRepo) ListDailyBalances(ctx context.Context, cardID int64, from, to time.Time) ([]*entity.DailyBalance, error) {
+	rows, err := r.conn().Query(ctx, `
+	  SELECT card_id, day, balance_minor, last_transaction_id FROM card_daily_balances
+	  WHERE card_id = $1 AND day >= $2 AND day < $3
+	  ORDER BY day`, cardID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var balances []*entity.DailyBalance
+	for rows.Next() {
+		var b entity.DailyBalance
+		if err := rows.Scan(&b.CardID, &b.Day, &b.Balance, &b.LastTransactionID); err != nil {
+			return nil, err
+		}
+		balances = append(balances, &b)
+	}
+	return balances, rows.Err()
+}
+
+func (r *cardRepo) GetDailyBalanceBefore(ctx context.Context, cardID int64, day time.Time) (int64, error) {
+	var balance int64
+	err := r.conn().QueryRow(ctx, `
+		SELECT balance_minor FROM card_daily_balances
+		WHERE card_id = $1 AND day < $2
+		ORDER BY day DESC
+		LIMIT 1`, cardID, day).Scan(&balance)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return 0, nil
+	}
+	return balance, err
+}
+
+func isUniqueViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "23505"
+}
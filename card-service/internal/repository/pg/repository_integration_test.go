@@ -0,0 +1,144 @@
+package pg
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"github.com/mrevds/pizza-app/card-service/client"
+	"github.com/mrevds/pizza-app/card-service/internal/config"
+	"github.com/mrevds/pizza-app/card-service/internal/entity"
+	"github.com/mrevds/pizza-app/card-service/internal/money"
+	"github.com/mrevds/pizza-app/card-service/internal/service"
+)
+
+// Тесты на настоящем Postgres. Нужна отдельная БД с примененными миграциями,
+// её DSN - в CARD_TEST_DSN (make test-integration). Без CARD_TEST_DSN тесты пропускаются.
+// Каждый запуск создает своего пользователя и карты, данные после теста не удаляются.
+const testDSNEnv = "CARD_TEST_DSN"
+
+func newIntegrationDB(t *testing.T) *client.DB {
+	t.Helper()
+	dsn := os.Getenv(testDSNEnv)
+	if dsn == "" {
+		t.Skipf("%s is not set", testDSNEnv)
+	}
+	pool, err := pgxpool.Connect(context.Background(), dsn)
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	t.Cleanup(pool.Close)
+	return &client.DB{Pool: pool}
+}
+
+// TestTransferOppositeDirections гоняет встречные переводы между картами через
+// cardRepo: блокировки строк карт в порядке id не должны дедлокаться, а деньги -
+// теряться или появляться при READ COMMITTED.
+func TestTransferOppositeDirections(t *testing.T) {
+	db := newIntegrationDB(t)
+	repo := NewCardRepo(db)
+	cfg := &config.Config{Card: config.CardConfig{DefaultCurrency: "RUB", IdempotencyTTL: time.Hour}}
+	s, err := service.NewCardService(repo, cfg, nil, nil, nil, nil, nil)
+	if err != nil {
+		t.Fatalf("NewCardService: %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	userID, err := repo.ResolveUser(ctx, uuid.NewString())
+	if err != nil {
+		t.Fatalf("ResolveUser: %v", err)
+	}
+	const (
+		cards     = 4
+		workers   = 8
+		perWorker = 100
+		initial   = 10_000
+	)
+	ids := make([]int64, 0, cards)
+	for i := 0; i < cards; i++ {
+		card := &entity.Card{
+			UserID:           userID,
+			CardNumberMasked: fmt.Sprintf("**** **** **** %04d", i),
+			CardHolderName:   "TEST",
+			ExpiryDate:       "12/99",
+			CardType:         "visa",
+			Currency:         "RUB",
+			Status:           entity.CardStatusActive,
+			IsActive:         true,
+		}
+		if err := repo.CreateCard(ctx, card); err != nil {
+			t.Fatalf("CreateCard: %v", err)
+		}
+		amount := money.Money{UnitsMinor: initial, Currency: "RUB"}
+		if _, err := s.Deposit(ctx, service.OperationInput{UserID: userID, CardID: card.ID, Amount: amount}); err != nil {
+			t.Fatalf("Deposit: %v", err)
+		}
+		ids = append(ids, card.ID)
+	}
+
+	// Половина воркеров переводит по кругу в одну сторону, половина - в обратную:
+	// каждая пара карт все время получает встречные переводы
+	var wg sync.WaitGroup
+	errs := make(chan error, workers)
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < perWorker; i++ {
+				from, to := ids[(w+i)%cards], ids[(w+i+1)%cards]
+				if w%2 == 1 {
+					from, to = to, from
+				}
+				_, _, err := s.Transfer(ctx, service.TransferInput{
+					UserID:     userID,
+					FromCardID: from,
+					ToCardID:   to,
+					Amount:     money.Money{UnitsMinor: int64(1 + (w*perWorker+i)%3_000), Currency: "RUB"},
+				})
+				if err != nil && !errors.Is(err, service.ErrInsufficientFunds) {
+					errs <- fmt.Errorf("transfer %d -> %d: %w", from, to, err)
+					return
+				}
+			}
+		}(w)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		// Дедлок Postgres обрывает транзакцию с 40P01, зависание - по таймауту ctx
+		t.Fatal(err)
+	}
+
+	var total int64
+	for _, id := range ids {
+		card, err := repo.GetCard(ctx, id)
+		if err != nil {
+			t.Fatalf("GetCard: %v", err)
+		}
+		if card.Balance < 0 {
+			t.Errorf("card %d overdrawn: %d", id, card.Balance)
+		}
+		ledger, err := repo.GetCardLedgerBalance(ctx, id)
+		if err != nil {
+			t.Fatalf("GetCardLedgerBalance: %v", err)
+		}
+		account, err := repo.GetCardAccountBalance(ctx, id)
+		if err != nil {
+			t.Fatalf("GetCardAccountBalance: %v", err)
+		}
+		if ledger != card.Balance || account != card.Balance {
+			t.Errorf("card %d: balance %d, ledger %d, account %d", id, card.Balance, ledger, account)
+		}
+		total += card.Balance
+	}
+	if total != cards*initial {
+		t.Errorf("total money %d, want %d", total, cards*initial)
+	}
+}
@@ -0,0 +1,89 @@
+// Package risk оценивает списание по набору правил перед проведением.
+// Сработавшие правила добавляют баллы, сумма баллов сравнивается с порогами
+// review и deny.
+package risk
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/mrevds/pizza-app/card-service/internal/entity"
+	"github.com/mrevds/pizza-app/card-service/internal/money"
+)
+
+// Решения оценки
+const (
+	DecisionAllow  = "allow"
+	DecisionReview = "review" // списание проводится, решение попадает на разбор
+	DecisionDeny   = "deny"   // списание отклоняется, карта блокируется
+)
+
+// Input - оцениваемое списание
+type Input struct {
+	Card            *entity.Card
+	TransactionType string
+	Amount          int64 // в минимальных единицах валюты карты
+	Now             time.Time
+}
+
+// money - сумма в валюте карты для причин срабатывания
+func (in Input) money(units int64) money.Money {
+	return money.Money{UnitsMinor: units, Currency: in.Card.Currency}
+}
+
+// Stats - история карты и пользователя для правил. Реализуется репозиторием.
+type Stats interface {
+	// CountRiskDecisionsSince - сколько списаний по карте оценено начиная с since
+	CountRiskDecisionsSince(ctx context.Context, cardID int64, since time.Time) (int, error)
+	GetDebitStats(ctx context.Context, cardID int64, since time.Time) (entity.DebitStats, error)
+	// CountUserCardsSince - сколько карт пользователь добавил начиная с since, включая удаленные
+	CountUserCardsSince(ctx context.Context, userID int64, since time.Time) (int, error)
+}
+
+// Rule - правило оценки. Сработавшее правило возвращает баллы больше 0 и причину.
+type Rule interface {
+	Name() string
+	Evaluate(ctx context.Context, stats Stats, in Input) (score int, reason string, err error)
+}
+
+// Result - итог оценки списания
+type Result struct {
+	Decision string
+	Score    int
+	Hits     []entity.RiskHit
+}
+
+type Engine struct {
+	rules       []Rule
+	reviewScore int
+	denyScore   int
+}
+
+// NewEngine создает движок с порогами решений. Нулевой порог не применяется.
+func NewEngine(reviewScore, denyScore int, rules ...Rule) *Engine {
+	return &Engine{rules: rules, reviewScore: reviewScore, denyScore: denyScore}
+}
+
+// Evaluate прогоняет все правила и складывает баллы сработавших
+func (e *Engine) Evaluate(ctx context.Context, stats Stats, in Input) (*Result, error) {
+	res := &Result{Decision: DecisionAllow}
+	for _, rule := range e.rules {
+		score, reason, err := rule.Evaluate(ctx, stats, in)
+		if err != nil {
+			return nil, fmt.Errorf("risk rule %s: %w", rule.Name(), err)
+		}
+		if score <= 0 {
+			continue
+		}
+		res.Score += score
+		res.Hits = append(res.Hits, entity.RiskHit{Rule: rule.Name(), Score: score, Reason: reason})
+	}
+	switch {
+	case e.denyScore > 0 && res.Score >= e.denyScore:
+		res.Decision = DecisionDeny
+	case e.reviewScore > 0 && res.Score >= e.reviewScore:
+		res.Decision = DecisionReview
+	}
+	return res, nil
+}
@@ -0,0 +1,66 @@
+package risk
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/mrevds/pizza-app/card-service/internal/entity"
+)
+
+type fakeStats struct {
+	decisions int
+	debits    entity.DebitStats
+	cards     int
+}
+
+func (f fakeStats) CountRiskDecisionsSince(ctx context.Context, cardID int64, since time.Time) (int, error) {
+	return f.decisions, nil
+}
+
+func (f fakeStats) GetDebitStats(ctx context.Context, cardID int64, since time.Time) (entity.DebitStats, error) {
+	return f.debits, nil
+}
+
+func (f fakeStats) CountUserCardsSince(ctx context.Context, userID int64, since time.Time) (int, error) {
+	return f.cards, nil
+}
+
+func TestEngineDecisions(t *testing.T) {
+	now := time.Now()
+	engine := NewEngine(40, 80,
+		Velocity{Max: 3, Window: time.Minute, Score: 50},
+		AmountOutlier{Factor: 3, MinHistory: 5, Lookback: 24 * time.Hour, Score: 40},
+		NewCard{MaxAge: time.Hour, Amount: 100, Score: 40},
+		ManyCards{Max: 2, Window: time.Hour, Score: 30},
+	)
+	oldCard := &entity.Card{ID: 1, UserID: 1, Currency: "RUB", CreatedAt: now.Add(-48 * time.Hour)}
+	newCard := &entity.Card{ID: 2, UserID: 1, Currency: "RUB", CreatedAt: now.Add(-time.Minute)}
+
+	tests := []struct {
+		name     string
+		card     *entity.Card
+		amount   int64
+		stats    fakeStats
+		decision string
+		score    int
+	}{
+		{"nothing", oldCard, 1_000, fakeStats{decisions: 2}, DecisionAllow, 0},
+		{"many cards", oldCard, 1_000, fakeStats{cards: 3}, DecisionAllow, 30},
+		{"velocity", oldCard, 1_000, fakeStats{decisions: 3}, DecisionReview, 50},
+		{"outlier", oldCard, 3_001, fakeStats{debits: entity.DebitStats{Count: 5, Average: 1_000}}, DecisionReview, 40},
+		{"short history", oldCard, 10_000, fakeStats{debits: entity.DebitStats{Count: 4, Average: 1_000}}, DecisionAllow, 0},
+		{"small amount on new card", newCard, 9_999, fakeStats{}, DecisionAllow, 0},
+		{"new card and many cards", newCard, 10_000, fakeStats{cards: 3}, DecisionReview, 70},
+		{"velocity and new card", newCard, 10_000, fakeStats{decisions: 5}, DecisionDeny, 90},
+	}
+	for _, tt := range tests {
+		res, err := engine.Evaluate(context.Background(), tt.stats, Input{Card: tt.card, Amount: tt.amount, Now: now})
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if res.Decision != tt.decision || res.Score != tt.score {
+			t.Errorf("%s: got %s/%d (%+v), want %s/%d", tt.name, res.Decision, res.Score, res.Hits, tt.decision, tt.score)
+		}
+	}
+}
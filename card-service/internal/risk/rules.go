@@ -0,0 +1,97 @@
+package risk
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/mrevds/pizza-app/card-service/internal/money"
+)
+
+// Velocity - больше Max списаний по карте за Window, считая текущее
+type Velocity struct {
+	Max    int
+	Window time.Duration
+	Score  int
+}
+
+func (r Velocity) Name() string { return "velocity" }
+
+func (r Velocity) Evaluate(ctx context.Context, stats Stats, in Input) (int, string, error) {
+	n, err := stats.CountRiskDecisionsSince(ctx, in.Card.ID, in.Now.Add(-r.Window))
+	if err != nil {
+		return 0, "", err
+	}
+	if n+1 <= r.Max {
+		return 0, "", nil
+	}
+	return r.Score, fmt.Sprintf("%d debits in %s, max %d", n+1, r.Window, r.Max), nil
+}
+
+// AmountOutlier - сумма больше Factor средних успешных списаний карты за Lookback.
+// Карты, у которых меньше MinHistory списаний, правило не оценивает: для них есть NewCard.
+type AmountOutlier struct {
+	Factor     float64
+	MinHistory int
+	Lookback   time.Duration
+	Score      int
+}
+
+func (r AmountOutlier) Name() string { return "amount_outlier" }
+
+func (r AmountOutlier) Evaluate(ctx context.Context, stats Stats, in Input) (int, string, error) {
+	st, err := stats.GetDebitStats(ctx, in.Card.ID, in.Now.Add(-r.Lookback))
+	if err != nil {
+		return 0, "", err
+	}
+	if st.Count < r.MinHistory || st.Average <= 0 || float64(in.Amount) <= r.Factor*float64(st.Average) {
+		return 0, "", nil
+	}
+	return r.Score, fmt.Sprintf("amount %s is %.1fx the average %s of %d debits",
+		in.money(in.Amount), float64(in.Amount)/float64(st.Average), in.money(st.Average), st.Count), nil
+}
+
+// NewCard - карта добавлена меньше MaxAge назад, а списание не меньше Amount
+// (в основных единицах валюты карты)
+type NewCard struct {
+	MaxAge time.Duration
+	Amount float64
+	Score  int
+}
+
+func (r NewCard) Name() string { return "new_card" }
+
+func (r NewCard) Evaluate(ctx context.Context, stats Stats, in Input) (int, string, error) {
+	age := in.Now.Sub(in.Card.CreatedAt)
+	if age >= r.MaxAge {
+		return 0, "", nil
+	}
+	threshold, err := money.ToMinor(r.Amount, in.Card.Currency)
+	if err != nil {
+		return 0, "", err
+	}
+	if in.Amount < threshold {
+		return 0, "", nil
+	}
+	return r.Score, fmt.Sprintf("card added %s ago, amount %s >= %s", age.Round(time.Second), in.money(in.Amount), in.money(threshold)), nil
+}
+
+// ManyCards - пользователь добавил больше Max карт за Window, включая удаленные
+type ManyCards struct {
+	Max    int
+	Window time.Duration
+	Score  int
+}
+
+func (r ManyCards) Name() string { return "many_cards" }
+
+func (r ManyCards) Evaluate(ctx context.Context, stats Stats, in Input) (int, string, error) {
+	n, err := stats.CountUserCardsSince(ctx, in.Card.UserID, in.Now.Add(-r.Window))
+	if err != nil {
+		return 0, "", err
+	}
+	if n <= r.Max {
+		return 0, "", nil
+	}
+	return r.Score, fmt.Sprintf("%d cards added in %s, max %d", n, r.Window, r.Max), nil
+}
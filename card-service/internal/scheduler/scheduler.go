@@ -0,0 +1,97 @@
+package scheduler
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+)
+
+// Job - периодическая задача. Интервал 0 отключает задачу.
+type Job struct {
+	Name     string
+	Interval time.Duration
+	Run      func(ctx context.Context) error
+}
+
+// Locker выбирает лидера для запуска задачи: при нескольких репликах задачу
+// выполняет только та, что взяла блокировку с именем задачи
+type Locker interface {
+	// TryLock не ждет блокировку: acquired = false, если её держит другая реплика
+	TryLock(ctx context.Context, name string) (unlock func(), acquired bool, err error)
+}
+
+// Scheduler запускает задачи по таймеру, каждую в своей горутине
+type Scheduler struct {
+	locker Locker
+	jobs   []Job
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+func New(locker Locker, jobs ...Job) *Scheduler {
+	return &Scheduler{locker: locker, jobs: jobs}
+}
+
+func (s *Scheduler) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	s.cancel = cancel
+	for _, job := range s.jobs {
+		if job.Interval <= 0 {
+			log.Printf("scheduler: job %s is disabled", job.Name)
+			continue
+		}
+		s.wg.Add(1)
+		go s.loop(ctx, job)
+	}
+}
+
+// Stop отменяет контекст задач и ждет их завершения, но не дольше ctx
+func (s *Scheduler) Stop(ctx context.Context) error {
+	if s.cancel == nil {
+		return nil
+	}
+	s.cancel()
+	done := make(chan struct{})
+	go func() {
+		s.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (s *Scheduler) loop(ctx context.Context, job Job) {
+	defer s.wg.Done()
+	ticker := time.NewTicker(job.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.runOnce(ctx, job)
+		}
+	}
+}
+
+// runOnce выполняет задачу, если эта реплика - лидер для неё
+func (s *Scheduler) runOnce(ctx context.Context, job Job) bool {
+	unlock, acquired, err := s.locker.TryLock(ctx, job.Name)
+	if err != nil {
+		log.Printf("scheduler: job %s: failed to take lock: %v", job.Name, err)
+		return false
+	}
+	if !acquired {
+		return false
+	}
+	defer unlock()
+	if err := job.Run(ctx); err != nil && ctx.Err() == nil {
+		log.Printf("scheduler: job %s failed: %v", job.Name, err)
+	}
+	return true
+}
@@ -0,0 +1,83 @@
+package scheduler
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// memLocker - блокировки в памяти, общие для нескольких "реплик"
+type memLocker struct {
+	mu   sync.Mutex
+	held map[string]bool
+}
+
+func (l *memLocker) TryLock(ctx context.Context, name string) (func(), bool, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.held[name] {
+		return nil, false, nil
+	}
+	l.held[name] = true
+	return func() {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		delete(l.held, name)
+	}, true, nil
+}
+
+func TestRunOnceSkipsWithoutLock(t *testing.T) {
+	locker := &memLocker{held: make(map[string]bool)}
+	var runs int32
+	started, release := make(chan struct{}), make(chan struct{})
+	job := Job{Name: "expire_cards", Interval: time.Minute, Run: func(ctx context.Context) error {
+		atomic.AddInt32(&runs, 1)
+		close(started)
+		<-release
+		return nil
+	}}
+	replica1, replica2 := New(locker, job), New(locker, job)
+
+	done := make(chan bool)
+	go func() { done <- replica1.runOnce(context.Background(), job) }()
+	<-started
+	if replica2.runOnce(context.Background(), job) {
+		t.Fatal("second replica ran the job while the first one holds the lock")
+	}
+	close(release)
+	if !<-done {
+		t.Fatal("first replica did not run the job")
+	}
+	if runs != 1 {
+		t.Fatalf("job ran %d times, want 1", runs)
+	}
+}
+
+func TestStartStop(t *testing.T) {
+	var runs int32
+	s := New(&memLocker{held: make(map[string]bool)},
+		Job{Name: "tick", Interval: time.Millisecond, Run: func(ctx context.Context) error {
+			atomic.AddInt32(&runs, 1)
+			return nil
+		}},
+		Job{Name: "disabled", Run: func(ctx context.Context) error {
+			t.Error("disabled job ran")
+			return nil
+		}},
+	)
+	s.Start()
+	deadline := time.Now().Add(time.Second)
+	for atomic.LoadInt32(&runs) < 3 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := s.Stop(ctx); err != nil {
+		t.Fatalf("Stop: %v", err)
+	}
+	if atomic.LoadInt32(&runs) < 3 {
+		t.Fatalf("job ran %d times, want at least 3", runs)
+	}
+}
@@ -0,0 +1,280 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mrevds/pizza-app/card-service/internal/entity"
+	"github.com/mrevds/pizza-app/card-service/internal/repository"
+)
+
+// Аналитика трат и история баланса читают только дневные агрегаты card_daily_totals
+// и card_daily_balances, а не историю карты: запрос за год - не больше 366 строк
+// на тип и категорию. Агрегаты обновляет rollupTransaction в транзакции операции.
+// Дни считаются по UTC.
+
+const (
+	SpendingPeriodWeek  = "week"  // недели с понедельника
+	SpendingPeriodMonth = "month" // календарные месяцы
+
+	maxCategoryLen        = 64
+	maxBalanceHistoryDays = 366
+)
+
+// SpendingQuery - траты за дни From <= day < To по карте CardID или всем картам (0).
+// From = 0 - с начала месяца To, To = 0 - по текущий момент включительно.
+type SpendingQuery struct {
+	UserID int64
+	CardID int64
+	From   time.Time
+	To     time.Time
+	Period string // SpendingPeriodWeek или SpendingPeriodMonth, по умолчанию месяц
+}
+
+// SpendingTotal - сумма и число транзакций по ключу в одной валюте
+type SpendingTotal struct {
+	Key      string
+	Currency string
+	Amount   int64
+	Count    int
+}
+
+// SpendingSummary - итоги за дни From..To включительно. ByType - все успешные транзакции
+// по типам. ByPeriod и ByCategory - траты на заказы: оплаты минус возвраты, Count - число
+// оплат. Ключ ByPeriod - первый день недели или месяца (YYYY-MM-DD), ByCategory - категория,
+// пусто - без категории.
+type SpendingSummary struct {
+	From       time.Time
+	To         time.Time
+	ByType     []*SpendingTotal
+	ByPeriod   []*SpendingTotal
+	ByCategory []*SpendingTotal
+}
+
+// BalanceHistoryQuery - баланс карты на конец дней From <= day < To, не дальше сегодня.
+// From = 0 - 30 дней до To, To = 0 - по сегодня включительно.
+type BalanceHistoryQuery struct {
+	UserID int64
+	CardID int64
+	From   time.Time
+	To     time.Time
+}
+
+func (s *cardService) GetSpendingSummary(ctx context.Context, query SpendingQuery) (*SpendingSummary, error) {
+	now := time.Now()
+	if query.To.IsZero() {
+		query.To = now
+	}
+	if query.From.IsZero() {
+		to := query.To.UTC()
+		query.From = time.Date(to.Year(), to.Month(), 1, 0, 0, 0, 0, time.UTC)
+	}
+	if query.Period == "" {
+		query.Period = SpendingPeriodMonth
+	}
+	verr := &ValidationError{}
+	if query.Period != SpendingPeriodWeek && query.Period != SpendingPeriodMonth {
+		verr.add("period", "must be week or month")
+	}
+	from, to := entity.DayOf(query.From), dayRangeEnd(query.To)
+	if !from.Before(to) {
+		verr.add("to", "must be after from")
+	}
+	if err := verr.errOrNil(); err != nil {
+		return nil, err
+	}
+	if query.CardID != 0 {
+		if _, err := s.getOwnedCard(ctx, s.repo, query.UserID, query.CardID); err != nil {
+			return nil, err
+		}
+	}
+
+	totals, err := s.repo.ListDailyTotals(ctx, repository.DailyTotalFilter{
+		CardID: query.CardID,
+		UserID: query.UserID,
+		From:   from,
+		To:     to,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	byType := newSpendingTotals()
+	byPeriod := newSpendingTotals()
+	byCategory := newSpendingTotals()
+	for _, t := range totals {
+		byType.add(t.TransactionType, t.Currency, t.Amount, t.Count)
+		var amount int64
+		var count int
+		switch t.TransactionType {
+		case entity.TransactionTypePayment:
+			amount, count = t.Amount, t.Count
+		case entity.TransactionTypeRefund:
+			amount = -t.Amount
+		default:
+			continue
+		}
+		byPeriod.add(periodStart(t.Day, query.Period).Format(time.DateOnly), t.Currency, amount, count)
+		byCategory.add(t.Category, t.Currency, amount, count)
+	}
+
+	summary := &SpendingSummary{
+		From:       from,
+		To:         to.AddDate(0, 0, -1),
+		ByType:     byType.list(),
+		ByPeriod:   byPeriod.list(),
+		ByCategory: byCategory.list(),
+	}
+	// Категории - от больших трат к меньшим
+	sort.SliceStable(summary.ByCategory, func(i, j int) bool {
+		return summary.ByCategory[i].Amount > summary.ByCategory[j].Amount
+	})
+	return summary, nil
+}
+
+// BalanceHistory - баланс карты на конец каждого дня периода в валюте карты Currency
+type BalanceHistory struct {
+	CardID   int64
+	Currency string
+	Days     []*entity.DailyBalance
+}
+
+// GetBalanceHistory возвращает баланс на конец каждого дня периода. Дни без операций
+// берут баланс предыдущего дня.
+func (s *cardService) GetBalanceHistory(ctx context.Context, query BalanceHistoryQuery) (*BalanceHistory, error) {
+	tomorrow := entity.DayOf(time.Now()).AddDate(0, 0, 1)
+	to := tomorrow
+	if !query.To.IsZero() {
+		to = dayRangeEnd(query.To)
+		if to.After(tomorrow) {
+			to = tomorrow
+		}
+	}
+	from := to.AddDate(0, 0, -30)
+	if !query.From.IsZero() {
+		from = entity.DayOf(query.From)
+	}
+	verr := &ValidationError{}
+	if !from.Before(to) {
+		verr.add("to", "must be after from")
+	} else if to.Sub(from) > maxBalanceHistoryDays*24*time.Hour {
+		verr.add("from", fmt.Sprintf("period must be at most %d days", maxBalanceHistoryDays))
+	}
+	if err := verr.errOrNil(); err != nil {
+		return nil, err
+	}
+
+	card, err := s.getOwnedCard(ctx, s.repo, query.UserID, query.CardID)
+	if err != nil {
+		return nil, err
+	}
+	balance, err := s.repo.GetDailyBalanceBefore(ctx, card.ID, from)
+	if err != nil {
+		return nil, err
+	}
+	stored, err := s.repo.ListDailyBalances(ctx, card.ID, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	history := &BalanceHistory{
+		CardID:   card.ID,
+		Currency: card.Currency,
+		Days:     make([]*entity.DailyBalance, 0, int(to.Sub(from)/(24*time.Hour))),
+	}
+	next := 0
+	for day := from; day.Before(to); day = day.AddDate(0, 0, 1) {
+		point := &entity.DailyBalance{CardID: card.ID, Day: day, Balance: balance}
+		if next < len(stored) && stored[next].Day.Equal(day) {
+			point = stored[next]
+			balance = point.Balance
+			next++
+		}
+		history.Days = append(history.Days, point)
+	}
+	return history, nil
+}
+
+// rollupTransaction добавляет успешную транзакцию в дневные агрегаты. Вызывать в транзакции
+// её создания под блокировкой карты, после CreateTransaction.
+func rollupTransaction(ctx context.Context, repo repository.CardRepository, txn *entity.Transaction) error {
+	if txn.Status != entity.TransactionStatusSuccess {
+		return nil
+	}
+	day := entity.DayOf(txn.CreatedAt)
+	if err := repo.AddDailyTotal(ctx, &entity.DailyTotal{
+		CardID:          txn.CardID,
+		Day:             day,
+		TransactionType: txn.TransactionType,
+		Category:        txn.Category,
+		Currency:        txn.Currency,
+		Amount:          txn.Amount,
+		Count:           1,
+	}); err != nil {
+		return err
+	}
+	return repo.SetDailyBalance(ctx, &entity.DailyBalance{
+		CardID:            txn.CardID,
+		Day:               day,
+		Balance:           txn.BalanceAfter,
+		LastTransactionID: txn.ID,
+	})
+}
+
+// normalizeCategory приводит категорию заказа к нижнему регистру без пробелов по краям
+func normalizeCategory(category string) (string, error) {
+	category = strings.ToLower(strings.TrimSpace(category))
+	if len(category) > maxCategoryLen {
+		verr := &ValidationError{}
+		verr.add("category", fmt.Sprintf("must be at most %d characters", maxCategoryLen))
+		return "", verr
+	}
+	return category, nil
+}
+
+// dayRangeEnd - первый день после периода, заканчивающегося моментом to (не включая):
+// день, в который попадает to, входит в период, если to не ровно полночь
+func dayRangeEnd(to time.Time) time.Time {
+	day := entity.DayOf(to)
+	if day.Equal(to) {
+		return day
+	}
+	return day.AddDate(0, 0, 1)
+}
+
+// periodStart - понедельник недели или первое число месяца дня day
+func periodStart(day time.Time, period string) time.Time {
+	if period == SpendingPeriodWeek {
+		offset := (int(day.Weekday()) + 6) % 7
+		return day.AddDate(0, 0, -offset)
+	}
+	return time.Date(day.Year(), day.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
+
+// spendingTotals складывает суммы по ключу и валюте в порядке появления ключей
+type spendingTotals struct {
+	index  map[[2]string]*SpendingTotal
+	totals []*SpendingTotal
+}
+
+func newSpendingTotals() *spendingTotals {
+	return &spendingTotals{index: make(map[[2]string]*SpendingTotal)}
+}
+
+func (st *spendingTotals) add(key, currency string, amount int64, count int) {
+	t := st.index[[2]string{key, currency}]
+	if t == nil {
+		t = &SpendingTotal{Key: key, Currency: currency}
+		st.index[[2]string{key, currency}] = t
+		st.totals = append(st.totals, t)
+	}
+	t.Amount += amount
+	t.Count += count
+}
+
+func (st *spendingTotals) list() []*SpendingTotal {
+	return st.totals
+}
@@ -0,0 +1,127 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/mrevds/pizza-app/card-service/internal/entity"
+)
+
+func TestGetSpendingSummary(t *testing.T) {
+	s, repo := newTestService(t)
+	s.cfg.Card.AuthorizationTTL = time.Hour
+	ctx := context.Background()
+	first := addFundedCard(t, s, repo, testUserID, 1_000)
+	second := addFundedCard(t, s, repo, testUserID, 1_000)
+
+	pizza, err := s.ProcessPayment(ctx, PaymentInput{UserID: testUserID, CardID: first.ID, Amount: rub(300), OrderID: "order-1", Category: " Pizza "})
+	if err != nil {
+		t.Fatalf("ProcessPayment: %v", err)
+	}
+	if _, err := s.ProcessPayment(ctx, PaymentInput{UserID: testUserID, CardID: second.ID, Amount: rub(200), OrderID: "order-2", Category: "pizza"}); err != nil {
+		t.Fatalf("ProcessPayment: %v", err)
+	}
+	if _, err := s.ProcessPayment(ctx, PaymentInput{UserID: testUserID, CardID: first.ID, Amount: rub(100), OrderID: "order-3", Category: "drinks"}); err != nil {
+		t.Fatalf("ProcessPayment: %v", err)
+	}
+	// Capture берет категорию холда, возврат - категорию оплаты
+	auth, err := s.AuthorizePayment(ctx, AuthorizeInput{UserID: testUserID, CardID: first.ID, Amount: rub(150), OrderID: "order-4", Category: "drinks"})
+	if err != nil {
+		t.Fatalf("AuthorizePayment: %v", err)
+	}
+	if _, _, err := s.CapturePayment(ctx, CaptureInput{UserID: testUserID, AuthorizationID: auth.ID, OrderID: "order-4"}); err != nil {
+		t.Fatalf("CapturePayment: %v", err)
+	}
+	if _, err := s.RefundPayment(ctx, RefundInput{UserID: testUserID, TransactionID: pizza.ID, Amount: rub(100)}); err != nil {
+		t.Fatalf("RefundPayment: %v", err)
+	}
+
+	summary, err := s.GetSpendingSummary(ctx, SpendingQuery{UserID: testUserID})
+	if err != nil {
+		t.Fatalf("GetSpendingSummary: %v", err)
+	}
+	byType := make(map[string]SpendingTotal)
+	for _, total := range summary.ByType {
+		byType[total.Key] = *total
+	}
+	if byType[entity.TransactionTypeDeposit].Amount != 2_000 || byType[entity.TransactionTypePayment].Amount != 750 ||
+		byType[entity.TransactionTypePayment].Count != 4 || byType[entity.TransactionTypeRefund].Amount != 100 {
+		t.Errorf("by type = %+v, want deposits 2000, 4 payments 750, refunds 100", byType)
+	}
+	if len(summary.ByCategory) != 2 || *summary.ByCategory[0] != (SpendingTotal{Key: "pizza", Currency: "RUB", Amount: 400, Count: 2}) ||
+		*summary.ByCategory[1] != (SpendingTotal{Key: "drinks", Currency: "RUB", Amount: 250, Count: 2}) {
+		t.Errorf("by category = %+v, want pizza 400 then drinks 250", summary.ByCategory)
+	}
+	month := periodStart(entity.DayOf(time.Now()), SpendingPeriodMonth).Format(time.DateOnly)
+	if len(summary.ByPeriod) != 1 || summary.ByPeriod[0].Key != month || summary.ByPeriod[0].Amount != 650 {
+		t.Errorf("by period = %+v, want %s with 650", summary.ByPeriod, month)
+	}
+
+	summary, err = s.GetSpendingSummary(ctx, SpendingQuery{UserID: testUserID, CardID: second.ID, Period: SpendingPeriodWeek})
+	if err != nil {
+		t.Fatalf("GetSpendingSummary for card: %v", err)
+	}
+	if len(summary.ByCategory) != 1 || summary.ByCategory[0].Amount != 200 {
+		t.Errorf("card by category = %+v, want pizza 200", summary.ByCategory)
+	}
+
+	other := addFundedCard(t, s, repo, testUserID+1, 0)
+	if _, err := s.GetSpendingSummary(ctx, SpendingQuery{UserID: testUserID, CardID: other.ID}); !errors.Is(err, ErrCardNotFound) {
+		t.Errorf("foreign card err = %v, want ErrCardNotFound", err)
+	}
+	var verr *ValidationError
+	if _, err := s.GetSpendingSummary(ctx, SpendingQuery{UserID: testUserID, Period: "day"}); !errors.As(err, &verr) {
+		t.Errorf("unknown period err = %v, want ValidationError", err)
+	}
+}
+
+func TestGetBalanceHistory(t *testing.T) {
+	s, repo := newTestService(t)
+	ctx := context.Background()
+	card := addFundedCard(t, s, repo, testUserID, 1_000)
+	if _, err := s.ProcessPayment(ctx, PaymentInput{UserID: testUserID, CardID: card.ID, Amount: rub(300)}); err != nil {
+		t.Fatalf("ProcessPayment: %v", err)
+	}
+	today := entity.DayOf(time.Now())
+	for _, b := range []*entity.DailyBalance{
+		{CardID: card.ID, Day: today.AddDate(0, 0, -10), Balance: 100},
+		{CardID: card.ID, Day: today.AddDate(0, 0, -5), Balance: 200},
+	} {
+		if err := repo.SetDailyBalance(ctx, b); err != nil {
+			t.Fatalf("SetDailyBalance: %v", err)
+		}
+	}
+
+	// Конец периода в будущем обрезается до сегодня, дни без операций берут предыдущий баланс
+	history, err := s.GetBalanceHistory(ctx, BalanceHistoryQuery{
+		UserID: testUserID,
+		CardID: card.ID,
+		From:   today.AddDate(0, 0, -7),
+		To:     today.AddDate(0, 0, 3),
+	})
+	if err != nil {
+		t.Fatalf("GetBalanceHistory: %v", err)
+	}
+	if history.CardID != card.ID || history.Currency != "RUB" {
+		t.Errorf("history card %d %s, want %d RUB", history.CardID, history.Currency, card.ID)
+	}
+	want := []int64{100, 100, 200, 200, 200, 200, 200, 700}
+	if len(history.Days) != len(want) {
+		t.Fatalf("history = %d days, want %d", len(history.Days), len(want))
+	}
+	for i, point := range history.Days {
+		if day := today.AddDate(0, 0, i-7); !point.Day.Equal(day) || point.Balance != want[i] {
+			t.Errorf("day %d = %s %d, want %s %d", i, point.Day.Format(time.DateOnly), point.Balance, day.Format(time.DateOnly), want[i])
+		}
+	}
+
+	var verr *ValidationError
+	if _, err := s.GetBalanceHistory(ctx, BalanceHistoryQuery{UserID: testUserID, CardID: card.ID, From: today.AddDate(-2, 0, 0)}); !errors.As(err, &verr) {
+		t.Errorf("two years err = %v, want ValidationError", err)
+	}
+	if _, err := s.GetBalanceHistory(ctx, BalanceHistoryQuery{UserID: testUserID + 1, CardID: card.ID}); !errors.Is(err, ErrCardNotFound) {
+		t.Errorf("foreign card err = %v, want ErrCardNotFound", err)
+	}
+}
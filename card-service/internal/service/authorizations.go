@@ -0,0 +1,239 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/mrevds/pizza-app/card-service/internal/entity"
+	"github.com/mrevds/pizza-app/card-service/internal/money"
+	"github.com/mrevds/pizza-app/card-service/internal/repository"
+)
+
+type AuthorizeInput struct {
+	UserID         int64
+	CardID         int64
+	Amount         money.Money
+	OrderID        string
+	Category       string // переходит в транзакцию capture
+	Description    string
+	IdempotencyKey string `json:"-"`
+}
+
+// CaptureInput - списание по холду. Amount.UnitsMinor = 0 - списать всю сумму холда.
+// Заказ, по которому уже списывали, списывается снова только с AdditionalCharge.
+type CaptureInput struct {
+	UserID           int64
+	AuthorizationID  int64
+	OrderID          string
+	Amount           money.Money
+	AdditionalCharge bool
+	IdempotencyKey   string `json:"-"`
+}
+
+// AuthorizePayment резервирует сумму под заказ. Баланс карты не меняется,
+// уменьшается только доступный баланс.
+func (s *cardService) AuthorizePayment(ctx context.Context, input AuthorizeInput) (*entity.Authorization, error) {
+	amount, err := normalizeAmount(input.Amount)
+	if err != nil {
+		return nil, err
+	}
+	input.OrderID = strings.TrimSpace(input.OrderID)
+	if input.OrderID == "" {
+		return nil, ErrOrderIDRequired
+	}
+	if input.Category, err = normalizeCategory(input.Category); err != nil {
+		return nil, err
+	}
+
+	input.Amount = amount
+	var auth *entity.Authorization
+	err = s.runIdempotent(ctx, input.UserID, input.IdempotencyKey, idempotentAuthorize, input, &auth, func(repo repository.CardRepository) error {
+		card, err := s.lockOwnedCard(ctx, repo, input.UserID, input.CardID)
+		if err != nil {
+			return err
+		}
+		if err := checkCardOperation(card, amount); err != nil {
+			return err
+		}
+		if card.Available() < amount.UnitsMinor {
+			return ErrInsufficientFunds
+		}
+		// Лимиты проверяются при холде, capture в пределах холда их не перепроверяет.
+		// Активные холды входят в потраченное, поэтому следующие холды и списания их учитывают
+		if err := checkLimits(ctx, repo, card, entity.TransactionTypePayment, amount); err != nil {
+			return err
+		}
+		// Риск тоже оценивается при холде: capture проводит уже одобренную сумму
+		decision, err := s.assessRisk(ctx, repo, card, entity.TransactionTypePayment, amount, input.OrderID)
+		if err != nil {
+			return err
+		}
+
+		auth = &entity.Authorization{
+			CardID:      card.ID,
+			UserID:      card.UserID,
+			OrderID:     input.OrderID,
+			Amount:      amount.UnitsMinor,
+			Currency:    amount.Currency,
+			Status:      entity.AuthorizationStatusActive,
+			Description: input.Description,
+			Category:    input.Category,
+			ExpiresAt:   time.Now().Add(s.cfg.Card.AuthorizationTTL),
+		}
+		if err := repo.CreateAuthorization(ctx, auth); err != nil {
+			if errors.Is(err, repository.ErrAlreadyExists) {
+				return ErrAuthorizationExists
+			}
+			return err
+		}
+		if err := recordRisk(ctx, repo, decision, 0); err != nil {
+			return err
+		}
+		return emitBalanceChanged(ctx, repo, card.ID)
+	})
+	if err != nil {
+		return nil, s.commitRiskDenial(ctx, err)
+	}
+	return auth, nil
+}
+
+// CapturePayment списывает по холду сумму не больше зарезервированной.
+// Остаток холда освобождается, повторный capture невозможен.
+func (s *cardService) CapturePayment(ctx context.Context, input CaptureInput) (*entity.Authorization, *entity.Transaction, error) {
+	if input.Amount.UnitsMinor != 0 {
+		amount, err := normalizeAmount(input.Amount)
+		if err != nil {
+			return nil, nil, err
+		}
+		input.Amount = amount
+	}
+
+	var res captureResult
+	err := s.runIdempotent(ctx, input.UserID, input.IdempotencyKey, idempotentCapture, input, &res, func(repo repository.CardRepository) error {
+		auth, err := lockAuthorization(ctx, repo, input.UserID, input.AuthorizationID, input.OrderID)
+		if err != nil {
+			return err
+		}
+		if !auth.IsActive(time.Now()) {
+			return ErrAuthorizationNotActive
+		}
+		amount := money.Money{UnitsMinor: auth.Amount, Currency: auth.Currency}
+		if input.Amount.UnitsMinor != 0 {
+			if input.Amount.Currency != auth.Currency {
+				return ErrCurrencyMismatch
+			}
+			if input.Amount.UnitsMinor > auth.Amount {
+				return ErrCaptureExceedsAuthorization
+			}
+			amount = input.Amount
+		}
+		if err := claimOrderCharge(ctx, repo, input.UserID, auth.OrderID, input.AdditionalCharge); err != nil {
+			return err
+		}
+
+		// Блокировка карты после холда: порядок holds -> cards не пересекается
+		// с другими операциями, они холды не блокируют
+		card, err := s.lockOwnedCard(ctx, repo, input.UserID, auth.CardID)
+		if err != nil {
+			return err
+		}
+		if err := checkCardOperation(card, amount); err != nil {
+			return err
+		}
+
+		// Холд снимается до проводки, чтобы его сумма снова стала доступна для списания
+		auth.Status = entity.AuthorizationStatusCaptured
+		auth.Captured = amount.UnitsMinor
+		if err := repo.UpdateAuthorization(ctx, auth); err != nil {
+			return err
+		}
+		card.Held -= auth.Amount
+
+		txns, err := applyOperation(ctx, repo, ledgerOperation{
+			Operation:   entity.JournalOperationPayment,
+			Amount:      amount,
+			From:        ledgerSide{Card: card, TxType: entity.TransactionTypePayment},
+			To:          ledgerSide{Kind: entity.AccountKindMerchantSettlement},
+			Description: auth.Description,
+			OrderID:     auth.OrderID,
+			Category:    auth.Category,
+		})
+		if err != nil {
+			return err
+		}
+		res.Authorization, res.Transaction = auth, txns[0]
+		return outboxPaymentCompleted(ctx, repo, card, res.Transaction, auth.ID)
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return res.Authorization, res.Transaction, nil
+}
+
+// VoidAuthorization снимает холд без списания. Повторный void возвращает тот же холд.
+func (s *cardService) VoidAuthorization(ctx context.Context, userID, authorizationID int64, orderID string) (*entity.Authorization, error) {
+	var auth *entity.Authorization
+	err := s.repo.RunInTx(ctx, func(repo repository.CardRepository) error {
+		var err error
+		auth, err = lockAuthorization(ctx, repo, userID, authorizationID, orderID)
+		if err != nil {
+			return err
+		}
+		if auth.Status == entity.AuthorizationStatusVoided {
+			return nil
+		}
+		if !auth.IsActive(time.Now()) {
+			return ErrAuthorizationNotActive
+		}
+		auth.Status = entity.AuthorizationStatusVoided
+		if err := repo.UpdateAuthorization(ctx, auth); err != nil {
+			return err
+		}
+		return emitBalanceChanged(ctx, repo, auth.CardID)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return auth, nil
+}
+
+func (s *cardService) ExpireAuthorizations(ctx context.Context) (int64, error) {
+	var expired int64
+	err := s.repo.RunInTx(ctx, func(repo repository.CardRepository) error {
+		cardIDs, err := repo.ExpireAuthorizations(ctx)
+		if err != nil {
+			return err
+		}
+		expired = int64(len(cardIDs))
+		// Снятый холд увеличивает доступный баланс
+		seen := make(map[int64]bool, len(cardIDs))
+		for _, id := range cardIDs {
+			if seen[id] {
+				continue
+			}
+			seen[id] = true
+			if err := emitBalanceChanged(ctx, repo, id); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return expired, err
+}
+
+// lockAuthorization блокирует холд и проверяет что он принадлежит пользователю и заказу
+func lockAuthorization(ctx context.Context, repo repository.CardRepository, userID, authorizationID int64, orderID string) (*entity.Authorization, error) {
+	auth, err := repo.GetAuthorizationForUpdate(ctx, authorizationID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrAuthorizationNotFound
+		}
+		return nil, err
+	}
+	if auth.UserID != userID || auth.OrderID != strings.TrimSpace(orderID) {
+		return nil, ErrAuthorizationNotFound
+	}
+	return auth, nil
+}
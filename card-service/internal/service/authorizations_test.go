@@ -0,0 +1,73 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestAuthorizeCaptureVoid(t *testing.T) {
+	s, repo := newTestService(t)
+	s.cfg.Card.AuthorizationTTL = time.Hour
+	ctx := context.Background()
+	card := addFundedCard(t, s, repo, testUserID, 1_000)
+
+	auth, err := s.AuthorizePayment(ctx, AuthorizeInput{UserID: testUserID, CardID: card.ID, Amount: rub(700), OrderID: "order-1"})
+	if err != nil {
+		t.Fatalf("AuthorizePayment: %v", err)
+	}
+	got, _ := repo.GetCard(ctx, card.ID)
+	if got.Balance != 1_000 || got.Available() != 300 {
+		t.Fatalf("after authorize: balance %d, available %d, want 1000 and 300", got.Balance, got.Available())
+	}
+
+	// Зарезервированные деньги недоступны для других списаний и холдов
+	if _, err := s.Withdraw(ctx, OperationInput{UserID: testUserID, CardID: card.ID, Amount: rub(500)}); !errors.Is(err, ErrInsufficientFunds) {
+		t.Fatalf("Withdraw over available: got %v, want %v", err, ErrInsufficientFunds)
+	}
+	if _, err := s.AuthorizePayment(ctx, AuthorizeInput{UserID: testUserID, CardID: card.ID, Amount: rub(100), OrderID: "order-1"}); !errors.Is(err, ErrAuthorizationExists) {
+		t.Fatalf("second authorize for order: got %v, want %v", err, ErrAuthorizationExists)
+	}
+
+	if _, _, err := s.CapturePayment(ctx, CaptureInput{UserID: testUserID, AuthorizationID: auth.ID, OrderID: "order-1", Amount: rub(800)}); !errors.Is(err, ErrCaptureExceedsAuthorization) {
+		t.Fatalf("capture over hold: got %v, want %v", err, ErrCaptureExceedsAuthorization)
+	}
+	if _, _, err := s.CapturePayment(ctx, CaptureInput{UserID: testUserID, AuthorizationID: auth.ID, OrderID: "order-2"}); !errors.Is(err, ErrAuthorizationNotFound) {
+		t.Fatalf("capture with other order: got %v, want %v", err, ErrAuthorizationNotFound)
+	}
+
+	// Частичный capture списывает 600, остаток холда освобождается
+	captured, txn, err := s.CapturePayment(ctx, CaptureInput{UserID: testUserID, AuthorizationID: auth.ID, OrderID: "order-1", Amount: rub(600)})
+	if err != nil {
+		t.Fatalf("CapturePayment: %v", err)
+	}
+	if captured.Captured != 600 || txn.Amount != 600 || txn.BalanceAfter != 400 {
+		t.Fatalf("capture: captured %d, txn amount %d, balance after %d", captured.Captured, txn.Amount, txn.BalanceAfter)
+	}
+	got, _ = repo.GetCard(ctx, card.ID)
+	if got.Balance != 400 || got.Held != 0 {
+		t.Fatalf("after capture: balance %d, held %d, want 400 and 0", got.Balance, got.Held)
+	}
+	if _, _, err := s.CapturePayment(ctx, CaptureInput{UserID: testUserID, AuthorizationID: auth.ID, OrderID: "order-1"}); !errors.Is(err, ErrAuthorizationNotActive) {
+		t.Fatalf("second capture: got %v, want %v", err, ErrAuthorizationNotActive)
+	}
+	if _, err := s.VoidAuthorization(ctx, testUserID, auth.ID, "order-1"); !errors.Is(err, ErrAuthorizationNotActive) {
+		t.Fatalf("void captured: got %v, want %v", err, ErrAuthorizationNotActive)
+	}
+
+	// Void возвращает доступный баланс, повторный void успешен
+	auth2, err := s.AuthorizePayment(ctx, AuthorizeInput{UserID: testUserID, CardID: card.ID, Amount: rub(400), OrderID: "order-2"})
+	if err != nil {
+		t.Fatalf("AuthorizePayment: %v", err)
+	}
+	for i := 0; i < 2; i++ {
+		if _, err := s.VoidAuthorization(ctx, testUserID, auth2.ID, "order-2"); err != nil {
+			t.Fatalf("VoidAuthorization #%d: %v", i+1, err)
+		}
+	}
+	got, _ = repo.GetCard(ctx, card.ID)
+	if got.Available() != 400 {
+		t.Fatalf("after void: available %d, want 400", got.Available())
+	}
+}
@@ -0,0 +1,108 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/mrevds/pizza-app/card-service/internal/entity"
+	"github.com/mrevds/pizza-app/card-service/internal/repository"
+)
+
+// maxResumeEvents - сколько пропущенных событий можно догнать при возобновлении подписки.
+// Если пропущено больше, клиенту проще перечитать состояние карт.
+const maxResumeEvents = 1000
+
+// WatchInput - подписка на события карт пользователя. CardID = 0 - все карты,
+// AfterEventID > 0 - сначала отдать события после него (возобновление после обрыва).
+type WatchInput struct {
+	UserID       int64
+	CardID       int64
+	AfterEventID int64
+}
+
+// WatchCardEvents передает события в send, пока не отменен ctx или send не вернет ошибку
+func (s *cardService) WatchCardEvents(ctx context.Context, input WatchInput, send func(*entity.CardEvent) error) error {
+	if input.CardID != 0 {
+		if _, err := s.getOwnedCard(ctx, s.repo, input.UserID, input.CardID); err != nil {
+			return err
+		}
+	}
+	match := func(e *entity.CardEvent) bool {
+		return input.CardID == 0 || e.CardID == input.CardID
+	}
+
+	// Подписка оформляется до чтения пропущенных событий, чтобы между ними ничего не потерять.
+	// Событие может прийти и из базы, и из подписки - повтор отбрасывается.
+	sub := s.events.Subscribe(input.UserID)
+	defer s.events.Unsubscribe(sub)
+
+	resumed := make(map[int64]bool)
+	if input.AfterEventID > 0 {
+		missed, err := s.repo.ListCardEvents(ctx, input.AfterEventID, input.UserID, maxResumeEvents+1)
+		if err != nil {
+			return err
+		}
+		if len(missed) > maxResumeEvents {
+			return ErrResumeTooOld
+		}
+		for _, e := range missed {
+			resumed[e.ID] = true
+			if !match(e) {
+				continue
+			}
+			if err := send(e); err != nil {
+				return err
+			}
+		}
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case e, ok := <-sub.C():
+			if !ok {
+				return sub.Err()
+			}
+			if resumed[e.ID] || e.ID <= input.AfterEventID || !match(e) {
+				continue
+			}
+			if err := send(e); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// PurgeCardEvents удаляет события старше events.retention
+func (s *cardService) PurgeCardEvents(ctx context.Context) (int64, error) {
+	return s.repo.DeleteCardEventsBefore(ctx, time.Now().Add(-s.cfg.Events.Retention))
+}
+
+// emitCardEvent записывает событие карты в текущей транзакции repo
+func emitCardEvent(ctx context.Context, repo repository.CardRepository, card *entity.Card, eventType string, payload interface{}) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	return repo.CreateCardEvent(ctx, &entity.CardEvent{
+		UserID:  card.UserID,
+		CardID:  card.ID,
+		Type:    eventType,
+		Payload: data,
+	})
+}
+
+// emitBalanceChanged перечитывает карту, чтобы событие несло баланс и холды после изменения
+func emitBalanceChanged(ctx context.Context, repo repository.CardRepository, cardID int64) error {
+	card, err := repo.GetCard(ctx, cardID)
+	if err != nil {
+		return err
+	}
+	return emitCardEvent(ctx, repo, card, entity.CardEventBalanceChanged, entity.BalanceEvent{
+		Balance:  card.Balance,
+		Held:     card.Held,
+		Currency: card.Currency,
+	})
+}
@@ -0,0 +1,85 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/mrevds/pizza-app/card-service/internal/entity"
+	"github.com/mrevds/pizza-app/card-service/internal/events"
+)
+
+// idleListener не получает уведомлений: Hub будится вручную через Notify
+type idleListener struct{}
+
+func (idleListener) Listen(ctx context.Context, channel string, notify func()) error {
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+func TestWatchCardEvents(t *testing.T) {
+	s, repo := newTestService(t)
+	ctx := context.Background()
+	card := addFundedCard(t, s, repo, testUserID, 1_000)
+	other := addFundedCard(t, s, repo, testUserID, 500)
+
+	s.events = events.New(repo, idleListener{}, events.Config{})
+	if err := s.events.Start(ctx); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	defer s.events.Stop(ctx)
+
+	// Пополнение карты при создании: transaction_created, затем balance_changed.
+	// Подписка возобновляется после первого, второе должно прийти из истории.
+	first, err := repo.ListCardEvents(ctx, 0, testUserID, 1)
+	if err != nil || len(first) != 1 || first[0].Type != entity.CardEventTransactionCreated {
+		t.Fatalf("first event: %v %v", first, err)
+	}
+
+	watchCtx, cancel := context.WithCancel(ctx)
+	received := make(chan *entity.CardEvent, 10)
+	done := make(chan error, 1)
+	go func() {
+		done <- s.WatchCardEvents(watchCtx, WatchInput{UserID: testUserID, CardID: card.ID, AfterEventID: first[0].ID},
+			func(e *entity.CardEvent) error {
+				received <- e
+				return nil
+			})
+	}()
+
+	next := func() *entity.CardEvent {
+		t.Helper()
+		select {
+		case e := <-received:
+			return e
+		case <-time.After(time.Second):
+			t.Fatal("no event received")
+			return nil
+		}
+	}
+	if e := next(); e.Type != entity.CardEventBalanceChanged || e.CardID != card.ID {
+		t.Fatalf("resumed event: %+v", e)
+	}
+
+	// Живые события: пополнение другой карты отфильтровывается, блокировка приходит
+	if _, err := s.Deposit(ctx, OperationInput{UserID: testUserID, CardID: other.ID, Amount: rub(10)}); err != nil {
+		t.Fatalf("Deposit: %v", err)
+	}
+	if err := s.BlockCard(ctx, testUserID, card.ID, "lost"); err != nil {
+		t.Fatalf("BlockCard: %v", err)
+	}
+	s.events.Notify()
+	e := next()
+	if e.Type != entity.CardEventStatusChanged || string(e.Payload) != `{"status":"blocked_by_user","block_reason":"lost","actor":"user"}` {
+		t.Fatalf("live event: %+v %s", e, e.Payload)
+	}
+
+	cancel()
+	if err := <-done; !errors.Is(err, context.Canceled) {
+		t.Fatalf("WatchCardEvents after cancel: %v", err)
+	}
+	if len(received) != 0 {
+		t.Fatalf("unexpected events of other card: %d", len(received))
+	}
+}
@@ -0,0 +1,144 @@
+package service
+
+import (
+	"context"
+
+	"github.com/mrevds/pizza-app/card-service/internal/entity"
+	"github.com/mrevds/pizza-app/card-service/internal/repository"
+	eventsv1 "github.com/mrevds/pizza-app/card-service/pkg/card-events_v1"
+)
+
+// StatusChangeInput - смена статуса админом или системой. ActorName - имя админа из его
+// токена (CARD_ADMIN_TOKENS), обязательно для admin: по нему видно в истории, кто сменил статус.
+type StatusChangeInput struct {
+	CardID    int64
+	Status    string
+	Actor     string // admin или system
+	ActorName string
+	Reason    string
+}
+
+// changeActor - кто меняет статус или лимиты карты: пользователь по id, админ по имени или система
+type changeActor struct {
+	Kind string // entity.Actor*
+	ID   int64  // id пользователя
+	Name string // имя админа
+}
+
+func (s *cardService) BlockCard(ctx context.Context, userID, cardID int64, reason string) error {
+	return s.repo.RunInTx(ctx, func(repo repository.CardRepository) error {
+		card, err := s.lockOwnedCard(ctx, repo, userID, cardID)
+		if err != nil {
+			return err
+		}
+		if entity.IsBlockedStatus(card.Status) {
+			return ErrCardBlocked
+		}
+		return changeCardStatus(ctx, repo, card, entity.CardStatusBlockedByUser, changeActor{Kind: entity.ActorUser, ID: userID}, reason)
+	})
+}
+
+// UnblockCard снимает блокировку, поставленную самим пользователем.
+// Блокировку админом или системой пользователь снять не может.
+func (s *cardService) UnblockCard(ctx context.Context, userID, cardID int64) error {
+	return s.repo.RunInTx(ctx, func(repo repository.CardRepository) error {
+		card, err := s.lockOwnedCard(ctx, repo, userID, cardID)
+		if err != nil {
+			return err
+		}
+		if !entity.IsBlockedStatus(card.Status) {
+			return ErrCardNotBlocked
+		}
+		if card.Status != entity.CardStatusBlockedByUser {
+			return ErrUnblockForbidden
+		}
+		history, err := repo.GetCardStatusHistory(ctx, card.ID)
+		if err != nil {
+			return err
+		}
+		if n := len(history); n > 0 && history[n-1].Actor != entity.ActorUser {
+			return ErrUnblockForbidden
+		}
+		return changeCardStatus(ctx, repo, card, entity.CardStatusActive, changeActor{Kind: entity.ActorUser, ID: userID}, "")
+	})
+}
+
+func (s *cardService) ChangeCardStatus(ctx context.Context, input StatusChangeInput) (*entity.Card, error) {
+	if !entity.IsCardStatus(input.Status) {
+		return nil, ErrInvalidCardStatus
+	}
+	if input.Actor != entity.ActorAdmin && input.Actor != entity.ActorSystem {
+		return nil, ErrStatusTransition
+	}
+	if input.Actor == entity.ActorAdmin && input.ActorName == "" {
+		verr := &ValidationError{}
+		verr.add("actor_name", "is required for admin")
+		return nil, verr
+	}
+	var card *entity.Card
+	err := s.repo.RunInTx(ctx, func(repo repository.CardRepository) error {
+		var err error
+		card, err = lockCard(ctx, repo, input.CardID)
+		if err != nil {
+			return err
+		}
+		return changeCardStatus(ctx, repo, card, input.Status, changeActor{Kind: input.Actor, Name: input.ActorName}, input.Reason)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return card, nil
+}
+
+func (s *cardService) GetCardStatusHistory(ctx context.Context, userID, cardID int64) ([]*entity.CardStatusChange, error) {
+	if _, err := s.getOwnedCard(ctx, s.repo, userID, cardID); err != nil {
+		return nil, err
+	}
+	return s.repo.GetCardStatusHistory(ctx, cardID)
+}
+
+// changeCardStatus переводит заблокированную (FOR UPDATE) карту в статус to и пишет историю.
+// Причина сохраняется в block_reason только для блокировок.
+func changeCardStatus(ctx context.Context, repo repository.CardRepository, card *entity.Card, to string, actor changeActor, reason string) error {
+	if !entity.CanTransition(card.Status, to) {
+		return ErrStatusTransition
+	}
+	blockReason := ""
+	if entity.IsBlockedStatus(to) {
+		blockReason = reason
+	}
+	if err := repo.UpdateCardStatus(ctx, card.ID, to, blockReason); err != nil {
+		return err
+	}
+	from := card.Status
+	if err := repo.CreateStatusChange(ctx, &entity.CardStatusChange{
+		CardID:     card.ID,
+		FromStatus: from,
+		ToStatus:   to,
+		Actor:      actor.Kind,
+		ActorID:    actor.ID,
+		ActorName:  actor.Name,
+		Reason:     reason,
+	}); err != nil {
+		return err
+	}
+	card.Status = to
+	card.IsActive = to != entity.CardStatusClosed
+	card.IsBlocked = entity.IsBlockedStatus(to)
+	card.BlockReason = blockReason
+	if err := emitCardEvent(ctx, repo, card, entity.CardEventStatusChanged, entity.StatusEvent{
+		Status:      to,
+		BlockReason: blockReason,
+		Actor:       actor.Kind,
+	}); err != nil {
+		return err
+	}
+	return enqueueOutbox(ctx, repo, card, entity.OutboxCardStatusChanged, &eventsv1.DomainEvent{
+		Payload: &eventsv1.DomainEvent_CardStatusChanged{CardStatusChanged: &eventsv1.CardStatusChanged{
+			FromStatus: from,
+			ToStatus:   to,
+			Reason:     reason,
+			Actor:      actor.Kind,
+		}},
+	})
+}
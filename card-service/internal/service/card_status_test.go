@@ -0,0 +1,73 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/mrevds/pizza-app/card-service/internal/entity"
+)
+
+func TestCardBlockUnblock(t *testing.T) {
+	s, repo := newTestService(t)
+	ctx := context.Background()
+	card := addFundedCard(t, s, repo, testUserID, 1_000)
+
+	if err := s.UnblockCard(ctx, testUserID, card.ID); !errors.Is(err, ErrCardNotBlocked) {
+		t.Fatalf("unblock active card: got %v, want %v", err, ErrCardNotBlocked)
+	}
+	if err := s.BlockCard(ctx, testUserID, card.ID, "lost"); err != nil {
+		t.Fatalf("BlockCard: %v", err)
+	}
+	if err := s.BlockCard(ctx, testUserID, card.ID, "lost"); !errors.Is(err, ErrCardBlocked) {
+		t.Fatalf("block blocked card: got %v, want %v", err, ErrCardBlocked)
+	}
+	if _, err := s.Withdraw(ctx, OperationInput{UserID: testUserID, CardID: card.ID, Amount: rub(100)}); !errors.Is(err, ErrCardBlocked) {
+		t.Fatalf("withdraw from blocked card: got %v, want %v", err, ErrCardBlocked)
+	}
+	if err := s.UnblockCard(ctx, testUserID, card.ID); err != nil {
+		t.Fatalf("UnblockCard: %v", err)
+	}
+
+	// Блокировку банком пользователь не снимает, даже если админ поставил blocked_by_user
+	for _, status := range []string{entity.CardStatusBlockedByFraud, entity.CardStatusBlockedByUser} {
+		if status == entity.CardStatusBlockedByUser {
+			if _, err := s.ChangeCardStatus(ctx, StatusChangeInput{CardID: card.ID, Status: entity.CardStatusActive, Actor: entity.ActorAdmin, ActorName: "support-alice"}); err != nil {
+				t.Fatalf("ChangeCardStatus active: %v", err)
+			}
+		}
+		if _, err := s.ChangeCardStatus(ctx, StatusChangeInput{CardID: card.ID, Status: status, Actor: entity.ActorAdmin, ActorName: "support-alice", Reason: "chargeback"}); err != nil {
+			t.Fatalf("ChangeCardStatus %s: %v", status, err)
+		}
+		if err := s.UnblockCard(ctx, testUserID, card.ID); !errors.Is(err, ErrUnblockForbidden) {
+			t.Fatalf("user unblock of %s by admin: got %v, want %v", status, err, ErrUnblockForbidden)
+		}
+	}
+
+	if _, err := s.ChangeCardStatus(ctx, StatusChangeInput{CardID: card.ID, Status: entity.CardStatusExpired, Actor: entity.ActorSystem}); err != nil {
+		t.Fatalf("ChangeCardStatus expired: %v", err)
+	}
+	if _, err := s.ChangeCardStatus(ctx, StatusChangeInput{CardID: card.ID, Status: entity.CardStatusActive, Actor: entity.ActorAdmin, ActorName: "support-alice"}); !errors.Is(err, ErrStatusTransition) {
+		t.Fatalf("activate expired card: got %v, want %v", err, ErrStatusTransition)
+	}
+
+	history, err := s.GetCardStatusHistory(ctx, testUserID, card.ID)
+	if err != nil {
+		t.Fatalf("GetCardStatusHistory: %v", err)
+	}
+	want := []string{
+		entity.CardStatusBlockedByUser, entity.CardStatusActive, entity.CardStatusBlockedByFraud,
+		entity.CardStatusActive, entity.CardStatusBlockedByUser, entity.CardStatusExpired,
+	}
+	if len(history) != len(want) {
+		t.Fatalf("history has %d entries, want %d", len(history), len(want))
+	}
+	for i, c := range history {
+		if c.ToStatus != want[i] {
+			t.Fatalf("history[%d] = %s, want %s", i, c.ToStatus, want[i])
+		}
+	}
+	if history[0].Actor != entity.ActorUser || history[0].Reason != "lost" || history[2].Actor != entity.ActorAdmin || history[2].ActorName != "support-alice" {
+		t.Fatalf("unexpected actors: %+v %+v", history[0], history[2])
+	}
+}
@@ -0,0 +1,338 @@
+package service
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/mrevds/pizza-app/card-service/internal/entity"
+	"github.com/mrevds/pizza-app/card-service/internal/money"
+	"github.com/mrevds/pizza-app/card-service/internal/repository"
+)
+
+// Подарочная карта - карта типа gift без номера. При выпуске она пополняется со счета
+// gift_card_sales и получает код и PIN. Пока карта не погашена, у неё нет владельца,
+// и ею можно расплатиться по коду и PIN через ProcessPayment - при первой оплате карта
+// переходит к плательщику. RedeemGiftCard привязывает карту к пользователю или
+// переносит её баланс на его карту.
+
+const (
+	// giftCodeAlphabet без 0/O и 1/I, чтобы код с карточки было легко переписать.
+	// 32 символа: байт случайности дает символ без смещения.
+	giftCodeAlphabet = "23456789ABCDEFGHJKLMNPQRSTUVWXYZ"
+	giftCodeLen      = 16 // 80 бит
+	maxGiftBatchLen  = 64
+)
+
+// GiftCardIssueInput - выпуск подарочной карты на Amount
+type GiftCardIssueInput struct {
+	Amount      money.Money
+	ExpiryDate  string // MM/YY, пусто - без срока
+	BatchID     string // партия для партнера, попадает в gift_cards.batch_id
+	Description string
+}
+
+// IssuedGiftCard - выпущенная карта. Code и PIN в открытом виде есть только здесь.
+type IssuedGiftCard struct {
+	Card *entity.Card
+	Code string // "ABCD-EFGH-JKLM-NPQR"
+	PIN  string
+}
+
+// GiftCardRedeemInput - погашение. ToCardID = 0 - карта остается подарочной картой
+// пользователя, иначе её баланс переносится на ToCardID и карта закрывается.
+type GiftCardRedeemInput struct {
+	UserID         int64
+	Code           string
+	PIN            string `json:"-"`
+	ToCardID       int64
+	IdempotencyKey string `json:"-"`
+}
+
+// GiftCardRedemption - подарочная карта после погашения и зачисление на ToCardID
+type GiftCardRedemption struct {
+	Card        *entity.Card        `json:"card"`
+	Transaction *entity.Transaction `json:"transaction"` // nil, если баланс не переносился
+}
+
+func (s *cardService) IssueGiftCard(ctx context.Context, input GiftCardIssueInput) (*IssuedGiftCard, error) {
+	amount, err := normalizeAmount(input.Amount)
+	if err != nil {
+		return nil, err
+	}
+	expiry := strings.TrimSpace(input.ExpiryDate)
+	batch := strings.TrimSpace(input.BatchID)
+	verr := &ValidationError{}
+	if expiry != "" {
+		validateExpiryField(verr, expiry, time.Now())
+	}
+	if len(batch) > maxGiftBatchLen {
+		verr.add("batch_id", fmt.Sprintf("must be at most %d characters", maxGiftBatchLen))
+	}
+	if err := verr.errOrNil(); err != nil {
+		return nil, err
+	}
+
+	code, pin, err := newGiftCode()
+	if err != nil {
+		return nil, err
+	}
+	card := &entity.Card{
+		CardNumberMasked: "GIFT **** **** " + code[giftCodeLen-4:],
+		ExpiryDate:       expiry,
+		CardType:         entity.CardTypeGift,
+		Currency:         amount.Currency,
+		Status:           entity.CardStatusActive,
+		IsActive:         true,
+	}
+	gift := &entity.GiftCard{
+		BatchID:         batch,
+		CodeFingerprint: s.giftCodeFingerprint(code),
+		PINHash:         s.giftPINHash(code, pin),
+	}
+	err = s.repo.RunInTx(ctx, func(repo repository.CardRepository) error {
+		if err := repo.CreateCard(ctx, card); err != nil {
+			return err
+		}
+		if err := repo.CreateStatusChange(ctx, &entity.CardStatusChange{
+			CardID:   card.ID,
+			ToStatus: entity.CardStatusActive,
+			Actor:    entity.ActorAdmin,
+			Reason:   "gift card issued",
+		}); err != nil {
+			return err
+		}
+		gift.CardID = card.ID
+		if err := repo.CreateGiftCard(ctx, gift); err != nil {
+			return err
+		}
+		_, err := applyOperation(ctx, repo, ledgerOperation{
+			Operation:   entity.JournalOperationGiftCardIssue,
+			Amount:      amount,
+			From:        ledgerSide{Kind: entity.AccountKindGiftCardSales},
+			To:          ledgerSide{Card: card, TxType: entity.TransactionTypeDeposit},
+			Description: input.Description,
+		})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &IssuedGiftCard{Card: card, Code: formatGiftCode(code), PIN: pin}, nil
+}
+
+func (s *cardService) RedeemGiftCard(ctx context.Context, input GiftCardRedeemInput) (*GiftCardRedemption, error) {
+	gift, err := s.authenticateGiftCard(ctx, input.Code, input.PIN)
+	if err != nil {
+		return nil, err
+	}
+	if input.ToCardID == gift.CardID {
+		return nil, ErrSameCard
+	}
+
+	var res GiftCardRedemption
+	err = s.runIdempotent(ctx, input.UserID, input.IdempotencyKey, idempotentRedeemGiftCard, input, &res, func(repo repository.CardRepository) error {
+		if input.ToCardID == 0 {
+			card, err := lockCard(ctx, repo, gift.CardID)
+			if err != nil {
+				return err
+			}
+			if err := claimGiftCard(ctx, repo, card, input.UserID); err != nil {
+				return err
+			}
+			res.Card = card
+			return nil
+		}
+
+		card, to, err := lockTransferCards(ctx, repo, gift.CardID, input.ToCardID)
+		if err != nil {
+			return err
+		}
+		if to.UserID != input.UserID {
+			return ErrCardNotFound
+		}
+		if err := claimGiftCard(ctx, repo, card, input.UserID); err != nil {
+			return err
+		}
+		// Захолдированное под заказы остается на подарочной карте
+		amount := money.Money{UnitsMinor: card.Available(), Currency: card.Currency}
+		if amount.UnitsMinor <= 0 {
+			return ErrGiftCardEmpty
+		}
+		if err := checkCardOperation(card, amount); err != nil {
+			return err
+		}
+		if err := checkCardOperation(to, amount); err != nil {
+			return err
+		}
+		txns, err := applyOperation(ctx, repo, ledgerOperation{
+			Operation:   entity.JournalOperationTransfer,
+			Amount:      amount,
+			From:        ledgerSide{Card: card, TxType: entity.TransactionTypeTransferOut},
+			To:          ledgerSide{Card: to, TxType: entity.TransactionTypeTransferIn},
+			Description: "gift card redeemed",
+		})
+		if err != nil {
+			return err
+		}
+		if card.Balance == 0 {
+			if err := changeCardStatus(ctx, repo, card, entity.CardStatusClosed, changeActor{Kind: entity.ActorUser, ID: input.UserID}, "gift card redeemed"); err != nil {
+				return err
+			}
+		}
+		res.Card, res.Transaction = card, txns[1]
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+func (s *cardService) GetGiftCardBalance(ctx context.Context, code, pin string) (*entity.GiftCard, *entity.Card, error) {
+	gift, err := s.authenticateGiftCard(ctx, code, pin)
+	if err != nil {
+		return nil, nil, err
+	}
+	card, err := s.repo.GetCard(ctx, gift.CardID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, nil, ErrGiftCardInvalid
+		}
+		return nil, nil, err
+	}
+	return gift, card, nil
+}
+
+// authenticateGiftCard проверяет код и PIN в отдельной транзакции: счетчик неверных PIN
+// сохраняется, даже если операция, для которой проверялась карта, потом откатится.
+// Неизвестный код и неверный PIN неотличимы.
+func (s *cardService) authenticateGiftCard(ctx context.Context, code, pin string) (*entity.GiftCard, error) {
+	code, pin = normalizeGiftCode(code), strings.TrimSpace(pin)
+	if code == "" || pin == "" {
+		return nil, ErrGiftCardCodeRequired
+	}
+
+	var (
+		gift     *entity.GiftCard
+		wrongPIN bool
+	)
+	maxAttempts := s.cfg.GiftCard.MaxPINAttempts
+	err := s.repo.RunInTx(ctx, func(repo repository.CardRepository) error {
+		g, err := repo.GetGiftCardByCodeForUpdate(ctx, s.giftCodeFingerprint(code))
+		if err != nil {
+			if errors.Is(err, repository.ErrNotFound) {
+				return ErrGiftCardInvalid
+			}
+			return err
+		}
+		if maxAttempts > 0 && g.FailedPINAttempts >= maxAttempts {
+			return ErrGiftCardLocked
+		}
+		if !hmac.Equal([]byte(g.PINHash), []byte(s.giftPINHash(code, pin))) {
+			wrongPIN = true
+			g.FailedPINAttempts++
+			return repo.UpdateGiftCard(ctx, g)
+		}
+		if g.FailedPINAttempts > 0 {
+			g.FailedPINAttempts = 0
+			if err := repo.UpdateGiftCard(ctx, g); err != nil {
+				return err
+			}
+		}
+		gift = g
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if wrongPIN {
+		return nil, ErrGiftCardInvalid
+	}
+	return gift, nil
+}
+
+// claimGiftCard привязывает заблокированную подарочную карту к userID при первом
+// использовании по коду. Карта, погашенная другим пользователем, по коду недоступна.
+func claimGiftCard(ctx context.Context, repo repository.CardRepository, card *entity.Card, userID int64) error {
+	gift, err := repo.GetGiftCardForUpdate(ctx, card.ID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return ErrCardNotFound
+		}
+		return err
+	}
+	if gift.Redeemed() {
+		if card.UserID != userID {
+			return ErrGiftCardRedeemed
+		}
+		return nil
+	}
+	if err := checkCardUsable(card); err != nil {
+		return err
+	}
+	now := time.Now()
+	gift.RedeemedBy, gift.RedeemedAt = userID, &now
+	if err := repo.UpdateGiftCard(ctx, gift); err != nil {
+		return err
+	}
+	if err := repo.SetCardOwner(ctx, card.ID, userID); err != nil {
+		return err
+	}
+	card.UserID = userID
+	return nil
+}
+
+// giftCodeFingerprint и giftPINHash - HMAC с ключом отпечатков номеров карт.
+// PIN хешируется вместе с кодом, одинаковые PIN разных карт не совпадают.
+func (s *cardService) giftCodeFingerprint(code string) string {
+	return s.envelope.Fingerprint("gift-code:" + code)
+}
+
+func (s *cardService) giftPINHash(code, pin string) string {
+	return s.envelope.Fingerprint("gift-pin:" + code + ":" + pin)
+}
+
+// newGiftCode возвращает код без разделителей и PIN
+func newGiftCode() (code, pin string, err error) {
+	b := make([]byte, giftCodeLen)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", err
+	}
+	for i := range b {
+		b[i] = giftCodeAlphabet[int(b[i])%len(giftCodeAlphabet)]
+	}
+	n, err := rand.Int(rand.Reader, big.NewInt(1_000_000))
+	if err != nil {
+		return "", "", err
+	}
+	return string(b), fmt.Sprintf("%06d", n.Int64()), nil
+}
+
+// normalizeGiftCode приводит введенный код к виду без разделителей: "abcd-efgh ..." -> "ABCDEFGH..."
+func normalizeGiftCode(code string) string {
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case '-', ' ':
+			return -1
+		}
+		return r
+	}, strings.ToUpper(strings.TrimSpace(code)))
+}
+
+// formatGiftCode разбивает код на группы по 4 символа
+func formatGiftCode(code string) string {
+	var sb strings.Builder
+	for i := 0; i < len(code); i += 4 {
+		if i > 0 {
+			sb.WriteByte('-')
+		}
+		sb.WriteString(code[i:min(i+4, len(code))])
+	}
+	return sb.String()
+}
@@ -0,0 +1,182 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/mrevds/pizza-app/card-service/internal/config"
+	"github.com/mrevds/pizza-app/card-service/internal/encryption"
+	"github.com/mrevds/pizza-app/card-service/internal/entity"
+)
+
+// newGiftTestService - сервис с ключом отпечатков для кодов и PIN
+func newGiftTestService(t *testing.T) (*cardService, *memRepo) {
+	t.Helper()
+	s, repo := newTestService(t)
+	key := base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{7}, 32))
+	envelope, err := encryption.NewEnvelope(&config.Config{Encryption: config.EncryptionConfig{
+		ActiveKeyVersion: 1,
+		MasterKeys:       "1:" + key,
+		FingerprintKey:   key,
+	}})
+	if err != nil {
+		t.Fatalf("NewEnvelope: %v", err)
+	}
+	s.envelope = envelope
+	s.cfg.GiftCard.MaxPINAttempts = 3
+	return s, repo
+}
+
+func issueGiftCard(t *testing.T, s *cardService, amount int64) *IssuedGiftCard {
+	t.Helper()
+	gc, err := s.IssueGiftCard(context.Background(), GiftCardIssueInput{Amount: rub(amount), ExpiryDate: "12/99", BatchID: "partner-1"})
+	if err != nil {
+		t.Fatalf("IssueGiftCard: %v", err)
+	}
+	return gc
+}
+
+func TestGiftCardPaymentByCode(t *testing.T) {
+	s, repo := newGiftTestService(t)
+	ctx := context.Background()
+	gc := issueGiftCard(t, s, 1_000)
+	if gc.Card.CardType != entity.CardTypeGift || gc.Card.UserID != 0 || len(gc.PIN) != 6 {
+		t.Fatalf("issued = %+v, want unowned gift card with 6-digit PIN", gc)
+	}
+
+	gift, card, err := s.GetGiftCardBalance(ctx, gc.Code, gc.PIN)
+	if err != nil {
+		t.Fatalf("GetGiftCardBalance: %v", err)
+	}
+	if card.Balance != 1_000 || gift.Redeemed() || gift.BatchID != "partner-1" {
+		t.Fatalf("balance = %d, redeemed = %v, batch = %q, want 1000 unredeemed from partner-1", card.Balance, gift.Redeemed(), gift.BatchID)
+	}
+
+	// Код вводится без дефисов и в нижнем регистре
+	code := strings.ToLower(strings.ReplaceAll(gc.Code, "-", ""))
+	txn, err := s.ProcessPayment(ctx, PaymentInput{UserID: testUserID, GiftCode: code, GiftPIN: gc.PIN, Amount: rub(300), OrderID: "order-1"})
+	if err != nil {
+		t.Fatalf("ProcessPayment by gift code: %v", err)
+	}
+	if txn.CardID != gc.Card.ID || txn.BalanceAfter != 700 {
+		t.Errorf("payment = %+v, want gift card %d with balance 700", txn, gc.Card.ID)
+	}
+	if got := repo.store.cards[gc.Card.ID].UserID; got != testUserID {
+		t.Errorf("gift card owner = %d, want %d after first payment", got, testUserID)
+	}
+
+	// Дальше карта - обычная карта пользователя, другим по коду недоступна
+	if _, err := s.ProcessPayment(ctx, PaymentInput{UserID: testUserID, CardID: gc.Card.ID, Amount: rub(200), OrderID: "order-2"}); err != nil {
+		t.Fatalf("ProcessPayment by card_id: %v", err)
+	}
+	_, err = s.ProcessPayment(ctx, PaymentInput{UserID: testUserID + 1, GiftCode: gc.Code, GiftPIN: gc.PIN, Amount: rub(100), OrderID: "order-3"})
+	if !errors.Is(err, ErrGiftCardRedeemed) {
+		t.Errorf("payment by another user err = %v, want ErrGiftCardRedeemed", err)
+	}
+	_, err = s.ProcessPayment(ctx, PaymentInput{UserID: testUserID, CardID: gc.Card.ID, GiftCode: gc.Code, GiftPIN: gc.PIN, Amount: rub(100)})
+	if !errors.Is(err, ErrPaymentCardConflict) {
+		t.Errorf("card_id with gift code err = %v, want ErrPaymentCardConflict", err)
+	}
+	if got := repo.store.cards[gc.Card.ID].Balance; got != 500 {
+		t.Errorf("gift card balance = %d, want 500", got)
+	}
+	if _, err := s.UpdateCard(ctx, UpdateCardInput{UserID: testUserID, CardID: gc.Card.ID, ExpiryDate: "12/99"}); !errors.Is(err, ErrGiftCardReadOnly) {
+		t.Errorf("UpdateCard of gift card err = %v, want ErrGiftCardReadOnly", err)
+	}
+}
+
+func TestRedeemGiftCard(t *testing.T) {
+	s, repo := newGiftTestService(t)
+	ctx := context.Background()
+	primary := addFundedCard(t, s, repo, testUserID, 100)
+
+	// Без карты назначения подарочная карта появляется среди карт пользователя
+	kept := issueGiftCard(t, s, 700)
+	res, err := s.RedeemGiftCard(ctx, GiftCardRedeemInput{UserID: testUserID, Code: kept.Code, PIN: kept.PIN})
+	if err != nil {
+		t.Fatalf("RedeemGiftCard: %v", err)
+	}
+	if res.Card.UserID != testUserID || res.Transaction != nil {
+		t.Fatalf("redemption = %+v, want gift card of user %d without transaction", res, testUserID)
+	}
+	cards, err := s.GetUserCards(ctx, testUserID)
+	if err != nil || len(cards) != 2 {
+		t.Fatalf("GetUserCards = %d cards, %v, want primary and gift card", len(cards), err)
+	}
+
+	// С картой назначения баланс переносится, опустевшая подарочная карта закрывается
+	moved := issueGiftCard(t, s, 500)
+	input := GiftCardRedeemInput{UserID: testUserID, Code: moved.Code, PIN: moved.PIN, ToCardID: primary.ID, IdempotencyKey: "redeem-1"}
+	res, err = s.RedeemGiftCard(ctx, input)
+	if err != nil {
+		t.Fatalf("RedeemGiftCard to card: %v", err)
+	}
+	if res.Transaction == nil || res.Transaction.CardID != primary.ID || res.Transaction.BalanceAfter != 600 {
+		t.Fatalf("transaction = %+v, want transfer_in to card %d with balance 600", res.Transaction, primary.ID)
+	}
+	if c := repo.store.cards[moved.Card.ID]; c.Balance != 0 || c.Status != entity.CardStatusClosed {
+		t.Errorf("gift card = balance %d status %s, want closed with 0", c.Balance, c.Status)
+	}
+	again, err := s.RedeemGiftCard(ctx, input)
+	if err != nil || again.Transaction.ID != res.Transaction.ID {
+		t.Fatalf("replay = %+v, %v, want transaction %d", again, err, res.Transaction.ID)
+	}
+	input.IdempotencyKey = ""
+	if _, err := s.RedeemGiftCard(ctx, input); !errors.Is(err, ErrGiftCardEmpty) {
+		t.Errorf("second redemption err = %v, want ErrGiftCardEmpty", err)
+	}
+	if got := repo.store.cards[primary.ID].Balance; got != 600 {
+		t.Errorf("primary card balance = %d, want 600", got)
+	}
+
+	// Погашенную другим пользователем карту не погасить
+	other := addFundedCard(t, s, repo, testUserID+1, 0)
+	_, err = s.RedeemGiftCard(ctx, GiftCardRedeemInput{UserID: testUserID + 1, Code: kept.Code, PIN: kept.PIN, ToCardID: other.ID})
+	if !errors.Is(err, ErrGiftCardRedeemed) {
+		t.Errorf("redemption by another user err = %v, want ErrGiftCardRedeemed", err)
+	}
+}
+
+func TestGiftCardPINAttempts(t *testing.T) {
+	s, _ := newGiftTestService(t)
+	ctx := context.Background()
+	gc := issueGiftCard(t, s, 1_000)
+	spare := issueGiftCard(t, s, 1_000)
+
+	// Счетчик неверных PIN сбрасывается верным PIN
+	for i := 0; i < 2; i++ {
+		if _, _, err := s.GetGiftCardBalance(ctx, spare.Code, "000000x"); !errors.Is(err, ErrGiftCardInvalid) {
+			t.Fatalf("wrong PIN %d err = %v, want ErrGiftCardInvalid", i, err)
+		}
+	}
+	if _, _, err := s.GetGiftCardBalance(ctx, spare.Code, spare.PIN); err != nil {
+		t.Fatalf("correct PIN: %v", err)
+	}
+
+	for i := 0; i < 3; i++ {
+		if _, _, err := s.GetGiftCardBalance(ctx, gc.Code, "000000x"); !errors.Is(err, ErrGiftCardInvalid) {
+			t.Fatalf("wrong PIN %d err = %v, want ErrGiftCardInvalid", i, err)
+		}
+	}
+	if _, _, err := s.GetGiftCardBalance(ctx, gc.Code, gc.PIN); !errors.Is(err, ErrGiftCardLocked) {
+		t.Errorf("correct PIN after limit err = %v, want ErrGiftCardLocked", err)
+	}
+	if _, _, err := s.GetGiftCardBalance(ctx, spare.Code, spare.PIN); err != nil {
+		t.Errorf("other card after lock: %v", err)
+	}
+
+	if _, _, err := s.GetGiftCardBalance(ctx, "AAAA-BBBB-CCCC-DDDD", gc.PIN); !errors.Is(err, ErrGiftCardInvalid) {
+		t.Errorf("unknown code err = %v, want ErrGiftCardInvalid", err)
+	}
+	if _, _, err := s.GetGiftCardBalance(ctx, gc.Code, " "); !errors.Is(err, ErrGiftCardCodeRequired) {
+		t.Errorf("empty PIN err = %v, want ErrGiftCardCodeRequired", err)
+	}
+	var verr *ValidationError
+	if _, err := s.IssueGiftCard(ctx, GiftCardIssueInput{Amount: rub(100), ExpiryDate: "01/20"}); !errors.As(err, &verr) {
+		t.Errorf("expired expiry err = %v, want ValidationError", err)
+	}
+}
@@ -0,0 +1,125 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/mrevds/pizza-app/card-service/internal/entity"
+	"github.com/mrevds/pizza-app/card-service/internal/repository"
+)
+
+const maxIdempotencyKeyLen = 128
+
+// Операции, для которых сохраняется результат по ключу идемпотентности
+const (
+	idempotentDeposit   = "deposit"
+	idempotentWithdraw  = "withdraw"
+	idempotentTransfer  = "transfer"
+	idempotentPayment   = "payment"
+	idempotentSplit     = "split_payment"
+	idempotentAuthorize = "authorize"
+	idempotentCapture   = "capture"
+	idempotentRefund    = "refund"
+
+	idempotentTransferToUser = "transfer_to_user"
+	idempotentRedeemGiftCard = "redeem_gift_card"
+)
+
+// transferResult - результат Transfer в сохраненном ответе
+type transferResult struct {
+	From *entity.Transaction `json:"from"`
+	To   *entity.Transaction `json:"to"`
+}
+
+// captureResult - результат CapturePayment в сохраненном ответе
+type captureResult struct {
+	Authorization *entity.Authorization `json:"authorization"`
+	Transaction   *entity.Transaction   `json:"transaction"`
+}
+
+// runIdempotent выполняет fn в транзакции БД не более одного раза для (userID, key).
+// Результат (result) сохраняется в той же транзакции, повтор с тем же ключом и тем же
+// запросом получает сохраненный result, с другим запросом - ErrIdempotencyKeyReused.
+// Ошибки не сохраняются: повтор неуспешной операции выполняет её заново.
+// Пустой key - обычное выполнение без идемпотентности.
+func (s *cardService) runIdempotent(ctx context.Context, userID int64, key, operation string, request, result any,
+	fn func(repo repository.CardRepository) error) error {
+	if key == "" {
+		return s.repo.RunInTx(ctx, fn)
+	}
+	if len(key) > maxIdempotencyKeyLen {
+		return ErrInvalidIdempotencyKey
+	}
+	fingerprint, err := requestFingerprint(operation, request)
+	if err != nil {
+		return err
+	}
+
+	replayed, err := s.replayIdempotent(ctx, userID, key, fingerprint, result)
+	if err != nil || replayed {
+		return err
+	}
+
+	err = s.repo.RunInTx(ctx, func(repo repository.CardRepository) error {
+		if err := fn(repo); err != nil {
+			return err
+		}
+		response, err := json.Marshal(result)
+		if err != nil {
+			return fmt.Errorf("failed to marshal idempotent response: %w", err)
+		}
+		return repo.CreateIdempotencyRecord(ctx, &entity.IdempotencyRecord{
+			UserID:      userID,
+			Key:         key,
+			Operation:   operation,
+			Fingerprint: fingerprint,
+			Response:    response,
+			ExpiresAt:   time.Now().Add(s.cfg.Card.IdempotencyTTL),
+		})
+	})
+	if errors.Is(err, repository.ErrAlreadyExists) {
+		// Параллельный запрос с тем же ключом успел раньше, наша транзакция откатилась
+		replayed, err := s.replayIdempotent(ctx, userID, key, fingerprint, result)
+		if err != nil {
+			return err
+		}
+		if !replayed {
+			return fmt.Errorf("idempotency record for key %q disappeared", key)
+		}
+		return nil
+	}
+	return err
+}
+
+// replayIdempotent загружает сохраненный результат в result, если он есть
+func (s *cardService) replayIdempotent(ctx context.Context, userID int64, key, fingerprint string, result any) (bool, error) {
+	rec, err := s.repo.GetIdempotencyRecord(ctx, userID, key)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return false, nil
+		}
+		return false, err
+	}
+	if rec.Fingerprint != fingerprint {
+		return false, ErrIdempotencyKeyReused
+	}
+	if err := json.Unmarshal(rec.Response, result); err != nil {
+		return false, fmt.Errorf("failed to unmarshal idempotent response: %w", err)
+	}
+	return true, nil
+}
+
+// requestFingerprint - sha256 операции и входных данных в JSON
+func requestFingerprint(operation string, request any) (string, error) {
+	payload, err := json.Marshal(request)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal request: %w", err)
+	}
+	sum := sha256.Sum256(append([]byte(operation+"\x00"), payload...))
+	return hex.EncodeToString(sum[:]), nil
+}
@@ -0,0 +1,41 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/mrevds/pizza-app/card-service/internal/money"
+)
+
+func TestRequestFingerprint(t *testing.T) {
+	base := OperationInput{
+		UserID:         1,
+		CardID:         2,
+		Amount:         money.Money{UnitsMinor: 1000, Currency: "RUB"},
+		Description:    "top up",
+		IdempotencyKey: "key-1",
+	}
+	fp := func(operation string, input OperationInput) string {
+		t.Helper()
+		s, err := requestFingerprint(operation, input)
+		if err != nil {
+			t.Fatalf("requestFingerprint: %v", err)
+		}
+		return s
+	}
+	want := fp(idempotentDeposit, base)
+
+	otherKey := base
+	otherKey.IdempotencyKey = "another-key"
+	if got := fp(idempotentDeposit, otherKey); got != want {
+		t.Fatalf("fingerprint depends on idempotency key")
+	}
+
+	otherAmount := base
+	otherAmount.Amount.UnitsMinor = 1001
+	if got := fp(idempotentDeposit, otherAmount); got == want {
+		t.Fatalf("fingerprint ignores amount")
+	}
+	if got := fp(idempotentWithdraw, base); got == want {
+		t.Fatalf("fingerprint ignores operation")
+	}
+}
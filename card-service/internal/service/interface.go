@@ -0,0 +1,163 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"io"
+
+	"github.com/mrevds/pizza-app/card-service/internal/entity"
+	"github.com/mrevds/pizza-app/card-service/internal/money"
+)
+
+var (
+	ErrCardNotFound        = errors.New("card not found")
+	ErrTransactionNotFound = errors.New("transaction not found")
+	ErrCardBlocked         = errors.New("card is blocked")
+	ErrCardInactive        = errors.New("card is not active")
+	ErrCardExpired         = errors.New("card is expired")
+	ErrCardNotBlocked      = errors.New("card is not blocked")
+	ErrUnblockForbidden    = errors.New("card was blocked by the bank and can not be unblocked by the user")
+	ErrInvalidCardStatus   = errors.New("invalid card status")
+	ErrStatusTransition    = errors.New("card status change is not allowed")
+	ErrInsufficientFunds   = errors.New("insufficient funds")
+	ErrInvalidAmount       = errors.New("amount must be positive")
+	ErrSameCard            = errors.New("cannot transfer to the same card")
+	ErrCurrencyMismatch    = errors.New("currency does not match card currency")
+	ErrUnsupportedCurrency = errors.New("unsupported currency")
+	ErrInvalidCardData     = errors.New("invalid card data")
+	ErrInvalidUserID       = errors.New("user id must be a UUID")
+	ErrUserUUIDRequired    = errors.New("user_uuid is required")
+	ErrCardAlreadyExists   = errors.New("card is already added")
+
+	ErrOrderIDRequired             = errors.New("order_id is required")
+	ErrAuthorizationNotFound       = errors.New("authorization not found")
+	ErrAuthorizationExists         = errors.New("order already has an active authorization on this card")
+	ErrAuthorizationNotActive      = errors.New("authorization is not active")
+	ErrCaptureExceedsAuthorization = errors.New("capture amount exceeds authorized amount")
+
+	ErrSplitLegsInvalid      = errors.New("split payment must have from 1 to 10 legs")
+	ErrSplitCurrencyMismatch = errors.New("all legs of a split payment must be in one currency")
+	ErrSplitDuplicateCard    = errors.New("card is used in several legs of a split payment")
+	ErrOrderAlreadyCharged   = errors.New("order is already charged, set additional_charge to charge it again")
+
+	ErrRefundTargetRequired  = errors.New("transaction_id or order_id is required")
+	ErrAmbiguousOrderPayment = errors.New("order has several payments, transaction_id is required")
+	ErrNotRefundable         = errors.New("transaction is not a successful payment")
+	ErrPaymentReversed       = errors.New("payment is reversed")
+	ErrPaymentFullyRefunded  = errors.New("payment is already fully refunded")
+	ErrRefundExceedsPayment  = errors.New("refund amount exceeds refundable amount")
+
+	ErrLimitExceeded   = errors.New("card limit exceeded")
+	ErrLimitSetByAdmin = errors.New("limit was set by the bank and can not be changed by the user")
+	ErrRiskDenied      = errors.New("operation declined by risk check, card is blocked")
+
+	ErrReconcileReasonRequired = errors.New("reason is required to fix balances")
+
+	ErrScheduledTransferNotFound  = errors.New("scheduled transfer not found")
+	ErrScheduledTransferNotActive = errors.New("scheduled transfer is not active")
+	ErrInvalidSchedule            = errors.New("invalid schedule")
+
+	ErrP2PUnavailable       = errors.New("transfers by phone number are not available")
+	ErrPhoneRequired        = errors.New("phone_number is required")
+	ErrRecipientNotFound    = errors.New("recipient not found")
+	ErrRecipientNoCard      = errors.New("recipient can not receive transfers in this currency")
+	ErrSelfTransfer         = errors.New("recipient is the sender, use Transfer between own cards")
+	ErrTooManyLookups       = errors.New("too many recipient lookups, try again later")
+	ErrConfirmationRequired = errors.New("confirmation_token is required")
+	ErrP2PTransferNotFound  = errors.New("transfer confirmation not found")
+	ErrP2PTransferExpired   = errors.New("transfer confirmation expired, preview the transfer again")
+	ErrP2PTransferCompleted = errors.New("transfer is already completed")
+
+	ErrGiftCardCodeRequired = errors.New("gift card code and PIN are required")
+	ErrGiftCardInvalid      = errors.New("invalid gift card code or PIN")
+	ErrGiftCardLocked       = errors.New("too many wrong PIN attempts, gift card is locked")
+	ErrGiftCardRedeemed     = errors.New("gift card is already redeemed by another user")
+	ErrGiftCardEmpty        = errors.New("gift card balance is empty")
+	ErrGiftCardReadOnly     = errors.New("gift card details can not be changed")
+	ErrPaymentCardConflict  = errors.New("set either card_id or gift card code, not both")
+
+	ErrInvalidPageToken = errors.New("invalid page token")
+	ErrResumeTooOld     = errors.New("too many events since after_event_id, reload card state")
+
+	ErrIdempotencyKeyReused  = errors.New("idempotency key was already used with a different request")
+	ErrInvalidIdempotencyKey = errors.New("idempotency key is too long")
+)
+
+type CardService interface {
+	// ResolveUser возвращает id пользователя card-service по его UUID в user-service
+	ResolveUser(ctx context.Context, externalID string) (int64, error)
+
+	AddCard(ctx context.Context, input AddCardInput) (*entity.Card, error)
+	GetCard(ctx context.Context, userID, cardID int64) (*entity.Card, error)
+	GetUserCards(ctx context.Context, userID int64) ([]*entity.Card, error)
+	UpdateCard(ctx context.Context, input UpdateCardInput) (*entity.Card, error)
+	DeleteCard(ctx context.Context, userID, cardID int64) error
+	BlockCard(ctx context.Context, userID, cardID int64, reason string) error
+	UnblockCard(ctx context.Context, userID, cardID int64) error
+	// ChangeCardStatus - смена статуса админом или системой (actor admin/system), без проверки владельца
+	ChangeCardStatus(ctx context.Context, input StatusChangeInput) (*entity.Card, error)
+	GetCardStatusHistory(ctx context.Context, userID, cardID int64) ([]*entity.CardStatusChange, error)
+
+	GetBalance(ctx context.Context, userID, cardID int64) (*entity.Card, error)
+	Deposit(ctx context.Context, input OperationInput) (*entity.Transaction, error)
+	Withdraw(ctx context.Context, input OperationInput) (*entity.Transaction, error)
+	Transfer(ctx context.Context, input TransferInput) (from, to *entity.Transaction, err error)
+
+	// Перевод другому пользователю по номеру телефона, см. p2p.go
+	SetDefaultCard(ctx context.Context, userID, cardID int64) (*entity.Card, error)
+	PreviewTransferToUser(ctx context.Context, input TransferToUserPreviewInput) (*entity.P2PTransfer, error)
+	TransferToUser(ctx context.Context, input TransferToUserInput) (*entity.P2PTransfer, *entity.Transaction, error)
+
+	// Подарочные карты, см. gift_cards.go. IssueGiftCard - операция админа.
+	IssueGiftCard(ctx context.Context, input GiftCardIssueInput) (*IssuedGiftCard, error)
+	RedeemGiftCard(ctx context.Context, input GiftCardRedeemInput) (*GiftCardRedemption, error)
+	GetGiftCardBalance(ctx context.Context, code, pin string) (*entity.GiftCard, *entity.Card, error)
+
+	GetTransactions(ctx context.Context, userID, cardID int64, limit, offset int) ([]*entity.Transaction, int, error)
+	// ListTransactions - история с фильтрами по одной или всем картам пользователя и курсорной пагинацией
+	ListTransactions(ctx context.Context, query TransactionQuery) (*TransactionPage, error)
+	// Аналитика по дневным агрегатам, см. analytics.go
+	GetSpendingSummary(ctx context.Context, query SpendingQuery) (*SpendingSummary, error)
+	GetBalanceHistory(ctx context.Context, query BalanceHistoryQuery) (*BalanceHistory, error)
+	// ExportStatement потоково пишет выписку по карте в w, begin получает имя и тип файла до первой записи
+	ExportStatement(ctx context.Context, input StatementInput, begin func(StatementFile) error, w io.Writer) error
+	// WatchCardEvents отдает события карт пользователя в send до отмены ctx
+	WatchCardEvents(ctx context.Context, input WatchInput, send func(*entity.CardEvent) error) error
+	// PurgeCardEvents удаляет старые события, вызывается фоновой задачей
+	PurgeCardEvents(ctx context.Context) (int64, error)
+	GetTransaction(ctx context.Context, userID, transactionID int64) (*entity.Transaction, error)
+
+	ProcessPayment(ctx context.Context, input PaymentInput) (*entity.Transaction, error)
+	// ProcessSplitPayment - оплата заказа несколькими картами целиком или никак, см. split_payments.go
+	ProcessSplitPayment(ctx context.Context, input SplitPaymentInput) ([]*entity.Transaction, error)
+	// GetPaymentsByOrder - транзакции заказа и итоговый статус оплаты, см. orders.go
+	GetPaymentsByOrder(ctx context.Context, userID int64, orderID string) (*OrderPaymentSummary, error)
+	ValidateCard(ctx context.Context, userID, cardID int64, amount money.Money) error
+
+	AuthorizePayment(ctx context.Context, input AuthorizeInput) (*entity.Authorization, error)
+	CapturePayment(ctx context.Context, input CaptureInput) (*entity.Authorization, *entity.Transaction, error)
+	VoidAuthorization(ctx context.Context, userID, authorizationID int64, orderID string) (*entity.Authorization, error)
+	// ExpireAuthorizations переводит истекшие холды в expired, вызывается фоновой задачей
+	ExpireAuthorizations(ctx context.Context) (int64, error)
+
+	RefundPayment(ctx context.Context, input RefundInput) (*RefundResult, error)
+
+	// Фоновые задачи, см. maintenance.go
+	ExpireCards(ctx context.Context) (int, error)
+	NotifyExpiringCards(ctx context.Context) (int, error)
+	FailStalePendingTransactions(ctx context.Context) (int64, error)
+	// Reconcile сверяет балансы карт с историей транзакций и главной книгой, см. reconcile.go
+	Reconcile(ctx context.Context, input ReconcileInput) (*entity.ReconcileReport, error)
+
+	CreateScheduledTransfer(ctx context.Context, input ScheduledTransferInput) (*entity.ScheduledTransfer, error)
+	ListScheduledTransfers(ctx context.Context, userID int64, includeFinished bool) ([]*entity.ScheduledTransfer, error)
+	CancelScheduledTransfer(ctx context.Context, userID, id int64) (*entity.ScheduledTransfer, error)
+	// RunScheduledTransfers выполняет наступившие запуски, вызывается фоновой задачей
+	RunScheduledTransfers(ctx context.Context) (int, error)
+
+	SetCardLimits(ctx context.Context, input SetLimitsInput) ([]*entity.CardLimit, error)
+	// AdminSetCardLimits заменяет лимиты любой карты от имени админа, возвращает лимиты и карту
+	AdminSetCardLimits(ctx context.Context, input AdminSetLimitsInput) ([]*entity.CardLimit, *entity.Card, error)
+	// GetCardLimits возвращает лимиты карты с потраченным за окна лимитов
+	GetCardLimits(ctx context.Context, userID, cardID int64) ([]*entity.CardLimit, error)
+}
@@ -0,0 +1,63 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/mrevds/pizza-app/card-service/internal/encryption"
+	"github.com/mrevds/pizza-app/card-service/internal/repository"
+)
+
+const keyRotationBatchSize = 100
+
+// KeyRotator переоборачивает ключи данных карт активной версией мастер-ключа
+type KeyRotator struct {
+	repo     repository.CardRepository
+	envelope *encryption.Envelope
+}
+
+func NewKeyRotator(repo repository.CardRepository, envelope *encryption.Envelope) *KeyRotator {
+	return &KeyRotator{repo: repo, envelope: envelope}
+}
+
+// Rotate обходит карты пачками и возвращает количество переобернутых ключей.
+// Старые мастер-ключи должны оставаться в конфиге, пока ротация не завершена.
+func (k *KeyRotator) Rotate(ctx context.Context) (int, error) {
+	active := k.envelope.ActiveVersion()
+	rotated := 0
+	var afterID int64
+
+	for {
+		keys, err := k.repo.ListCardKeysToRotate(ctx, active, afterID, keyRotationBatchSize)
+		if err != nil {
+			return rotated, fmt.Errorf("failed to list card keys: %w", err)
+		}
+		if len(keys) == 0 {
+			return rotated, nil
+		}
+
+		for _, key := range keys {
+			afterID = key.CardID
+			previous := key.KeyVersion
+
+			wrapped, err := k.envelope.Rewrap(key.WrappedKey, previous)
+			if err != nil {
+				return rotated, fmt.Errorf("card %d: %w", key.CardID, err)
+			}
+			key.WrappedKey = wrapped
+			key.KeyVersion = active
+
+			if err := k.repo.UpdateCardKey(ctx, key, previous); err != nil {
+				// Ключ уже переобернут параллельным запуском
+				if errors.Is(err, repository.ErrNotFound) {
+					continue
+				}
+				return rotated, fmt.Errorf("card %d: %w", key.CardID, err)
+			}
+			rotated++
+		}
+		log.Printf("key rotation: %d card keys re-wrapped with v%d", rotated, active)
+	}
+}
@@ -0,0 +1,135 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/mrevds/pizza-app/card-service/internal/entity"
+	"github.com/mrevds/pizza-app/card-service/internal/money"
+	"github.com/mrevds/pizza-app/card-service/internal/repository"
+)
+
+// ledgerSide - одна сторона операции: карта или системный счет вида Kind
+type ledgerSide struct {
+	Card   *entity.Card
+	Kind   string
+	TxType string // тип транзакции в истории карты, только для карты
+}
+
+// ledgerOperation - перемещение Amount со счета From на счет To
+type ledgerOperation struct {
+	Operation   string
+	Amount      money.Money
+	From        ledgerSide
+	To          ledgerSide
+	Description string
+	OrderID     string
+	Category    string
+	// OriginalTxID - исходная оплата для возврата
+	OriginalTxID int64
+}
+
+// applyOperation записывает операцию в журнал главной книги (дебет From, кредит To)
+// и пересчитывает балансы карт по журналу. Карты должны быть заблокированы (FOR UPDATE).
+// Возвращает транзакции истории карт: сначала From, затем To.
+func applyOperation(ctx context.Context, repo repository.CardRepository, op ledgerOperation) ([]*entity.Transaction, error) {
+	if from := op.From.Card; from != nil && from.Available() < op.Amount.UnitsMinor {
+		return nil, ErrInsufficientFunds
+	}
+
+	debit, err := ledgerAccount(ctx, repo, op.From, op.Amount.Currency)
+	if err != nil {
+		return nil, err
+	}
+	credit, err := ledgerAccount(ctx, repo, op.To, op.Amount.Currency)
+	if err != nil {
+		return nil, err
+	}
+
+	entry := &entity.JournalEntry{
+		Operation:   op.Operation,
+		Description: op.Description,
+		Postings: []*entity.Posting{
+			{AccountID: debit.ID, Direction: entity.PostingDebit, Amount: op.Amount.UnitsMinor, Currency: op.Amount.Currency},
+			{AccountID: credit.ID, Direction: entity.PostingCredit, Amount: op.Amount.UnitsMinor, Currency: op.Amount.Currency},
+		},
+	}
+	if !entry.Balanced() {
+		return nil, fmt.Errorf("unbalanced journal entry for %s", op.Operation)
+	}
+	if err := repo.CreateJournalEntry(ctx, entry); err != nil {
+		return nil, err
+	}
+
+	txns := make([]*entity.Transaction, 0, 2)
+	if op.From.Card != nil {
+		txn, err := syncCardBalance(ctx, repo, op.From, debit, entry, op)
+		if err != nil {
+			return nil, err
+		}
+		txns = append(txns, txn)
+	}
+	if op.To.Card != nil {
+		txn, err := syncCardBalance(ctx, repo, op.To, credit, entry, op)
+		if err != nil {
+			return nil, err
+		}
+		txns = append(txns, txn)
+	}
+	return txns, nil
+}
+
+func ledgerAccount(ctx context.Context, repo repository.CardRepository, side ledgerSide, currency string) (*entity.LedgerAccount, error) {
+	if side.Card != nil {
+		return repo.GetOrCreateAccount(ctx, entity.AccountKindCard, side.Card.ID, currency)
+	}
+	return repo.GetOrCreateAccount(ctx, side.Kind, 0, currency)
+}
+
+// syncCardBalance обновляет проекцию баланса карты по журналу и пишет транзакцию в историю карты.
+// balance_before/after берутся из проекции до и из текущего баланса счета после проводки:
+// CreateJournalEntry обновляет его в той же транзакции, проводки не суммируются.
+func syncCardBalance(ctx context.Context, repo repository.CardRepository, side ledgerSide, account *entity.LedgerAccount,
+	entry *entity.JournalEntry, op ledgerOperation) (*entity.Transaction, error) {
+	card := side.Card
+	before := card.Balance
+	after, err := repo.GetAccountBalance(ctx, account.ID)
+	if err != nil {
+		return nil, err
+	}
+	if after < 0 {
+		return nil, ErrInsufficientFunds
+	}
+	if err := repo.UpdateBalance(ctx, card.ID, after); err != nil {
+		return nil, err
+	}
+	card.Balance = after
+
+	txn := &entity.Transaction{
+		CardID:          card.ID,
+		TransactionType: side.TxType,
+		Amount:          op.Amount.UnitsMinor,
+		BalanceBefore:   before,
+		BalanceAfter:    after,
+		Currency:        card.Currency,
+		Description:     op.Description,
+		Status:          entity.TransactionStatusSuccess,
+		OrderID:         op.OrderID,
+		Category:        op.Category,
+		JournalEntryID:  entry.ID,
+		OriginalTxID:    op.OriginalTxID,
+	}
+	if err := repo.CreateTransaction(ctx, txn); err != nil {
+		return nil, err
+	}
+	if err := rollupTransaction(ctx, repo, txn); err != nil {
+		return nil, err
+	}
+	if err := emitCardEvent(ctx, repo, card, entity.CardEventTransactionCreated, txn); err != nil {
+		return nil, err
+	}
+	if err := emitBalanceChanged(ctx, repo, card.ID); err != nil {
+		return nil, err
+	}
+	return txn, nil
+}
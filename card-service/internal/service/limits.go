@@ -0,0 +1,275 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/mrevds/pizza-app/card-service/internal/entity"
+	"github.com/mrevds/pizza-app/card-service/internal/money"
+	"github.com/mrevds/pizza-app/card-service/internal/repository"
+)
+
+// LimitExceededError - операция превышает лимит карты.
+// errors.Is(err, ErrLimitExceeded) для неё возвращает true.
+type LimitExceededError struct {
+	Kind            string // per_transaction, daily, monthly
+	TransactionType string // "" - лимит на все списания
+	Limit           money.Money
+	Remaining       money.Money // сколько еще можно списать в рамках лимита
+}
+
+func (e *LimitExceededError) Error() string {
+	scope := "all debits"
+	if e.TransactionType != "" {
+		scope = e.TransactionType
+	}
+	return fmt.Sprintf("%s limit for %s exceeded: limit %s, remaining %s", e.Kind, scope, e.Limit, e.Remaining)
+}
+
+func (e *LimitExceededError) Is(target error) bool {
+	return target == ErrLimitExceeded
+}
+
+type SetLimitsInput struct {
+	UserID int64
+	CardID int64
+	Limits []LimitInput
+}
+
+// LimitInput - лимиты одного типа транзакций, нулевая сумма - без ограничения
+type LimitInput struct {
+	TransactionType string
+	PerTransaction  money.Money
+	Daily           money.Money
+	Monthly         money.Money
+}
+
+// AdminSetLimitsInput - замена лимитов любой карты админом поддержки. ActorName - имя
+// админа из его токена (CARD_ADMIN_TOKENS), вместе с Reason попадает в историю лимитов.
+type AdminSetLimitsInput struct {
+	CardID    int64
+	ActorName string
+	Reason    string
+	Limits    []LimitInput
+}
+
+// SetCardLimits заменяет лимиты карты, заданные владельцем. Пустой список снимает их.
+// Лимиты админа остаются в силе, менять их нельзя - ErrLimitSetByAdmin.
+func (s *cardService) SetCardLimits(ctx context.Context, input SetLimitsInput) ([]*entity.CardLimit, error) {
+	var limits []*entity.CardLimit
+	err := s.repo.RunInTx(ctx, func(repo repository.CardRepository) error {
+		card, err := s.lockOwnedCard(ctx, repo, input.UserID, input.CardID)
+		if err != nil {
+			return err
+		}
+		limits, err = replaceCardLimits(ctx, repo, card, input.Limits, changeActor{Kind: entity.ActorUser, ID: input.UserID}, "")
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return limits, nil
+}
+
+// AdminSetCardLimits заменяет все лимиты карты без проверки владельца, в том числе
+// заданные владельцем. Возвращает и карту: лимиты в ответе показываются в её валюте.
+func (s *cardService) AdminSetCardLimits(ctx context.Context, input AdminSetLimitsInput) ([]*entity.CardLimit, *entity.Card, error) {
+	if input.ActorName == "" {
+		verr := &ValidationError{}
+		verr.add("actor_name", "is required for admin")
+		return nil, nil, verr
+	}
+	var limits []*entity.CardLimit
+	var card *entity.Card
+	err := s.repo.RunInTx(ctx, func(repo repository.CardRepository) error {
+		var err error
+		card, err = lockCard(ctx, repo, input.CardID)
+		if err != nil {
+			return err
+		}
+		limits, err = replaceCardLimits(ctx, repo, card, input.Limits, changeActor{Kind: entity.ActorAdmin, Name: input.ActorName}, input.Reason)
+		return err
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return limits, card, nil
+}
+
+// replaceCardLimits заменяет лимиты заблокированной (FOR UPDATE) карты и пишет, кто их заменил.
+// Блокировка карты нужна, чтобы проверки лимитов в операциях не увидели наполовину замененный набор.
+// Владелец заменяет только свои лимиты, лимиты админа переносятся в новый набор как есть.
+func replaceCardLimits(ctx context.Context, repo repository.CardRepository, card *entity.Card, inputs []LimitInput, actor changeActor, reason string) ([]*entity.CardLimit, error) {
+	limits, err := validateLimits(inputs, card.Currency)
+	if err != nil {
+		return nil, err
+	}
+	for _, l := range limits {
+		l.SetBy = actor.Kind
+	}
+	if actor.Kind == entity.ActorUser {
+		current, err := repo.GetCardLimits(ctx, card.ID)
+		if err != nil {
+			return nil, err
+		}
+		for _, c := range current {
+			if c.SetBy != entity.ActorAdmin {
+				continue
+			}
+			for _, l := range limits {
+				if l.TransactionType == c.TransactionType {
+					return nil, ErrLimitSetByAdmin
+				}
+			}
+			limits = append(limits, c)
+		}
+		sort.Slice(limits, func(i, j int) bool { return limits[i].TransactionType < limits[j].TransactionType })
+	}
+	if err := repo.ReplaceCardLimits(ctx, card.ID, limits); err != nil {
+		return nil, err
+	}
+	if err := fillLimitUsage(ctx, repo, card.ID, limits, time.Now()); err != nil {
+		return nil, err
+	}
+	if err := repo.CreateLimitChange(ctx, &entity.CardLimitChange{
+		CardID:    card.ID,
+		Actor:     actor.Kind,
+		ActorID:   actor.ID,
+		ActorName: actor.Name,
+		Reason:    reason,
+		Limits:    limits,
+	}); err != nil {
+		return nil, err
+	}
+	return limits, nil
+}
+
+// GetCardLimits возвращает лимиты карты с потраченным за окна лимитов
+func (s *cardService) GetCardLimits(ctx context.Context, userID, cardID int64) ([]*entity.CardLimit, error) {
+	if _, err := s.getOwnedCard(ctx, s.repo, userID, cardID); err != nil {
+		return nil, err
+	}
+	limits, err := s.repo.GetCardLimits(ctx, cardID)
+	if err != nil {
+		return nil, err
+	}
+	if err := fillLimitUsage(ctx, s.repo, cardID, limits, time.Now()); err != nil {
+		return nil, err
+	}
+	return limits, nil
+}
+
+func validateLimits(inputs []LimitInput, currency string) ([]*entity.CardLimit, error) {
+	verr := &ValidationError{}
+	seen := make(map[string]bool, len(inputs))
+	limits := make([]*entity.CardLimit, 0, len(inputs))
+	for i, in := range inputs {
+		field := fmt.Sprintf("limits[%d]", i)
+		if in.TransactionType != "" && !isDebitType(in.TransactionType) {
+			verr.add(field+".transaction_type", "must be empty, withdraw, transfer_out or payment")
+		}
+		if seen[in.TransactionType] {
+			verr.add(field+".transaction_type", "duplicate limit for transaction type")
+		}
+		seen[in.TransactionType] = true
+
+		l := &entity.CardLimit{TransactionType: in.TransactionType}
+		for _, v := range []struct {
+			name   string
+			amount money.Money
+			dst    *int64
+		}{
+			{"per_transaction", in.PerTransaction, &l.PerTransaction},
+			{"daily", in.Daily, &l.Daily},
+			{"monthly", in.Monthly, &l.Monthly},
+		} {
+			switch {
+			case v.amount.UnitsMinor == 0:
+			case v.amount.UnitsMinor < 0:
+				verr.add(field+"."+v.name, "must not be negative")
+			case money.NormalizeCurrency(v.amount.Currency) != currency:
+				verr.add(field+"."+v.name, "currency must match card currency "+currency)
+			default:
+				*v.dst = v.amount.UnitsMinor
+			}
+		}
+		limits = append(limits, l)
+	}
+	if err := verr.errOrNil(); err != nil {
+		return nil, err
+	}
+	return limits, nil
+}
+
+func isDebitType(txType string) bool {
+	for _, t := range entity.DebitTransactionTypes {
+		if t == txType {
+			return true
+		}
+	}
+	return false
+}
+
+func fillLimitUsage(ctx context.Context, repo repository.CardRepository, cardID int64, limits []*entity.CardLimit, now time.Time) error {
+	for _, l := range limits {
+		var err error
+		if l.DailySpent, err = repo.GetSpentSince(ctx, cardID, l.LimitedTypes(), now.Add(-entity.LimitDailyWindow)); err != nil {
+			return err
+		}
+		if l.MonthlySpent, err = repo.GetSpentSince(ctx, cardID, l.LimitedTypes(), now.Add(-entity.LimitMonthlyWindow)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkLimits проверяет что списание amount типа txType укладывается в лимиты карты.
+// В операциях вызывается под блокировкой карты, поэтому потраченное не меняется до конца транзакции.
+func checkLimits(ctx context.Context, repo repository.CardRepository, card *entity.Card, txType string, amount money.Money) error {
+	limits, err := repo.GetCardLimits(ctx, card.ID)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	for _, l := range limits {
+		if l.TransactionType != "" && l.TransactionType != txType {
+			continue
+		}
+		exceeded := func(kind string, limit, remaining int64) error {
+			if remaining < 0 {
+				remaining = 0
+			}
+			return &LimitExceededError{
+				Kind:            kind,
+				TransactionType: l.TransactionType,
+				Limit:           money.Money{UnitsMinor: limit, Currency: card.Currency},
+				Remaining:       money.Money{UnitsMinor: remaining, Currency: card.Currency},
+			}
+		}
+		if l.PerTransaction > 0 && amount.UnitsMinor > l.PerTransaction {
+			return exceeded(entity.LimitPerTransaction, l.PerTransaction, l.PerTransaction)
+		}
+		for _, w := range []struct {
+			kind   string
+			limit  int64
+			window time.Duration
+		}{
+			{entity.LimitDaily, l.Daily, entity.LimitDailyWindow},
+			{entity.LimitMonthly, l.Monthly, entity.LimitMonthlyWindow},
+		} {
+			if w.limit == 0 {
+				continue
+			}
+			spent, err := repo.GetSpentSince(ctx, card.ID, l.LimitedTypes(), now.Add(-w.window))
+			if err != nil {
+				return err
+			}
+			if spent+amount.UnitsMinor > w.limit {
+				return exceeded(w.kind, w.limit, w.limit-spent)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,232 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/mrevds/pizza-app/card-service/internal/entity"
+	"github.com/mrevds/pizza-app/card-service/internal/money"
+)
+
+func TestCardLimits(t *testing.T) {
+	s, repo := newTestService(t)
+	ctx := context.Background()
+	card := addFundedCard(t, s, repo, testUserID, 10_000)
+	other := addFundedCard(t, s, repo, testUserID, 0)
+
+	_, err := s.SetCardLimits(ctx, SetLimitsInput{UserID: testUserID, CardID: card.ID, Limits: []LimitInput{
+		{PerTransaction: rub(2_000), Daily: rub(3_000)},
+		{TransactionType: entity.TransactionTypePayment, Monthly: rub(1_500)},
+	}})
+	if err != nil {
+		t.Fatalf("SetCardLimits: %v", err)
+	}
+
+	var lerr *LimitExceededError
+	if _, err := s.Withdraw(ctx, OperationInput{UserID: testUserID, CardID: card.ID, Amount: rub(2_500)}); !errors.As(err, &lerr) || lerr.Kind != entity.LimitPerTransaction {
+		t.Fatalf("withdraw over per transaction limit: got %v", err)
+	}
+	if _, err := s.Withdraw(ctx, OperationInput{UserID: testUserID, CardID: card.ID, Amount: rub(1_000)}); err != nil {
+		t.Fatalf("Withdraw: %v", err)
+	}
+	if _, err := s.ProcessPayment(ctx, PaymentInput{UserID: testUserID, CardID: card.ID, Amount: rub(1_200), OrderID: "order-1"}); err != nil {
+		t.Fatalf("ProcessPayment: %v", err)
+	}
+
+	// Лимит на оплаты: потрачено 1200 из 1500
+	err = s.ValidateCard(ctx, testUserID, card.ID, rub(400))
+	if !errors.As(err, &lerr) || lerr.Kind != entity.LimitMonthly || lerr.TransactionType != entity.TransactionTypePayment || lerr.Remaining.UnitsMinor != 300 {
+		t.Fatalf("ValidateCard over monthly payment limit: got %v", err)
+	}
+	// Общий дневной лимит: потрачено 2200 из 3000, перевод тоже учитывается
+	_, _, err = s.Transfer(ctx, TransferInput{UserID: testUserID, FromCardID: card.ID, ToCardID: other.ID, Amount: rub(900)})
+	if !errors.As(err, &lerr) || lerr.Kind != entity.LimitDaily || lerr.Remaining.UnitsMinor != 800 {
+		t.Fatalf("transfer over daily limit: got %v", err)
+	}
+	if !errors.Is(err, ErrLimitExceeded) {
+		t.Fatalf("errors.Is(%v, ErrLimitExceeded) = false", err)
+	}
+
+	// Транзакции старше суток в дневной лимит не входят
+	for _, txn := range repo.store.txns {
+		txn.CreatedAt = txn.CreatedAt.Add(-25 * time.Hour)
+	}
+	if _, _, err := s.Transfer(ctx, TransferInput{UserID: testUserID, FromCardID: card.ID, ToCardID: other.ID, Amount: rub(900)}); err != nil {
+		t.Fatalf("Transfer after daily window: %v", err)
+	}
+
+	limits, err := s.GetCardLimits(ctx, testUserID, card.ID)
+	if err != nil {
+		t.Fatalf("GetCardLimits: %v", err)
+	}
+	if len(limits) != 2 || limits[0].DailySpent != 900 || limits[0].MonthlySpent != 3_100 || limits[1].MonthlySpent != 1_200 {
+		t.Fatalf("unexpected limits usage: %+v %+v", limits[0], limits[1])
+	}
+
+	_, err = s.SetCardLimits(ctx, SetLimitsInput{UserID: testUserID, CardID: card.ID, Limits: []LimitInput{
+		{TransactionType: "deposit", Daily: rub(100)},
+		{Daily: money.Money{UnitsMinor: 100, Currency: "USD"}},
+	}})
+	var verr *ValidationError
+	if !errors.As(err, &verr) || len(verr.Violations) != 2 {
+		t.Fatalf("invalid limits: got %v", err)
+	}
+}
+
+func TestCardLimitsCountHolds(t *testing.T) {
+	s, repo := newTestService(t)
+	s.cfg.Card.AuthorizationTTL = time.Hour
+	ctx := context.Background()
+	card := addFundedCard(t, s, repo, testUserID, 10_000)
+
+	if _, err := s.SetCardLimits(ctx, SetLimitsInput{UserID: testUserID, CardID: card.ID, Limits: []LimitInput{{Daily: rub(1_000)}}}); err != nil {
+		t.Fatalf("SetCardLimits: %v", err)
+	}
+	auth, err := s.AuthorizePayment(ctx, AuthorizeInput{UserID: testUserID, CardID: card.ID, Amount: rub(1_000), OrderID: "order-1"})
+	if err != nil {
+		t.Fatalf("AuthorizePayment: %v", err)
+	}
+
+	// Активный холд занимает лимит: ни второй холд, ни списание его не обходят
+	var lerr *LimitExceededError
+	if _, err := s.AuthorizePayment(ctx, AuthorizeInput{UserID: testUserID, CardID: card.ID, Amount: rub(1_000), OrderID: "order-2"}); !errors.As(err, &lerr) || lerr.Kind != entity.LimitDaily || lerr.Remaining.UnitsMinor != 0 {
+		t.Fatalf("second hold over daily limit: got %v", err)
+	}
+	if _, err := s.Withdraw(ctx, OperationInput{UserID: testUserID, CardID: card.ID, Amount: rub(1_000)}); !errors.Is(err, ErrLimitExceeded) {
+		t.Fatalf("withdraw after hold: got %v, want ErrLimitExceeded", err)
+	}
+	limits, err := s.GetCardLimits(ctx, testUserID, card.ID)
+	if err != nil {
+		t.Fatalf("GetCardLimits: %v", err)
+	}
+	if limits[0].DailySpent != 1_000 {
+		t.Errorf("daily spent with hold = %d, want 1000", limits[0].DailySpent)
+	}
+
+	// Capture части холда: в лимите остается только списанное, холд не считается второй раз
+	if _, _, err := s.CapturePayment(ctx, CaptureInput{UserID: testUserID, AuthorizationID: auth.ID, OrderID: "order-1", Amount: rub(600)}); err != nil {
+		t.Fatalf("CapturePayment: %v", err)
+	}
+	if _, err := s.Withdraw(ctx, OperationInput{UserID: testUserID, CardID: card.ID, Amount: rub(400)}); err != nil {
+		t.Fatalf("withdraw within limit after capture: %v", err)
+	}
+	if _, err := s.Withdraw(ctx, OperationInput{UserID: testUserID, CardID: card.ID, Amount: rub(1)}); !errors.Is(err, ErrLimitExceeded) {
+		t.Errorf("withdraw over daily limit: got %v, want ErrLimitExceeded", err)
+	}
+
+	// Снятый холд лимит освобождает
+	for _, txn := range repo.store.txns {
+		txn.CreatedAt = txn.CreatedAt.Add(-25 * time.Hour)
+	}
+	auth, err = s.AuthorizePayment(ctx, AuthorizeInput{UserID: testUserID, CardID: card.ID, Amount: rub(1_000), OrderID: "order-3"})
+	if err != nil {
+		t.Fatalf("AuthorizePayment after daily window: %v", err)
+	}
+	if _, err := s.VoidAuthorization(ctx, testUserID, auth.ID, "order-3"); err != nil {
+		t.Fatalf("VoidAuthorization: %v", err)
+	}
+	if _, err := s.Withdraw(ctx, OperationInput{UserID: testUserID, CardID: card.ID, Amount: rub(1_000)}); err != nil {
+		t.Errorf("withdraw after void: %v", err)
+	}
+}
+
+func TestAdminSetCardLimits(t *testing.T) {
+	s, repo := newTestService(t)
+	ctx := context.Background()
+	card := addFundedCard(t, s, repo, testUserID, 10_000)
+
+	if _, err := s.SetCardLimits(ctx, SetLimitsInput{UserID: testUserID, CardID: card.ID, Limits: []LimitInput{{Daily: rub(5_000)}}}); err != nil {
+		t.Fatalf("SetCardLimits: %v", err)
+	}
+	limits, got, err := s.AdminSetCardLimits(ctx, AdminSetLimitsInput{
+		CardID: card.ID, ActorName: "support-alice", Reason: "fraud check",
+		Limits: []LimitInput{{PerTransaction: rub(1_000)}},
+	})
+	if err != nil {
+		t.Fatalf("AdminSetCardLimits: %v", err)
+	}
+	if len(limits) != 1 || limits[0].PerTransaction != 1_000 || limits[0].Daily != 0 || got.Currency != "RUB" {
+		t.Fatalf("limits = %+v, card currency %s, want per transaction 1000 in RUB", limits, got.Currency)
+	}
+	if _, err := s.Withdraw(ctx, OperationInput{UserID: testUserID, CardID: card.ID, Amount: rub(1_500)}); !errors.Is(err, ErrLimitExceeded) {
+		t.Fatalf("withdraw over admin limit: got %v", err)
+	}
+
+	changes := repo.store.limitLog
+	if len(changes) != 2 {
+		t.Fatalf("limit changes = %d, want 2", len(changes))
+	}
+	if c := changes[0]; c.Actor != entity.ActorUser || c.ActorID != testUserID || c.ActorName != "" {
+		t.Errorf("user change = %+v", c)
+	}
+	if c := changes[1]; c.Actor != entity.ActorAdmin || c.ActorName != "support-alice" || c.Reason != "fraud check" ||
+		len(c.Limits) != 1 || c.Limits[0].PerTransaction != 1_000 {
+		t.Errorf("admin change = %+v", c)
+	}
+
+	var verr *ValidationError
+	if _, _, err := s.AdminSetCardLimits(ctx, AdminSetLimitsInput{CardID: card.ID}); !errors.As(err, &verr) {
+		t.Errorf("no admin name: got %v, want ValidationError", err)
+	}
+	if _, _, err := s.AdminSetCardLimits(ctx, AdminSetLimitsInput{CardID: card.ID + 100, ActorName: "support-alice"}); !errors.Is(err, ErrCardNotFound) {
+		t.Errorf("unknown card: got %v, want ErrCardNotFound", err)
+	}
+	if len(repo.store.limitLog) != 2 {
+		t.Errorf("failed changes were recorded: %+v", repo.store.limitLog)
+	}
+}
+
+func TestUserCanNotChangeAdminLimits(t *testing.T) {
+	s, repo := newTestService(t)
+	ctx := context.Background()
+	card := addFundedCard(t, s, repo, testUserID, 10_000)
+
+	if _, _, err := s.AdminSetCardLimits(ctx, AdminSetLimitsInput{
+		CardID: card.ID, ActorName: "support-alice", Reason: "fraud check",
+		Limits: []LimitInput{{PerTransaction: rub(1_000)}},
+	}); err != nil {
+		t.Fatalf("AdminSetCardLimits: %v", err)
+	}
+
+	// Пустой список владельца снимает только его лимиты, лимит админа остается
+	limits, err := s.SetCardLimits(ctx, SetLimitsInput{UserID: testUserID, CardID: card.ID})
+	if err != nil {
+		t.Fatalf("SetCardLimits: %v", err)
+	}
+	if len(limits) != 1 || limits[0].SetBy != entity.ActorAdmin || limits[0].PerTransaction != 1_000 {
+		t.Fatalf("limits after user clear = %+v, want admin limit", limits)
+	}
+	if _, err := s.Withdraw(ctx, OperationInput{UserID: testUserID, CardID: card.ID, Amount: rub(1_500)}); !errors.Is(err, ErrLimitExceeded) {
+		t.Fatalf("withdraw over admin limit after user clear: got %v", err)
+	}
+
+	// Лимит того же типа владелец не задает, набор не меняется
+	_, err = s.SetCardLimits(ctx, SetLimitsInput{UserID: testUserID, CardID: card.ID, Limits: []LimitInput{{PerTransaction: rub(5_000)}}})
+	if !errors.Is(err, ErrLimitSetByAdmin) {
+		t.Fatalf("user override of admin limit: got %v, want ErrLimitSetByAdmin", err)
+	}
+
+	// Лимит другого типа добавляется к лимиту админа
+	limits, err = s.SetCardLimits(ctx, SetLimitsInput{UserID: testUserID, CardID: card.ID, Limits: []LimitInput{
+		{TransactionType: entity.TransactionTypePayment, Daily: rub(3_000)},
+	}})
+	if err != nil {
+		t.Fatalf("SetCardLimits payment: %v", err)
+	}
+	if len(limits) != 2 || limits[0].SetBy != entity.ActorAdmin || limits[1].SetBy != entity.ActorUser || limits[1].Daily != 3_000 {
+		t.Fatalf("limits = %+v %+v, want admin and user limits", limits[0], limits[1])
+	}
+	if got, _ := s.GetCardLimits(ctx, testUserID, card.ID); len(got) != 2 {
+		t.Errorf("stored limits = %d, want 2", len(got))
+	}
+
+	// Админ заменяет все лимиты, в том числе владельца
+	if limits, _, err = s.AdminSetCardLimits(ctx, AdminSetLimitsInput{CardID: card.ID, ActorName: "support-alice", Reason: "cleared"}); err != nil || len(limits) != 0 {
+		t.Fatalf("admin clear: limits %+v, err %v", limits, err)
+	}
+	if _, err := s.Withdraw(ctx, OperationInput{UserID: testUserID, CardID: card.ID, Amount: rub(1_500)}); err != nil {
+		t.Errorf("withdraw after admin clear: %v", err)
+	}
+}
@@ -0,0 +1,87 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/mrevds/pizza-app/card-service/internal/entity"
+	"github.com/mrevds/pizza-app/card-service/internal/notify"
+	"github.com/mrevds/pizza-app/card-service/internal/utils"
+)
+
+// maintenanceBatch - сколько карт фоновая задача обрабатывает за один запрос
+const maintenanceBatch = 100
+
+// ExpireCards переводит карты с истекшим сроком в expired от имени системы.
+// Возвращает число обработанных карт.
+func (s *cardService) ExpireCards(ctx context.Context) (int, error) {
+	expired := 0
+	for {
+		now := time.Now()
+		ids, err := s.repo.ListCardsToExpire(ctx, now, maintenanceBatch)
+		if err != nil {
+			return expired, err
+		}
+		for _, id := range ids {
+			_, err := s.ChangeCardStatus(ctx, StatusChangeInput{
+				CardID: id,
+				Status: entity.CardStatusExpired,
+				Actor:  entity.ActorSystem,
+				Reason: "card expiry date passed",
+			})
+			// Карту могли удалить или закрыть между выборкой и блокировкой
+			if err != nil && !errors.Is(err, ErrCardNotFound) && !errors.Is(err, ErrStatusTransition) {
+				return expired, fmt.Errorf("expire card %d: %w", id, err)
+			}
+			if err == nil {
+				expired++
+			}
+		}
+		if len(ids) < maintenanceBatch {
+			return expired, nil
+		}
+	}
+}
+
+// NotifyExpiringCards предупреждает владельцев карт, срок которых кончается в пределах
+// scheduler.expiry_notice_window. О каждом сроке карты предупреждает один раз.
+func (s *cardService) NotifyExpiringCards(ctx context.Context) (int, error) {
+	notified := 0
+	for {
+		now := time.Now()
+		cards, err := s.repo.ListCardsExpiringBefore(ctx, now, now.Add(s.cfg.Scheduler.ExpiryNoticeWindow), maintenanceBatch)
+		if err != nil {
+			return notified, err
+		}
+		for _, card := range cards {
+			expiresAt, err := utils.ParseExpiry(card.ExpiryDate)
+			if err != nil {
+				return notified, fmt.Errorf("card %d: %w", card.ID, err)
+			}
+			err = s.notifier.Notify(ctx, card.UserID, notify.Notification{
+				Kind:   notify.KindCardExpiring,
+				CardID: card.ID,
+				Text: fmt.Sprintf("Срок действия карты %s истекает %s",
+					card.CardNumberMasked, expiresAt.AddDate(0, 0, -1).Format("02.01.2006")),
+			})
+			if err != nil {
+				return notified, fmt.Errorf("notify about card %d: %w", card.ID, err)
+			}
+			if err := s.repo.MarkExpiryNotified(ctx, card.ID, card.ExpiryDate); err != nil {
+				return notified, err
+			}
+			notified++
+		}
+		if len(cards) < maintenanceBatch {
+			return notified, nil
+		}
+	}
+}
+
+// FailStalePendingTransactions переводит транзакции, зависшие в pending дольше
+// scheduler.pending_timeout, в failed
+func (s *cardService) FailStalePendingTransactions(ctx context.Context) (int64, error) {
+	return s.repo.FailPendingTransactions(ctx, time.Now().Add(-s.cfg.Scheduler.PendingTimeout))
+}
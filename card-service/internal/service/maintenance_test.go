@@ -0,0 +1,84 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/mrevds/pizza-app/card-service/internal/entity"
+	"github.com/mrevds/pizza-app/card-service/internal/notify"
+)
+
+type recordingNotifier struct {
+	sent []notify.Notification
+}
+
+func (n *recordingNotifier) Notify(ctx context.Context, userID int64, msg notify.Notification) error {
+	n.sent = append(n.sent, msg)
+	return nil
+}
+
+func TestExpireCards(t *testing.T) {
+	s, repo := newTestService(t)
+	ctx := context.Background()
+	valid := addFundedCard(t, s, repo, testUserID, 1_000)
+	expired := addFundedCard(t, s, repo, testUserID, 1_000)
+	repo.store.cards[expired.ID].ExpiryDate = "01/20"
+
+	n, err := s.ExpireCards(ctx)
+	if err != nil {
+		t.Fatalf("ExpireCards: %v", err)
+	}
+	if n != 1 || repo.store.cards[expired.ID].Status != entity.CardStatusExpired || repo.store.cards[valid.ID].Status != entity.CardStatusActive {
+		t.Fatalf("ExpireCards expired %d cards, statuses %s/%s", n, repo.store.cards[expired.ID].Status, repo.store.cards[valid.ID].Status)
+	}
+	if _, err := s.ProcessPayment(ctx, PaymentInput{UserID: testUserID, CardID: expired.ID, Amount: rub(100), OrderID: "o"}); !errors.Is(err, ErrCardExpired) {
+		t.Fatalf("payment from expired card: got %v, want %v", err, ErrCardExpired)
+	}
+	history, _ := s.GetCardStatusHistory(ctx, testUserID, expired.ID)
+	if len(history) != 1 || history[0].Actor != entity.ActorSystem {
+		t.Fatalf("unexpected status history: %+v", history)
+	}
+	if n, err := s.ExpireCards(ctx); err != nil || n != 0 {
+		t.Fatalf("second ExpireCards: %d, %v", n, err)
+	}
+}
+
+func TestNotifyExpiringCards(t *testing.T) {
+	s, repo := newTestService(t)
+	notifier := &recordingNotifier{}
+	s.notifier = notifier
+	s.cfg.Scheduler.ExpiryNoticeWindow = 30 * 24 * time.Hour
+	ctx := context.Background()
+
+	soon := addFundedCard(t, s, repo, testUserID, 0)
+	repo.store.cards[soon.ID].ExpiryDate = time.Now().UTC().Format("01/06")
+	addFundedCard(t, s, repo, testUserID, 0) // 12/99
+
+	for i := 0; i < 2; i++ {
+		if _, err := s.NotifyExpiringCards(ctx); err != nil {
+			t.Fatalf("NotifyExpiringCards: %v", err)
+		}
+	}
+	if len(notifier.sent) != 1 || notifier.sent[0].CardID != soon.ID || notifier.sent[0].Kind != notify.KindCardExpiring {
+		t.Fatalf("unexpected notifications: %+v", notifier.sent)
+	}
+}
+
+func TestFailStalePendingTransactions(t *testing.T) {
+	s, repo := newTestService(t)
+	s.cfg.Scheduler.PendingTimeout = 15 * time.Minute
+	card := addFundedCard(t, s, repo, testUserID, 0)
+	for _, created := range []time.Time{time.Now().Add(-time.Hour), time.Now()} {
+		if err := repo.CreateTransaction(context.Background(), &entity.Transaction{CardID: card.ID, Status: entity.TransactionStatusPending}); err != nil {
+			t.Fatalf("CreateTransaction: %v", err)
+		}
+		repo.store.txns[repo.store.nextID].CreatedAt = created
+	}
+
+	n, err := s.FailStalePendingTransactions(context.Background())
+	if err != nil || n != 1 {
+		t.Fatalf("FailStalePendingTransactions: %d, %v", n, err)
+	}
+}
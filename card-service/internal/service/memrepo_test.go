@@ -0,0 +1,1282 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mrevds/pizza-app/card-service/internal/entity"
+	"github.com/mrevds/pizza-app/card-service/internal/repository"
+	"github.com/mrevds/pizza-app/card-service/internal/utils"
+)
+
+// memStore - данные репозитория в памяти для тестов сервиса. Блокировки строк
+// карт ведут себя как SELECT ... FOR UPDATE: держатся до конца транзакции.
+type memStore struct {
+	mu       sync.Mutex
+	nextID   int64
+	cards    map[int64]*entity.Card
+	rowLocks map[int64]*sync.Mutex
+	accounts map[int64]*entity.LedgerAccount
+	entries  map[int64]*entity.JournalEntry
+	postings map[int64]*entity.Posting
+	txns     map[int64]*entity.Transaction
+	idem     map[string]*entity.IdempotencyRecord
+	auths    map[int64]*entity.Authorization
+	authLock map[int64]*sync.Mutex
+	limits   map[int64][]*entity.CardLimit
+	limitLog []*entity.CardLimitChange
+	statuses []*entity.CardStatusChange
+	notified map[int64]string // card_id -> expiry_notified_for
+	events   []*entity.CardEvent
+	risk     []*entity.RiskDecision
+	outbox   []*entity.OutboxMessage
+	users    map[string]int64 // external_id -> id
+	defaults map[int64]int64  // user_id -> default_card_id
+	lookups  map[int64][]time.Time
+	p2p      map[string]*entity.P2PTransfer // по token
+	gifts    map[int64]*entity.GiftCard     // по card_id
+	charged  map[orderKey]bool
+	totals   map[dailyKey]*entity.DailyTotal
+	balances map[dailyKey]*entity.DailyBalance
+
+	scheduled     map[int64]*entity.ScheduledTransfer
+	scheduledRuns []*entity.ScheduledTransferRun
+}
+
+func newMemStore() *memStore {
+	return &memStore{
+		cards:    make(map[int64]*entity.Card),
+		rowLocks: make(map[int64]*sync.Mutex),
+		accounts: make(map[int64]*entity.LedgerAccount),
+		entries:  make(map[int64]*entity.JournalEntry),
+		postings: make(map[int64]*entity.Posting),
+		txns:     make(map[int64]*entity.Transaction),
+		idem:     make(map[string]*entity.IdempotencyRecord),
+		auths:    make(map[int64]*entity.Authorization),
+		authLock: make(map[int64]*sync.Mutex),
+		limits:   make(map[int64][]*entity.CardLimit),
+		notified: make(map[int64]string),
+		users:    make(map[string]int64),
+		defaults: make(map[int64]int64),
+		lookups:  make(map[int64][]time.Time),
+		p2p:      make(map[string]*entity.P2PTransfer),
+		gifts:    make(map[int64]*entity.GiftCard),
+		charged:  make(map[orderKey]bool),
+		totals:   make(map[dailyKey]*entity.DailyTotal),
+		balances: make(map[dailyKey]*entity.DailyBalance),
+
+		scheduled: make(map[int64]*entity.ScheduledTransfer),
+	}
+}
+
+// dailyKey - ключ дневного агрегата, у балансов тип и категория пустые
+type orderKey struct {
+	userID  int64
+	orderID string
+}
+
+type dailyKey struct {
+	cardID   int64
+	day      time.Time
+	txType   string
+	category string
+}
+
+// id выдает следующий идентификатор, вызывать под mu
+func (s *memStore) id() int64 {
+	s.nextID++
+	return s.nextID
+}
+
+// memTx - открытая транзакция: удерживаемые блокировки и журнал отката
+type memTx struct {
+	held     map[int64]bool
+	heldAuth map[int64]bool
+	undo     []func()
+}
+
+// memRepo реализует только методы, нужные тестам. Остальные методы
+// встроенного интерфейса равны nil и паникуют при вызове.
+type memRepo struct {
+	repository.CardRepository
+	store *memStore
+	tx    *memTx
+}
+
+func newMemRepo() *memRepo {
+	return &memRepo{store: newMemStore()}
+}
+
+func (r *memRepo) RunInTx(ctx context.Context, fn func(repo repository.CardRepository) error) error {
+	if r.tx != nil {
+		return fn(r)
+	}
+	tx := &memTx{held: make(map[int64]bool), heldAuth: make(map[int64]bool)}
+	err := fn(&memRepo{store: r.store, tx: tx})
+	if err != nil {
+		r.store.mu.Lock()
+		for i := len(tx.undo) - 1; i >= 0; i-- {
+			tx.undo[i]()
+		}
+		r.store.mu.Unlock()
+	}
+	r.store.mu.Lock()
+	for id := range tx.held {
+		r.store.rowLocks[id].Unlock()
+	}
+	for id := range tx.heldAuth {
+		r.store.authLock[id].Unlock()
+	}
+	r.store.mu.Unlock()
+	return err
+}
+
+// onRollback запоминает действие отката, вызывать под mu
+func (r *memRepo) onRollback(fn func()) {
+	if r.tx != nil {
+		r.tx.undo = append(r.tx.undo, fn)
+	}
+}
+
+func (r *memRepo) addCard(c entity.Card) *entity.Card {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+	c.ID = r.store.id()
+	r.store.cards[c.ID] = &c
+	r.store.rowLocks[c.ID] = &sync.Mutex{}
+	return &c
+}
+
+func (r *memRepo) CreateCard(ctx context.Context, c *entity.Card) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+	c.ID = r.store.id()
+	c.CreatedAt = time.Now()
+	c.UpdatedAt = c.CreatedAt
+	cp := *c
+	r.store.cards[c.ID] = &cp
+	r.store.rowLocks[c.ID] = &sync.Mutex{}
+	r.onRollback(func() { delete(r.store.cards, c.ID) })
+	return nil
+}
+
+func (r *memRepo) SetCardOwner(ctx context.Context, cardID, userID int64) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+	c, ok := r.store.cards[cardID]
+	if !ok || c.DeletedAt != nil {
+		return repository.ErrNotFound
+	}
+	prev := c.UserID
+	c.UserID = userID
+	r.onRollback(func() { c.UserID = prev })
+	return nil
+}
+
+func (r *memRepo) GetCard(ctx context.Context, cardID int64) (*entity.Card, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+	c, ok := r.store.cards[cardID]
+	if !ok || c.DeletedAt != nil {
+		return nil, repository.ErrNotFound
+	}
+	cp := *c
+	now := time.Now()
+	for _, a := range r.store.auths {
+		if a.CardID == cardID && a.IsActive(now) {
+			cp.Held += a.Amount
+		}
+	}
+	return &cp, nil
+}
+
+func (r *memRepo) GetCardForUpdate(ctx context.Context, cardID int64) (*entity.Card, error) {
+	if r.tx == nil {
+		panic("GetCardForUpdate outside of transaction")
+	}
+	r.store.mu.Lock()
+	lock, ok := r.store.rowLocks[cardID]
+	r.store.mu.Unlock()
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	if !r.tx.held[cardID] {
+		lock.Lock()
+		r.tx.held[cardID] = true
+	}
+	return r.GetCard(ctx, cardID)
+}
+
+func (r *memRepo) UpdateBalance(ctx context.Context, cardID int64, balance int64) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+	c := r.store.cards[cardID]
+	if balance < 0 {
+		return fmt.Errorf("check constraint: balance %d < 0", balance)
+	}
+	prev := c.Balance
+	c.Balance = balance
+	c.UpdatedAt = time.Now()
+	r.onRollback(func() { c.Balance = prev })
+	return nil
+}
+
+func (r *memRepo) GetOrCreateAccount(ctx context.Context, kind string, cardID int64, currency string) (*entity.LedgerAccount, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+	for _, a := range r.store.accounts {
+		if a.Kind == kind && a.CardID == cardID && a.Currency == currency {
+			cp := *a
+			return &cp, nil
+		}
+	}
+	a := &entity.LedgerAccount{ID: r.store.id(), Kind: kind, CardID: cardID, Currency: currency, CreatedAt: time.Now()}
+	r.store.accounts[a.ID] = a
+	cp := *a
+	return &cp, nil
+}
+
+func (r *memRepo) CreateJournalEntry(ctx context.Context, e *entity.JournalEntry) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+	e.ID = r.store.id()
+	e.CreatedAt = time.Now()
+	r.store.entries[e.ID] = e
+	ids := []int64{}
+	deltas := make(map[int64]int64)
+	for _, p := range e.Postings {
+		p.ID = r.store.id()
+		p.EntryID = e.ID
+		cp := *p
+		r.store.postings[p.ID] = &cp
+		ids = append(ids, p.ID)
+		if a := r.store.accounts[p.AccountID]; a != nil && a.Kind == entity.AccountKindCard {
+			delta := p.Amount
+			if p.Direction == entity.PostingDebit {
+				delta = -delta
+			}
+			a.Balance += delta
+			deltas[a.ID] += delta
+		}
+	}
+	r.onRollback(func() {
+		delete(r.store.entries, e.ID)
+		for _, id := range ids {
+			delete(r.store.postings, id)
+		}
+		for id, delta := range deltas {
+			r.store.accounts[id].Balance -= delta
+		}
+	})
+	return nil
+}
+
+func (r *memRepo) GetAccountBalance(ctx context.Context, accountID int64) (int64, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+	a, ok := r.store.accounts[accountID]
+	if !ok {
+		return 0, repository.ErrNotFound
+	}
+	return a.Balance, nil
+}
+
+// accountBalance - кредиты минус дебеты, вызывать под mu
+func (s *memStore) accountBalance(accountID int64) int64 {
+	var balance int64
+	for _, p := range s.postings {
+		if p.AccountID != accountID {
+			continue
+		}
+		if p.Direction == entity.PostingCredit {
+			balance += p.Amount
+		} else {
+			balance -= p.Amount
+		}
+	}
+	return balance
+}
+
+func (r *memRepo) CreateTransaction(ctx context.Context, t *entity.Transaction) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+	t.ID = r.store.id()
+	t.CreatedAt = time.Now()
+	cp := *t
+	r.store.txns[t.ID] = &cp
+	r.onRollback(func() { delete(r.store.txns, t.ID) })
+	return nil
+}
+
+func (r *memRepo) GetIdempotencyRecord(ctx context.Context, userID int64, key string) (*entity.IdempotencyRecord, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+	rec, ok := r.store.idem[fmt.Sprintf("%d/%s", userID, key)]
+	if !ok || !rec.ExpiresAt.After(time.Now()) {
+		return nil, repository.ErrNotFound
+	}
+	cp := *rec
+	return &cp, nil
+}
+
+func (r *memRepo) CreateIdempotencyRecord(ctx context.Context, rec *entity.IdempotencyRecord) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+	k := fmt.Sprintf("%d/%s", rec.UserID, rec.Key)
+	if prev, ok := r.store.idem[k]; ok && prev.ExpiresAt.After(time.Now()) {
+		return repository.ErrAlreadyExists
+	}
+	cp := *rec
+	cp.CreatedAt = time.Now()
+	r.store.idem[k] = &cp
+	r.onRollback(func() { delete(r.store.idem, k) })
+	return nil
+}
+
+func (r *memRepo) CreateAuthorization(ctx context.Context, a *entity.Authorization) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+	for _, prev := range r.store.auths {
+		if prev.CardID == a.CardID && prev.OrderID == a.OrderID && prev.Status == entity.AuthorizationStatusActive {
+			return repository.ErrAlreadyExists
+		}
+	}
+	a.ID = r.store.id()
+	a.CreatedAt = time.Now()
+	a.UpdatedAt = a.CreatedAt
+	cp := *a
+	r.store.auths[a.ID] = &cp
+	r.store.authLock[a.ID] = &sync.Mutex{}
+	r.onRollback(func() { delete(r.store.auths, a.ID) })
+	return nil
+}
+
+func (r *memRepo) GetAuthorizationForUpdate(ctx context.Context, id int64) (*entity.Authorization, error) {
+	r.store.mu.Lock()
+	lock, ok := r.store.authLock[id]
+	r.store.mu.Unlock()
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	if !r.tx.heldAuth[id] {
+		lock.Lock()
+		r.tx.heldAuth[id] = true
+	}
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+	a, ok := r.store.auths[id]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	cp := *a
+	return &cp, nil
+}
+
+func (r *memRepo) UpdateAuthorization(ctx context.Context, a *entity.Authorization) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+	stored := r.store.auths[a.ID]
+	prev := *stored
+	stored.Status, stored.Captured, stored.UpdatedAt = a.Status, a.Captured, time.Now()
+	r.onRollback(func() { *stored = prev })
+	return nil
+}
+
+func (r *memRepo) GetTransaction(ctx context.Context, transactionID int64) (*entity.Transaction, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+	t, ok := r.store.txns[transactionID]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	cp := *t
+	return &cp, nil
+}
+
+func (r *memRepo) GetOrderPayments(ctx context.Context, userID int64, orderID string) ([]*entity.Transaction, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+	var txs []*entity.Transaction
+	for _, t := range r.store.txns {
+		if t.OrderID == orderID && t.TransactionType == entity.TransactionTypePayment && r.store.cards[t.CardID].UserID == userID {
+			cp := *t
+			txs = append(txs, &cp)
+		}
+	}
+	return txs, nil
+}
+
+func (r *memRepo) ListOrderPayments(ctx context.Context, userID int64, orderIDs []string) ([]*entity.Transaction, error) {
+	var txs []*entity.Transaction
+	for _, orderID := range orderIDs {
+		payments, err := r.GetOrderPayments(ctx, userID, orderID)
+		if err != nil {
+			return nil, err
+		}
+		txs = append(txs, payments...)
+	}
+	sort.Slice(txs, func(i, j int) bool { return txs[i].ID < txs[j].ID })
+	return txs, nil
+}
+
+func (r *memRepo) GetOrderTransactions(ctx context.Context, userID int64, orderID string) ([]*entity.Transaction, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+	var txs []*entity.Transaction
+	for _, t := range r.store.txns {
+		switch t.TransactionType {
+		case entity.TransactionTypePayment, entity.TransactionTypeRefund,
+			entity.TransactionTypeAdjustmentCredit, entity.TransactionTypeAdjustmentDebit:
+		default:
+			continue
+		}
+		if t.OrderID == orderID && r.store.cards[t.CardID].UserID == userID {
+			cp := *t
+			txs = append(txs, &cp)
+		}
+	}
+	sort.Slice(txs, func(i, j int) bool { return txs[i].ID < txs[j].ID })
+	return txs, nil
+}
+
+func (r *memRepo) CreateOrderCharge(ctx context.Context, orderID string, userID int64) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+	key := orderKey{userID: userID, orderID: orderID}
+	if r.store.charged[key] {
+		return repository.ErrAlreadyExists
+	}
+	r.store.charged[key] = true
+	r.onRollback(func() { delete(r.store.charged, key) })
+	return nil
+}
+
+func (r *memRepo) GetRefundedAmount(ctx context.Context, paymentID int64) (int64, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+	var refunded int64
+	for _, t := range r.store.txns {
+		if t.OriginalTxID == paymentID && t.TransactionType == entity.TransactionTypeRefund && t.Status == entity.TransactionStatusSuccess {
+			refunded += t.Amount
+		}
+	}
+	return refunded, nil
+}
+
+func (r *memRepo) AddDailyTotal(ctx context.Context, t *entity.DailyTotal) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+	k := dailyKey{cardID: t.CardID, day: t.Day, txType: t.TransactionType, category: t.Category}
+	stored, ok := r.store.totals[k]
+	if !ok {
+		cp := *t
+		r.store.totals[k] = &cp
+		r.onRollback(func() { delete(r.store.totals, k) })
+		return nil
+	}
+	prev := *stored
+	stored.Amount += t.Amount
+	stored.Count += t.Count
+	r.onRollback(func() { *stored = prev })
+	return nil
+}
+
+func (r *memRepo) SetDailyBalance(ctx context.Context, b *entity.DailyBalance) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+	k := dailyKey{cardID: b.CardID, day: b.Day}
+	stored, ok := r.store.balances[k]
+	if !ok {
+		cp := *b
+		r.store.balances[k] = &cp
+		r.onRollback(func() { delete(r.store.balances, k) })
+		return nil
+	}
+	if stored.LastTransactionID >= b.LastTransactionID {
+		return nil
+	}
+	prev := *stored
+	stored.Balance, stored.LastTransactionID = b.Balance, b.LastTransactionID
+	r.onRollback(func() { *stored = prev })
+	return nil
+}
+
+func (r *memRepo) ListDailyTotals(ctx context.Context, f repository.DailyTotalFilter) ([]*entity.DailyTotal, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+	sums := make(map[dailyKey]*entity.DailyTotal)
+	var totals []*entity.DailyTotal
+	for k, t := range r.store.totals {
+		card := r.store.cards[k.cardID]
+		if f.CardID != 0 && k.cardID != f.CardID || f.CardID == 0 && (card.UserID != f.UserID || card.DeletedAt != nil) {
+			continue
+		}
+		if t.Day.Before(f.From) || !t.Day.Before(f.To) {
+			continue
+		}
+		k.cardID = 0
+		sum := sums[k]
+		if sum == nil {
+			sum = &entity.DailyTotal{Day: t.Day, TransactionType: t.TransactionType, Category: t.Category, Currency: t.Currency}
+			sums[k] = sum
+			totals = append(totals, sum)
+		}
+		sum.Amount += t.Amount
+		sum.Count += t.Count
+	}
+	sort.Slice(totals, func(i, j int) bool {
+		a, b := totals[i], totals[j]
+		if !a.Day.Equal(b.Day) {
+			return a.Day.Before(b.Day)
+		}
+		if a.TransactionType != b.TransactionType {
+			return a.TransactionType < b.TransactionType
+		}
+		return a.Category < b.Category
+	})
+	return totals, nil
+}
+
+func (r *memRepo) ListDailyBalances(ctx context.Context, cardID int64, from, to time.Time) ([]*entity.DailyBalance, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+	var balances []*entity.DailyBalance
+	for _, b := range r.store.balances {
+		if b.CardID == cardID && !b.Day.Before(from) && b.Day.Before(to) {
+			cp := *b
+			balances = append(balances, &cp)
+		}
+	}
+	sort.Slice(balances, func(i, j int) bool { return balances[i].Day.Before(balances[j].Day) })
+	return balances, nil
+}
+
+func (r *memRepo) GetDailyBalanceBefore(ctx context.Context, cardID int64, day time.Time) (int64, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+	var last *entity.DailyBalance
+	for _, b := range r.store.balances {
+		if b.CardID == cardID && b.Day.Before(day) && (last == nil || b.Day.After(last.Day)) {
+			last = b
+		}
+	}
+	if last == nil {
+		return 0, nil
+	}
+	return last.Balance, nil
+}
+
+func (r *memRepo) ListTransactions(ctx context.Context, f repository.TransactionFilter) ([]*entity.Transaction, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+	var txs []*entity.Transaction
+	for _, t := range r.store.txns {
+		if !r.store.matchTransaction(t, f) {
+			continue
+		}
+		if f.After != nil && (t.ID == f.After.ID || transactionLess(t, f.After) == f.Ascending) {
+			continue
+		}
+		cp := *t
+		txs = append(txs, &cp)
+	}
+	sort.Slice(txs, func(i, j int) bool {
+		less := transactionLess(txs[i], &repository.TransactionCursor{CreatedAt: txs[j].CreatedAt, ID: txs[j].ID})
+		return less == f.Ascending
+	})
+	if f.Offset > 0 {
+		if f.Offset >= len(txs) {
+			return nil, nil
+		}
+		txs = txs[f.Offset:]
+	}
+	if f.Limit > 0 && len(txs) > f.Limit {
+		txs = txs[:f.Limit]
+	}
+	return txs, nil
+}
+
+func (r *memRepo) CountTransactions(ctx context.Context, f repository.TransactionFilter) (int, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+	n := 0
+	for _, t := range r.store.txns {
+		if r.store.matchTransaction(t, f) {
+			n++
+		}
+	}
+	return n, nil
+}
+
+// transactionLess сравнивает (created_at, id) транзакции с курсором
+func transactionLess(t *entity.Transaction, c *repository.TransactionCursor) bool {
+	if !t.CreatedAt.Equal(c.CreatedAt) {
+		return t.CreatedAt.Before(c.CreatedAt)
+	}
+	return t.ID < c.ID
+}
+
+func (r *memRepo) GetBalanceBefore(ctx context.Context, cardID int64, at time.Time) (int64, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+	var last *entity.Transaction
+	for _, t := range r.store.txns {
+		if t.CardID != cardID || t.Status != entity.TransactionStatusSuccess || !t.CreatedAt.Before(at) {
+			continue
+		}
+		if last == nil || transactionLess(last, &repository.TransactionCursor{CreatedAt: t.CreatedAt, ID: t.ID}) {
+			last = t
+		}
+	}
+	if last == nil {
+		return 0, nil
+	}
+	return last.BalanceAfter, nil
+}
+
+// matchTransaction повторяет условия transactionWhere, вызывать под mu
+func (s *memStore) matchTransaction(t *entity.Transaction, f repository.TransactionFilter) bool {
+	card := s.cards[t.CardID]
+	switch {
+	case f.CardID != 0 && t.CardID != f.CardID,
+		f.CardID == 0 && (card.UserID != f.UserID || card.DeletedAt != nil),
+		!f.From.IsZero() && t.CreatedAt.Before(f.From),
+		!f.To.IsZero() && !t.CreatedAt.Before(f.To),
+		len(f.Types) > 0 && !contains(f.Types, t.TransactionType),
+		len(f.Statuses) > 0 && !contains(f.Statuses, t.Status),
+		f.Currency != "" && t.Currency != f.Currency,
+		f.MinAmount > 0 && t.Amount < f.MinAmount,
+		f.MaxAmount > 0 && t.Amount > f.MaxAmount,
+		f.Text != "" && !strings.Contains(strings.ToLower(t.Description), strings.ToLower(f.Text)):
+		return false
+	}
+	return true
+}
+
+func contains(list []string, v string) bool {
+	for _, item := range list {
+		if item == v {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *memRepo) GetCardLimits(ctx context.Context, cardID int64) ([]*entity.CardLimit, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+	var limits []*entity.CardLimit
+	for _, l := range r.store.limits[cardID] {
+		cp := *l
+		limits = append(limits, &cp)
+	}
+	return limits, nil
+}
+
+func (r *memRepo) ReplaceCardLimits(ctx context.Context, cardID int64, limits []*entity.CardLimit) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+	prev := r.store.limits[cardID]
+	stored := make([]*entity.CardLimit, 0, len(limits))
+	for _, l := range limits {
+		l.CardID = cardID
+		if l.UpdatedAt.IsZero() {
+			l.UpdatedAt = time.Now()
+		}
+		cp := *l
+		stored = append(stored, &cp)
+	}
+	r.store.limits[cardID] = stored
+	r.onRollback(func() { r.store.limits[cardID] = prev })
+	return nil
+}
+
+func (r *memRepo) CreateLimitChange(ctx context.Context, c *entity.CardLimitChange) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+	c.ID = r.store.id()
+	c.CreatedAt = time.Now()
+	cp := *c
+	n := len(r.store.limitLog)
+	r.store.limitLog = append(r.store.limitLog, &cp)
+	r.onRollback(func() { r.store.limitLog = r.store.limitLog[:n] })
+	return nil
+}
+
+func (r *memRepo) GetSpentSince(ctx context.Context, cardID int64, txTypes []string, since time.Time) (int64, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+	var spent int64
+	for _, t := range r.store.txns {
+		if t.CardID != cardID || t.Status != entity.TransactionStatusSuccess || !t.CreatedAt.After(since) {
+			continue
+		}
+		for _, typ := range txTypes {
+			if t.TransactionType == typ {
+				spent += t.Amount
+			}
+		}
+	}
+	if slices.Contains(txTypes, entity.TransactionTypePayment) {
+		now := time.Now()
+		for _, a := range r.store.auths {
+			if a.CardID == cardID && a.IsActive(now) {
+				spent += a.Amount
+			}
+		}
+	}
+	return spent, nil
+}
+
+func (r *memRepo) UpdateCardStatus(ctx context.Context, cardID int64, status, blockReason string) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+	c, ok := r.store.cards[cardID]
+	if !ok {
+		return repository.ErrNotFound
+	}
+	prev := *c
+	c.Status, c.BlockReason = status, blockReason
+	c.IsActive = status != entity.CardStatusClosed
+	c.IsBlocked = entity.IsBlockedStatus(status)
+	r.onRollback(func() { *c = prev })
+	return nil
+}
+
+func (r *memRepo) CreateStatusChange(ctx context.Context, change *entity.CardStatusChange) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+	change.ID = r.store.id()
+	change.CreatedAt = time.Now()
+	cp := *change
+	n := len(r.store.statuses)
+	r.store.statuses = append(r.store.statuses, &cp)
+	r.onRollback(func() { r.store.statuses = r.store.statuses[:n] })
+	return nil
+}
+
+func (r *memRepo) GetCardStatusHistory(ctx context.Context, cardID int64) ([]*entity.CardStatusChange, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+	var changes []*entity.CardStatusChange
+	for _, c := range r.store.statuses {
+		if c.CardID == cardID {
+			cp := *c
+			changes = append(changes, &cp)
+		}
+	}
+	return changes, nil
+}
+
+// openCards - карты, которые фоновые задачи еще обрабатывают, вызывать под mu
+func (s *memStore) openCards() []*entity.Card {
+	var cards []*entity.Card
+	for id := int64(1); id <= s.nextID; id++ {
+		c, ok := s.cards[id]
+		if ok && c.DeletedAt == nil && (c.Status == entity.CardStatusActive || entity.IsBlockedStatus(c.Status)) {
+			cards = append(cards, c)
+		}
+	}
+	return cards
+}
+
+func (r *memRepo) ListCardsToExpire(ctx context.Context, now time.Time, limit int) ([]int64, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+	var ids []int64
+	for _, c := range r.store.openCards() {
+		if expiresAt, err := utils.ParseExpiry(c.ExpiryDate); err == nil && !expiresAt.After(now) && len(ids) < limit {
+			ids = append(ids, c.ID)
+		}
+	}
+	return ids, nil
+}
+
+func (r *memRepo) ListCardsExpiringBefore(ctx context.Context, now, before time.Time, limit int) ([]*entity.Card, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+	var cards []*entity.Card
+	for _, c := range r.store.openCards() {
+		expiresAt, err := utils.ParseExpiry(c.ExpiryDate)
+		if err != nil || !expiresAt.After(now) || expiresAt.After(before) || r.store.notified[c.ID] == c.ExpiryDate || c.UserID == 0 {
+			continue
+		}
+		if len(cards) < limit {
+			cp := *c
+			cards = append(cards, &cp)
+		}
+	}
+	return cards, nil
+}
+
+func (r *memRepo) MarkExpiryNotified(ctx context.Context, cardID int64, expiryDate string) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+	r.store.notified[cardID] = expiryDate
+	return nil
+}
+
+func (r *memRepo) FailPendingTransactions(ctx context.Context, before time.Time) (int64, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+	var n int64
+	for _, t := range r.store.txns {
+		if t.Status == entity.TransactionStatusPending && t.CreatedAt.Before(before) {
+			t.Status = entity.TransactionStatusFailed
+			n++
+		}
+	}
+	return n, nil
+}
+
+func (r *memRepo) CreateCardEvent(ctx context.Context, e *entity.CardEvent) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+	e.ID = r.store.id()
+	e.CreatedAt = time.Now()
+	cp := *e
+	r.store.events = append(r.store.events, &cp)
+	r.onRollback(func() {
+		for i, stored := range r.store.events {
+			if stored.ID == e.ID {
+				r.store.events = append(r.store.events[:i], r.store.events[i+1:]...)
+				return
+			}
+		}
+	})
+	return nil
+}
+
+func (r *memRepo) ListCardEvents(ctx context.Context, afterID, userID int64, limit int) ([]*entity.CardEvent, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+	var events []*entity.CardEvent
+	for _, e := range r.store.events {
+		if e.ID > afterID && (userID == 0 || e.UserID == userID) && len(events) < limit {
+			cp := *e
+			events = append(events, &cp)
+		}
+	}
+	return events, nil
+}
+
+func (r *memRepo) LastCardEventID(ctx context.Context) (int64, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+	if len(r.store.events) == 0 {
+		return 0, nil
+	}
+	return r.store.events[len(r.store.events)-1].ID, nil
+}
+
+func (r *memRepo) CreateRiskDecision(ctx context.Context, d *entity.RiskDecision) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+	d.ID = r.store.id()
+	d.CreatedAt = time.Now()
+	cp := *d
+	n := len(r.store.risk)
+	r.store.risk = append(r.store.risk, &cp)
+	r.onRollback(func() { r.store.risk = r.store.risk[:n] })
+	return nil
+}
+
+func (r *memRepo) CountRiskDecisionsSince(ctx context.Context, cardID int64, since time.Time) (int, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+	n := 0
+	for _, d := range r.store.risk {
+		if d.CardID == cardID && d.CreatedAt.After(since) {
+			n++
+		}
+	}
+	return n, nil
+}
+
+func (r *memRepo) GetDebitStats(ctx context.Context, cardID int64, since time.Time) (entity.DebitStats, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+	var st entity.DebitStats
+	var sum int64
+	for _, t := range r.store.txns {
+		if t.CardID == cardID && t.Status == entity.TransactionStatusSuccess && t.CreatedAt.After(since) && isDebitType(t.TransactionType) {
+			st.Count++
+			sum += t.Amount
+		}
+	}
+	if st.Count > 0 {
+		st.Average = sum / int64(st.Count)
+	}
+	return st, nil
+}
+
+func (r *memRepo) CountUserCardsSince(ctx context.Context, userID int64, since time.Time) (int, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+	n := 0
+	for _, c := range r.store.cards {
+		if c.UserID == userID && c.CreatedAt.After(since) {
+			n++
+		}
+	}
+	return n, nil
+}
+
+func (r *memRepo) CreateOutboxMessage(ctx context.Context, m *entity.OutboxMessage) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+	m.ID = r.store.id()
+	m.CreatedAt = time.Now()
+	cp := *m
+	n := len(r.store.outbox)
+	r.store.outbox = append(r.store.outbox, &cp)
+	r.onRollback(func() { r.store.outbox = r.store.outbox[:n] })
+	return nil
+}
+
+func (r *memRepo) ResolveUser(ctx context.Context, externalID string) (int64, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+	if id, ok := r.store.users[externalID]; ok {
+		return id, nil
+	}
+	id := r.store.id()
+	r.store.users[externalID] = id
+	return id, nil
+}
+
+func (r *memRepo) hasUser(userID int64) bool {
+	for _, id := range r.store.users {
+		if id == userID {
+			return true
+		}
+	}
+	return false
+}
+
+// LockUser без блокировки: в тестах поиски получателя идут из одной горутины
+func (r *memRepo) LockUser(ctx context.Context, userID int64) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+	if !r.hasUser(userID) {
+		return repository.ErrNotFound
+	}
+	return nil
+}
+
+func (r *memRepo) GetDefaultCardID(ctx context.Context, userID int64) (int64, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+	return r.store.defaults[userID], nil
+}
+
+func (r *memRepo) SetDefaultCard(ctx context.Context, userID, cardID int64) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+	if !r.hasUser(userID) {
+		return repository.ErrNotFound
+	}
+	r.store.defaults[userID] = cardID
+	return nil
+}
+
+func (r *memRepo) GetUserCards(ctx context.Context, userID int64) ([]*entity.Card, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+	var out []*entity.Card
+	for _, c := range r.store.cards {
+		if c.UserID == userID && c.DeletedAt == nil {
+			cp := *c
+			out = append(out, &cp)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ID < out[j].ID })
+	return out, nil
+}
+
+func (r *memRepo) CreatePhoneLookup(ctx context.Context, userID int64) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+	n := len(r.store.lookups[userID])
+	r.store.lookups[userID] = append(r.store.lookups[userID], time.Now())
+	r.onRollback(func() { r.store.lookups[userID] = r.store.lookups[userID][:n] })
+	return nil
+}
+
+func (r *memRepo) CountPhoneLookupsSince(ctx context.Context, userID int64, since time.Time) (int, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+	n := 0
+	for _, at := range r.store.lookups[userID] {
+		if at.After(since) {
+			n++
+		}
+	}
+	return n, nil
+}
+
+func (r *memRepo) CreateP2PTransfer(ctx context.Context, t *entity.P2PTransfer) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+	t.ID = r.store.id()
+	t.CreatedAt = time.Now()
+	cp := *t
+	r.store.p2p[t.Token] = &cp
+	r.onRollback(func() { delete(r.store.p2p, t.Token) })
+	return nil
+}
+
+// GetP2PTransferByTokenForUpdate без блокировки: в тестах переводы идут из одной горутины
+func (r *memRepo) GetP2PTransferByTokenForUpdate(ctx context.Context, token string) (*entity.P2PTransfer, error) {
+	if r.tx == nil {
+		panic("GetP2PTransferByTokenForUpdate outside of transaction")
+	}
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+	t, ok := r.store.p2p[token]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	cp := *t
+	return &cp, nil
+}
+
+func (r *memRepo) UpdateP2PTransfer(ctx context.Context, t *entity.P2PTransfer) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+	stored := r.store.p2p[t.Token]
+	prev := *stored
+	stored.Status, stored.TransactionID, stored.CompletedAt = t.Status, t.TransactionID, t.CompletedAt
+	r.onRollback(func() { *stored = prev })
+	return nil
+}
+
+func (r *memRepo) ListCardIDs(ctx context.Context, afterID int64, limit int) ([]int64, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+	var ids []int64
+	for id, c := range r.store.cards {
+		if id > afterID && c.DeletedAt == nil {
+			ids = append(ids, id)
+		}
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	if len(ids) > limit {
+		ids = ids[:limit]
+	}
+	return ids, nil
+}
+
+func (r *memRepo) ListCardTransactions(ctx context.Context, cardID, afterID int64, limit int) ([]*entity.Transaction, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+	var out []*entity.Transaction
+	for _, t := range r.store.txns {
+		if t.CardID == cardID && t.ID > afterID {
+			cp := *t
+			out = append(out, &cp)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ID < out[j].ID })
+	if len(out) > limit {
+		out = out[:limit]
+	}
+	return out, nil
+}
+
+func (r *memRepo) GetCardLedgerBalance(ctx context.Context, cardID int64) (int64, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+	for _, a := range r.store.accounts {
+		if a.Kind == entity.AccountKindCard && a.CardID == cardID {
+			return r.store.accountBalance(a.ID), nil
+		}
+	}
+	return 0, nil
+}
+
+func (r *memRepo) GetCardAccountBalance(ctx context.Context, cardID int64) (int64, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+	for _, a := range r.store.accounts {
+		if a.Kind == entity.AccountKindCard && a.CardID == cardID {
+			return a.Balance, nil
+		}
+	}
+	return 0, nil
+}
+
+func (r *memRepo) SetCardAccountBalance(ctx context.Context, cardID, balance int64) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+	for _, a := range r.store.accounts {
+		if a.Kind == entity.AccountKindCard && a.CardID == cardID {
+			prev := a.Balance
+			a.Balance = balance
+			r.onRollback(func() { a.Balance = prev })
+		}
+	}
+	return nil
+}
+
+func (r *memRepo) CreateScheduledTransfer(ctx context.Context, t *entity.ScheduledTransfer) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+	t.ID = r.store.id()
+	t.CreatedAt = time.Now()
+	t.UpdatedAt = t.CreatedAt
+	cp := *t
+	r.store.scheduled[t.ID] = &cp
+	r.onRollback(func() { delete(r.store.scheduled, t.ID) })
+	return nil
+}
+
+func (r *memRepo) GetScheduledTransfer(ctx context.Context, id int64) (*entity.ScheduledTransfer, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+	t, ok := r.store.scheduled[id]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	cp := *t
+	return &cp, nil
+}
+
+// GetScheduledTransferForUpdate без блокировки: в тестах запуски идут из одной горутины
+func (r *memRepo) GetScheduledTransferForUpdate(ctx context.Context, id int64) (*entity.ScheduledTransfer, error) {
+	if r.tx == nil {
+		panic("GetScheduledTransferForUpdate outside of transaction")
+	}
+	return r.GetScheduledTransfer(ctx, id)
+}
+
+func (r *memRepo) ListUserScheduledTransfers(ctx context.Context, userID int64, activeOnly bool) ([]*entity.ScheduledTransfer, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+	var out []*entity.ScheduledTransfer
+	for _, t := range r.store.scheduled {
+		if t.UserID == userID && (!activeOnly || t.Status == entity.ScheduledStatusActive) {
+			cp := *t
+			out = append(out, &cp)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ID > out[j].ID })
+	return out, nil
+}
+
+func (r *memRepo) ListDueScheduledTransfers(ctx context.Context, now time.Time, afterID int64, limit int) ([]*entity.ScheduledTransfer, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+	var out []*entity.ScheduledTransfer
+	for _, t := range r.store.scheduled {
+		if t.Status == entity.ScheduledStatusActive && !t.NextRunAt.After(now) &&
+			(t.RetryAt == nil || !t.RetryAt.After(now)) && t.ID > afterID {
+			cp := *t
+			out = append(out, &cp)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ID < out[j].ID })
+	if len(out) > limit {
+		out = out[:limit]
+	}
+	return out, nil
+}
+
+func (r *memRepo) UpdateScheduledTransfer(ctx context.Context, t *entity.ScheduledTransfer) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+	stored := r.store.scheduled[t.ID]
+	prev := *stored
+	stored.Status, stored.NextRunAt, stored.LastRunAt, stored.Attempt = t.Status, t.NextRunAt, t.LastRunAt, t.Attempt
+	stored.LastError, stored.ErrorCount, stored.RetryAt = t.LastError, t.ErrorCount, t.RetryAt
+	stored.UpdatedAt = time.Now()
+	t.UpdatedAt = stored.UpdatedAt
+	r.onRollback(func() { *stored = prev })
+	return nil
+}
+
+func (r *memRepo) CreateScheduledTransferRun(ctx context.Context, run *entity.ScheduledTransferRun) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+	run.ID = r.store.id()
+	run.CreatedAt = time.Now()
+	cp := *run
+	n := len(r.store.scheduledRuns)
+	r.store.scheduledRuns = append(r.store.scheduledRuns, &cp)
+	r.onRollback(func() { r.store.scheduledRuns = r.store.scheduledRuns[:n] })
+	return nil
+}
+
+func (r *memRepo) ListScheduledTransferRuns(ctx context.Context, scheduledTransferID int64, limit int) ([]*entity.ScheduledTransferRun, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+	var out []*entity.ScheduledTransferRun
+	for i := len(r.store.scheduledRuns) - 1; i >= 0 && len(out) < limit; i-- {
+		if run := r.store.scheduledRuns[i]; run.ScheduledTransferID == scheduledTransferID {
+			cp := *run
+			out = append(out, &cp)
+		}
+	}
+	return out, nil
+}
+
+func (r *memRepo) CreateGiftCard(ctx context.Context, g *entity.GiftCard) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+	for _, stored := range r.store.gifts {
+		if stored.CodeFingerprint == g.CodeFingerprint {
+			return repository.ErrAlreadyExists
+		}
+	}
+	g.CreatedAt = time.Now()
+	cp := *g
+	r.store.gifts[g.CardID] = &cp
+	r.onRollback(func() { delete(r.store.gifts, g.CardID) })
+	return nil
+}
+
+// GetGiftCardByCodeForUpdate и GetGiftCardForUpdate без блокировки: в тестах
+// подарочные карты используются из одной горутины
+func (r *memRepo) GetGiftCardByCodeForUpdate(ctx context.Context, codeFingerprint string) (*entity.GiftCard, error) {
+	if r.tx == nil {
+		panic("GetGiftCardByCodeForUpdate outside of transaction")
+	}
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+	for _, g := range r.store.gifts {
+		if g.CodeFingerprint == codeFingerprint {
+			cp := *g
+			return &cp, nil
+		}
+	}
+	return nil, repository.ErrNotFound
+}
+
+func (r *memRepo) GetGiftCardForUpdate(ctx context.Context, cardID int64) (*entity.GiftCard, error) {
+	if r.tx == nil {
+		panic("GetGiftCardForUpdate outside of transaction")
+	}
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+	g, ok := r.store.gifts[cardID]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	cp := *g
+	return &cp, nil
+}
+
+func (r *memRepo) UpdateGiftCard(ctx context.Context, g *entity.GiftCard) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+	stored := r.store.gifts[g.CardID]
+	prev := *stored
+	stored.FailedPINAttempts, stored.RedeemedBy, stored.RedeemedAt = g.FailedPINAttempts, g.RedeemedBy, g.RedeemedAt
+	r.onRollback(func() { *stored = prev })
+	return nil
+}
@@ -0,0 +1,370 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"github.com/mrevds/pizza-app/card-service/internal/entity"
+	"github.com/mrevds/pizza-app/card-service/internal/money"
+	"github.com/mrevds/pizza-app/card-service/internal/repository"
+)
+
+// Суммы во входных структурах - в минимальных единицах валюты,
+// валюта должна совпадать с валютой карты. IdempotencyKey не входит
+// в отпечаток запроса (json:"-"), см. runIdempotent.
+
+type OperationInput struct {
+	UserID         int64
+	CardID         int64
+	Amount         money.Money
+	Description    string
+	IdempotencyKey string `json:"-"`
+}
+
+type TransferInput struct {
+	UserID         int64
+	FromCardID     int64
+	ToCardID       int64
+	Amount         money.Money
+	Description    string
+	IdempotencyKey string `json:"-"`
+}
+
+// PaymentInput - оплата картой CardID или подарочной картой по GiftCode и GiftPIN.
+// Заказ OrderID, по которому уже списывали, оплачивается снова только с AdditionalCharge.
+// Category - категория заказа или мерчант для аналитики трат.
+type PaymentInput struct {
+	UserID           int64
+	CardID           int64
+	GiftCode         string
+	GiftPIN          string `json:"-"`
+	Amount           money.Money
+	OrderID          string
+	Category         string
+	AdditionalCharge bool
+	Description      string
+	IdempotencyKey   string `json:"-"`
+}
+
+func (s *cardService) GetBalance(ctx context.Context, userID, cardID int64) (*entity.Card, error) {
+	return s.getOwnedCard(ctx, s.repo, userID, cardID)
+}
+
+func (s *cardService) Deposit(ctx context.Context, input OperationInput) (*entity.Transaction, error) {
+	amount, err := normalizeAmount(input.Amount)
+	if err != nil {
+		return nil, err
+	}
+
+	var txn *entity.Transaction
+	input.Amount = amount
+	err = s.runIdempotent(ctx, input.UserID, input.IdempotencyKey, idempotentDeposit, input, &txn, func(repo repository.CardRepository) error {
+		card, err := s.lockOwnedCard(ctx, repo, input.UserID, input.CardID)
+		if err != nil {
+			return err
+		}
+		if err := checkCardOperation(card, amount); err != nil {
+			return err
+		}
+		txns, err := applyOperation(ctx, repo, ledgerOperation{
+			Operation:   entity.JournalOperationDeposit,
+			Amount:      amount,
+			From:        ledgerSide{Kind: entity.AccountKindCashIn},
+			To:          ledgerSide{Card: card, TxType: entity.TransactionTypeDeposit},
+			Description: input.Description,
+		})
+		if err != nil {
+			return err
+		}
+		txn = txns[0]
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return txn, nil
+}
+
+func (s *cardService) Withdraw(ctx context.Context, input OperationInput) (*entity.Transaction, error) {
+	amount, err := normalizeAmount(input.Amount)
+	if err != nil {
+		return nil, err
+	}
+
+	var txn *entity.Transaction
+	input.Amount = amount
+	err = s.runIdempotent(ctx, input.UserID, input.IdempotencyKey, idempotentWithdraw, input, &txn, func(repo repository.CardRepository) error {
+		card, err := s.lockOwnedCard(ctx, repo, input.UserID, input.CardID)
+		if err != nil {
+			return err
+		}
+		if err := checkCardOperation(card, amount); err != nil {
+			return err
+		}
+		if err := checkLimits(ctx, repo, card, entity.TransactionTypeWithdraw, amount); err != nil {
+			return err
+		}
+		decision, err := s.assessRisk(ctx, repo, card, entity.TransactionTypeWithdraw, amount, "")
+		if err != nil {
+			return err
+		}
+		txns, err := applyOperation(ctx, repo, ledgerOperation{
+			Operation:   entity.JournalOperationWithdraw,
+			Amount:      amount,
+			From:        ledgerSide{Card: card, TxType: entity.TransactionTypeWithdraw},
+			To:          ledgerSide{Kind: entity.AccountKindCashIn},
+			Description: input.Description,
+		})
+		if err != nil {
+			return err
+		}
+		txn = txns[0]
+		return recordRisk(ctx, repo, decision, txn.ID)
+	})
+	if err != nil {
+		return nil, s.commitRiskDenial(ctx, err)
+	}
+	return txn, nil
+}
+
+// Transfer выполняется в транзакции READ COMMITTED, Serializable здесь не нужен.
+// Все, что меняет баланс карты (операции, холды, возвраты, сверка), сначала берет
+// FOR UPDATE на строку карты, а проводки, остаток счета в журнале, потраченное по
+// лимитам и доступный баланс читаются и пишутся уже под этой блокировкой. Поэтому
+// операции над одной картой выполняются строго последовательно и видят результат
+// друг друга, как при Serializable. Несколько карт блокируются в порядке возрастания
+// id (lockTransferCards, lockSplitCards), так что встречные переводы не дедлокаются
+// и повторять транзакцию на 40001 не требуется. На Postgres это проверяет
+// TestTransferOppositeDirections в repository/pg.
+func (s *cardService) Transfer(ctx context.Context, input TransferInput) (from, to *entity.Transaction, err error) {
+	amount, err := normalizeAmount(input.Amount)
+	if err != nil {
+		return nil, nil, err
+	}
+	if input.FromCardID == input.ToCardID {
+		return nil, nil, ErrSameCard
+	}
+
+	input.Amount = amount
+	var res transferResult
+	err = s.runIdempotent(ctx, input.UserID, input.IdempotencyKey, idempotentTransfer, input, &res, func(repo repository.CardRepository) error {
+		fromCard, toCard, err := lockTransferCards(ctx, repo, input.FromCardID, input.ToCardID)
+		if err != nil {
+			return err
+		}
+		if fromCard.UserID != input.UserID {
+			return ErrCardNotFound
+		}
+		if err := checkCardOperation(fromCard, amount); err != nil {
+			return err
+		}
+		if err := checkCardOperation(toCard, amount); err != nil {
+			return err
+		}
+		if err := checkLimits(ctx, repo, fromCard, entity.TransactionTypeTransferOut, amount); err != nil {
+			return err
+		}
+		decision, err := s.assessRisk(ctx, repo, fromCard, entity.TransactionTypeTransferOut, amount, "")
+		if err != nil {
+			return err
+		}
+
+		txns, err := applyOperation(ctx, repo, ledgerOperation{
+			Operation:   entity.JournalOperationTransfer,
+			Amount:      amount,
+			From:        ledgerSide{Card: fromCard, TxType: entity.TransactionTypeTransferOut},
+			To:          ledgerSide{Card: toCard, TxType: entity.TransactionTypeTransferIn},
+			Description: input.Description,
+		})
+		if err != nil {
+			return err
+		}
+		res.From, res.To = txns[0], txns[1]
+		return recordRisk(ctx, repo, decision, res.From.ID)
+	})
+	if err != nil {
+		return nil, nil, s.commitRiskDenial(ctx, err)
+	}
+	return res.From, res.To, nil
+}
+
+// lockTransferCards блокирует обе карты перевода в порядке возрастания id.
+// Встречные переводы A->B и B->A берут блокировки в одном порядке и не дедлокаются.
+func lockTransferCards(ctx context.Context, repo repository.CardRepository, fromID, toID int64) (from, to *entity.Card, err error) {
+	first, second := fromID, toID
+	if second < first {
+		first, second = second, first
+	}
+	locked := make(map[int64]*entity.Card, 2)
+	for _, id := range []int64{first, second} {
+		card, err := repo.GetCardForUpdate(ctx, id)
+		if err != nil {
+			if errors.Is(err, repository.ErrNotFound) {
+				return nil, nil, ErrCardNotFound
+			}
+			return nil, nil, err
+		}
+		locked[id] = card
+	}
+	return locked[fromID], locked[toID], nil
+}
+
+func (s *cardService) GetTransactions(ctx context.Context, userID, cardID int64, limit, offset int) ([]*entity.Transaction, int, error) {
+	if _, err := s.getOwnedCard(ctx, s.repo, userID, cardID); err != nil {
+		return nil, 0, err
+	}
+	if limit <= 0 || limit > 100 {
+		limit = 20
+	}
+	if offset < 0 {
+		offset = 0
+	}
+	return s.repo.GetTransactions(ctx, cardID, limit, offset)
+}
+
+func (s *cardService) GetTransaction(ctx context.Context, userID, transactionID int64) (*entity.Transaction, error) {
+	txn, err := s.repo.GetTransaction(ctx, transactionID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrTransactionNotFound
+		}
+		return nil, err
+	}
+	// Транзакция видна только владельцу карты
+	if _, err := s.getOwnedCard(ctx, s.repo, userID, txn.CardID); err != nil {
+		if errors.Is(err, ErrCardNotFound) {
+			return nil, ErrTransactionNotFound
+		}
+		return nil, err
+	}
+	return txn, nil
+}
+
+func (s *cardService) ProcessPayment(ctx context.Context, input PaymentInput) (*entity.Transaction, error) {
+	amount, err := normalizeAmount(input.Amount)
+	if err != nil {
+		return nil, err
+	}
+	if input.Category, err = normalizeCategory(input.Category); err != nil {
+		return nil, err
+	}
+
+	if input.GiftCode != "" {
+		if input.CardID != 0 {
+			return nil, ErrPaymentCardConflict
+		}
+		gift, err := s.authenticateGiftCard(ctx, input.GiftCode, input.GiftPIN)
+		if err != nil {
+			return nil, err
+		}
+		input.CardID = gift.CardID
+	}
+
+	var txn *entity.Transaction
+	input.Amount = amount
+	err = s.runIdempotent(ctx, input.UserID, input.IdempotencyKey, idempotentPayment, input, &txn, func(repo repository.CardRepository) error {
+		if err := claimOrderCharge(ctx, repo, input.UserID, input.OrderID, input.AdditionalCharge); err != nil {
+			return err
+		}
+		card, err := s.lockPaymentCard(ctx, repo, input)
+		if err != nil {
+			return err
+		}
+		if err := checkCardOperation(card, amount); err != nil {
+			return err
+		}
+		if err := checkLimits(ctx, repo, card, entity.TransactionTypePayment, amount); err != nil {
+			return err
+		}
+		decision, err := s.assessRisk(ctx, repo, card, entity.TransactionTypePayment, amount, input.OrderID)
+		if err != nil {
+			return err
+		}
+		txns, err := applyOperation(ctx, repo, ledgerOperation{
+			Operation:   entity.JournalOperationPayment,
+			Amount:      amount,
+			From:        ledgerSide{Card: card, TxType: entity.TransactionTypePayment},
+			To:          ledgerSide{Kind: entity.AccountKindMerchantSettlement},
+			Description: input.Description,
+			OrderID:     input.OrderID,
+			Category:    input.Category,
+		})
+		if err != nil {
+			return err
+		}
+		txn = txns[0]
+		if err := outboxPaymentCompleted(ctx, repo, card, txn, 0); err != nil {
+			return err
+		}
+		return recordRisk(ctx, repo, decision, txn.ID)
+	})
+	if err != nil {
+		return nil, s.commitRiskDenial(ctx, err)
+	}
+	return txn, nil
+}
+
+// lockPaymentCard блокирует карту оплаты. Подарочная карта, которой платят по коду,
+// при первой оплате переходит к плательщику.
+func (s *cardService) lockPaymentCard(ctx context.Context, repo repository.CardRepository, input PaymentInput) (*entity.Card, error) {
+	if input.GiftCode == "" {
+		return s.lockOwnedCard(ctx, repo, input.UserID, input.CardID)
+	}
+	card, err := lockCard(ctx, repo, input.CardID)
+	if err != nil {
+		return nil, err
+	}
+	if err := claimGiftCard(ctx, repo, card, input.UserID); err != nil {
+		return nil, err
+	}
+	return card, nil
+}
+
+// ValidateCard проверяет карту и, если amount не нулевой, что на ней достаточно средств
+// и оплата укладывается в лимиты
+func (s *cardService) ValidateCard(ctx context.Context, userID, cardID int64, amount money.Money) error {
+	card, err := s.getOwnedCard(ctx, s.repo, userID, cardID)
+	if err != nil {
+		return err
+	}
+	if err := checkCardUsable(card); err != nil {
+		return err
+	}
+	if amount.UnitsMinor == 0 {
+		return nil
+	}
+	amount, err = normalizeAmount(amount)
+	if err != nil {
+		return err
+	}
+	if amount.Currency != card.Currency {
+		return ErrCurrencyMismatch
+	}
+	if card.Available() < amount.UnitsMinor {
+		return ErrInsufficientFunds
+	}
+	return checkLimits(ctx, s.repo, card, entity.TransactionTypePayment, amount)
+}
+
+// normalizeAmount проверяет что сумма положительная и валюта поддерживается
+func normalizeAmount(amount money.Money) (money.Money, error) {
+	if amount.UnitsMinor <= 0 {
+		return money.Money{}, ErrInvalidAmount
+	}
+	amount.Currency = money.NormalizeCurrency(amount.Currency)
+	if !money.IsSupported(amount.Currency) {
+		return money.Money{}, ErrUnsupportedCurrency
+	}
+	return amount, nil
+}
+
+// checkCardOperation проверяет что по карте можно провести операцию на amount
+func checkCardOperation(card *entity.Card, amount money.Money) error {
+	if err := checkCardUsable(card); err != nil {
+		return err
+	}
+	if card.Currency != amount.Currency {
+		return ErrCurrencyMismatch
+	}
+	return nil
+}
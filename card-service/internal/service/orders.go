@@ -0,0 +1,92 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/mrevds/pizza-app/card-service/internal/entity"
+	"github.com/mrevds/pizza-app/card-service/internal/repository"
+)
+
+// OrderPaymentSummary - транзакции заказа и итог по ним. Суммы - в валюте первой
+// транзакции, все списания заказа проводятся в одной валюте.
+type OrderPaymentSummary struct {
+	OrderID      string
+	Status       string // entity.OrderPaymentStatus*
+	Currency     string
+	Paid         int64 // успешные оплаты
+	Refunded     int64 // успешные возвраты
+	Adjusted     int64 // исправления сверки: adjustment_debit минус adjustment_credit
+	Net          int64 // Paid - Refunded + Adjusted
+	Transactions []*entity.Transaction
+}
+
+// GetPaymentsByOrder возвращает оплаты, возвраты и исправления по заказу. Заказ без
+// транзакций - не ошибка, а статус unpaid: так после сбоя можно узнать, прошла ли оплата.
+func (s *cardService) GetPaymentsByOrder(ctx context.Context, userID int64, orderID string) (*OrderPaymentSummary, error) {
+	orderID = strings.TrimSpace(orderID)
+	if orderID == "" {
+		return nil, ErrOrderIDRequired
+	}
+	txs, err := s.repo.GetOrderTransactions(ctx, userID, orderID)
+	if err != nil {
+		return nil, err
+	}
+
+	summary := &OrderPaymentSummary{OrderID: orderID, Transactions: txs}
+	pending := false
+	for _, t := range txs {
+		if summary.Currency == "" {
+			summary.Currency = t.Currency
+		}
+		if t.TransactionType == entity.TransactionTypePayment && t.Status == entity.TransactionStatusPending {
+			pending = true
+		}
+		if t.Status != entity.TransactionStatusSuccess {
+			continue
+		}
+		switch t.TransactionType {
+		case entity.TransactionTypePayment:
+			summary.Paid += t.Amount
+		case entity.TransactionTypeRefund:
+			summary.Refunded += t.Amount
+		case entity.TransactionTypeAdjustmentDebit:
+			summary.Adjusted += t.Amount
+		case entity.TransactionTypeAdjustmentCredit:
+			summary.Adjusted -= t.Amount
+		}
+	}
+	summary.Net = summary.Paid - summary.Refunded + summary.Adjusted
+
+	switch {
+	case summary.Paid == 0 && pending:
+		summary.Status = entity.OrderPaymentStatusPending
+	case summary.Paid == 0:
+		summary.Status = entity.OrderPaymentStatusUnpaid
+	case summary.Net <= 0:
+		summary.Status = entity.OrderPaymentStatusRefunded
+	case summary.Net < summary.Paid:
+		summary.Status = entity.OrderPaymentStatusPartiallyRefunded
+	default:
+		summary.Status = entity.OrderPaymentStatusPaid
+	}
+	return summary, nil
+}
+
+// claimOrderCharge отмечает списание по заказу в транзакции списания. Заказ, по которому
+// уже списывали, можно списать снова только с additional. Вызывать до блокировки карт:
+// параллельное списание того же заказа ждет на вставке, не держа карт.
+func claimOrderCharge(ctx context.Context, repo repository.CardRepository, userID int64, orderID string, additional bool) error {
+	if orderID == "" {
+		return nil
+	}
+	err := repo.CreateOrderCharge(ctx, orderID, userID)
+	if errors.Is(err, repository.ErrAlreadyExists) {
+		if additional {
+			return nil
+		}
+		return ErrOrderAlreadyCharged
+	}
+	return err
+}
@@ -0,0 +1,153 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/mrevds/pizza-app/card-service/internal/entity"
+)
+
+func TestOrderChargedOnce(t *testing.T) {
+	s, repo := newTestService(t)
+	s.cfg.Card.AuthorizationTTL = time.Hour
+	ctx := context.Background()
+	card := addFundedCard(t, s, repo, testUserID, 1_000)
+	other := addFundedCard(t, s, repo, testUserID, 1_000)
+
+	// Отказ не занимает заказ
+	if _, err := s.ProcessPayment(ctx, PaymentInput{UserID: testUserID, CardID: card.ID, Amount: rub(5_000), OrderID: "order-1"}); !errors.Is(err, ErrInsufficientFunds) {
+		t.Fatalf("payment over balance err = %v, want ErrInsufficientFunds", err)
+	}
+	if _, err := s.ProcessPayment(ctx, PaymentInput{UserID: testUserID, CardID: card.ID, Amount: rub(300), OrderID: "order-1"}); err != nil {
+		t.Fatalf("ProcessPayment: %v", err)
+	}
+
+	// Повторное списание по заказу любым способом - только с AdditionalCharge
+	if _, err := s.ProcessPayment(ctx, PaymentInput{UserID: testUserID, CardID: other.ID, Amount: rub(300), OrderID: "order-1"}); !errors.Is(err, ErrOrderAlreadyCharged) {
+		t.Errorf("second payment err = %v, want ErrOrderAlreadyCharged", err)
+	}
+	split := SplitPaymentInput{UserID: testUserID, OrderID: "order-1", Legs: []PaymentLeg{{CardID: other.ID, Amount: rub(100)}}}
+	if _, err := s.ProcessSplitPayment(ctx, split); !errors.Is(err, ErrOrderAlreadyCharged) {
+		t.Errorf("split payment err = %v, want ErrOrderAlreadyCharged", err)
+	}
+	auth, err := s.AuthorizePayment(ctx, AuthorizeInput{UserID: testUserID, CardID: other.ID, Amount: rub(200), OrderID: "order-1"})
+	if err != nil {
+		t.Fatalf("AuthorizePayment: %v", err)
+	}
+	capture := CaptureInput{UserID: testUserID, AuthorizationID: auth.ID, OrderID: "order-1"}
+	if _, _, err := s.CapturePayment(ctx, capture); !errors.Is(err, ErrOrderAlreadyCharged) {
+		t.Errorf("capture err = %v, want ErrOrderAlreadyCharged", err)
+	}
+	if got := repo.store.cards[other.ID].Balance; got != 1_000 {
+		t.Fatalf("other card balance = %d, want 1000", got)
+	}
+
+	capture.AdditionalCharge = true
+	if _, _, err := s.CapturePayment(ctx, capture); err != nil {
+		t.Fatalf("additional capture: %v", err)
+	}
+	split.AdditionalCharge = true
+	if _, err := s.ProcessSplitPayment(ctx, split); err != nil {
+		t.Fatalf("additional split payment: %v", err)
+	}
+	if got := repo.store.cards[other.ID].Balance; got != 700 {
+		t.Errorf("other card balance = %d, want 700", got)
+	}
+}
+
+func TestOrderChargedPerUser(t *testing.T) {
+	s, repo := newTestService(t)
+	s.cfg.Card.AuthorizationTTL = time.Hour
+	ctx := context.Background()
+	otherUser := int64(testUserID + 1)
+	card := addFundedCard(t, s, repo, testUserID, 1_000)
+	otherCard := addFundedCard(t, s, repo, otherUser, 1_000)
+
+	for _, orderID := range []string{"order-1", "order-2", "order-3"} {
+		if _, err := s.ProcessPayment(ctx, PaymentInput{UserID: testUserID, CardID: card.ID, Amount: rub(100), OrderID: orderID}); err != nil {
+			t.Fatalf("ProcessPayment %s: %v", orderID, err)
+		}
+	}
+
+	// Тот же order_id другого пользователя - отдельный заказ, списывается без AdditionalCharge
+	if _, err := s.ProcessPayment(ctx, PaymentInput{UserID: otherUser, CardID: otherCard.ID, Amount: rub(100), OrderID: "order-1"}); err != nil {
+		t.Errorf("other user payment: %v", err)
+	}
+	split := SplitPaymentInput{UserID: otherUser, OrderID: "order-2", Legs: []PaymentLeg{{CardID: otherCard.ID, Amount: rub(100)}}}
+	if _, err := s.ProcessSplitPayment(ctx, split); err != nil {
+		t.Errorf("other user split payment: %v", err)
+	}
+	auth, err := s.AuthorizePayment(ctx, AuthorizeInput{UserID: otherUser, CardID: otherCard.ID, Amount: rub(100), OrderID: "order-3"})
+	if err != nil {
+		t.Fatalf("AuthorizePayment: %v", err)
+	}
+	if _, _, err := s.CapturePayment(ctx, CaptureInput{UserID: otherUser, AuthorizationID: auth.ID, OrderID: "order-3"}); err != nil {
+		t.Errorf("other user capture: %v", err)
+	}
+	if got := repo.store.cards[otherCard.ID].Balance; got != 700 {
+		t.Errorf("other card balance = %d, want 700", got)
+	}
+
+	// Повторное списание своего заказа по-прежнему запрещено
+	if _, err := s.ProcessPayment(ctx, PaymentInput{UserID: otherUser, CardID: otherCard.ID, Amount: rub(100), OrderID: "order-1"}); !errors.Is(err, ErrOrderAlreadyCharged) {
+		t.Errorf("second payment err = %v, want ErrOrderAlreadyCharged", err)
+	}
+	for _, user := range []int64{testUserID, otherUser} {
+		summary, err := s.GetPaymentsByOrder(ctx, user, "order-1")
+		if err != nil {
+			t.Fatalf("GetPaymentsByOrder: %v", err)
+		}
+		if summary.Status != entity.OrderPaymentStatusPaid || summary.Paid != 100 {
+			t.Errorf("user %d summary = %+v, want paid 100", user, summary)
+		}
+	}
+}
+
+func TestGetPaymentsByOrder(t *testing.T) {
+	s, repo := newTestService(t)
+	ctx := context.Background()
+	card := addFundedCard(t, s, repo, testUserID, 1_000)
+
+	summary, err := s.GetPaymentsByOrder(ctx, testUserID, "order-1")
+	if err != nil {
+		t.Fatalf("GetPaymentsByOrder: %v", err)
+	}
+	if summary.Status != entity.OrderPaymentStatusUnpaid || len(summary.Transactions) != 0 {
+		t.Fatalf("summary = %+v, want unpaid without transactions", summary)
+	}
+
+	payment, err := s.ProcessPayment(ctx, PaymentInput{UserID: testUserID, CardID: card.ID, Amount: rub(600), OrderID: "order-1"})
+	if err != nil {
+		t.Fatalf("ProcessPayment: %v", err)
+	}
+	if _, err := s.RefundPayment(ctx, RefundInput{UserID: testUserID, TransactionID: payment.ID, Amount: rub(200)}); err != nil {
+		t.Fatalf("RefundPayment: %v", err)
+	}
+	summary, err = s.GetPaymentsByOrder(ctx, testUserID, " order-1 ")
+	if err != nil {
+		t.Fatalf("GetPaymentsByOrder: %v", err)
+	}
+	if summary.Status != entity.OrderPaymentStatusPartiallyRefunded || summary.Paid != 600 || summary.Refunded != 200 || summary.Net != 400 {
+		t.Errorf("summary = %+v, want partially refunded 600 - 200", summary)
+	}
+	if len(summary.Transactions) != 2 || summary.Transactions[1].TransactionType != entity.TransactionTypeRefund {
+		t.Errorf("transactions = %+v, want payment and refund", summary.Transactions)
+	}
+
+	if _, err := s.RefundPayment(ctx, RefundInput{UserID: testUserID, TransactionID: payment.ID}); err != nil {
+		t.Fatalf("RefundPayment rest: %v", err)
+	}
+	if summary, _ = s.GetPaymentsByOrder(ctx, testUserID, "order-1"); summary.Status != entity.OrderPaymentStatusRefunded || summary.Net != 0 {
+		t.Errorf("summary = %+v, want refunded with net 0", summary)
+	}
+
+	// Заказ виден только по картам пользователя
+	if summary, _ = s.GetPaymentsByOrder(ctx, testUserID+1, "order-1"); summary.Status != entity.OrderPaymentStatusUnpaid {
+		t.Errorf("another user status = %s, want unpaid", summary.Status)
+	}
+	if _, err := s.GetPaymentsByOrder(ctx, testUserID, " "); !errors.Is(err, ErrOrderIDRequired) {
+		t.Errorf("empty order_id err = %v, want ErrOrderIDRequired", err)
+	}
+}
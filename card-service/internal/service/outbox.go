@@ -0,0 +1,78 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+
+	"github.com/mrevds/pizza-app/card-service/internal/entity"
+	"github.com/mrevds/pizza-app/card-service/internal/repository"
+	eventsv1 "github.com/mrevds/pizza-app/card-service/pkg/card-events_v1"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// enqueueOutbox дописывает доменное событие карты в outbox. Вызывать в транзакции
+// изменения под блокировкой карты: так id событий одной карты растут в порядке коммитов.
+func enqueueOutbox(ctx context.Context, repo repository.CardRepository, card *entity.Card, eventType string, event *eventsv1.DomainEvent) error {
+	id, err := newEventID()
+	if err != nil {
+		return err
+	}
+	event.EventId = id
+	event.EventType = eventType
+	event.CardId = card.ID
+	event.UserId = card.UserID
+	event.OccurredAt = timestamppb.Now()
+	payload, err := proto.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to marshal %s event: %w", eventType, err)
+	}
+	return repo.CreateOutboxMessage(ctx, &entity.OutboxMessage{
+		CardID:        card.ID,
+		EventType:     eventType,
+		SchemaVersion: entity.OutboxSchemaVersion,
+		Payload:       payload,
+	})
+}
+
+// outboxPaymentCompleted - оплата списана, authorizationID = 0 у оплаты без холда
+func outboxPaymentCompleted(ctx context.Context, repo repository.CardRepository, card *entity.Card, txn *entity.Transaction, authorizationID int64) error {
+	return enqueueOutbox(ctx, repo, card, entity.OutboxPaymentCompleted, &eventsv1.DomainEvent{
+		Payload: &eventsv1.DomainEvent_PaymentCompleted{PaymentCompleted: &eventsv1.PaymentCompleted{
+			TransactionId:   txn.ID,
+			OrderId:         txn.OrderID,
+			Amount:          outboxMoney(txn.Amount, txn.Currency),
+			AuthorizationId: authorizationID,
+		}},
+	})
+}
+
+func outboxPaymentRefunded(ctx context.Context, repo repository.CardRepository, card *entity.Card, res *RefundResult) error {
+	return enqueueOutbox(ctx, repo, card, entity.OutboxPaymentRefunded, &eventsv1.DomainEvent{
+		Payload: &eventsv1.DomainEvent_PaymentRefunded{PaymentRefunded: &eventsv1.PaymentRefunded{
+			TransactionId:        res.Refund.ID,
+			PaymentTransactionId: res.Payment.ID,
+			OrderId:              res.Payment.OrderID,
+			Amount:               outboxMoney(res.Refund.Amount, res.Refund.Currency),
+			RefundedTotal:        outboxMoney(res.Refunded, res.Payment.Currency),
+		}},
+	})
+}
+
+func outboxMoney(units int64, currency string) *eventsv1.Money {
+	return &eventsv1.Money{UnitsMinor: units, Currency: currency}
+}
+
+// newEventID - случайный UUID v4, по нему потребители отбрасывают повторные доставки
+func newEventID() (string, error) {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return "", fmt.Errorf("failed to generate event id: %w", err)
+	}
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	h := hex.EncodeToString(b[:])
+	return h[0:8] + "-" + h[8:12] + "-" + h[12:16] + "-" + h[16:20] + "-" + h[20:], nil
+}
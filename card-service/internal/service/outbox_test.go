@@ -0,0 +1,58 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/mrevds/pizza-app/card-service/internal/entity"
+	eventsv1 "github.com/mrevds/pizza-app/card-service/pkg/card-events_v1"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestOutboxDomainEvents(t *testing.T) {
+	s, repo := newTestService(t)
+	ctx := context.Background()
+	card := addFundedCard(t, s, repo, testUserID, 10_000)
+
+	payment, err := s.ProcessPayment(ctx, PaymentInput{UserID: testUserID, CardID: card.ID, Amount: rub(3_000), OrderID: "order-1"})
+	if err != nil {
+		t.Fatalf("ProcessPayment: %v", err)
+	}
+	if _, err := s.RefundPayment(ctx, RefundInput{UserID: testUserID, TransactionID: payment.ID, Amount: rub(1_000)}); err != nil {
+		t.Fatalf("RefundPayment: %v", err)
+	}
+	// Отказ не пишет событий: транзакция откатывается вместе с outbox
+	if _, err := s.ProcessPayment(ctx, PaymentInput{UserID: testUserID, CardID: card.ID, Amount: rub(100_000), OrderID: "order-2"}); err == nil {
+		t.Fatal("payment over balance succeeded")
+	}
+	if err := s.BlockCard(ctx, testUserID, card.ID, "lost"); err != nil {
+		t.Fatalf("BlockCard: %v", err)
+	}
+
+	want := []string{entity.OutboxPaymentCompleted, entity.OutboxPaymentRefunded, entity.OutboxCardStatusChanged}
+	if len(repo.store.outbox) != len(want) {
+		t.Fatalf("got %d outbox messages, want %d", len(repo.store.outbox), len(want))
+	}
+	events := make([]*eventsv1.DomainEvent, len(want))
+	for i, m := range repo.store.outbox {
+		if m.EventType != want[i] || m.CardID != card.ID || m.SchemaVersion != entity.OutboxSchemaVersion {
+			t.Fatalf("message %d: %+v", i, m)
+		}
+		events[i] = &eventsv1.DomainEvent{}
+		if err := proto.Unmarshal(m.Payload, events[i]); err != nil {
+			t.Fatalf("message %d payload: %v", i, err)
+		}
+		if events[i].GetEventId() == "" || events[i].GetEventType() != want[i] || events[i].GetUserId() != testUserID {
+			t.Fatalf("event %d envelope: %v", i, events[i])
+		}
+	}
+	if p := events[0].GetPaymentCompleted(); p.GetTransactionId() != payment.ID || p.GetOrderId() != "order-1" || p.GetAmount().GetUnitsMinor() != 3_000 {
+		t.Fatalf("payment event: %v", p)
+	}
+	if r := events[1].GetPaymentRefunded(); r.GetPaymentTransactionId() != payment.ID || r.GetAmount().GetUnitsMinor() != 1_000 || r.GetRefundedTotal().GetUnitsMinor() != 1_000 {
+		t.Fatalf("refund event: %v", r)
+	}
+	if st := events[2].GetCardStatusChanged(); st.GetFromStatus() != entity.CardStatusActive || st.GetToStatus() != entity.CardStatusBlockedByUser || st.GetReason() != "lost" {
+		t.Fatalf("status event: %v", st)
+	}
+}
@@ -0,0 +1,260 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/mrevds/pizza-app/card-service/internal/entity"
+	"github.com/mrevds/pizza-app/card-service/internal/money"
+	"github.com/mrevds/pizza-app/card-service/internal/repository"
+	"github.com/mrevds/pizza-app/card-service/internal/userdir"
+)
+
+// Перевод по номеру телефона проходит в два шага. PreviewTransferToUser ищет получателя
+// в user-service, выбирает его карту и сохраняет подтверждение с замаскированным именем.
+// TransferToUser выполняет перевод по токену подтверждения, который пользователь получил
+// вместе с именем получателя. Номер телефона и карта получателя отправителю не видны.
+
+// TransferToUserPreviewInput - подготовка перевода, Amount в минимальных единицах
+type TransferToUserPreviewInput struct {
+	UserID      int64
+	FromCardID  int64
+	PhoneNumber string
+	Amount      money.Money
+	Description string
+}
+
+// TransferToUserInput - выполнение подтвержденного перевода
+type TransferToUserInput struct {
+	UserID            int64
+	ConfirmationToken string
+	IdempotencyKey    string `json:"-"`
+}
+
+// p2pResult - результат TransferToUser в сохраненном ответе
+type p2pResult struct {
+	Transfer    *entity.P2PTransfer `json:"transfer"`
+	Transaction *entity.Transaction `json:"transaction"`
+}
+
+func (s *cardService) SetDefaultCard(ctx context.Context, userID, cardID int64) (*entity.Card, error) {
+	card, err := s.getOwnedCard(ctx, s.repo, userID, cardID)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkCardUsable(card); err != nil {
+		return nil, err
+	}
+	if err := s.repo.SetDefaultCard(ctx, userID, cardID); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrCardNotFound
+		}
+		return nil, err
+	}
+	return card, nil
+}
+
+func (s *cardService) PreviewTransferToUser(ctx context.Context, input TransferToUserPreviewInput) (*entity.P2PTransfer, error) {
+	if s.directory == nil {
+		return nil, ErrP2PUnavailable
+	}
+	amount, err := normalizeAmount(input.Amount)
+	if err != nil {
+		return nil, err
+	}
+	phone := strings.TrimSpace(input.PhoneNumber)
+	if phone == "" {
+		return nil, ErrPhoneRequired
+	}
+	from, err := s.getOwnedCard(ctx, s.repo, input.UserID, input.FromCardID)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkCardOperation(from, amount); err != nil {
+		return nil, err
+	}
+
+	if err := s.reservePhoneLookup(ctx, input.UserID); err != nil {
+		return nil, err
+	}
+	found, err := s.directory.LookupByPhone(ctx, phone)
+	if err != nil {
+		if errors.Is(err, userdir.ErrNotFound) {
+			return nil, ErrRecipientNotFound
+		}
+		return nil, err
+	}
+	recipientID, err := s.ResolveUser(ctx, found.ExternalID)
+	if err != nil {
+		return nil, err
+	}
+	if recipientID == input.UserID {
+		return nil, ErrSelfTransfer
+	}
+	to, err := recipientCard(ctx, s.repo, recipientID, amount)
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := newConfirmationToken()
+	if err != nil {
+		return nil, err
+	}
+	t := &entity.P2PTransfer{
+		Token:           token,
+		UserID:          input.UserID,
+		FromCardID:      from.ID,
+		RecipientUserID: recipientID,
+		RecipientCardID: to.ID,
+		RecipientName:   found.MaskedName,
+		Amount:          amount.UnitsMinor,
+		Currency:        amount.Currency,
+		Description:     input.Description,
+		Status:          entity.P2PStatusPending,
+		ExpiresAt:       time.Now().Add(s.cfg.P2P.ConfirmationTTL),
+	}
+	if err := s.repo.CreateP2PTransfer(ctx, t); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+func (s *cardService) TransferToUser(ctx context.Context, input TransferToUserInput) (*entity.P2PTransfer, *entity.Transaction, error) {
+	if input.ConfirmationToken == "" {
+		return nil, nil, ErrConfirmationRequired
+	}
+
+	var res p2pResult
+	err := s.runIdempotent(ctx, input.UserID, input.IdempotencyKey, idempotentTransferToUser, input, &res, func(repo repository.CardRepository) error {
+		t, err := repo.GetP2PTransferByTokenForUpdate(ctx, input.ConfirmationToken)
+		if err != nil {
+			if errors.Is(err, repository.ErrNotFound) {
+				return ErrP2PTransferNotFound
+			}
+			return err
+		}
+		if t.UserID != input.UserID {
+			return ErrP2PTransferNotFound
+		}
+		if t.Status == entity.P2PStatusCompleted {
+			return ErrP2PTransferCompleted
+		}
+		now := time.Now()
+		if !now.Before(t.ExpiresAt) {
+			return ErrP2PTransferExpired
+		}
+
+		amount := money.Money{UnitsMinor: t.Amount, Currency: t.Currency}
+		fromCard, toCard, err := lockTransferCards(ctx, repo, t.FromCardID, t.RecipientCardID)
+		if err != nil {
+			if errors.Is(err, ErrCardNotFound) {
+				if _, ferr := repo.GetCard(ctx, t.FromCardID); ferr == nil {
+					return ErrRecipientNoCard
+				}
+			}
+			return err
+		}
+		if err := checkCardOperation(fromCard, amount); err != nil {
+			return err
+		}
+		// Состояние карты получателя отправителю не раскрывается
+		if checkCardOperation(toCard, amount) != nil {
+			return ErrRecipientNoCard
+		}
+		if err := checkLimits(ctx, repo, fromCard, entity.TransactionTypeTransferOut, amount); err != nil {
+			return err
+		}
+		decision, err := s.assessRisk(ctx, repo, fromCard, entity.TransactionTypeTransferOut, amount, "")
+		if err != nil {
+			return err
+		}
+
+		txns, err := applyOperation(ctx, repo, ledgerOperation{
+			Operation:   entity.JournalOperationTransfer,
+			Amount:      amount,
+			From:        ledgerSide{Card: fromCard, TxType: entity.TransactionTypeTransferOut},
+			To:          ledgerSide{Card: toCard, TxType: entity.TransactionTypeTransferIn},
+			Description: t.Description,
+		})
+		if err != nil {
+			return err
+		}
+		t.Status = entity.P2PStatusCompleted
+		t.TransactionID = txns[0].ID
+		t.CompletedAt = &now
+		if err := repo.UpdateP2PTransfer(ctx, t); err != nil {
+			return err
+		}
+		res.Transfer, res.Transaction = t, txns[0]
+		return recordRisk(ctx, repo, decision, txns[0].ID)
+	})
+	if err != nil {
+		return nil, nil, s.commitRiskDenial(ctx, err)
+	}
+	return res.Transfer, res.Transaction, nil
+}
+
+// reservePhoneLookup учитывает поиск получателя до обращения в user-service, чтобы
+// в лимит попадали и поиски незарегистрированных номеров. Строка пользователя
+// блокируется, поэтому параллельные запросы не превышают лимит.
+func (s *cardService) reservePhoneLookup(ctx context.Context, userID int64) error {
+	limit := s.cfg.P2P
+	return s.repo.RunInTx(ctx, func(repo repository.CardRepository) error {
+		if err := repo.LockUser(ctx, userID); err != nil {
+			return err
+		}
+		if limit.LookupLimit > 0 {
+			n, err := repo.CountPhoneLookupsSince(ctx, userID, time.Now().Add(-limit.LookupWindow))
+			if err != nil {
+				return err
+			}
+			if n >= limit.LookupLimit {
+				return ErrTooManyLookups
+			}
+		}
+		return repo.CreatePhoneLookup(ctx, userID)
+	})
+}
+
+// recipientCard выбирает карту получателя: выбранную им для переводов, если на неё
+// можно зачислить amount, иначе первую добавленную действующую карту в валюте перевода.
+// Подарочные карты выбираются, только если получатель указал их сам.
+func recipientCard(ctx context.Context, repo repository.CardRepository, userID int64, amount money.Money) (*entity.Card, error) {
+	defaultID, err := repo.GetDefaultCardID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	cards, err := repo.GetUserCards(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	var first *entity.Card
+	for _, c := range cards {
+		if checkCardOperation(c, amount) != nil {
+			continue
+		}
+		if c.ID == defaultID {
+			return c, nil
+		}
+		if first == nil && c.CardType != entity.CardTypeGift {
+			first = c
+		}
+	}
+	if first == nil {
+		return nil, ErrRecipientNoCard
+	}
+	return first, nil
+}
+
+// newConfirmationToken - случайный токен подтверждения, по нему нельзя угадать чужой перевод
+func newConfirmationToken() (string, error) {
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
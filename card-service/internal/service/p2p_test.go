@@ -0,0 +1,227 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/mrevds/pizza-app/card-service/internal/config"
+	"github.com/mrevds/pizza-app/card-service/internal/entity"
+	"github.com/mrevds/pizza-app/card-service/internal/userdir"
+)
+
+const (
+	senderUUID     = "6f1c2a9e-3b4d-4e5f-8a7b-9c0d1e2f3a4b"
+	recipientUUID  = "0a1b2c3d-4e5f-4a6b-8c7d-9e0f1a2b3c4d"
+	recipientPhone = "+79990000001"
+	senderPhone    = "+79990000002"
+)
+
+// fakeDirectory - user-service с заданными номерами
+type fakeDirectory map[string]*userdir.Recipient
+
+func (d fakeDirectory) LookupByPhone(ctx context.Context, phone string) (*userdir.Recipient, error) {
+	if r, ok := d[phone]; ok {
+		return r, nil
+	}
+	return nil, userdir.ErrNotFound
+}
+
+// newP2PTestService возвращает сервис с отправителем и получателем, зарегистрированными в users
+func newP2PTestService(t *testing.T) (s *cardService, repo *memRepo, sender, recipient int64) {
+	t.Helper()
+	s, repo = newTestService(t)
+	s.cfg.P2P = config.P2PConfig{LookupLimit: 3, LookupWindow: time.Hour, ConfirmationTTL: 5 * time.Minute}
+	s.directory = fakeDirectory{
+		recipientPhone: {ExternalID: recipientUUID, MaskedName: "Иван П."},
+		senderPhone:    {ExternalID: senderUUID, MaskedName: "Петр С."},
+	}
+	var err error
+	if sender, err = s.ResolveUser(context.Background(), senderUUID); err != nil {
+		t.Fatalf("ResolveUser sender: %v", err)
+	}
+	if recipient, err = s.ResolveUser(context.Background(), recipientUUID); err != nil {
+		t.Fatalf("ResolveUser recipient: %v", err)
+	}
+	return s, repo, sender, recipient
+}
+
+func TestTransferToUser(t *testing.T) {
+	s, repo, sender, recipient := newP2PTestService(t)
+	ctx := context.Background()
+	from := addFundedCard(t, s, repo, sender, 10_000)
+	addFundedCard(t, s, repo, recipient, 0)
+	primary := addFundedCard(t, s, repo, recipient, 0)
+	if _, err := s.SetDefaultCard(ctx, recipient, primary.ID); err != nil {
+		t.Fatalf("SetDefaultCard: %v", err)
+	}
+
+	preview, err := s.PreviewTransferToUser(ctx, TransferToUserPreviewInput{
+		UserID: sender, FromCardID: from.ID, PhoneNumber: " " + recipientPhone, Amount: rub(3_000), Description: "за обед",
+	})
+	if err != nil {
+		t.Fatalf("PreviewTransferToUser: %v", err)
+	}
+	if preview.RecipientName != "Иван П." || preview.RecipientCardID != primary.ID || preview.Token == "" {
+		t.Fatalf("preview = %+v, want Иван П. to card %d with a token", preview, primary.ID)
+	}
+	if got := repo.store.cards[from.ID].Balance; got != 10_000 {
+		t.Fatalf("balance after preview = %d, want 10000", got)
+	}
+
+	input := TransferToUserInput{UserID: sender, ConfirmationToken: preview.Token, IdempotencyKey: "p2p-1"}
+	transfer, txn, err := s.TransferToUser(ctx, input)
+	if err != nil {
+		t.Fatalf("TransferToUser: %v", err)
+	}
+	if transfer.Status != entity.P2PStatusCompleted || transfer.TransactionID != txn.ID {
+		t.Errorf("transfer = %+v, want completed with transaction %d", transfer, txn.ID)
+	}
+	if txn.CardID != from.ID || txn.TransactionType != entity.TransactionTypeTransferOut || txn.BalanceAfter != 7_000 {
+		t.Errorf("transaction = %+v, want transfer_out of card %d with balance 7000", txn, from.ID)
+	}
+	if got := repo.store.cards[primary.ID].Balance; got != 3_000 {
+		t.Errorf("default card balance = %d, want 3000", got)
+	}
+
+	// Повтор с тем же ключом - сохраненный ответ, без ключа - токен уже использован
+	_, again, err := s.TransferToUser(ctx, input)
+	if err != nil || again.ID != txn.ID {
+		t.Fatalf("replay = %v, %v, want transaction %d", again, err, txn.ID)
+	}
+	input.IdempotencyKey = ""
+	if _, _, err := s.TransferToUser(ctx, input); !errors.Is(err, ErrP2PTransferCompleted) {
+		t.Fatalf("second TransferToUser err = %v, want ErrP2PTransferCompleted", err)
+	}
+	if got := repo.store.cards[from.ID].Balance; got != 7_000 {
+		t.Errorf("sender balance = %d, want 7000", got)
+	}
+}
+
+func TestPreviewTransferToUserRecipientCard(t *testing.T) {
+	s, repo, sender, recipient := newP2PTestService(t)
+	s.cfg.P2P.LookupLimit = 0
+	ctx := context.Background()
+	from := addFundedCard(t, s, repo, sender, 10_000)
+	preview := func(phone string) (*entity.P2PTransfer, error) {
+		return s.PreviewTransferToUser(ctx, TransferToUserPreviewInput{
+			UserID: sender, FromCardID: from.ID, PhoneNumber: phone, Amount: rub(100),
+		})
+	}
+
+	if _, err := preview(recipientPhone); !errors.Is(err, ErrRecipientNoCard) {
+		t.Fatalf("recipient without cards err = %v, want ErrRecipientNoCard", err)
+	}
+	usd := addFundedCard(t, s, repo, recipient, 0)
+	repo.store.cards[usd.ID].Currency = "USD"
+	first := addFundedCard(t, s, repo, recipient, 0)
+	chosen := addFundedCard(t, s, repo, recipient, 0)
+	if _, err := s.SetDefaultCard(ctx, recipient, chosen.ID); err != nil {
+		t.Fatalf("SetDefaultCard: %v", err)
+	}
+	repo.store.cards[chosen.ID].Status = entity.CardStatusBlockedByUser
+
+	// Выбранная карта заблокирована, USD не подходит по валюте - первая карта в рублях
+	p, err := preview(recipientPhone)
+	if err != nil {
+		t.Fatalf("PreviewTransferToUser: %v", err)
+	}
+	if p.RecipientCardID != first.ID {
+		t.Errorf("recipient card = %d, want first RUB card %d", p.RecipientCardID, first.ID)
+	}
+
+	// Карту заблокировали после подтверждения - отправитель не узнает причину
+	repo.store.cards[first.ID].Status = entity.CardStatusBlockedByFraud
+	_, _, err = s.TransferToUser(ctx, TransferToUserInput{UserID: sender, ConfirmationToken: p.Token})
+	if !errors.Is(err, ErrRecipientNoCard) {
+		t.Fatalf("TransferToUser to blocked card err = %v, want ErrRecipientNoCard", err)
+	}
+
+	if _, err := preview("+70000000000"); !errors.Is(err, ErrRecipientNotFound) {
+		t.Errorf("unknown phone err = %v, want ErrRecipientNotFound", err)
+	}
+	if _, err := preview(senderPhone); !errors.Is(err, ErrSelfTransfer) {
+		t.Errorf("own phone err = %v, want ErrSelfTransfer", err)
+	}
+	if _, err := preview(" "); !errors.Is(err, ErrPhoneRequired) {
+		t.Errorf("empty phone err = %v, want ErrPhoneRequired", err)
+	}
+}
+
+func TestPreviewTransferToUserRateLimit(t *testing.T) {
+	s, repo, sender, recipient := newP2PTestService(t)
+	ctx := context.Background()
+	from := addFundedCard(t, s, repo, sender, 10_000)
+	addFundedCard(t, s, repo, recipient, 0)
+	preview := func(userID, cardID int64, phone string) error {
+		_, err := s.PreviewTransferToUser(ctx, TransferToUserPreviewInput{
+			UserID: userID, FromCardID: cardID, PhoneNumber: phone, Amount: rub(100),
+		})
+		return err
+	}
+
+	// Поиски несуществующих номеров тоже считаются
+	for i, phone := range []string{"+70000000001", recipientPhone, "+70000000002"} {
+		if err := preview(sender, from.ID, phone); err != nil && !errors.Is(err, ErrRecipientNotFound) {
+			t.Fatalf("lookup %d: %v", i, err)
+		}
+	}
+	if err := preview(sender, from.ID, recipientPhone); !errors.Is(err, ErrTooManyLookups) {
+		t.Fatalf("lookup over limit err = %v, want ErrTooManyLookups", err)
+	}
+
+	// Окно прошло - поиск снова доступен
+	for i := range repo.store.lookups[sender] {
+		repo.store.lookups[sender][i] = time.Now().Add(-2 * time.Hour)
+	}
+	if err := preview(sender, from.ID, recipientPhone); err != nil {
+		t.Fatalf("lookup after window: %v", err)
+	}
+
+	// Лимит у каждого отправителя свой
+	other, err := s.ResolveUser(ctx, "11111111-2222-4333-8444-555555555555")
+	if err != nil {
+		t.Fatalf("ResolveUser: %v", err)
+	}
+	otherCard := addFundedCard(t, s, repo, other, 1_000)
+	if err := preview(other, otherCard.ID, recipientPhone); err != nil {
+		t.Fatalf("other sender lookup: %v", err)
+	}
+}
+
+func TestTransferToUserConfirmation(t *testing.T) {
+	s, repo, sender, recipient := newP2PTestService(t)
+	ctx := context.Background()
+	from := addFundedCard(t, s, repo, sender, 10_000)
+	addFundedCard(t, s, repo, recipient, 0)
+
+	p, err := s.PreviewTransferToUser(ctx, TransferToUserPreviewInput{
+		UserID: sender, FromCardID: from.ID, PhoneNumber: recipientPhone, Amount: rub(500),
+	})
+	if err != nil {
+		t.Fatalf("PreviewTransferToUser: %v", err)
+	}
+
+	if _, _, err := s.TransferToUser(ctx, TransferToUserInput{UserID: recipient, ConfirmationToken: p.Token}); !errors.Is(err, ErrP2PTransferNotFound) {
+		t.Errorf("foreign confirmation err = %v, want ErrP2PTransferNotFound", err)
+	}
+	if _, _, err := s.TransferToUser(ctx, TransferToUserInput{UserID: sender}); !errors.Is(err, ErrConfirmationRequired) {
+		t.Errorf("empty token err = %v, want ErrConfirmationRequired", err)
+	}
+	repo.store.p2p[p.Token].ExpiresAt = time.Now().Add(-time.Second)
+	if _, _, err := s.TransferToUser(ctx, TransferToUserInput{UserID: sender, ConfirmationToken: p.Token}); !errors.Is(err, ErrP2PTransferExpired) {
+		t.Errorf("expired confirmation err = %v, want ErrP2PTransferExpired", err)
+	}
+	if got := repo.store.cards[from.ID].Balance; got != 10_000 {
+		t.Errorf("sender balance = %d, want 10000", got)
+	}
+
+	s.directory = nil
+	_, err = s.PreviewTransferToUser(ctx, TransferToUserPreviewInput{
+		UserID: sender, FromCardID: from.ID, PhoneNumber: recipientPhone, Amount: rub(500),
+	})
+	if !errors.Is(err, ErrP2PUnavailable) {
+		t.Errorf("without user-service err = %v, want ErrP2PUnavailable", err)
+	}
+}
@@ -0,0 +1,222 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/mrevds/pizza-app/card-service/internal/entity"
+	"github.com/mrevds/pizza-app/card-service/internal/repository"
+	"github.com/mrevds/pizza-app/card-service/internal/statement"
+)
+
+// reconcileBatch - сколько транзакций карты сверка читает за один запрос
+const reconcileBatch = 1000
+
+// ReconcileInput - параметры сверки балансов
+type ReconcileInput struct {
+	CardID int64 // 0 - все неудаленные карты
+	Fix    bool
+	Reason string // обязательна с Fix, попадает в описание исправляющих транзакций
+}
+
+// Reconcile пересчитывает баланс каждой карты по успешным транзакциям, проверяет цепочку
+// balance_before/after и сравнивает с главной книгой, хранимым балансом и текущим балансом
+// счета. Источник истины - сумма всех проводок счета: с Fix разница истории записывается
+// транзакцией adjustment_credit/debit, а хранимый баланс и баланс счета берутся из книги. Деньги при этом не двигаются, проводок нет.
+func (s *cardService) Reconcile(ctx context.Context, input ReconcileInput) (*entity.ReconcileReport, error) {
+	reason := strings.TrimSpace(input.Reason)
+	if input.Fix && reason == "" {
+		return nil, ErrReconcileReasonRequired
+	}
+	report := &entity.ReconcileReport{
+		StartedAt: time.Now(),
+		Fix:       input.Fix,
+		Reason:    reason,
+		Cards:     []*entity.CardReconciliation{},
+	}
+	add := func(rec *entity.CardReconciliation) {
+		report.CardsChecked++
+		if rec.HasDrift() {
+			report.CardsWithDrift++
+		}
+		if rec.Fixed {
+			report.CardsFixed++
+		}
+		if len(rec.Issues) > 0 {
+			report.Cards = append(report.Cards, rec)
+		}
+	}
+
+	if input.CardID != 0 {
+		rec, err := s.reconcileCard(ctx, input.CardID, input.Fix, reason)
+		if err != nil {
+			return nil, err
+		}
+		add(rec)
+	} else {
+		var afterID int64
+		for {
+			ids, err := s.repo.ListCardIDs(ctx, afterID, maintenanceBatch)
+			if err != nil {
+				return nil, err
+			}
+			for _, id := range ids {
+				rec, err := s.reconcileCard(ctx, id, input.Fix, reason)
+				if errors.Is(err, ErrCardNotFound) {
+					continue // карту удалили во время сверки
+				}
+				if err != nil {
+					return nil, fmt.Errorf("reconcile card %d: %w", id, err)
+				}
+				add(rec)
+			}
+			if len(ids) < maintenanceBatch {
+				break
+			}
+			afterID = ids[len(ids)-1]
+		}
+	}
+	report.FinishedAt = time.Now()
+	return report, nil
+}
+
+// reconcileCard сверяет карту под блокировкой: операции по ней ждут конца сверки,
+// поэтому история, книга и хранимый баланс читаются согласованно
+func (s *cardService) reconcileCard(ctx context.Context, cardID int64, fix bool, reason string) (*entity.CardReconciliation, error) {
+	var rec *entity.CardReconciliation
+	err := s.repo.RunInTx(ctx, func(repo repository.CardRepository) error {
+		card, err := lockCard(ctx, repo, cardID)
+		if err != nil {
+			return err
+		}
+		rec, err = checkCardBalance(ctx, repo, card)
+		if err != nil || !fix || !rec.HasDrift() {
+			return err
+		}
+		return fixCardBalance(ctx, repo, card, rec, reason)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return rec, nil
+}
+
+func checkCardBalance(ctx context.Context, repo repository.CardRepository, card *entity.Card) (*entity.CardReconciliation, error) {
+	rec := &entity.CardReconciliation{
+		CardID:        card.ID,
+		UserID:        card.UserID,
+		Currency:      card.Currency,
+		StoredBalance: card.Balance,
+		Issues:        []entity.ReconcileIssue{},
+	}
+	ledger, err := repo.GetCardLedgerBalance(ctx, card.ID)
+	if err != nil {
+		return nil, err
+	}
+	rec.LedgerBalance = ledger
+	if rec.AccountBalance, err = repo.GetCardAccountBalance(ctx, card.ID); err != nil {
+		return nil, err
+	}
+
+	var afterID, prev int64
+	for {
+		txs, err := repo.ListCardTransactions(ctx, card.ID, afterID, reconcileBatch)
+		if err != nil {
+			return nil, err
+		}
+		for _, t := range txs {
+			if t.Status != entity.TransactionStatusSuccess {
+				continue
+			}
+			rec.Transactions++
+			amount := statement.SignedAmount(t)
+			if t.BalanceBefore != prev {
+				rec.Issues = append(rec.Issues, entity.ReconcileIssue{
+					Type: entity.ReconcileIssueChainGap, TransactionID: t.ID, Expected: prev, Actual: t.BalanceBefore,
+				})
+			}
+			if t.BalanceAfter != t.BalanceBefore+amount {
+				rec.Issues = append(rec.Issues, entity.ReconcileIssue{
+					Type: entity.ReconcileIssueAmountMismatch, TransactionID: t.ID, Expected: t.BalanceBefore + amount, Actual: t.BalanceAfter,
+				})
+			}
+			prev = t.BalanceAfter
+			rec.ComputedBalance += amount
+		}
+		if len(txs) < reconcileBatch {
+			break
+		}
+		afterID = txs[len(txs)-1].ID
+	}
+
+	if rec.ComputedBalance != ledger {
+		rec.Issues = append(rec.Issues, entity.ReconcileIssue{
+			Type: entity.ReconcileIssueHistoryDrift, Expected: ledger, Actual: rec.ComputedBalance,
+		})
+	}
+	if rec.StoredBalance != ledger {
+		rec.Issues = append(rec.Issues, entity.ReconcileIssue{
+			Type: entity.ReconcileIssueStoredDrift, Expected: ledger, Actual: rec.StoredBalance,
+		})
+	}
+	if rec.AccountBalance != ledger {
+		rec.Issues = append(rec.Issues, entity.ReconcileIssue{
+			Type: entity.ReconcileIssueAccountDrift, Expected: ledger, Actual: rec.AccountBalance,
+		})
+	}
+	return rec, nil
+}
+
+// fixCardBalance приводит историю, хранимый баланс карты и текущий баланс счета к главной книге.
+// Исправляющая транзакция продолжает цепочку от баланса по истории до баланса книги.
+func fixCardBalance(ctx context.Context, repo repository.CardRepository, card *entity.Card,
+	rec *entity.CardReconciliation, reason string) error {
+	if delta := rec.LedgerBalance - rec.ComputedBalance; delta != 0 {
+		txn := &entity.Transaction{
+			CardID:          card.ID,
+			TransactionType: entity.TransactionTypeAdjustmentCredit,
+			Amount:          delta,
+			BalanceBefore:   rec.ComputedBalance,
+			BalanceAfter:    rec.LedgerBalance,
+			Currency:        card.Currency,
+			Description:     "reconciliation: " + reason,
+			Status:          entity.TransactionStatusSuccess,
+		}
+		if delta < 0 {
+			txn.TransactionType = entity.TransactionTypeAdjustmentDebit
+			txn.Amount = -delta
+		}
+		if err := repo.CreateTransaction(ctx, txn); err != nil {
+			return err
+		}
+		if err := rollupTransaction(ctx, repo, txn); err != nil {
+			return err
+		}
+		if err := emitCardEvent(ctx, repo, card, entity.CardEventTransactionCreated, txn); err != nil {
+			return err
+		}
+		rec.AdjustmentTransactionID = txn.ID
+	}
+	if rec.StoredBalance != rec.LedgerBalance {
+		if err := repo.UpdateBalance(ctx, card.ID, rec.LedgerBalance); err != nil {
+			return err
+		}
+		card.Balance = rec.LedgerBalance
+		if err := emitBalanceChanged(ctx, repo, card.ID); err != nil {
+			return err
+		}
+	}
+	if rec.AccountBalance != rec.LedgerBalance {
+		if err := repo.SetCardAccountBalance(ctx, card.ID, rec.LedgerBalance); err != nil {
+			return err
+		}
+	}
+	rec.Fixed = true
+	log.Printf("reconcile: card %d fixed (stored %d, history %d -> ledger %d): %s",
+		card.ID, rec.StoredBalance, rec.ComputedBalance, rec.LedgerBalance, reason)
+	return nil
+}
@@ -0,0 +1,174 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/mrevds/pizza-app/card-service/internal/entity"
+)
+
+func reconcile(t *testing.T, s *cardService, input ReconcileInput) *entity.ReconcileReport {
+	t.Helper()
+	report, err := s.Reconcile(context.Background(), input)
+	if err != nil {
+		t.Fatalf("Reconcile: %v", err)
+	}
+	return report
+}
+
+func issueTypes(rec *entity.CardReconciliation) map[string]int {
+	types := make(map[string]int)
+	for _, i := range rec.Issues {
+		types[i.Type]++
+	}
+	return types
+}
+
+func TestReconcileClean(t *testing.T) {
+	s, repo := newTestService(t)
+	ctx := context.Background()
+	a := addFundedCard(t, s, repo, testUserID, 10_000)
+	b := addFundedCard(t, s, repo, testUserID, 0)
+	if _, _, err := s.Transfer(ctx, TransferInput{UserID: testUserID, FromCardID: a.ID, ToCardID: b.ID, Amount: rub(2_500)}); err != nil {
+		t.Fatalf("Transfer: %v", err)
+	}
+	if _, err := s.Withdraw(ctx, OperationInput{UserID: testUserID, CardID: b.ID, Amount: rub(500)}); err != nil {
+		t.Fatalf("Withdraw: %v", err)
+	}
+
+	report := reconcile(t, s, ReconcileInput{})
+	if report.CardsChecked != 2 || report.CardsWithDrift != 0 || len(report.Cards) != 0 {
+		t.Errorf("report = %+v, want 2 clean cards", report)
+	}
+}
+
+func TestReconcileStoredDrift(t *testing.T) {
+	s, repo := newTestService(t)
+	card := addFundedCard(t, s, repo, testUserID, 10_000)
+	repo.store.cards[card.ID].Balance = 12_345 // правка баланса в обход книги
+
+	report := reconcile(t, s, ReconcileInput{})
+	if report.CardsWithDrift != 1 || len(report.Cards) != 1 {
+		t.Fatalf("report = %+v, want one card with drift", report)
+	}
+	rec := report.Cards[0]
+	if rec.StoredBalance != 12_345 || rec.LedgerBalance != 10_000 || rec.ComputedBalance != 10_000 {
+		t.Errorf("balances = %d/%d/%d, want 12345/10000/10000", rec.StoredBalance, rec.LedgerBalance, rec.ComputedBalance)
+	}
+	if got := issueTypes(rec); got[entity.ReconcileIssueStoredDrift] != 1 || len(got) != 1 {
+		t.Errorf("issues = %v, want stored_drift", rec.Issues)
+	}
+	if repo.store.cards[card.ID].Balance != 12_345 {
+		t.Error("report mode changed the balance")
+	}
+
+	report = reconcile(t, s, ReconcileInput{Fix: true, Reason: "manual balance edit"})
+	if report.CardsFixed != 1 || report.Cards[0].AdjustmentTransactionID != 0 {
+		t.Errorf("fix report = %+v, want fixed without adjustment: history matches the ledger", report.Cards[0])
+	}
+	if got := repo.store.cards[card.ID].Balance; got != 10_000 {
+		t.Errorf("balance after fix = %d, want 10000", got)
+	}
+	if report := reconcile(t, s, ReconcileInput{}); report.CardsWithDrift != 0 {
+		t.Errorf("drift after fix: %+v", report.Cards)
+	}
+}
+
+func TestReconcileAccountDrift(t *testing.T) {
+	s, repo := newTestService(t)
+	card := addFundedCard(t, s, repo, testUserID, 10_000)
+	for _, a := range repo.store.accounts {
+		if a.CardID == card.ID {
+			a.Balance = 9_000 // текущий баланс счета разошелся с проводками
+		}
+	}
+
+	report := reconcile(t, s, ReconcileInput{})
+	if report.CardsWithDrift != 1 || len(report.Cards) != 1 {
+		t.Fatalf("report = %+v, want one card with drift", report)
+	}
+	rec := report.Cards[0]
+	if rec.AccountBalance != 9_000 || rec.LedgerBalance != 10_000 {
+		t.Errorf("account/ledger = %d/%d, want 9000/10000", rec.AccountBalance, rec.LedgerBalance)
+	}
+	if got := issueTypes(rec); got[entity.ReconcileIssueAccountDrift] != 1 || len(got) != 1 {
+		t.Errorf("issues = %v, want account_drift", rec.Issues)
+	}
+
+	report = reconcile(t, s, ReconcileInput{Fix: true, Reason: "running balance drift"})
+	if report.CardsFixed != 1 || report.Cards[0].AdjustmentTransactionID != 0 {
+		t.Errorf("fix report = %+v, want fixed without adjustment", report.Cards[0])
+	}
+	if report := reconcile(t, s, ReconcileInput{}); report.CardsWithDrift != 0 {
+		t.Errorf("drift after fix: %+v", report.Cards)
+	}
+	// Следующая операция продолжает от исправленного баланса
+	if _, err := s.Withdraw(context.Background(), OperationInput{UserID: testUserID, CardID: card.ID, Amount: rub(1_000)}); err != nil {
+		t.Fatalf("Withdraw: %v", err)
+	}
+	if got := repo.store.cards[card.ID].Balance; got != 9_000 {
+		t.Errorf("balance = %d, want 9000", got)
+	}
+}
+
+func TestReconcileHistoryDrift(t *testing.T) {
+	s, repo := newTestService(t)
+	ctx := context.Background()
+	card := addFundedCard(t, s, repo, testUserID, 10_000)
+	withdraw, err := s.Withdraw(ctx, OperationInput{UserID: testUserID, CardID: card.ID, Amount: rub(3_000)})
+	if err != nil {
+		t.Fatalf("Withdraw: %v", err)
+	}
+	if _, err := s.Deposit(ctx, OperationInput{UserID: testUserID, CardID: card.ID, Amount: rub(1_000)}); err != nil {
+		t.Fatalf("Deposit: %v", err)
+	}
+	delete(repo.store.txns, withdraw.ID) // потерянная строка истории
+
+	report := reconcile(t, s, ReconcileInput{CardID: card.ID})
+	if report.CardsWithDrift != 1 {
+		t.Fatalf("report = %+v, want drift", report)
+	}
+	rec := report.Cards[0]
+	if rec.ComputedBalance != 11_000 || rec.LedgerBalance != 8_000 || rec.StoredBalance != 8_000 {
+		t.Errorf("balances = %d/%d/%d, want stored 8000, ledger 8000, computed 11000",
+			rec.StoredBalance, rec.LedgerBalance, rec.ComputedBalance)
+	}
+	if got := issueTypes(rec); got[entity.ReconcileIssueChainGap] != 1 || got[entity.ReconcileIssueHistoryDrift] != 1 {
+		t.Errorf("issues = %v, want chain_gap and history_drift", rec.Issues)
+	}
+
+	if _, err := s.Reconcile(ctx, ReconcileInput{Fix: true}); !errors.Is(err, ErrReconcileReasonRequired) {
+		t.Fatalf("fix without reason err = %v, want ErrReconcileReasonRequired", err)
+	}
+	report = reconcile(t, s, ReconcileInput{Fix: true, Reason: "lost withdraw row"})
+	rec = report.Cards[0]
+	if !rec.Fixed || rec.AdjustmentTransactionID == 0 {
+		t.Fatalf("fix = %+v, want adjustment transaction", rec)
+	}
+	adj := repo.store.txns[rec.AdjustmentTransactionID]
+	if adj.TransactionType != entity.TransactionTypeAdjustmentDebit || adj.Amount != 3_000 ||
+		adj.BalanceBefore != 11_000 || adj.BalanceAfter != 8_000 || adj.Description != "reconciliation: lost withdraw row" {
+		t.Errorf("adjustment = %+v", adj)
+	}
+	if got := repo.store.cards[card.ID].Balance; got != 8_000 {
+		t.Errorf("balance = %d, want 8000: fix must not move money", got)
+	}
+
+	// Разрывы цепочки остаются в отчете (исправление начинается с баланса по истории),
+	// но расхождения с книгой больше нет
+	report = reconcile(t, s, ReconcileInput{})
+	if report.CardsWithDrift != 0 || len(report.Cards) != 1 {
+		t.Fatalf("report after fix = %+v", report)
+	}
+	if got := issueTypes(report.Cards[0]); len(got) != 1 || got[entity.ReconcileIssueChainGap] == 0 {
+		t.Errorf("issues after fix = %v, want only chain_gap", report.Cards[0].Issues)
+	}
+}
+
+func TestReconcileUnknownCard(t *testing.T) {
+	s, _ := newTestService(t)
+	if _, err := s.Reconcile(context.Background(), ReconcileInput{CardID: 404}); !errors.Is(err, ErrCardNotFound) {
+		t.Errorf("err = %v, want ErrCardNotFound", err)
+	}
+}
@@ -0,0 +1,157 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/mrevds/pizza-app/card-service/internal/entity"
+	"github.com/mrevds/pizza-app/card-service/internal/money"
+	"github.com/mrevds/pizza-app/card-service/internal/repository"
+)
+
+// RefundInput - возврат по оплате. Оплата задается TransactionID или OrderID,
+// Amount.UnitsMinor = 0 - вернуть весь невозвращенный остаток
+type RefundInput struct {
+	UserID         int64
+	TransactionID  int64
+	OrderID        string
+	Amount         money.Money
+	Reason         string
+	IdempotencyKey string `json:"-"`
+}
+
+// RefundResult - транзакция возврата и состояние возвратов по оплате после неё
+type RefundResult struct {
+	Refund     *entity.Transaction `json:"refund"`
+	Payment    *entity.Transaction `json:"payment"`
+	Refunded   int64               `json:"refunded"`   // всего возвращено по оплате
+	Refundable int64               `json:"refundable"` // сколько еще можно вернуть
+}
+
+// RefundPayment возвращает на карту всю оплату или её часть. Частичных возвратов
+// может быть несколько, в сумме не больше оплаты.
+func (s *cardService) RefundPayment(ctx context.Context, input RefundInput) (*RefundResult, error) {
+	if input.Amount.UnitsMinor != 0 {
+		amount, err := normalizeAmount(input.Amount)
+		if err != nil {
+			return nil, err
+		}
+		input.Amount = amount
+	}
+	input.OrderID = strings.TrimSpace(input.OrderID)
+	if input.TransactionID == 0 && input.OrderID == "" {
+		return nil, ErrRefundTargetRequired
+	}
+
+	var res RefundResult
+	err := s.runIdempotent(ctx, input.UserID, input.IdempotencyKey, idempotentRefund, input, &res, func(repo repository.CardRepository) error {
+		payment, err := findPayment(ctx, repo, input)
+		if err != nil {
+			return err
+		}
+		// Возвраты по оплате сериализуются блокировкой карты, сумма возвратов
+		// считается уже под ней. Блокировка или истечение карты возврату не мешают.
+		card, err := s.lockOwnedCard(ctx, repo, input.UserID, payment.CardID)
+		if err != nil {
+			if errors.Is(err, ErrCardNotFound) {
+				return ErrTransactionNotFound
+			}
+			return err
+		}
+		if err := checkRefundable(payment); err != nil {
+			return err
+		}
+
+		refunded, err := repo.GetRefundedAmount(ctx, payment.ID)
+		if err != nil {
+			return err
+		}
+		refundable := payment.Amount - refunded
+		if refundable <= 0 {
+			return ErrPaymentFullyRefunded
+		}
+		amount := money.Money{UnitsMinor: refundable, Currency: payment.Currency}
+		if input.Amount.UnitsMinor != 0 {
+			if input.Amount.Currency != payment.Currency {
+				return ErrCurrencyMismatch
+			}
+			if input.Amount.UnitsMinor > refundable {
+				return ErrRefundExceedsPayment
+			}
+			amount = input.Amount
+		}
+		if card.Currency != amount.Currency {
+			return ErrCurrencyMismatch
+		}
+
+		txns, err := applyOperation(ctx, repo, ledgerOperation{
+			Operation:    entity.JournalOperationRefund,
+			Amount:       amount,
+			From:         ledgerSide{Kind: entity.AccountKindMerchantSettlement},
+			To:           ledgerSide{Card: card, TxType: entity.TransactionTypeRefund},
+			Description:  input.Reason,
+			OrderID:      payment.OrderID,
+			Category:     payment.Category,
+			OriginalTxID: payment.ID,
+		})
+		if err != nil {
+			return err
+		}
+		res = RefundResult{
+			Refund:     txns[0],
+			Payment:    payment,
+			Refunded:   refunded + amount.UnitsMinor,
+			Refundable: refundable - amount.UnitsMinor,
+		}
+		return outboxPaymentRefunded(ctx, repo, card, &res)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+// findPayment находит оплату по id или по заказу. Владелец карты проверяется вызывающим.
+func findPayment(ctx context.Context, repo repository.CardRepository, input RefundInput) (*entity.Transaction, error) {
+	if input.TransactionID != 0 {
+		payment, err := repo.GetTransaction(ctx, input.TransactionID)
+		if err != nil {
+			if errors.Is(err, repository.ErrNotFound) {
+				return nil, ErrTransactionNotFound
+			}
+			return nil, err
+		}
+		if input.OrderID != "" && payment.OrderID != input.OrderID {
+			return nil, ErrTransactionNotFound
+		}
+		return payment, nil
+	}
+
+	payments, err := repo.GetOrderPayments(ctx, input.UserID, input.OrderID)
+	if err != nil {
+		return nil, err
+	}
+	switch len(payments) {
+	case 0:
+		return nil, ErrTransactionNotFound
+	case 1:
+		return payments[0], nil
+	default:
+		return nil, ErrAmbiguousOrderPayment
+	}
+}
+
+func checkRefundable(payment *entity.Transaction) error {
+	if payment.TransactionType != entity.TransactionTypePayment {
+		return ErrNotRefundable
+	}
+	switch payment.Status {
+	case entity.TransactionStatusSuccess:
+		return nil
+	case entity.TransactionStatusReversed:
+		return ErrPaymentReversed
+	default:
+		return ErrNotRefundable
+	}
+}
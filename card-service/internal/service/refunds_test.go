@@ -0,0 +1,64 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/mrevds/pizza-app/card-service/internal/entity"
+)
+
+func TestRefundPayment(t *testing.T) {
+	s, repo := newTestService(t)
+	ctx := context.Background()
+	card := addFundedCard(t, s, repo, testUserID, 1_000)
+
+	payment, err := s.ProcessPayment(ctx, PaymentInput{UserID: testUserID, CardID: card.ID, Amount: rub(600), OrderID: "order-1"})
+	if err != nil {
+		t.Fatalf("ProcessPayment: %v", err)
+	}
+
+	// Два частичных возврата: по id оплаты и по заказу
+	res, err := s.RefundPayment(ctx, RefundInput{UserID: testUserID, TransactionID: payment.ID, Amount: rub(200), Reason: "late"})
+	if err != nil {
+		t.Fatalf("RefundPayment: %v", err)
+	}
+	if res.Refund.TransactionType != entity.TransactionTypeRefund || res.Refund.OriginalTxID != payment.ID || res.Refund.OrderID != "order-1" {
+		t.Fatalf("unexpected refund transaction: %+v", res.Refund)
+	}
+	if res.Refunded != 200 || res.Refundable != 400 || res.Refund.BalanceAfter != 600 {
+		t.Fatalf("after first refund: refunded %d, refundable %d, balance %d", res.Refunded, res.Refundable, res.Refund.BalanceAfter)
+	}
+
+	if _, err := s.RefundPayment(ctx, RefundInput{UserID: testUserID, OrderID: "order-1", Amount: rub(500)}); !errors.Is(err, ErrRefundExceedsPayment) {
+		t.Fatalf("refund over payment: got %v, want %v", err, ErrRefundExceedsPayment)
+	}
+	if _, err := s.RefundPayment(ctx, RefundInput{UserID: 2, TransactionID: payment.ID}); !errors.Is(err, ErrTransactionNotFound) {
+		t.Fatalf("refund by other user: got %v, want %v", err, ErrTransactionNotFound)
+	}
+
+	// Без суммы возвращается весь остаток
+	res, err = s.RefundPayment(ctx, RefundInput{UserID: testUserID, OrderID: "order-1"})
+	if err != nil {
+		t.Fatalf("RefundPayment: %v", err)
+	}
+	if res.Refund.Amount != 400 || res.Refundable != 0 || res.Refund.BalanceAfter != 1_000 {
+		t.Fatalf("full refund: amount %d, refundable %d, balance %d", res.Refund.Amount, res.Refundable, res.Refund.BalanceAfter)
+	}
+	if _, err := s.RefundPayment(ctx, RefundInput{UserID: testUserID, TransactionID: payment.ID, Amount: rub(1)}); !errors.Is(err, ErrPaymentFullyRefunded) {
+		t.Fatalf("refund of refunded payment: got %v, want %v", err, ErrPaymentFullyRefunded)
+	}
+
+	// Возврат не по оплате и по отмененной оплате
+	if _, err := s.RefundPayment(ctx, RefundInput{UserID: testUserID, TransactionID: res.Refund.ID}); !errors.Is(err, ErrNotRefundable) {
+		t.Fatalf("refund of refund: got %v, want %v", err, ErrNotRefundable)
+	}
+	reversed, err := s.ProcessPayment(ctx, PaymentInput{UserID: testUserID, CardID: card.ID, Amount: rub(100), OrderID: "order-2"})
+	if err != nil {
+		t.Fatalf("ProcessPayment: %v", err)
+	}
+	repo.store.txns[reversed.ID].Status = entity.TransactionStatusReversed
+	if _, err := s.RefundPayment(ctx, RefundInput{UserID: testUserID, OrderID: "order-2"}); !errors.Is(err, ErrPaymentReversed) {
+		t.Fatalf("refund of reversed payment: got %v, want %v", err, ErrPaymentReversed)
+	}
+}
@@ -0,0 +1,98 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/mrevds/pizza-app/card-service/internal/entity"
+	"github.com/mrevds/pizza-app/card-service/internal/money"
+	"github.com/mrevds/pizza-app/card-service/internal/repository"
+	"github.com/mrevds/pizza-app/card-service/internal/risk"
+)
+
+// riskDenial - списание отклонено оценкой риска. Транзакция операции с ним
+// откатывается, решение сохраняет и карту блокирует commitRiskDenial.
+// errors.Is(err, ErrRiskDenied) для него возвращает true.
+type riskDenial struct {
+	decision *entity.RiskDecision
+}
+
+func (e *riskDenial) Error() string {
+	return ErrRiskDenied.Error()
+}
+
+func (e *riskDenial) Is(target error) bool {
+	return target == ErrRiskDenied
+}
+
+// assessRisk оценивает списание с заблокированной (FOR UPDATE) карты: под блокировкой
+// параллельные списания не обходят правило частоты. Решение allow/review сохраняет
+// recordRisk вместе с операцией, deny возвращается ошибкой *riskDenial.
+// Без движка риска возвращает nil.
+func (s *cardService) assessRisk(ctx context.Context, repo repository.CardRepository, card *entity.Card,
+	txType string, amount money.Money, orderID string) (*entity.RiskDecision, error) {
+	if s.risk == nil {
+		return nil, nil
+	}
+	res, err := s.risk.Evaluate(ctx, repo, risk.Input{
+		Card:            card,
+		TransactionType: txType,
+		Amount:          amount.UnitsMinor,
+		Now:             time.Now(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	decision := &entity.RiskDecision{
+		CardID:          card.ID,
+		UserID:          card.UserID,
+		TransactionType: txType,
+		Amount:          amount.UnitsMinor,
+		Currency:        amount.Currency,
+		OrderID:         orderID,
+		Decision:        res.Decision,
+		Score:           res.Score,
+		Hits:            res.Hits,
+	}
+	if decision.Decision == risk.DecisionDeny {
+		return nil, &riskDenial{decision: decision}
+	}
+	return decision, nil
+}
+
+// recordRisk сохраняет решение по проведенному списанию, txID - его транзакция (0 у холда)
+func recordRisk(ctx context.Context, repo repository.CardRepository, decision *entity.RiskDecision, txID int64) error {
+	if decision == nil {
+		return nil
+	}
+	decision.TransactionID = txID
+	return repo.CreateRiskDecision(ctx, decision)
+}
+
+// commitRiskDenial отдельной транзакцией сохраняет отказ по риску и блокирует карту
+// с причиной fraud. Остальные ошибки возвращает без изменений.
+func (s *cardService) commitRiskDenial(ctx context.Context, err error) error {
+	var denial *riskDenial
+	if !errors.As(err, &denial) {
+		return err
+	}
+	txErr := s.repo.RunInTx(ctx, func(repo repository.CardRepository) error {
+		card, err := lockCard(ctx, repo, denial.decision.CardID)
+		if err != nil {
+			return err
+		}
+		if err := repo.CreateRiskDecision(ctx, denial.decision); err != nil {
+			return err
+		}
+		// Карту могли закрыть или уже заблокировать банком, пока шла оценка
+		if !entity.CanTransition(card.Status, entity.CardStatusBlockedByFraud) {
+			return nil
+		}
+		return changeCardStatus(ctx, repo, card, entity.CardStatusBlockedByFraud, changeActor{Kind: entity.ActorSystem}, entity.BlockReasonFraud)
+	})
+	if txErr != nil {
+		return txErr
+	}
+	return err
+}
@@ -0,0 +1,78 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/mrevds/pizza-app/card-service/internal/entity"
+	"github.com/mrevds/pizza-app/card-service/internal/risk"
+)
+
+func TestRiskDenyBlocksCard(t *testing.T) {
+	s, repo := newTestService(t)
+	s.risk = risk.NewEngine(50, 100,
+		risk.Velocity{Max: 2, Window: time.Minute, Score: 60},
+		risk.NewCard{MaxAge: 24 * time.Hour, Amount: 50, Score: 50},
+	)
+	ctx := context.Background()
+	card := addFundedCard(t, s, repo, testUserID, 100_000)
+	repo.store.cards[card.ID].CreatedAt = time.Now()
+
+	if _, err := s.ProcessPayment(ctx, PaymentInput{UserID: testUserID, CardID: card.ID, Amount: rub(1_000), OrderID: "order-1"}); err != nil {
+		t.Fatalf("ProcessPayment: %v", err)
+	}
+	// Крупная сумма с новой карты и третье списание за минуту - по 1 правилу, на разбор
+	if _, err := s.ProcessPayment(ctx, PaymentInput{UserID: testUserID, CardID: card.ID, Amount: rub(6_000), OrderID: "order-2"}); err != nil {
+		t.Fatalf("ProcessPayment for review: %v", err)
+	}
+	if _, err := s.Withdraw(ctx, OperationInput{UserID: testUserID, CardID: card.ID, Amount: rub(1_000)}); err != nil {
+		t.Fatalf("Withdraw for review: %v", err)
+	}
+	// Оба правила сразу - отказ и блокировка
+	_, err := s.ProcessPayment(ctx, PaymentInput{UserID: testUserID, CardID: card.ID, Amount: rub(6_000), OrderID: "order-3"})
+	if !errors.Is(err, ErrRiskDenied) {
+		t.Fatalf("payment over deny score: got %v", err)
+	}
+
+	decisions := repo.store.risk
+	want := []string{risk.DecisionAllow, risk.DecisionReview, risk.DecisionReview, risk.DecisionDeny}
+	if len(decisions) != len(want) {
+		t.Fatalf("got %d risk decisions, want %d", len(decisions), len(want))
+	}
+	for i, d := range decisions {
+		if d.Decision != want[i] {
+			t.Fatalf("decision %d: got %s (score %d, hits %+v), want %s", i, d.Decision, d.Score, d.Hits, want[i])
+		}
+		if (d.TransactionID == 0) != (d.Decision == risk.DecisionDeny) {
+			t.Fatalf("decision %d: transaction_id %d", i, d.TransactionID)
+		}
+	}
+	if deny := decisions[3]; deny.Score != 110 || len(deny.Hits) != 2 || deny.OrderID != "order-3" {
+		t.Fatalf("unexpected deny decision: %+v", deny)
+	}
+
+	got, err := s.GetCard(ctx, testUserID, card.ID)
+	if err != nil {
+		t.Fatalf("GetCard: %v", err)
+	}
+	if got.Status != entity.CardStatusBlockedByFraud || got.BlockReason != entity.BlockReasonFraud {
+		t.Fatalf("card after deny: status %s, reason %q", got.Status, got.BlockReason)
+	}
+	// Отклоненное списание не проведено
+	if got.Balance != 100_000-8_000 {
+		t.Fatalf("balance %d, want %d", got.Balance, 100_000-8_000)
+	}
+	history, _ := s.GetCardStatusHistory(ctx, testUserID, card.ID)
+	if last := history[len(history)-1]; last.Actor != entity.ActorSystem || last.Reason != entity.BlockReasonFraud {
+		t.Fatalf("last status change: %+v", last)
+	}
+
+	if _, err := s.ProcessPayment(ctx, PaymentInput{UserID: testUserID, CardID: card.ID, Amount: rub(100), OrderID: "order-4"}); !errors.Is(err, ErrCardBlocked) {
+		t.Fatalf("payment from fraud blocked card: got %v", err)
+	}
+	if err := s.UnblockCard(ctx, testUserID, card.ID); !errors.Is(err, ErrUnblockForbidden) {
+		t.Fatalf("user unblock after fraud block: got %v", err)
+	}
+}
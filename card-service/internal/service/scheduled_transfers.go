@@ -0,0 +1,418 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+	_ "time/tzdata" // пояса расписаний не зависят от tzdata в образе
+
+	"github.com/mrevds/pizza-app/card-service/internal/cron"
+	"github.com/mrevds/pizza-app/card-service/internal/entity"
+	"github.com/mrevds/pizza-app/card-service/internal/money"
+	"github.com/mrevds/pizza-app/card-service/internal/notify"
+	"github.com/mrevds/pizza-app/card-service/internal/repository"
+)
+
+const (
+	// maxScheduleAhead - на сколько вперед можно запланировать разовый перевод
+	maxScheduleAhead = 366 * 24 * time.Hour
+	// scheduledRunsShown - сколько последних запусков отдает ListScheduledTransfers
+	scheduledRunsShown = 5
+	// maxScheduledErrorBackoff - самая длинная пауза перед повтором после внутренней ошибки
+	maxScheduledErrorBackoff = 24 * time.Hour
+)
+
+// ScheduledTransferInput - новый запланированный перевод. Задается ровно одно из
+// RunAt (разовый) и Cron (повторяющийся, в поясе Timezone, по умолчанию UTC).
+type ScheduledTransferInput struct {
+	UserID      int64
+	FromCardID  int64 // 0 - пополнение ToCardID
+	ToCardID    int64
+	Amount      money.Money
+	Description string
+	RunAt       time.Time
+	Cron        string
+	Timezone    string
+}
+
+func (s *cardService) CreateScheduledTransfer(ctx context.Context, input ScheduledTransferInput) (*entity.ScheduledTransfer, error) {
+	amount, err := normalizeAmount(input.Amount)
+	if err != nil {
+		return nil, err
+	}
+	if input.FromCardID == input.ToCardID {
+		return nil, ErrSameCard
+	}
+	st := &entity.ScheduledTransfer{
+		UserID:      input.UserID,
+		FromCardID:  input.FromCardID,
+		ToCardID:    input.ToCardID,
+		Amount:      amount.UnitsMinor,
+		Currency:    amount.Currency,
+		Description: input.Description,
+		Cron:        strings.TrimSpace(input.Cron),
+		Timezone:    strings.TrimSpace(input.Timezone),
+		Status:      entity.ScheduledStatusActive,
+	}
+	if st.Timezone == "" {
+		st.Timezone = "UTC"
+	}
+	if st.NextRunAt, err = firstRun(st, input.RunAt, time.Now()); err != nil {
+		return nil, err
+	}
+
+	// Карты проверяются сразу, чтобы ошибка была видна при создании, а не при первом запуске.
+	// Пополнять можно только свою карту, переводить - на любую.
+	if st.FromCardID != 0 {
+		from, err := s.getOwnedCard(ctx, s.repo, input.UserID, st.FromCardID)
+		if err != nil {
+			return nil, err
+		}
+		if err := checkCardOperation(from, amount); err != nil {
+			return nil, err
+		}
+	}
+	var to *entity.Card
+	if st.IsDeposit() {
+		to, err = s.getOwnedCard(ctx, s.repo, input.UserID, st.ToCardID)
+	} else {
+		to, err = s.repo.GetCard(ctx, st.ToCardID)
+		if errors.Is(err, repository.ErrNotFound) {
+			err = ErrCardNotFound
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := checkCardOperation(to, amount); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.CreateScheduledTransfer(ctx, st); err != nil {
+		return nil, err
+	}
+	return st, nil
+}
+
+// firstRun проверяет расписание и возвращает время первого запуска
+func firstRun(st *entity.ScheduledTransfer, runAt, now time.Time) (time.Time, error) {
+	switch {
+	case runAt.IsZero() && st.Cron == "":
+		return time.Time{}, fmt.Errorf("%w: run_at or cron is required", ErrInvalidSchedule)
+	case !runAt.IsZero() && st.Cron != "":
+		return time.Time{}, fmt.Errorf("%w: run_at and cron are mutually exclusive", ErrInvalidSchedule)
+	case st.Cron == "":
+		if !runAt.After(now) {
+			return time.Time{}, fmt.Errorf("%w: run_at is in the past", ErrInvalidSchedule)
+		}
+		if runAt.After(now.Add(maxScheduleAhead)) {
+			return time.Time{}, fmt.Errorf("%w: run_at is more than a year ahead", ErrInvalidSchedule)
+		}
+		return runAt, nil
+	}
+	next, err := nextCronRun(st, now)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if next.IsZero() {
+		return time.Time{}, fmt.Errorf("%w: cron %q never fires", ErrInvalidSchedule, st.Cron)
+	}
+	return next, nil
+}
+
+// nextCronRun - первый запуск по Cron после after, нулевое время если его нет
+func nextCronRun(st *entity.ScheduledTransfer, after time.Time) (time.Time, error) {
+	loc, err := time.LoadLocation(st.Timezone)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%w: unknown timezone %q", ErrInvalidSchedule, st.Timezone)
+	}
+	sched, err := cron.Parse(st.Cron)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%w: %v", ErrInvalidSchedule, err)
+	}
+	return sched.Next(after.In(loc)), nil
+}
+
+// ListScheduledTransfers возвращает переводы пользователя с последними запусками.
+// Без includeFinished - только активные.
+func (s *cardService) ListScheduledTransfers(ctx context.Context, userID int64, includeFinished bool) ([]*entity.ScheduledTransfer, error) {
+	transfers, err := s.repo.ListUserScheduledTransfers(ctx, userID, !includeFinished)
+	if err != nil {
+		return nil, err
+	}
+	for _, st := range transfers {
+		if st.Runs, err = s.repo.ListScheduledTransferRuns(ctx, st.ID, scheduledRunsShown); err != nil {
+			return nil, err
+		}
+	}
+	return transfers, nil
+}
+
+func (s *cardService) CancelScheduledTransfer(ctx context.Context, userID, id int64) (*entity.ScheduledTransfer, error) {
+	var st *entity.ScheduledTransfer
+	err := s.repo.RunInTx(ctx, func(repo repository.CardRepository) error {
+		var err error
+		st, err = repo.GetScheduledTransferForUpdate(ctx, id)
+		if errors.Is(err, repository.ErrNotFound) || err == nil && st.UserID != userID {
+			return ErrScheduledTransferNotFound
+		}
+		if err != nil {
+			return err
+		}
+		if st.Status != entity.ScheduledStatusActive {
+			return ErrScheduledTransferNotActive
+		}
+		st.Status = entity.ScheduledStatusCancelled
+		return repo.UpdateScheduledTransfer(ctx, st)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return st, nil
+}
+
+// RunScheduledTransfers выполняет наступившие запуски, вызывается фоновой задачей.
+// Возвращает число выполненных запусков, в том числе неудачных. Запуски с внутренней
+// ошибкой не считаются: ошибка пишется в перевод, и пачка идет дальше.
+func (s *cardService) RunScheduledTransfers(ctx context.Context) (int, error) {
+	runs := 0
+	var afterID int64
+	for {
+		now := time.Now()
+		due, err := s.repo.ListDueScheduledTransfers(ctx, now, afterID, maintenanceBatch)
+		if err != nil {
+			return runs, err
+		}
+		for _, st := range due {
+			scheduledFor := st.NextRunAt
+			if err := s.runScheduledTransfer(ctx, st, now); err != nil {
+				if ctx.Err() != nil {
+					return runs, ctx.Err()
+				}
+				// Одна сломанная запись не останавливает остальные переводы пачки
+				log.Printf("scheduled transfer %d: %v", st.ID, err)
+				if err := s.recordScheduledError(ctx, st.ID, scheduledFor, err, now); err != nil {
+					log.Printf("scheduled transfer %d: failed to record error: %v", st.ID, err)
+				}
+				continue
+			}
+			runs++
+		}
+		if len(due) < maintenanceBatch {
+			return runs, nil
+		}
+		afterID = due[len(due)-1].ID
+	}
+}
+
+// runScheduledTransfer проводит перевод через Transfer или Deposit и записывает результат.
+// Ключ идемпотентности - перевод и время запуска: если результат не записался,
+// следующий тик повторит тот же запуск и получит сохраненный ответ, а не второе списание.
+// Ошибки, не связанные с картами и деньгами, не записываются в запуски: их сохраняет
+// recordScheduledError, и запуск повторяется с тем же ключом.
+func (s *cardService) runScheduledTransfer(ctx context.Context, st *entity.ScheduledTransfer, now time.Time) error {
+	scheduledFor := st.NextRunAt
+	txnID, runErr := s.executeScheduledTransfer(ctx, st, scheduledRunKey(st))
+	code, retryable := scheduledErrorCode(runErr)
+	if runErr != nil && code == "" {
+		return runErr
+	}
+
+	run := &entity.ScheduledTransferRun{
+		ScheduledTransferID: st.ID,
+		ScheduledFor:        scheduledFor,
+		TransactionID:       txnID,
+		ErrorCode:           code,
+	}
+	err := s.repo.RunInTx(ctx, func(repo repository.CardRepository) error {
+		cur, err := repo.GetScheduledTransferForUpdate(ctx, st.ID)
+		if err != nil {
+			return err
+		}
+		run.Attempt = cur.Attempt + 1
+		switch {
+		case runErr == nil:
+			run.Status = entity.ScheduledRunSuccess
+		case retryable && cur.Attempt < s.cfg.Scheduler.ScheduledTransferRetries:
+			run.Status = entity.ScheduledRunRetry
+		default:
+			run.Status = entity.ScheduledRunFailed
+		}
+		if runErr != nil {
+			run.Error = runErr.Error()
+		}
+		if err := repo.CreateScheduledTransferRun(ctx, run); err != nil {
+			return err
+		}
+		// Перевод отменили, пока шел запуск: результат записан, расписание не трогаем
+		if cur.Status != entity.ScheduledStatusActive || !cur.NextRunAt.Equal(scheduledFor) {
+			*st = *cur
+			return nil
+		}
+		if err := advanceSchedule(cur, run, now, s.cfg.Scheduler.ScheduledTransferRetryDelay); err != nil {
+			return err
+		}
+		*st = *cur
+		return repo.UpdateScheduledTransfer(ctx, cur)
+	})
+	if err != nil {
+		return err
+	}
+	s.notifyScheduledRun(ctx, st, run)
+	return nil
+}
+
+// recordScheduledError сохраняет внутреннюю ошибку запуска scheduledFor и откладывает повтор.
+// NextRunAt не меняется: повтор должен прийти с тем же ключом идемпотентности, иначе
+// перевод, проведенный до ошибки записи результата, прошел бы второй раз.
+func (s *cardService) recordScheduledError(ctx context.Context, id int64, scheduledFor time.Time, runErr error, now time.Time) error {
+	return s.repo.RunInTx(ctx, func(repo repository.CardRepository) error {
+		st, err := repo.GetScheduledTransferForUpdate(ctx, id)
+		if err != nil {
+			return err
+		}
+		// Перевод отменили или запуск успел записаться
+		if st.Status != entity.ScheduledStatusActive || !st.NextRunAt.Equal(scheduledFor) {
+			return nil
+		}
+		st.LastError = runErr.Error()
+		st.ErrorCount++
+		retryAt := now.Add(scheduledErrorBackoff(s.cfg.Scheduler.ScheduledTransferRetryDelay, st.ErrorCount))
+		st.RetryAt = &retryAt
+		return repo.UpdateScheduledTransfer(ctx, st)
+	})
+}
+
+// scheduledErrorBackoff - пауза после errorCount внутренних ошибок подряд: delay, 2*delay, 4*delay...
+// но не больше maxScheduledErrorBackoff
+func scheduledErrorBackoff(delay time.Duration, errorCount int) time.Duration {
+	backoff := delay
+	for i := 1; i < errorCount && backoff < maxScheduledErrorBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > maxScheduledErrorBackoff {
+		return maxScheduledErrorBackoff
+	}
+	return backoff
+}
+
+// scheduledRunKey - ключ идемпотентности запуска: у каждого повтора свое время запуска
+func scheduledRunKey(st *entity.ScheduledTransfer) string {
+	return fmt.Sprintf("scheduled-transfer:%d:%d", st.ID, st.NextRunAt.Unix())
+}
+
+func (s *cardService) executeScheduledTransfer(ctx context.Context, st *entity.ScheduledTransfer, key string) (int64, error) {
+	amount := money.Money{UnitsMinor: st.Amount, Currency: st.Currency}
+	if st.IsDeposit() {
+		txn, err := s.Deposit(ctx, OperationInput{
+			UserID:         st.UserID,
+			CardID:         st.ToCardID,
+			Amount:         amount,
+			Description:    st.Description,
+			IdempotencyKey: key,
+		})
+		if err != nil {
+			return 0, err
+		}
+		return txn.ID, nil
+	}
+	from, _, err := s.Transfer(ctx, TransferInput{
+		UserID:         st.UserID,
+		FromCardID:     st.FromCardID,
+		ToCardID:       st.ToCardID,
+		Amount:         amount,
+		Description:    st.Description,
+		IdempotencyKey: key,
+	})
+	if err != nil {
+		return 0, err
+	}
+	return from.ID, nil
+}
+
+// scheduledErrorCode - причина неудачного запуска и можно ли его повторить.
+// Пустой код - ошибка не про карты и деньги.
+func scheduledErrorCode(err error) (code string, retryable bool) {
+	switch {
+	case err == nil:
+		return "", false
+	case errors.Is(err, ErrInsufficientFunds):
+		return entity.ScheduledErrorInsufficientFunds, true
+	case errors.Is(err, ErrLimitExceeded):
+		return entity.ScheduledErrorLimitExceeded, true
+	case errors.Is(err, ErrCardBlocked):
+		return entity.ScheduledErrorCardBlocked, false
+	case errors.Is(err, ErrRiskDenied):
+		return entity.ScheduledErrorRiskDenied, false
+	case errors.Is(err, ErrCardNotFound),
+		errors.Is(err, ErrCardInactive),
+		errors.Is(err, ErrCardExpired),
+		errors.Is(err, ErrCurrencyMismatch):
+		return entity.ScheduledErrorCardUnavailable, false
+	}
+	return "", false
+}
+
+// advanceSchedule переводит расписание к следующему запуску по результату run.
+// Неудачный запуск повторяется через retryDelay, пока не кончатся попытки, потом пропускается.
+// Пропущенные, пока сервис не работал, запуски по Cron не наверстываются.
+func advanceSchedule(st *entity.ScheduledTransfer, run *entity.ScheduledTransferRun, now time.Time, retryDelay time.Duration) error {
+	st.LastRunAt = &now
+	st.LastError, st.ErrorCount, st.RetryAt = "", 0, nil
+	if run.Status == entity.ScheduledRunRetry {
+		st.Attempt++
+		st.NextRunAt = now.Add(retryDelay)
+		return nil
+	}
+	st.Attempt = 0
+	switch {
+	case run.ErrorCode == entity.ScheduledErrorCardUnavailable:
+		// Карта закрыта или истекла - следующие запуски тоже не пройдут
+		st.Status = entity.ScheduledStatusFailed
+	case !st.IsRecurring() && run.Status == entity.ScheduledRunSuccess:
+		st.Status = entity.ScheduledStatusCompleted
+	case !st.IsRecurring():
+		st.Status = entity.ScheduledStatusFailed
+	default:
+		next, err := nextCronRun(st, now)
+		if err != nil {
+			return err
+		}
+		if next.IsZero() {
+			st.Status = entity.ScheduledStatusCompleted
+			return nil
+		}
+		st.NextRunAt = next
+	}
+	return nil
+}
+
+// notifyScheduledRun сообщает владельцу об итоге запуска. О повторах не сообщает:
+// итог будет после последней попытки. Ошибка уведомления не отменяет записанный результат.
+func (s *cardService) notifyScheduledRun(ctx context.Context, st *entity.ScheduledTransfer, run *entity.ScheduledTransferRun) {
+	if run.Status == entity.ScheduledRunRetry {
+		return
+	}
+	amount := money.Money{UnitsMinor: st.Amount, Currency: st.Currency}
+	n := notify.Notification{
+		Kind:   notify.KindScheduledTransferCompleted,
+		CardID: st.FromCardID,
+		Text:   fmt.Sprintf("Запланированный перевод #%d на %s выполнен", st.ID, amount),
+	}
+	if st.IsDeposit() {
+		n.CardID = st.ToCardID
+	}
+	if run.Status == entity.ScheduledRunFailed {
+		n.Kind = notify.KindScheduledTransferFailed
+		n.Text = fmt.Sprintf("Запланированный перевод #%d на %s не выполнен: %s", st.ID, amount, run.ErrorCode)
+		if st.Status == entity.ScheduledStatusFailed && st.IsRecurring() {
+			n.Text += ", расписание остановлено"
+		}
+	}
+	if err := s.notifier.Notify(ctx, st.UserID, n); err != nil {
+		log.Printf("scheduled transfer %d: failed to notify user %d: %v", st.ID, st.UserID, err)
+	}
+}
@@ -0,0 +1,335 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/mrevds/pizza-app/card-service/internal/entity"
+	"github.com/mrevds/pizza-app/card-service/internal/notify"
+)
+
+func newScheduledTestService(t *testing.T) (*cardService, *memRepo, *recordingNotifier) {
+	t.Helper()
+	s, repo := newTestService(t)
+	notifier := &recordingNotifier{}
+	s.notifier = notifier
+	s.cfg.Scheduler.ScheduledTransferRetries = 1
+	s.cfg.Scheduler.ScheduledTransferRetryDelay = time.Hour
+	return s, repo, notifier
+}
+
+// makeDue переносит запуск в прошлое, как будто его время наступило
+func makeDue(repo *memRepo, id int64) time.Time {
+	at := time.Now().Add(-time.Minute).Truncate(time.Second)
+	repo.store.scheduled[id].NextRunAt = at
+	return at
+}
+
+func runScheduled(t *testing.T, s *cardService, want int) {
+	t.Helper()
+	n, err := s.RunScheduledTransfers(context.Background())
+	if err != nil {
+		t.Fatalf("RunScheduledTransfers: %v", err)
+	}
+	if n != want {
+		t.Fatalf("RunScheduledTransfers ran %d, want %d", n, want)
+	}
+}
+
+func TestCreateScheduledTransferValidation(t *testing.T) {
+	s, repo, _ := newScheduledTestService(t)
+	from := addFundedCard(t, s, repo, testUserID, 1_000)
+	to := addFundedCard(t, s, repo, 2, 0)
+	usd := addFundedCard(t, s, repo, testUserID, 0)
+	repo.store.cards[usd.ID].Currency = "USD"
+	tomorrow := time.Now().Add(24 * time.Hour)
+
+	cases := []struct {
+		name  string
+		input ScheduledTransferInput
+		want  error
+	}{
+		{"no schedule", ScheduledTransferInput{FromCardID: from.ID, ToCardID: to.ID}, ErrInvalidSchedule},
+		{"run_at and cron", ScheduledTransferInput{FromCardID: from.ID, ToCardID: to.ID, RunAt: tomorrow, Cron: "@daily"}, ErrInvalidSchedule},
+		{"run_at in the past", ScheduledTransferInput{FromCardID: from.ID, ToCardID: to.ID, RunAt: time.Now().Add(-time.Minute)}, ErrInvalidSchedule},
+		{"run_at too far", ScheduledTransferInput{FromCardID: from.ID, ToCardID: to.ID, RunAt: time.Now().AddDate(2, 0, 0)}, ErrInvalidSchedule},
+		{"bad cron", ScheduledTransferInput{FromCardID: from.ID, ToCardID: to.ID, Cron: "0 25 * * *"}, ErrInvalidSchedule},
+		{"cron never fires", ScheduledTransferInput{FromCardID: from.ID, ToCardID: to.ID, Cron: "0 0 30 2 *"}, ErrInvalidSchedule},
+		{"bad timezone", ScheduledTransferInput{FromCardID: from.ID, ToCardID: to.ID, Cron: "@daily", Timezone: "Mars/Olympus"}, ErrInvalidSchedule},
+		{"same card", ScheduledTransferInput{FromCardID: from.ID, ToCardID: from.ID, Cron: "@daily"}, ErrSameCard},
+		{"foreign source card", ScheduledTransferInput{FromCardID: to.ID, ToCardID: from.ID, Cron: "@daily"}, ErrCardNotFound},
+		{"deposit to foreign card", ScheduledTransferInput{ToCardID: to.ID, Cron: "@daily"}, ErrCardNotFound},
+		{"currency mismatch", ScheduledTransferInput{FromCardID: from.ID, ToCardID: usd.ID, Cron: "@daily"}, ErrCurrencyMismatch},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.input.UserID = testUserID
+			tc.input.Amount = rub(500)
+			if _, err := s.CreateScheduledTransfer(context.Background(), tc.input); !errors.Is(err, tc.want) {
+				t.Fatalf("err = %v, want %v", err, tc.want)
+			}
+		})
+	}
+	if len(repo.store.scheduled) != 0 {
+		t.Errorf("%d scheduled transfers created, want none", len(repo.store.scheduled))
+	}
+}
+
+func TestScheduledTransferOneShot(t *testing.T) {
+	s, repo, notifier := newScheduledTestService(t)
+	ctx := context.Background()
+	from := addFundedCard(t, s, repo, testUserID, 1_000)
+	to := addFundedCard(t, s, repo, 2, 0)
+
+	st, err := s.CreateScheduledTransfer(ctx, ScheduledTransferInput{
+		UserID: testUserID, FromCardID: from.ID, ToCardID: to.ID, Amount: rub(400), RunAt: time.Now().Add(24 * time.Hour),
+	})
+	if err != nil {
+		t.Fatalf("CreateScheduledTransfer: %v", err)
+	}
+	runScheduled(t, s, 0) // время еще не пришло
+
+	makeDue(repo, st.ID)
+	runScheduled(t, s, 1)
+	if got := repo.store.cards[from.ID].Balance; got != 600 {
+		t.Errorf("from balance = %d, want 600", got)
+	}
+	if got := repo.store.cards[to.ID].Balance; got != 400 {
+		t.Errorf("to balance = %d, want 400", got)
+	}
+	stored := repo.store.scheduled[st.ID]
+	if stored.Status != entity.ScheduledStatusCompleted || stored.LastRunAt == nil {
+		t.Errorf("scheduled transfer = %+v, want completed", stored)
+	}
+	runs := repo.store.scheduledRuns
+	if len(runs) != 1 || runs[0].Status != entity.ScheduledRunSuccess || runs[0].TransactionID == 0 || runs[0].Attempt != 1 {
+		t.Fatalf("runs = %+v, want one success", runs)
+	}
+	if len(notifier.sent) != 1 || notifier.sent[0].Kind != notify.KindScheduledTransferCompleted || notifier.sent[0].CardID != from.ID {
+		t.Errorf("notifications = %+v", notifier.sent)
+	}
+	runScheduled(t, s, 0)
+}
+
+func TestScheduledTransferRetryPolicy(t *testing.T) {
+	s, repo, notifier := newScheduledTestService(t)
+	ctx := context.Background()
+	from := addFundedCard(t, s, repo, testUserID, 100)
+	to := addFundedCard(t, s, repo, testUserID, 0)
+
+	st, err := s.CreateScheduledTransfer(ctx, ScheduledTransferInput{
+		UserID: testUserID, FromCardID: from.ID, ToCardID: to.ID, Amount: rub(500),
+		Cron: "0 9 * * MON", Timezone: "Europe/Moscow",
+	})
+	if err != nil {
+		t.Fatalf("CreateScheduledTransfer: %v", err)
+	}
+	moscow, _ := time.LoadLocation("Europe/Moscow")
+	if next := st.NextRunAt.In(moscow); next.Weekday() != time.Monday || next.Hour() != 9 {
+		t.Fatalf("NextRunAt = %v, want Monday 9:00 MSK", next)
+	}
+
+	// Первая попытка: денег нет - повтор через retry delay, без уведомления
+	makeDue(repo, st.ID)
+	runScheduled(t, s, 1)
+	stored := repo.store.scheduled[st.ID]
+	if stored.Attempt != 1 || stored.Status != entity.ScheduledStatusActive || time.Until(stored.NextRunAt) < 59*time.Minute {
+		t.Fatalf("after first failure: %+v, want retry in an hour", stored)
+	}
+	if run := repo.store.scheduledRuns[0]; run.Status != entity.ScheduledRunRetry || run.ErrorCode != entity.ScheduledErrorInsufficientFunds {
+		t.Fatalf("run = %+v, want retry insufficient_funds", run)
+	}
+	if len(notifier.sent) != 0 {
+		t.Fatalf("notified about a retry: %+v", notifier.sent)
+	}
+
+	// Попытки кончились - запуск пропущен, расписание идет дальше
+	makeDue(repo, st.ID)
+	runScheduled(t, s, 1)
+	stored = repo.store.scheduled[st.ID]
+	if stored.Attempt != 0 || stored.Status != entity.ScheduledStatusActive || stored.NextRunAt.In(moscow).Weekday() != time.Monday {
+		t.Fatalf("after last failure: %+v, want next Monday", stored)
+	}
+	if run := repo.store.scheduledRuns[1]; run.Status != entity.ScheduledRunFailed || run.Attempt != 2 {
+		t.Fatalf("run = %+v, want failed second attempt", run)
+	}
+	if len(notifier.sent) != 1 || notifier.sent[0].Kind != notify.KindScheduledTransferFailed {
+		t.Fatalf("notifications = %+v, want one failure", notifier.sent)
+	}
+
+	// Следующий запуск с деньгами на карте проходит
+	if _, err := s.Deposit(ctx, OperationInput{UserID: testUserID, CardID: from.ID, Amount: rub(1_000)}); err != nil {
+		t.Fatalf("Deposit: %v", err)
+	}
+	makeDue(repo, st.ID)
+	runScheduled(t, s, 1)
+	if got := repo.store.cards[to.ID].Balance; got != 500 {
+		t.Errorf("to balance = %d, want 500", got)
+	}
+	transfers, err := s.ListScheduledTransfers(ctx, testUserID, false)
+	if err != nil {
+		t.Fatalf("ListScheduledTransfers: %v", err)
+	}
+	if len(transfers) != 1 || len(transfers[0].Runs) != 3 || transfers[0].Runs[0].Status != entity.ScheduledRunSuccess {
+		t.Fatalf("transfers = %+v, want one with 3 runs, newest success", transfers)
+	}
+}
+
+func TestScheduledTransferCardProblems(t *testing.T) {
+	s, repo, notifier := newScheduledTestService(t)
+	ctx := context.Background()
+	blocked := addFundedCard(t, s, repo, testUserID, 0)
+	closed := addFundedCard(t, s, repo, testUserID, 0)
+	topUp := func(cardID int64) *entity.ScheduledTransfer {
+		st, err := s.CreateScheduledTransfer(ctx, ScheduledTransferInput{
+			UserID: testUserID, ToCardID: cardID, Amount: rub(500), Cron: "@daily",
+		})
+		if err != nil {
+			t.Fatalf("CreateScheduledTransfer: %v", err)
+		}
+		return st
+	}
+	onBlocked, onClosed := topUp(blocked.ID), topUp(closed.ID)
+	repo.store.cards[blocked.ID].Status = entity.CardStatusBlockedByUser
+	now := time.Now()
+	repo.store.cards[closed.ID].DeletedAt = &now
+
+	makeDue(repo, onBlocked.ID)
+	makeDue(repo, onClosed.ID)
+	runScheduled(t, s, 2)
+
+	// Блокировка может быть снята - запуск пропускается, расписание остается
+	if st := repo.store.scheduled[onBlocked.ID]; st.Status != entity.ScheduledStatusActive || !st.NextRunAt.After(now) {
+		t.Errorf("blocked card schedule = %+v, want active", st)
+	}
+	// Закрытая карта - расписание останавливается
+	if st := repo.store.scheduled[onClosed.ID]; st.Status != entity.ScheduledStatusFailed {
+		t.Errorf("closed card schedule = %+v, want failed", st)
+	}
+	codes := map[int64]string{}
+	for _, run := range repo.store.scheduledRuns {
+		codes[run.ScheduledTransferID] = run.ErrorCode
+	}
+	if codes[onBlocked.ID] != entity.ScheduledErrorCardBlocked || codes[onClosed.ID] != entity.ScheduledErrorCardUnavailable {
+		t.Errorf("error codes = %v", codes)
+	}
+	if len(notifier.sent) != 2 {
+		t.Errorf("notifications = %+v, want two failures", notifier.sent)
+	}
+}
+
+func TestScheduledTransferRerunIsIdempotent(t *testing.T) {
+	s, repo, _ := newScheduledTestService(t)
+	ctx := context.Background()
+	card := addFundedCard(t, s, repo, testUserID, 0)
+	st, err := s.CreateScheduledTransfer(ctx, ScheduledTransferInput{
+		UserID: testUserID, ToCardID: card.ID, Amount: rub(300), RunAt: time.Now().Add(time.Hour),
+	})
+	if err != nil {
+		t.Fatalf("CreateScheduledTransfer: %v", err)
+	}
+	due := makeDue(repo, st.ID)
+	st.NextRunAt = due
+
+	// Прошлый тик провел пополнение, но упал до записи результата
+	if _, err := s.executeScheduledTransfer(ctx, st, scheduledRunKey(st)); err != nil {
+		t.Fatalf("executeScheduledTransfer: %v", err)
+	}
+	runScheduled(t, s, 1)
+	if got := repo.store.cards[card.ID].Balance; got != 300 {
+		t.Fatalf("balance = %d, want 300: the rerun must replay the first deposit", got)
+	}
+	if st := repo.store.scheduled[st.ID]; st.Status != entity.ScheduledStatusCompleted {
+		t.Errorf("status = %s, want completed", st.Status)
+	}
+}
+
+func TestScheduledTransferInternalErrorDoesNotStopBatch(t *testing.T) {
+	s, repo, notifier := newScheduledTestService(t)
+	ctx := context.Background()
+	card := addFundedCard(t, s, repo, testUserID, 0)
+	topUp := func() *entity.ScheduledTransfer {
+		st, err := s.CreateScheduledTransfer(ctx, ScheduledTransferInput{
+			UserID: testUserID, ToCardID: card.ID, Amount: rub(300), RunAt: time.Now().Add(time.Hour),
+		})
+		if err != nil {
+			t.Fatalf("CreateScheduledTransfer: %v", err)
+		}
+		return st
+	}
+	broken, ok := topUp(), topUp()
+	due := makeDue(repo, broken.ID)
+	makeDue(repo, ok.ID)
+	// Ошибка, которую scheduledErrorCode не знает, - как сбой базы
+	repo.store.scheduled[broken.ID].Amount = 0
+
+	runScheduled(t, s, 1)
+	if got := repo.store.cards[card.ID].Balance; got != 300 {
+		t.Fatalf("balance = %d, want 300 from the second transfer", got)
+	}
+	stored := repo.store.scheduled[broken.ID]
+	if stored.Status != entity.ScheduledStatusActive || !stored.NextRunAt.Equal(due) || stored.ErrorCount != 1 ||
+		stored.LastError == "" || stored.RetryAt == nil || time.Until(*stored.RetryAt) < 59*time.Minute {
+		t.Fatalf("broken transfer = %+v, want error recorded and retry in an hour with the same next_run_at", stored)
+	}
+	if len(repo.store.scheduledRuns) != 1 || len(notifier.sent) != 1 {
+		t.Fatalf("runs = %+v, notifications = %+v, want only the second transfer", repo.store.scheduledRuns, notifier.sent)
+	}
+	runScheduled(t, s, 0) // повтор еще не наступил
+
+	// Вторая ошибка подряд - пауза вдвое длиннее
+	past := time.Now().Add(-time.Minute)
+	stored.RetryAt = &past
+	runScheduled(t, s, 0)
+	if stored := repo.store.scheduled[broken.ID]; stored.ErrorCount != 2 || time.Until(*stored.RetryAt) < 119*time.Minute {
+		t.Fatalf("after second error: %+v, want retry in two hours", stored)
+	}
+
+	// После исправления повтор проходит, ошибка стирается
+	stored = repo.store.scheduled[broken.ID]
+	stored.Amount = 300
+	stored.RetryAt = &past
+	runScheduled(t, s, 1)
+	stored = repo.store.scheduled[broken.ID]
+	if stored.Status != entity.ScheduledStatusCompleted || stored.ErrorCount != 0 || stored.LastError != "" || stored.RetryAt != nil {
+		t.Errorf("after retry: %+v, want completed without error", stored)
+	}
+	if got := repo.store.cards[card.ID].Balance; got != 600 {
+		t.Errorf("balance = %d, want 600", got)
+	}
+}
+
+func TestCancelScheduledTransfer(t *testing.T) {
+	s, repo, _ := newScheduledTestService(t)
+	ctx := context.Background()
+	card := addFundedCard(t, s, repo, testUserID, 0)
+	st, err := s.CreateScheduledTransfer(ctx, ScheduledTransferInput{
+		UserID: testUserID, ToCardID: card.ID, Amount: rub(300), Cron: "@weekly",
+	})
+	if err != nil {
+		t.Fatalf("CreateScheduledTransfer: %v", err)
+	}
+
+	if _, err := s.CancelScheduledTransfer(ctx, 2, st.ID); !errors.Is(err, ErrScheduledTransferNotFound) {
+		t.Fatalf("cancel by another user: err = %v, want ErrScheduledTransferNotFound", err)
+	}
+	cancelled, err := s.CancelScheduledTransfer(ctx, testUserID, st.ID)
+	if err != nil || cancelled.Status != entity.ScheduledStatusCancelled {
+		t.Fatalf("CancelScheduledTransfer = %+v, %v", cancelled, err)
+	}
+	if _, err := s.CancelScheduledTransfer(ctx, testUserID, st.ID); !errors.Is(err, ErrScheduledTransferNotActive) {
+		t.Fatalf("second cancel: err = %v, want ErrScheduledTransferNotActive", err)
+	}
+
+	makeDue(repo, st.ID)
+	runScheduled(t, s, 0)
+
+	if active, _ := s.ListScheduledTransfers(ctx, testUserID, false); len(active) != 0 {
+		t.Errorf("active transfers = %+v, want none", active)
+	}
+	if all, _ := s.ListScheduledTransfers(ctx, testUserID, true); len(all) != 1 {
+		t.Errorf("all transfers = %+v, want the cancelled one", all)
+	}
+}
@@ -0,0 +1,217 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/mrevds/pizza-app/card-service/internal/config"
+	"github.com/mrevds/pizza-app/card-service/internal/encryption"
+	"github.com/mrevds/pizza-app/card-service/internal/entity"
+	"github.com/mrevds/pizza-app/card-service/internal/events"
+	"github.com/mrevds/pizza-app/card-service/internal/money"
+	"github.com/mrevds/pizza-app/card-service/internal/notify"
+	"github.com/mrevds/pizza-app/card-service/internal/repository"
+	"github.com/mrevds/pizza-app/card-service/internal/risk"
+	"github.com/mrevds/pizza-app/card-service/internal/userdir"
+	"github.com/mrevds/pizza-app/card-service/internal/utils"
+)
+
+type AddCardInput struct {
+	UserID         int64
+	CardNumber     string
+	CardHolderName string
+	ExpiryDate     string
+	CVV            string
+}
+
+type UpdateCardInput struct {
+	UserID         int64
+	CardID         int64
+	CardHolderName string
+	ExpiryDate     string
+}
+
+type cardService struct {
+	repo      repository.CardRepository
+	cfg       *config.Config
+	envelope  *encryption.Envelope
+	notifier  notify.Notifier
+	events    *events.Hub
+	risk      *risk.Engine      // nil - списания не оцениваются
+	directory userdir.Directory // nil - переводы по номеру телефона отключены
+}
+
+func NewCardService(repo repository.CardRepository, cfg *config.Config, envelope *encryption.Envelope,
+	notifier notify.Notifier, hub *events.Hub, engine *risk.Engine, directory userdir.Directory) (CardService, error) {
+	if !money.IsSupported(cfg.Card.DefaultCurrency) {
+		return nil, fmt.Errorf("card.default_currency %q: %w", cfg.Card.DefaultCurrency, money.ErrUnsupportedCurrency)
+	}
+	return &cardService{
+		repo:      repo,
+		cfg:       cfg,
+		envelope:  envelope,
+		notifier:  notifier,
+		events:    hub,
+		risk:      engine,
+		directory: directory,
+	}, nil
+}
+
+func (s *cardService) AddCard(ctx context.Context, input AddCardInput) (*entity.Card, error) {
+	number, scheme, err := validateNewCard(input, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	// Номер хранится только зашифрованным, CVV проверен выше и не сохраняется.
+	// card_type и маска вычисляются сервером, а не приходят от клиента.
+	sealed, err := s.envelope.Encrypt([]byte(number))
+	if err != nil {
+		return nil, err
+	}
+	card := &entity.Card{
+		UserID:           input.UserID,
+		CardNumberMasked: utils.MaskCardNumber(number),
+		CardHolderName:   strings.TrimSpace(input.CardHolderName),
+		ExpiryDate:       strings.TrimSpace(input.ExpiryDate),
+		CardType:         scheme.CardType,
+		Currency:         money.NormalizeCurrency(s.cfg.Card.DefaultCurrency),
+		Status:           entity.CardStatusActive,
+		IsActive:         true,
+		PAN: &entity.EncryptedPAN{
+			Ciphertext:  sealed.Ciphertext,
+			WrappedKey:  sealed.WrappedKey,
+			KeyVersion:  sealed.KeyVersion,
+			Fingerprint: s.envelope.Fingerprint(number),
+		},
+	}
+	err = s.repo.RunInTx(ctx, func(repo repository.CardRepository) error {
+		if err := repo.CreateCard(ctx, card); err != nil {
+			if errors.Is(err, repository.ErrAlreadyExists) {
+				return ErrCardAlreadyExists
+			}
+			return err
+		}
+		return repo.CreateStatusChange(ctx, &entity.CardStatusChange{
+			CardID:   card.ID,
+			ToStatus: entity.CardStatusActive,
+			Actor:    entity.ActorUser,
+			ActorID:  input.UserID,
+			Reason:   "card added",
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return card, nil
+}
+
+func (s *cardService) GetCard(ctx context.Context, userID, cardID int64) (*entity.Card, error) {
+	return s.getOwnedCard(ctx, s.repo, userID, cardID)
+}
+
+func (s *cardService) GetUserCards(ctx context.Context, userID int64) ([]*entity.Card, error) {
+	return s.repo.GetUserCards(ctx, userID)
+}
+
+func (s *cardService) UpdateCard(ctx context.Context, input UpdateCardInput) (*entity.Card, error) {
+	if input.ExpiryDate != "" {
+		verr := &ValidationError{}
+		validateExpiryField(verr, input.ExpiryDate, time.Now())
+		if err := verr.errOrNil(); err != nil {
+			return nil, err
+		}
+	}
+
+	card, err := s.getOwnedCard(ctx, s.repo, input.UserID, input.CardID)
+	if err != nil {
+		return nil, err
+	}
+	// Срок подарочной карты задается при выпуске
+	if card.CardType == entity.CardTypeGift {
+		return nil, ErrGiftCardReadOnly
+	}
+	if name := strings.TrimSpace(input.CardHolderName); name != "" {
+		card.CardHolderName = name
+	}
+	if input.ExpiryDate != "" {
+		card.ExpiryDate = strings.TrimSpace(input.ExpiryDate)
+	}
+	if err := s.repo.UpdateCard(ctx, card); err != nil {
+		return nil, err
+	}
+	return card, nil
+}
+
+func (s *cardService) DeleteCard(ctx context.Context, userID, cardID int64) error {
+	return s.repo.RunInTx(ctx, func(repo repository.CardRepository) error {
+		card, err := s.lockOwnedCard(ctx, repo, userID, cardID)
+		if err != nil {
+			return err
+		}
+		if err := changeCardStatus(ctx, repo, card, entity.CardStatusClosed, changeActor{Kind: entity.ActorUser, ID: userID}, "card deleted"); err != nil {
+			return err
+		}
+		return repo.DeleteCard(ctx, cardID)
+	})
+}
+
+// getOwnedCard загружает карту и проверяет что она принадлежит пользователю.
+// Чужая карта неотличима от несуществующей.
+func (s *cardService) getOwnedCard(ctx context.Context, repo repository.CardRepository, userID, cardID int64) (*entity.Card, error) {
+	card, err := repo.GetCard(ctx, cardID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrCardNotFound
+		}
+		return nil, err
+	}
+	if card.UserID != userID {
+		return nil, ErrCardNotFound
+	}
+	return card, nil
+}
+
+// lockOwnedCard - то же что getOwnedCard, но берет блокировку строки (SELECT ... FOR UPDATE).
+// Вызывать только внутри RunInTx.
+func (s *cardService) lockOwnedCard(ctx context.Context, repo repository.CardRepository, userID, cardID int64) (*entity.Card, error) {
+	card, err := lockCard(ctx, repo, cardID)
+	if err != nil {
+		return nil, err
+	}
+	if card.UserID != userID {
+		return nil, ErrCardNotFound
+	}
+	return card, nil
+}
+
+// lockCard блокирует карту без проверки владельца, для админских и системных операций
+func lockCard(ctx context.Context, repo repository.CardRepository, cardID int64) (*entity.Card, error) {
+	card, err := repo.GetCardForUpdate(ctx, cardID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrCardNotFound
+		}
+		return nil, err
+	}
+	return card, nil
+}
+
+// checkCardUsable проверяет что по карте можно проводить операции
+func checkCardUsable(card *entity.Card) error {
+	switch card.Status {
+	case entity.CardStatusBlockedByUser, entity.CardStatusBlockedByFraud:
+		return ErrCardBlocked
+	case entity.CardStatusClosed:
+		return ErrCardInactive
+	case entity.CardStatusExpired:
+		return ErrCardExpired
+	}
+	if errors.Is(utils.ValidateExpiry(card.ExpiryDate, time.Now()), utils.ErrCardExpired) {
+		return ErrCardExpired
+	}
+	return nil
+}
@@ -0,0 +1,225 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/mrevds/pizza-app/card-service/internal/entity"
+	"github.com/mrevds/pizza-app/card-service/internal/money"
+	"github.com/mrevds/pizza-app/card-service/internal/repository"
+)
+
+// Разделенная оплата: заказ оплачивается несколькими картами, например часть
+// подарочной картой, остальное основной. Каждая часть - обычная оплата со своей
+// транзакцией payment и order_id заказа. Части проводятся в одной транзакции БД:
+// отказ по любой части откатывает все.
+
+const maxSplitLegs = 10
+
+// PaymentLeg - часть оплаты картой CardID или подарочной картой по GiftCode и GiftPIN
+type PaymentLeg struct {
+	CardID   int64
+	GiftCode string
+	GiftPIN  string `json:"-"`
+	Amount   money.Money
+}
+
+// SplitPaymentInput - части оплаты заказа. Все части - одно списание по заказу,
+// AdditionalCharge как у PaymentInput.
+type SplitPaymentInput struct {
+	UserID           int64
+	OrderID          string
+	Legs             []PaymentLeg
+	Category         string
+	AdditionalCharge bool
+	Description      string
+	IdempotencyKey   string `json:"-"`
+}
+
+// OrderPayments - оплаты заказа, разделенного между картами пользователя
+type OrderPayments struct {
+	OrderID  string
+	Amount   int64 // сумма частей
+	Currency string
+	Legs     []*entity.Transaction
+}
+
+// ProcessSplitPayment проводит части оплаты и возвращает их транзакции в порядке Legs.
+// Ошибка части оборачивается с её номером, errors.Is видит исходную ошибку.
+func (s *cardService) ProcessSplitPayment(ctx context.Context, input SplitPaymentInput) ([]*entity.Transaction, error) {
+	input.OrderID = strings.TrimSpace(input.OrderID)
+	if input.OrderID == "" {
+		return nil, ErrOrderIDRequired
+	}
+	if len(input.Legs) == 0 || len(input.Legs) > maxSplitLegs {
+		return nil, ErrSplitLegsInvalid
+	}
+	category, err := normalizeCategory(input.Category)
+	if err != nil {
+		return nil, err
+	}
+	input.Category = category
+
+	legs := make([]PaymentLeg, len(input.Legs))
+	seen := make(map[int64]bool, len(input.Legs))
+	for i, leg := range input.Legs {
+		amount, err := normalizeAmount(leg.Amount)
+		if err != nil {
+			return nil, legError(i, leg, err)
+		}
+		if amount.Currency != money.NormalizeCurrency(input.Legs[0].Amount.Currency) {
+			return nil, ErrSplitCurrencyMismatch
+		}
+		leg.Amount = amount
+		if leg.GiftCode != "" {
+			if leg.CardID != 0 {
+				return nil, legError(i, leg, ErrPaymentCardConflict)
+			}
+			gift, err := s.authenticateGiftCard(ctx, leg.GiftCode, leg.GiftPIN)
+			if err != nil {
+				return nil, legError(i, leg, err)
+			}
+			leg.CardID = gift.CardID
+		}
+		// Повторная карта взяла бы блокировку строки второй раз
+		if seen[leg.CardID] {
+			return nil, ErrSplitDuplicateCard
+		}
+		seen[leg.CardID] = true
+		legs[i] = leg
+	}
+
+	var txns []*entity.Transaction
+	input.Legs = legs
+	err = s.runIdempotent(ctx, input.UserID, input.IdempotencyKey, idempotentSplit, input, &txns, func(repo repository.CardRepository) error {
+		if err := claimOrderCharge(ctx, repo, input.UserID, input.OrderID, input.AdditionalCharge); err != nil {
+			return err
+		}
+		cards, err := lockSplitCards(ctx, repo, legs)
+		if err != nil {
+			return err
+		}
+		txns = make([]*entity.Transaction, 0, len(legs))
+		for i, leg := range legs {
+			txn, err := s.payLeg(ctx, repo, input, leg, cards[leg.CardID])
+			if err != nil {
+				return legError(i, leg, err)
+			}
+			txns = append(txns, txn)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, s.commitRiskDenial(ctx, err)
+	}
+	return txns, nil
+}
+
+// payLeg проводит одну часть по заблокированной карте так же, как ProcessPayment
+func (s *cardService) payLeg(ctx context.Context, repo repository.CardRepository, input SplitPaymentInput,
+	leg PaymentLeg, card *entity.Card) (*entity.Transaction, error) {
+	if leg.GiftCode != "" {
+		if err := claimGiftCard(ctx, repo, card, input.UserID); err != nil {
+			return nil, err
+		}
+	} else if card.UserID != input.UserID {
+		return nil, ErrCardNotFound
+	}
+	if err := checkCardOperation(card, leg.Amount); err != nil {
+		return nil, err
+	}
+	if err := checkLimits(ctx, repo, card, entity.TransactionTypePayment, leg.Amount); err != nil {
+		return nil, err
+	}
+	decision, err := s.assessRisk(ctx, repo, card, entity.TransactionTypePayment, leg.Amount, input.OrderID)
+	if err != nil {
+		return nil, err
+	}
+	txns, err := applyOperation(ctx, repo, ledgerOperation{
+		Operation:   entity.JournalOperationPayment,
+		Amount:      leg.Amount,
+		From:        ledgerSide{Card: card, TxType: entity.TransactionTypePayment},
+		To:          ledgerSide{Kind: entity.AccountKindMerchantSettlement},
+		Description: input.Description,
+		OrderID:     input.OrderID,
+		Category:    input.Category,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := outboxPaymentCompleted(ctx, repo, card, txns[0], 0); err != nil {
+		return nil, err
+	}
+	if err := recordRisk(ctx, repo, decision, txns[0].ID); err != nil {
+		return nil, err
+	}
+	return txns[0], nil
+}
+
+// lockSplitCards блокирует карты частей в порядке возрастания id, как lockTransferCards
+func lockSplitCards(ctx context.Context, repo repository.CardRepository, legs []PaymentLeg) (map[int64]*entity.Card, error) {
+	ids := make([]int64, 0, len(legs))
+	for _, leg := range legs {
+		ids = append(ids, leg.CardID)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	cards := make(map[int64]*entity.Card, len(ids))
+	for _, id := range ids {
+		card, err := lockCard(ctx, repo, id)
+		if err != nil {
+			return nil, err
+		}
+		cards[id] = card
+	}
+	return cards, nil
+}
+
+func legError(i int, leg PaymentLeg, err error) error {
+	if leg.CardID == 0 {
+		return fmt.Errorf("leg %d: %w", i+1, err)
+	}
+	return fmt.Errorf("leg %d (card %d): %w", i+1, leg.CardID, err)
+}
+
+// orderPayments собирает заказы страницы истории, оплаченные несколькими картами.
+// Части ищутся по всем картам пользователя, а не только на странице.
+func (s *cardService) orderPayments(ctx context.Context, userID int64, txs []*entity.Transaction) ([]*OrderPayments, error) {
+	var orderIDs []string
+	seen := make(map[string]bool)
+	for _, t := range txs {
+		if t.TransactionType == entity.TransactionTypePayment && t.OrderID != "" && !seen[t.OrderID] {
+			seen[t.OrderID] = true
+			orderIDs = append(orderIDs, t.OrderID)
+		}
+	}
+	if len(orderIDs) == 0 {
+		return nil, nil
+	}
+	payments, err := s.repo.ListOrderPayments(ctx, userID, orderIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	byOrder := make(map[string]*OrderPayments, len(orderIDs))
+	for _, p := range payments {
+		group := byOrder[p.OrderID]
+		if group == nil {
+			group = &OrderPayments{OrderID: p.OrderID, Currency: p.Currency}
+			byOrder[p.OrderID] = group
+		}
+		group.Legs = append(group.Legs, p)
+		if p.Status == entity.TransactionStatusSuccess {
+			group.Amount += p.Amount
+		}
+	}
+	var groups []*OrderPayments
+	for _, id := range orderIDs {
+		if group := byOrder[id]; group != nil && len(group.Legs) > 1 {
+			groups = append(groups, group)
+		}
+	}
+	return groups, nil
+}
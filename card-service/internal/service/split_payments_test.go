@@ -0,0 +1,92 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/mrevds/pizza-app/card-service/internal/money"
+)
+
+func TestProcessSplitPayment(t *testing.T) {
+	s, repo := newGiftTestService(t)
+	ctx := context.Background()
+	primary := addFundedCard(t, s, repo, testUserID, 5_000)
+	gc := issueGiftCard(t, s, 700)
+
+	input := SplitPaymentInput{
+		UserID:  testUserID,
+		OrderID: "order-1",
+		Legs: []PaymentLeg{
+			{GiftCode: gc.Code, GiftPIN: gc.PIN, Amount: rub(700)},
+			{CardID: primary.ID, Amount: rub(1_300)},
+		},
+		IdempotencyKey: "split-1",
+	}
+	txns, err := s.ProcessSplitPayment(ctx, input)
+	if err != nil {
+		t.Fatalf("ProcessSplitPayment: %v", err)
+	}
+	if len(txns) != 2 || txns[0].CardID != gc.Card.ID || txns[1].CardID != primary.ID || txns[1].BalanceAfter != 3_700 {
+		t.Fatalf("transactions = %+v, want gift card leg then primary card with balance 3700", txns)
+	}
+	if got := repo.store.cards[gc.Card.ID]; got.Balance != 0 || got.UserID != testUserID {
+		t.Errorf("gift card = balance %d owner %d, want 0 and %d", got.Balance, got.UserID, testUserID)
+	}
+	again, err := s.ProcessSplitPayment(ctx, input)
+	if err != nil || len(again) != 2 || again[1].ID != txns[1].ID {
+		t.Fatalf("replay = %+v, %v, want the same transactions", again, err)
+	}
+
+	// История одной карты показывает заказ со всеми частями
+	if _, err := s.ProcessPayment(ctx, PaymentInput{UserID: testUserID, CardID: primary.ID, Amount: rub(100), OrderID: "order-2"}); err != nil {
+		t.Fatalf("ProcessPayment: %v", err)
+	}
+	page, err := s.ListTransactions(ctx, TransactionQuery{UserID: testUserID, CardID: primary.ID})
+	if err != nil {
+		t.Fatalf("ListTransactions: %v", err)
+	}
+	if len(page.Orders) != 1 {
+		t.Fatalf("orders = %d, want only the split order-1", len(page.Orders))
+	}
+	order := page.Orders[0]
+	if order.OrderID != "order-1" || order.Amount != 2_000 || len(order.Legs) != 2 || order.Legs[0].CardID != gc.Card.ID {
+		t.Errorf("order = %+v, want order-1 for 2000 with the gift card leg", order)
+	}
+}
+
+func TestProcessSplitPaymentAllOrNothing(t *testing.T) {
+	s, repo := newTestService(t)
+	ctx := context.Background()
+	first := addFundedCard(t, s, repo, testUserID, 1_000)
+	second := addFundedCard(t, s, repo, testUserID, 100)
+	split := func(legs ...PaymentLeg) error {
+		_, err := s.ProcessSplitPayment(ctx, SplitPaymentInput{UserID: testUserID, OrderID: "order-1", Legs: legs})
+		return err
+	}
+
+	if err := split(PaymentLeg{CardID: first.ID, Amount: rub(500)}, PaymentLeg{CardID: second.ID, Amount: rub(500)}); !errors.Is(err, ErrInsufficientFunds) {
+		t.Fatalf("second leg over balance err = %v, want ErrInsufficientFunds", err)
+	}
+	if got := repo.store.cards[first.ID].Balance; got != 1_000 {
+		t.Errorf("first card balance = %d, want 1000 after rollback", got)
+	}
+	if payments, _ := repo.GetOrderPayments(ctx, testUserID, "order-1"); len(payments) != 0 {
+		t.Errorf("order payments = %d, want none", len(payments))
+	}
+
+	if err := split(PaymentLeg{CardID: first.ID, Amount: rub(100)}, PaymentLeg{CardID: first.ID, Amount: rub(100)}); !errors.Is(err, ErrSplitDuplicateCard) {
+		t.Errorf("same card twice err = %v, want ErrSplitDuplicateCard", err)
+	}
+	usd := money.Money{UnitsMinor: 100, Currency: "USD"}
+	if err := split(PaymentLeg{CardID: first.ID, Amount: rub(100)}, PaymentLeg{CardID: second.ID, Amount: usd}); !errors.Is(err, ErrSplitCurrencyMismatch) {
+		t.Errorf("mixed currencies err = %v, want ErrSplitCurrencyMismatch", err)
+	}
+	other := addFundedCard(t, s, repo, testUserID+1, 1_000)
+	if err := split(PaymentLeg{CardID: first.ID, Amount: rub(100)}, PaymentLeg{CardID: other.ID, Amount: rub(100)}); !errors.Is(err, ErrCardNotFound) {
+		t.Errorf("foreign card err = %v, want ErrCardNotFound", err)
+	}
+	if err := split(); !errors.Is(err, ErrSplitLegsInvalid) {
+		t.Errorf("no legs err = %v, want ErrSplitLegsInvalid", err)
+	}
+}
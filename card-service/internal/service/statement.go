@@ -0,0 +1,119 @@
+package service
+
+import (
+	"context"
+	"io"
+	"time"
+
+	"github.com/mrevds/pizza-app/card-service/internal/entity"
+	"github.com/mrevds/pizza-app/card-service/internal/repository"
+	"github.com/mrevds/pizza-app/card-service/internal/statement"
+)
+
+// statementBatchSize - сколько транзакций читается из базы за один запрос при выгрузке
+const statementBatchSize = 500
+
+// StatementInput - выписка по карте за период [From, To). To = 0 - по текущий момент
+type StatementInput struct {
+	UserID int64
+	CardID int64
+	From   time.Time
+	To     time.Time
+	Format string
+}
+
+// StatementFile - описание файла выписки, известное до начала выгрузки
+type StatementFile struct {
+	Name        string
+	ContentType string
+}
+
+// ExportStatement пишет выписку в w. Проверки выполняются до первой записи, так что
+// при ошибке в начале w остается пустым. begin вызывается перед первой записью.
+// Транзакции читаются пачками по statementBatchSize и сразу пишутся в w.
+func (s *cardService) ExportStatement(ctx context.Context, input StatementInput, begin func(StatementFile) error, w io.Writer) error {
+	if input.Format == "" {
+		input.Format = statement.FormatCSV
+	}
+	if input.To.IsZero() {
+		input.To = time.Now()
+	}
+	verr := &ValidationError{}
+	if !statement.IsFormat(input.Format) {
+		verr.add("format", "must be one of csv, ofx, jsonl")
+	}
+	if input.From.IsZero() {
+		verr.add("from", "is required")
+	} else if !input.From.Before(input.To) {
+		verr.add("to", "must be after from")
+	}
+	if err := verr.errOrNil(); err != nil {
+		return err
+	}
+
+	card, err := s.getOwnedCard(ctx, s.repo, input.UserID, input.CardID)
+	if err != nil {
+		return err
+	}
+	opening, err := s.repo.GetBalanceBefore(ctx, card.ID, input.From)
+	if err != nil {
+		return err
+	}
+	out, err := statement.NewWriter(input.Format, w)
+	if err != nil {
+		return err
+	}
+
+	if err := begin(StatementFile{
+		Name:        statement.FileName(card.ID, input.From, input.To, input.Format),
+		ContentType: statement.ContentType(input.Format),
+	}); err != nil {
+		return err
+	}
+	err = out.WriteHeader(statement.Header{
+		CardID:      card.ID,
+		CardMasked:  card.CardNumberMasked,
+		Currency:    card.Currency,
+		From:        input.From,
+		To:          input.To,
+		Opening:     opening,
+		GeneratedAt: time.Now(),
+	})
+	if err != nil {
+		return err
+	}
+
+	footer := statement.Footer{Closing: opening}
+	filter := repository.TransactionFilter{
+		CardID:    card.ID,
+		From:      input.From,
+		To:        input.To,
+		Statuses:  []string{entity.TransactionStatusSuccess},
+		Ascending: true,
+		Limit:     statementBatchSize,
+	}
+	for {
+		txs, err := s.repo.ListTransactions(ctx, filter)
+		if err != nil {
+			return err
+		}
+		for _, t := range txs {
+			if err := out.WriteTransaction(t); err != nil {
+				return err
+			}
+			if amount := statement.SignedAmount(t); amount < 0 {
+				footer.Debits -= amount
+			} else {
+				footer.Credits += amount
+			}
+			footer.Closing = t.BalanceAfter
+			footer.Count++
+		}
+		if len(txs) < statementBatchSize {
+			break
+		}
+		last := txs[len(txs)-1]
+		filter.After = &repository.TransactionCursor{CreatedAt: last.CreatedAt, ID: last.ID}
+	}
+	return out.WriteFooter(footer)
+}
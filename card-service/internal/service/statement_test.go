@@ -0,0 +1,67 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/mrevds/pizza-app/card-service/internal/statement"
+)
+
+func TestExportStatement(t *testing.T) {
+	s, repo := newTestService(t)
+	ctx := context.Background()
+	card := addFundedCard(t, s, repo, testUserID, 1_000)
+	withdraw, err := s.Withdraw(ctx, OperationInput{UserID: testUserID, CardID: card.ID, Amount: rub(300), Description: "Пицца"})
+	if err != nil {
+		t.Fatalf("Withdraw: %v", err)
+	}
+	if _, err := s.Deposit(ctx, OperationInput{UserID: testUserID, CardID: card.ID, Amount: rub(50)}); err != nil {
+		t.Fatalf("Deposit: %v", err)
+	}
+
+	// Пополнение при выпуске карты - до периода выписки
+	from := time.Now().Add(-time.Hour)
+	for _, txn := range repo.store.txns {
+		txn.CreatedAt = from.Add(time.Duration(txn.ID-withdraw.ID) * time.Second)
+	}
+
+	var file StatementFile
+	var buf bytes.Buffer
+	begin := func(f StatementFile) error {
+		file = f
+		return nil
+	}
+	if err := s.ExportStatement(ctx, StatementInput{UserID: testUserID, CardID: card.ID, From: from}, begin, &buf); err != nil {
+		t.Fatalf("ExportStatement: %v", err)
+	}
+	if file.ContentType != statement.ContentType(statement.FormatCSV) || file.Name == "" {
+		t.Fatalf("unexpected file %+v", file)
+	}
+	rows, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatalf("read csv: %v", err)
+	}
+	if len(rows) != 5 {
+		t.Fatalf("got %d rows, want 5:\n%v", len(rows), rows)
+	}
+	if rows[1][7] != "10.00" || rows[2][5] != "-3.00" || rows[3][5] != "0.50" || rows[4][7] != "7.50" {
+		t.Fatalf("unexpected statement:\n%v", rows)
+	}
+
+	// Ошибки возвращаются до записи в w
+	buf.Reset()
+	var verr *ValidationError
+	if err := s.ExportStatement(ctx, StatementInput{UserID: testUserID, CardID: card.ID, Format: "pdf"}, begin, &buf); !errors.As(err, &verr) || len(verr.Violations) != 2 {
+		t.Fatalf("invalid input: got %v", err)
+	}
+	if err := s.ExportStatement(ctx, StatementInput{UserID: 2, CardID: card.ID, From: from}, begin, &buf); !errors.Is(err, ErrCardNotFound) {
+		t.Fatalf("other user's card: got %v, want %v", err, ErrCardNotFound)
+	}
+	if buf.Len() != 0 {
+		t.Fatalf("statement written on error: %q", buf.String())
+	}
+}
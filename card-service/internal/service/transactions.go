@@ -0,0 +1,191 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"time"
+
+	"github.com/mrevds/pizza-app/card-service/internal/entity"
+	"github.com/mrevds/pizza-app/card-service/internal/money"
+	"github.com/mrevds/pizza-app/card-service/internal/repository"
+)
+
+const (
+	defaultPageSize = 20
+	maxPageSize     = 100
+	maxQueryLength  = 100
+)
+
+// TransactionQuery - фильтры истории. CardID = 0 - все карты пользователя.
+// Период полуоткрытый: From <= created_at < To. Нулевые поля не ограничивают выборку.
+type TransactionQuery struct {
+	UserID    int64
+	CardID    int64
+	From      time.Time
+	To        time.Time
+	Types     []string
+	Statuses  []string
+	MinAmount money.Money
+	MaxAmount money.Money
+	Text      string
+	PageSize  int
+	PageToken string
+}
+
+// TransactionPage - страница истории. Total считается только для первой страницы
+// (без PageToken), на следующих равен -1. Orders - заказы страницы, оплаченные
+// несколькими картами, с частями оплаты на всех картах пользователя.
+type TransactionPage struct {
+	Transactions  []*entity.Transaction
+	Orders        []*OrderPayments
+	Total         int
+	NextPageToken string
+}
+
+// pageToken - содержимое курсора: последняя запись страницы и отпечаток фильтров,
+// чтобы курсор нельзя было применить к другой выборке
+type pageToken struct {
+	CreatedAt int64  `json:"t"` // unix микросекунды, точность timestamp в postgres
+	ID        int64  `json:"i"`
+	Filter    string `json:"f"`
+}
+
+func (s *cardService) ListTransactions(ctx context.Context, query TransactionQuery) (*TransactionPage, error) {
+	filter, err := transactionFilter(query)
+	if err != nil {
+		return nil, err
+	}
+	if query.CardID != 0 {
+		if _, err := s.getOwnedCard(ctx, s.repo, query.UserID, query.CardID); err != nil {
+			return nil, err
+		}
+	}
+
+	if query.PageSize <= 0 || query.PageSize > maxPageSize {
+		query.PageSize = defaultPageSize
+	}
+
+	fingerprint := filterFingerprint(filter)
+	page := &TransactionPage{Total: -1}
+	if query.PageToken != "" {
+		cursor, err := decodePageToken(query.PageToken, fingerprint)
+		if err != nil {
+			return nil, err
+		}
+		filter.After = cursor
+	} else {
+		if page.Total, err = s.repo.CountTransactions(ctx, filter); err != nil {
+			return nil, err
+		}
+	}
+
+	// Лишняя запись показывает, есть ли следующая страница
+	filter.Limit = query.PageSize + 1
+	txs, err := s.repo.ListTransactions(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	if len(txs) > query.PageSize {
+		txs = txs[:query.PageSize]
+		last := txs[len(txs)-1]
+		page.NextPageToken = encodePageToken(pageToken{
+			CreatedAt: last.CreatedAt.UnixMicro(),
+			ID:        last.ID,
+			Filter:    fingerprint,
+		})
+	}
+	page.Transactions = txs
+	if page.Orders, err = s.orderPayments(ctx, query.UserID, txs); err != nil {
+		return nil, err
+	}
+	return page, nil
+}
+
+// transactionFilter проверяет запрос и переводит его в фильтр репозитория
+func transactionFilter(query TransactionQuery) (repository.TransactionFilter, error) {
+	verr := &ValidationError{}
+	filter := repository.TransactionFilter{
+		CardID:   query.CardID,
+		UserID:   query.UserID,
+		From:     query.From,
+		To:       query.To,
+		Types:    query.Types,
+		Statuses: query.Statuses,
+		Text:     query.Text,
+	}
+
+	if !query.From.IsZero() && !query.To.IsZero() && !query.From.Before(query.To) {
+		verr.add("to", "must be after from")
+	}
+	for _, t := range query.Types {
+		if !entity.IsTransactionType(t) {
+			verr.add("transaction_types", "unknown transaction type "+t)
+		}
+	}
+	for _, st := range query.Statuses {
+		if !entity.IsTransactionStatus(st) {
+			verr.add("statuses", "unknown transaction status "+st)
+		}
+	}
+	if len([]rune(query.Text)) > maxQueryLength {
+		verr.add("query", "is too long")
+	}
+
+	// Суммы сравниваются только в одной валюте, иначе диапазон не имеет смысла
+	for _, a := range []struct {
+		field  string
+		amount money.Money
+		dst    *int64
+	}{
+		{"min_amount", query.MinAmount, &filter.MinAmount},
+		{"max_amount", query.MaxAmount, &filter.MaxAmount},
+	} {
+		if a.amount.UnitsMinor == 0 && a.amount.Currency == "" {
+			continue
+		}
+		amount, err := normalizeAmount(a.amount)
+		if err != nil {
+			verr.add(a.field, err.Error())
+			continue
+		}
+		if filter.Currency != "" && filter.Currency != amount.Currency {
+			verr.add(a.field, "currency differs from min_amount currency")
+			continue
+		}
+		filter.Currency = amount.Currency
+		*a.dst = amount.UnitsMinor
+	}
+	if filter.MinAmount > 0 && filter.MaxAmount > 0 && filter.MinAmount > filter.MaxAmount {
+		verr.add("max_amount", "must not be less than min_amount")
+	}
+
+	return filter, verr.errOrNil()
+}
+
+// filterFingerprint - короткий хеш условий выборки без пагинации
+func filterFingerprint(filter repository.TransactionFilter) string {
+	filter.After, filter.Limit, filter.Offset = nil, 0, 0
+	data, _ := json.Marshal(filter)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:8])
+}
+
+func encodePageToken(t pageToken) string {
+	data, _ := json.Marshal(t)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodePageToken(token, fingerprint string) (*repository.TransactionCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, ErrInvalidPageToken
+	}
+	var t pageToken
+	if err := json.Unmarshal(data, &t); err != nil || t.ID <= 0 || t.Filter != fingerprint {
+		return nil, ErrInvalidPageToken
+	}
+	return &repository.TransactionCursor{CreatedAt: time.UnixMicro(t.CreatedAt), ID: t.ID}, nil
+}
@@ -0,0 +1,111 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/mrevds/pizza-app/card-service/internal/entity"
+)
+
+func TestListTransactionsPagination(t *testing.T) {
+	s, repo := newTestService(t)
+	ctx := context.Background()
+	first := addFundedCard(t, s, repo, testUserID, 1_000)
+	second := addFundedCard(t, s, repo, testUserID, 500)
+	other := addFundedCard(t, s, repo, 2, 700)
+
+	for _, op := range []OperationInput{
+		{UserID: testUserID, CardID: first.ID, Amount: rub(100), Description: "Кофе в Пицца-баре"},
+		{UserID: testUserID, CardID: second.ID, Amount: rub(50), Description: "Такси"},
+		{UserID: testUserID, CardID: first.ID, Amount: rub(300), Description: "Пицца 100%"},
+	} {
+		if _, err := s.Withdraw(ctx, op); err != nil {
+			t.Fatalf("Withdraw: %v", err)
+		}
+	}
+
+	// Все карты пользователя по 2 записи на страницу: 2 пополнения и 3 списания
+	var got []*entity.Transaction
+	query := TransactionQuery{UserID: testUserID, PageSize: 2}
+	for page := 0; ; page++ {
+		res, err := s.ListTransactions(ctx, query)
+		if err != nil {
+			t.Fatalf("ListTransactions: %v", err)
+		}
+		if page == 0 && res.Total != 5 || page > 0 && res.Total != -1 {
+			t.Fatalf("page %d: total %d", page, res.Total)
+		}
+		got = append(got, res.Transactions...)
+		if res.NextPageToken == "" {
+			break
+		}
+		query.PageToken = res.NextPageToken
+	}
+	if len(got) != 5 {
+		t.Fatalf("got %d transactions, want 5", len(got))
+	}
+	for i, txn := range got {
+		if txn.CardID == other.ID {
+			t.Fatalf("transaction of other user in history: %+v", txn)
+		}
+		if i > 0 && got[i-1].ID <= txn.ID {
+			t.Fatalf("history is not ordered from new to old: %d before %d", got[i-1].ID, txn.ID)
+		}
+	}
+
+	// Курсор привязан к фильтрам
+	filtered := TransactionQuery{UserID: testUserID, Types: []string{entity.TransactionTypeWithdraw}, PageToken: query.PageToken}
+	if _, err := s.ListTransactions(ctx, filtered); !errors.Is(err, ErrInvalidPageToken) {
+		t.Fatalf("page token with other filters: got %v, want %v", err, ErrInvalidPageToken)
+	}
+	if _, err := s.ListTransactions(ctx, TransactionQuery{UserID: testUserID, PageToken: "garbage"}); !errors.Is(err, ErrInvalidPageToken) {
+		t.Fatalf("garbage page token: got %v, want %v", err, ErrInvalidPageToken)
+	}
+}
+
+func TestListTransactionsFilters(t *testing.T) {
+	s, repo := newTestService(t)
+	ctx := context.Background()
+	card := addFundedCard(t, s, repo, testUserID, 1_000)
+	for _, op := range []OperationInput{
+		{UserID: testUserID, CardID: card.ID, Amount: rub(100), Description: "Кофе"},
+		{UserID: testUserID, CardID: card.ID, Amount: rub(300), Description: "ПИЦЦА"},
+		{UserID: testUserID, CardID: card.ID, Amount: rub(500), Description: "Пицца 100%"},
+	} {
+		if _, err := s.Withdraw(ctx, op); err != nil {
+			t.Fatalf("Withdraw: %v", err)
+		}
+	}
+
+	cases := []struct {
+		name  string
+		query TransactionQuery
+		want  int
+	}{
+		{"type", TransactionQuery{Types: []string{entity.TransactionTypeDeposit}}, 1},
+		{"status", TransactionQuery{Statuses: []string{entity.TransactionStatusFailed}}, 0},
+		{"amount range", TransactionQuery{MinAmount: rub(200), MaxAmount: rub(500)}, 2},
+		{"text ignores case", TransactionQuery{Text: "пицца"}, 2},
+		{"text is not a pattern", TransactionQuery{Text: "0%"}, 1},
+	}
+	for _, tc := range cases {
+		tc.query.UserID, tc.query.CardID = testUserID, card.ID
+		res, err := s.ListTransactions(ctx, tc.query)
+		if err != nil {
+			t.Fatalf("%s: %v", tc.name, err)
+		}
+		if len(res.Transactions) != tc.want || res.Total != tc.want {
+			t.Fatalf("%s: got %d (total %d), want %d", tc.name, len(res.Transactions), res.Total, tc.want)
+		}
+	}
+
+	var verr *ValidationError
+	bad := TransactionQuery{UserID: testUserID, Types: []string{"bonus"}, MinAmount: rub(500), MaxAmount: rub(100)}
+	if _, err := s.ListTransactions(ctx, bad); !errors.As(err, &verr) || len(verr.Violations) != 2 {
+		t.Fatalf("invalid filters: got %v", err)
+	}
+	if _, err := s.ListTransactions(ctx, TransactionQuery{UserID: 2, CardID: card.ID}); !errors.Is(err, ErrCardNotFound) {
+		t.Fatalf("other user's card: got %v, want %v", err, ErrCardNotFound)
+	}
+}
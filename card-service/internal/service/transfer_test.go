@@ -0,0 +1,183 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mrevds/pizza-app/card-service/internal/config"
+	"github.com/mrevds/pizza-app/card-service/internal/entity"
+	"github.com/mrevds/pizza-app/card-service/internal/money"
+)
+
+const testUserID = 1
+
+func newTestService(t *testing.T) (*cardService, *memRepo) {
+	t.Helper()
+	repo := newMemRepo()
+	cfg := &config.Config{Card: config.CardConfig{DefaultCurrency: "RUB", IdempotencyTTL: time.Hour}}
+	return &cardService{repo: repo, cfg: cfg}, repo
+}
+
+func rub(units int64) money.Money {
+	return money.Money{UnitsMinor: units, Currency: "RUB"}
+}
+
+// addFundedCard создает карту и пополняет её через Deposit, чтобы баланс был в журнале
+func addFundedCard(t *testing.T, s *cardService, repo *memRepo, userID, balance int64) *entity.Card {
+	t.Helper()
+	card := repo.addCard(entity.Card{
+		UserID:     userID,
+		ExpiryDate: "12/99",
+		CardType:   "visa",
+		Currency:   "RUB",
+		Status:     entity.CardStatusActive,
+		IsActive:   true,
+	})
+	if balance > 0 {
+		if _, err := s.Deposit(context.Background(), OperationInput{UserID: userID, CardID: card.ID, Amount: rub(balance)}); err != nil {
+			t.Fatalf("Deposit: %v", err)
+		}
+	}
+	return card
+}
+
+func TestTransferConcurrentConservesMoney(t *testing.T) {
+	s, repo := newTestService(t)
+	const (
+		cards     = 6
+		workers   = 8
+		perWorker = 300
+		initial   = 10_000
+	)
+	ids := make([]int64, 0, cards)
+	for i := 0; i < cards; i++ {
+		ids = append(ids, addFundedCard(t, s, repo, testUserID, initial).ID)
+	}
+
+	var wg sync.WaitGroup
+	errs := make(chan error, workers)
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(seed int64) {
+			defer wg.Done()
+			rnd := rand.New(rand.NewSource(seed))
+			for i := 0; i < perWorker; i++ {
+				from, to := ids[rnd.Intn(cards)], ids[rnd.Intn(cards)]
+				if from == to {
+					continue
+				}
+				_, _, err := s.Transfer(context.Background(), TransferInput{
+					UserID:     testUserID,
+					FromCardID: from,
+					ToCardID:   to,
+					Amount:     rub(int64(1 + rnd.Intn(4_000))),
+				})
+				if err != nil && !errors.Is(err, ErrInsufficientFunds) {
+					errs <- err
+					return
+				}
+			}
+		}(int64(w))
+	}
+
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(30 * time.Second):
+		t.Fatal("transfers did not finish: deadlock")
+	}
+	close(errs)
+	for err := range errs {
+		t.Fatalf("Transfer: %v", err)
+	}
+
+	repo.store.mu.Lock()
+	defer repo.store.mu.Unlock()
+	var total int64
+	for _, id := range ids {
+		card := repo.store.cards[id]
+		if card.Balance < 0 {
+			t.Fatalf("card %d overdrawn: %d", id, card.Balance)
+		}
+		total += card.Balance
+		for _, a := range repo.store.accounts {
+			if a.CardID == id && repo.store.accountBalance(a.ID) != card.Balance {
+				t.Fatalf("card %d: balance %d differs from ledger %d", id, card.Balance, repo.store.accountBalance(a.ID))
+			}
+		}
+	}
+	if total != cards*initial {
+		t.Fatalf("total money %d, want %d", total, cards*initial)
+	}
+
+	var debits, credits int64
+	for _, p := range repo.store.postings {
+		if p.Direction == entity.PostingDebit {
+			debits += p.Amount
+		} else {
+			credits += p.Amount
+		}
+	}
+	if debits != credits {
+		t.Fatalf("ledger is unbalanced: debits %d, credits %d", debits, credits)
+	}
+}
+
+func TestTransferChecks(t *testing.T) {
+	s, repo := newTestService(t)
+	from := addFundedCard(t, s, repo, testUserID, 1_000)
+	to := addFundedCard(t, s, repo, 2, 0)
+	foreign := addFundedCard(t, s, repo, 2, 1_000)
+	blocked := addFundedCard(t, s, repo, 2, 0)
+	repo.store.cards[blocked.ID].Status = entity.CardStatusBlockedByUser
+	repo.store.cards[blocked.ID].IsBlocked = true
+	expired := addFundedCard(t, s, repo, 2, 0)
+	repo.store.cards[expired.ID].ExpiryDate = "01/20"
+	usd := addFundedCard(t, s, repo, 2, 0)
+	repo.store.cards[usd.ID].Currency = "USD"
+
+	cases := []struct {
+		name   string
+		from   int64
+		to     int64
+		amount int64
+		want   error
+	}{
+		{"ok", from.ID, to.ID, 100, nil},
+		{"same card", from.ID, from.ID, 100, ErrSameCard},
+		{"foreign source card", foreign.ID, to.ID, 100, ErrCardNotFound},
+		{"missing target card", from.ID, 999_999, 100, ErrCardNotFound},
+		{"blocked target card", from.ID, blocked.ID, 100, ErrCardBlocked},
+		{"expired target card", from.ID, expired.ID, 100, ErrCardExpired},
+		{"currency mismatch", from.ID, usd.ID, 100, ErrCurrencyMismatch},
+		{"insufficient funds", from.ID, to.ID, 1_000_000, ErrInsufficientFunds},
+		{"zero amount", from.ID, to.ID, 0, ErrInvalidAmount},
+	}
+	for _, tc := range cases {
+		_, _, err := s.Transfer(context.Background(), TransferInput{
+			UserID:     testUserID,
+			FromCardID: tc.from,
+			ToCardID:   tc.to,
+			Amount:     rub(tc.amount),
+		})
+		if !errors.Is(err, tc.want) {
+			t.Fatalf("%s: got %v, want %v", tc.name, err, tc.want)
+		}
+	}
+
+	// Неуспешные переводы откатились, прошел только первый
+	if got := repo.store.cards[from.ID].Balance; got != 900 {
+		t.Fatalf("source balance %d, want 900", got)
+	}
+	if got := repo.store.cards[to.ID].Balance; got != 100 {
+		t.Fatalf("target balance %d, want 100", got)
+	}
+}
@@ -0,0 +1,17 @@
+package service
+
+import (
+	"context"
+	"strings"
+
+	"github.com/mrevds/pizza-app/card-service/internal/utils"
+)
+
+// ResolveUser переводит UUID пользователя user-service в id card-service.
+// Пользователь, который обращается впервые, получает новый id.
+func (s *cardService) ResolveUser(ctx context.Context, externalID string) (int64, error) {
+	if !utils.IsUUID(externalID) {
+		return 0, ErrInvalidUserID
+	}
+	return s.repo.ResolveUser(ctx, strings.ToLower(externalID))
+}
@@ -0,0 +1,37 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestResolveUser(t *testing.T) {
+	s, _ := newTestService(t)
+	ctx := context.Background()
+
+	id, err := s.ResolveUser(ctx, "6f1c2a9e-3b4d-4e5f-8a7b-9c0d1e2f3a4b")
+	if err != nil {
+		t.Fatalf("ResolveUser: %v", err)
+	}
+	again, err := s.ResolveUser(ctx, "6F1C2A9E-3B4D-4E5F-8A7B-9C0D1E2F3A4B")
+	if err != nil {
+		t.Fatalf("ResolveUser again: %v", err)
+	}
+	if again != id {
+		t.Errorf("same UUID in other case resolved to %d, want %d", again, id)
+	}
+	other, err := s.ResolveUser(ctx, "0a1b2c3d-4e5f-4a6b-8c7d-9e0f1a2b3c4d")
+	if err != nil {
+		t.Fatalf("ResolveUser other: %v", err)
+	}
+	if other == id {
+		t.Errorf("different users resolved to the same id %d", id)
+	}
+
+	for _, bad := range []string{"", "123", "6f1c2a9e3b4d4e5f8a7b9c0d1e2f3a4b", "6f1c2a9e-3b4d-4e5f-8a7b-9c0d1e2f3a4g"} {
+		if _, err := s.ResolveUser(ctx, bad); !errors.Is(err, ErrInvalidUserID) {
+			t.Errorf("ResolveUser(%q) err = %v, want ErrInvalidUserID", bad, err)
+		}
+	}
+}
@@ -0,0 +1,90 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/mrevds/pizza-app/card-service/internal/utils"
+)
+
+// FieldViolation - ошибка в конкретном поле запроса
+type FieldViolation struct {
+	Field       string
+	Description string
+}
+
+// ValidationError собирает все ошибки валидации запроса.
+// errors.Is(err, ErrInvalidCardData) для неё возвращает true.
+type ValidationError struct {
+	Violations []FieldViolation
+}
+
+func (e *ValidationError) Error() string {
+	msgs := make([]string, 0, len(e.Violations))
+	for _, v := range e.Violations {
+		msgs = append(msgs, v.Field+": "+v.Description)
+	}
+	return "invalid card data: " + strings.Join(msgs, "; ")
+}
+
+func (e *ValidationError) Is(target error) bool {
+	return target == ErrInvalidCardData
+}
+
+func (e *ValidationError) add(field, description string) {
+	e.Violations = append(e.Violations, FieldViolation{Field: field, Description: description})
+}
+
+// errOrNil возвращает nil, если нарушений нет
+func (e *ValidationError) errOrNil() error {
+	if len(e.Violations) == 0 {
+		return nil
+	}
+	return e
+}
+
+// validateNewCard проверяет данные AddCard и возвращает нормализованный номер
+// и платежную систему, определенную по BIN
+func validateNewCard(input AddCardInput, now time.Time) (string, utils.CardScheme, error) {
+	verr := &ValidationError{}
+
+	number := utils.NormalizeCardNumber(input.CardNumber)
+	scheme, known := utils.DetectScheme(number)
+	switch {
+	case !utils.IsDigits(number):
+		verr.add("card_number", "must contain only digits")
+	case !known:
+		verr.add("card_number", "unsupported card scheme")
+	case !scheme.ValidLength(len(number)):
+		verr.add("card_number", "invalid length for "+scheme.CardType)
+	case !utils.LuhnValid(number):
+		verr.add("card_number", "checksum mismatch")
+	}
+
+	if strings.TrimSpace(input.CardHolderName) == "" {
+		verr.add("card_holder_name", "must not be empty")
+	}
+
+	validateExpiryField(verr, input.ExpiryDate, now)
+
+	cvvLen := 3
+	if known {
+		cvvLen = scheme.CVVLen
+	}
+	if !utils.IsDigits(input.CVV) || len(input.CVV) != cvvLen {
+		verr.add("cvv", fmt.Sprintf("must be %d digits", cvvLen))
+	}
+
+	return number, scheme, verr.errOrNil()
+}
+
+func validateExpiryField(verr *ValidationError, expiry string, now time.Time) {
+	switch err := utils.ValidateExpiry(expiry, now); err {
+	case nil:
+	case utils.ErrCardExpired:
+		verr.add("expiry_date", "card is expired")
+	default:
+		verr.add("expiry_date", "must be in MM/YY format")
+	}
+}
@@ -0,0 +1,64 @@
+package statement
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/mrevds/pizza-app/card-service/internal/entity"
+	"github.com/mrevds/pizza-app/card-service/internal/money"
+)
+
+// csvWriter - одна строка на транзакцию, входящий и исходящий остаток - отдельными
+// строками opening_balance и closing_balance. Суммы в основных единицах, списания со знаком минус.
+type csvWriter struct {
+	w        *csv.Writer
+	currency string
+	exp      int
+	to       time.Time
+}
+
+var csvColumns = []string{"date", "transaction_id", "type", "description", "order_id", "amount", "currency", "balance"}
+
+func newCSVWriter(w io.Writer) *csvWriter {
+	return &csvWriter{w: csv.NewWriter(w)}
+}
+
+func (c *csvWriter) WriteHeader(h Header) error {
+	exp, err := money.Exponent(h.Currency)
+	if err != nil {
+		return err
+	}
+	c.currency, c.exp, c.to = h.Currency, exp, h.To
+	if err := c.w.Write(csvColumns); err != nil {
+		return err
+	}
+	return c.w.Write([]string{formatTime(h.From), "", "opening_balance", "", "", "", h.Currency, money.Format(h.Opening, exp)})
+}
+
+func (c *csvWriter) WriteTransaction(t *entity.Transaction) error {
+	return c.w.Write([]string{
+		formatTime(t.CreatedAt),
+		strconv.FormatInt(t.ID, 10),
+		t.TransactionType,
+		t.Description,
+		t.OrderID,
+		money.Format(SignedAmount(t), c.exp),
+		t.Currency,
+		money.Format(t.BalanceAfter, c.exp),
+	})
+}
+
+func (c *csvWriter) WriteFooter(f Footer) error {
+	err := c.w.Write([]string{formatTime(c.to), "", "closing_balance", "", "", "", c.currency, money.Format(f.Closing, c.exp)})
+	if err != nil {
+		return err
+	}
+	c.w.Flush()
+	return c.w.Error()
+}
+
+func formatTime(t time.Time) string {
+	return t.UTC().Format(time.RFC3339)
+}
@@ -0,0 +1,76 @@
+package statement
+
+import (
+	"encoding/json"
+	"io"
+	"time"
+
+	"github.com/mrevds/pizza-app/card-service/internal/entity"
+)
+
+// jsonlWriter - по объекту на строку: header, transaction..., footer. Поле record
+// различает строки, суммы в минимальных единицах валюты как в CardV2.
+type jsonlWriter struct {
+	enc      *json.Encoder
+	currency string
+}
+
+type jsonlHeader struct {
+	Record         string    `json:"record"`
+	CardID         int64     `json:"card_id"`
+	CardMasked     string    `json:"card_number_masked"`
+	Currency       string    `json:"currency"`
+	From           time.Time `json:"from"`
+	To             time.Time `json:"to"`
+	OpeningBalance int64     `json:"opening_balance"`
+	GeneratedAt    time.Time `json:"generated_at"`
+}
+
+type jsonlTransaction struct {
+	Record string `json:"record"`
+	*entity.Transaction
+}
+
+type jsonlFooter struct {
+	Record         string `json:"record"`
+	Currency       string `json:"currency"`
+	ClosingBalance int64  `json:"closing_balance"`
+	Credits        int64  `json:"credits"`
+	Debits         int64  `json:"debits"`
+	Count          int    `json:"count"`
+}
+
+func newJSONLWriter(w io.Writer) *jsonlWriter {
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	return &jsonlWriter{enc: enc}
+}
+
+func (j *jsonlWriter) WriteHeader(h Header) error {
+	j.currency = h.Currency
+	return j.enc.Encode(jsonlHeader{
+		Record:         "header",
+		CardID:         h.CardID,
+		CardMasked:     h.CardMasked,
+		Currency:       h.Currency,
+		From:           h.From.UTC(),
+		To:             h.To.UTC(),
+		OpeningBalance: h.Opening,
+		GeneratedAt:    h.GeneratedAt.UTC(),
+	})
+}
+
+func (j *jsonlWriter) WriteTransaction(t *entity.Transaction) error {
+	return j.enc.Encode(jsonlTransaction{Record: "transaction", Transaction: t})
+}
+
+func (j *jsonlWriter) WriteFooter(f Footer) error {
+	return j.enc.Encode(jsonlFooter{
+		Record:         "footer",
+		Currency:       j.currency,
+		ClosingBalance: f.Closing,
+		Credits:        f.Credits,
+		Debits:         f.Debits,
+		Count:          f.Count,
+	})
+}
@@ -0,0 +1,126 @@
+package statement
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mrevds/pizza-app/card-service/internal/entity"
+	"github.com/mrevds/pizza-app/card-service/internal/money"
+)
+
+// ofxWriter - выписка по кредитной карте OFX 2.2 (CREDITCARDMSGSRSV1). Входящий
+// остаток отдается в BALLIST, исходящий - в LEDGERBAL.
+type ofxWriter struct {
+	w      io.Writer
+	err    error
+	header Header
+	exp    int
+}
+
+const ofxProlog = `<?xml version="1.0" encoding="UTF-8" standalone="no"?>
+<?OFX OFXHEADER="200" VERSION="220" SECURITY="NONE" OLDFILEUID="NONE" NEWFILEUID="NONE"?>
+`
+
+func (o *ofxWriter) WriteHeader(h Header) error {
+	exp, err := money.Exponent(h.Currency)
+	if err != nil {
+		return err
+	}
+	o.header, o.exp = h, exp
+
+	o.raw(ofxProlog)
+	o.raw("<OFX>\n<SIGNONMSGSRSV1><SONRS>")
+	o.status()
+	o.elem("DTSERVER", ofxTime(h.GeneratedAt))
+	o.elem("LANGUAGE", "RUS")
+	o.raw("</SONRS></SIGNONMSGSRSV1>\n<CREDITCARDMSGSRSV1><CCSTMTTRNRS>")
+	o.elem("TRNUID", "0")
+	o.status()
+	o.raw("<CCSTMTRS>")
+	o.elem("CURDEF", h.Currency)
+	o.raw("<CCACCTFROM>")
+	o.elem("ACCTID", h.CardMasked)
+	o.raw("</CCACCTFROM>\n<BANKTRANLIST>")
+	o.elem("DTSTART", ofxTime(h.From))
+	o.elem("DTEND", ofxTime(h.To))
+	o.raw("\n")
+	return o.err
+}
+
+func (o *ofxWriter) WriteTransaction(t *entity.Transaction) error {
+	amount := SignedAmount(t)
+	trnType := "CREDIT"
+	if amount < 0 {
+		trnType = "DEBIT"
+	}
+	o.raw("<STMTTRN>")
+	o.elem("TRNTYPE", trnType)
+	o.elem("DTPOSTED", ofxTime(t.CreatedAt))
+	o.elem("TRNAMT", money.Format(amount, o.exp))
+	o.elem("FITID", strconv.FormatInt(t.ID, 10))
+	o.elem("NAME", ofxName(t))
+	if t.Description != "" {
+		o.elem("MEMO", t.Description)
+	}
+	o.raw("</STMTTRN>\n")
+	return o.err
+}
+
+func (o *ofxWriter) WriteFooter(f Footer) error {
+	o.raw("</BANKTRANLIST>\n<LEDGERBAL>")
+	o.elem("BALAMT", money.Format(f.Closing, o.exp))
+	o.elem("DTASOF", ofxTime(o.header.To))
+	o.raw("</LEDGERBAL>\n<BALLIST><BAL>")
+	o.elem("NAME", "Opening balance")
+	o.elem("DESC", "Balance at "+ofxTime(o.header.From))
+	o.elem("BALTYPE", "DOLLAR")
+	o.elem("VALUE", money.Format(o.header.Opening, o.exp))
+	o.elem("DTASOF", ofxTime(o.header.From))
+	o.raw("</BAL></BALLIST>\n</CCSTMTRS></CCSTMTTRNRS></CREDITCARDMSGSRSV1>\n</OFX>\n")
+	return o.err
+}
+
+func (o *ofxWriter) status() {
+	o.raw("<STATUS>")
+	o.elem("CODE", "0")
+	o.elem("SEVERITY", "INFO")
+	o.raw("</STATUS>")
+}
+
+// elem пишет <tag>value</tag> с экранированием value
+func (o *ofxWriter) elem(tag, value string) {
+	o.raw("<" + tag + ">")
+	if o.err == nil {
+		o.err = xml.EscapeText(o.w, []byte(value))
+	}
+	o.raw("</" + tag + ">")
+}
+
+// raw пишет s как есть. После первой ошибки записи ничего не делает, ошибку
+// возвращает ближайший WriteXxx.
+func (o *ofxWriter) raw(s string) {
+	if o.err == nil {
+		_, o.err = io.WriteString(o.w, s)
+	}
+}
+
+// ofxTime - дата в формате OFX: YYYYMMDDHHMMSS.XXX[0:UTC]
+func ofxTime(t time.Time) string {
+	return t.UTC().Format("20060102150405.000") + "[0:UTC]"
+}
+
+// ofxName - NAME ограничен 32 символами, полное описание остается в MEMO
+func ofxName(t *entity.Transaction) string {
+	name := t.TransactionType
+	if t.OrderID != "" {
+		name = fmt.Sprintf("%s %s", name, t.OrderID)
+	}
+	if r := []rune(strings.TrimSpace(name)); len(r) > 32 {
+		name = string(r[:32])
+	}
+	return name
+}
@@ -0,0 +1,101 @@
+// Package statement формирует выписку по карте в CSV, OFX 2.x и JSON Lines.
+// Транзакции пишутся по одной, поэтому выписка любого размера не держится в памяти.
+package statement
+
+import (
+	"errors"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/mrevds/pizza-app/card-service/internal/entity"
+)
+
+// Форматы выписки
+const (
+	FormatCSV   = "csv"
+	FormatOFX   = "ofx"
+	FormatJSONL = "jsonl"
+)
+
+var ErrUnknownFormat = errors.New("unknown statement format")
+
+// Header - начало выписки: карта, период [From, To) и входящий остаток
+type Header struct {
+	CardID      int64
+	CardMasked  string
+	Currency    string
+	From        time.Time
+	To          time.Time
+	Opening     int64
+	GeneratedAt time.Time
+}
+
+// Footer - итоги выписки: исходящий остаток и обороты
+type Footer struct {
+	Closing int64
+	Credits int64
+	Debits  int64
+	Count   int
+}
+
+// Writer пишет выписку в порядке WriteHeader, WriteTransaction..., WriteFooter
+type Writer interface {
+	WriteHeader(h Header) error
+	WriteTransaction(t *entity.Transaction) error
+	WriteFooter(f Footer) error
+}
+
+// NewWriter создает Writer формата format поверх w
+func NewWriter(format string, w io.Writer) (Writer, error) {
+	switch format {
+	case FormatCSV:
+		return newCSVWriter(w), nil
+	case FormatOFX:
+		return &ofxWriter{w: w}, nil
+	case FormatJSONL:
+		return newJSONLWriter(w), nil
+	}
+	return nil, ErrUnknownFormat
+}
+
+// IsFormat проверяет что format - поддерживаемый формат выписки
+func IsFormat(format string) bool {
+	switch format {
+	case FormatCSV, FormatOFX, FormatJSONL:
+		return true
+	}
+	return false
+}
+
+// ContentType - MIME тип файла выписки
+func ContentType(format string) string {
+	switch format {
+	case FormatCSV:
+		return "text/csv; charset=utf-8"
+	case FormatOFX:
+		return "application/x-ofx"
+	case FormatJSONL:
+		return "application/jsonl"
+	}
+	return "application/octet-stream"
+}
+
+// FileName - имя файла выписки по карте за период
+func FileName(cardID int64, from, to time.Time, format string) string {
+	const layout = "20060102"
+	return "statement_" + strconv.FormatInt(cardID, 10) + "_" + from.UTC().Format(layout) + "_" + to.UTC().Format(layout) + "." + format
+}
+
+// SignedAmount - сумма транзакции со знаком: списания отрицательные
+func SignedAmount(t *entity.Transaction) int64 {
+	if t.TransactionType == entity.TransactionTypeAdjustmentDebit {
+		return -t.Amount
+	}
+	for _, debit := range entity.DebitTransactionTypes {
+		if t.TransactionType == debit {
+			return -t.Amount
+		}
+	}
+	return t.Amount
+}
@@ -0,0 +1,121 @@
+package statement
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mrevds/pizza-app/card-service/internal/entity"
+)
+
+var (
+	testFrom = time.Date(2026, 9, 1, 0, 0, 0, 0, time.UTC)
+	testTo   = time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+)
+
+func writeStatement(t *testing.T, format string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	w, err := NewWriter(format, &buf)
+	if err != nil {
+		t.Fatalf("NewWriter(%s): %v", format, err)
+	}
+	if err := w.WriteHeader(Header{CardID: 7, CardMasked: "4532 **** **** 9010", Currency: "RUB", From: testFrom, To: testTo, Opening: 100_000, GeneratedAt: testTo}); err != nil {
+		t.Fatalf("WriteHeader: %v", err)
+	}
+	for _, txn := range []*entity.Transaction{
+		{ID: 1, TransactionType: entity.TransactionTypePayment, Amount: 30_050, BalanceAfter: 69_950, Currency: "RUB", Description: `Пицца "4 сыра" & <кола>`, OrderID: "order-1", CreatedAt: testFrom.Add(time.Hour)},
+		{ID: 2, TransactionType: entity.TransactionTypeDeposit, Amount: 5_000, BalanceAfter: 74_950, Currency: "RUB", Description: "Пополнение, наличные", CreatedAt: testFrom.Add(2 * time.Hour)},
+	} {
+		if err := w.WriteTransaction(txn); err != nil {
+			t.Fatalf("WriteTransaction: %v", err)
+		}
+	}
+	if err := w.WriteFooter(Footer{Closing: 74_950, Credits: 5_000, Debits: 30_050, Count: 2}); err != nil {
+		t.Fatalf("WriteFooter: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestCSV(t *testing.T) {
+	rows, err := csv.NewReader(bytes.NewReader(writeStatement(t, FormatCSV))).ReadAll()
+	if err != nil {
+		t.Fatalf("read csv: %v", err)
+	}
+	if len(rows) != 5 {
+		t.Fatalf("got %d rows, want header, opening, 2 transactions and closing", len(rows))
+	}
+	if rows[1][2] != "opening_balance" || rows[1][7] != "1000.00" {
+		t.Fatalf("unexpected opening row %v", rows[1])
+	}
+	if rows[2][3] != `Пицца "4 сыра" & <кола>` || rows[2][5] != "-300.50" || rows[3][5] != "50.00" {
+		t.Fatalf("unexpected transaction rows %v %v", rows[2], rows[3])
+	}
+	if rows[4][2] != "closing_balance" || rows[4][7] != "749.50" {
+		t.Fatalf("unexpected closing row %v", rows[4])
+	}
+}
+
+func TestJSONL(t *testing.T) {
+	var records []string
+	sc := bufio.NewScanner(bytes.NewReader(writeStatement(t, FormatJSONL)))
+	for sc.Scan() {
+		var line struct {
+			Record  string `json:"record"`
+			Opening int64  `json:"opening_balance"`
+			Closing int64  `json:"closing_balance"`
+		}
+		if err := json.Unmarshal(sc.Bytes(), &line); err != nil {
+			t.Fatalf("line %q: %v", sc.Text(), err)
+		}
+		records = append(records, line.Record)
+		if line.Record == "header" && line.Opening != 100_000 || line.Record == "footer" && line.Closing != 74_950 {
+			t.Fatalf("unexpected balances in %q", sc.Text())
+		}
+	}
+	if got := strings.Join(records, ","); got != "header,transaction,transaction,footer" {
+		t.Fatalf("records %s", got)
+	}
+}
+
+func TestOFX(t *testing.T) {
+	data := writeStatement(t, FormatOFX)
+	dec := xml.NewDecoder(bytes.NewReader(data))
+	var amounts []string
+	var current string
+	for {
+		tok, err := dec.Token()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			t.Fatalf("ofx is not well-formed xml: %v\n%s", err, data)
+		}
+		switch tok := tok.(type) {
+		case xml.StartElement:
+			current = tok.Name.Local
+		case xml.CharData:
+			if current == "TRNAMT" || current == "BALAMT" || current == "VALUE" {
+				amounts = append(amounts, current+"="+string(tok))
+			}
+		case xml.EndElement:
+			current = ""
+		}
+	}
+	if got := strings.Join(amounts, ","); got != "TRNAMT=-300.50,TRNAMT=50.00,BALAMT=749.50,VALUE=1000.00" {
+		t.Fatalf("amounts %s", got)
+	}
+}
+
+func TestUnknownFormat(t *testing.T) {
+	if _, err := NewWriter("pdf", io.Discard); !errors.Is(err, ErrUnknownFormat) {
+		t.Fatalf("got %v, want %v", err, ErrUnknownFormat)
+	}
+}
@@ -0,0 +1,76 @@
+// Package userdir ищет пользователей в user-service для переводов по номеру телефона
+package userdir
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	userGRPC "github.com/mrevds/pizza-app/card-service/pkg/user-service_v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// serviceTokenHeader - заголовок с токеном card-service для внутренних методов user-service
+const serviceTokenHeader = "x-service-token"
+
+var ErrNotFound = errors.New("user not found")
+
+// Recipient - найденный пользователь. user-service отдает только id и замаскированное имя.
+type Recipient struct {
+	ExternalID string // UUID пользователя в user-service
+	MaskedName string // "Иван П."
+}
+
+// Directory ищет пользователей по номеру телефона
+type Directory interface {
+	// LookupByPhone возвращает ErrNotFound, если с таким номером никто не зарегистрирован
+	LookupByPhone(ctx context.Context, phone string) (*Recipient, error)
+}
+
+// Client - Directory поверх gRPC user-service
+type Client struct {
+	conn    *grpc.ClientConn
+	client  userGRPC.UserServiceClient
+	token   string
+	timeout time.Duration
+}
+
+// New создает клиента. Соединение устанавливается при первом вызове.
+func New(addr, token string, timeout time.Duration) (*Client, error) {
+	conn, err := grpc.NewClient(addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create user service client: %w", err)
+	}
+	return &Client{
+		conn:    conn,
+		client:  userGRPC.NewUserServiceClient(conn),
+		token:   token,
+		timeout: timeout,
+	}, nil
+}
+
+func (c *Client) LookupByPhone(ctx context.Context, phone string) (*Recipient, error) {
+	if c.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.timeout)
+		defer cancel()
+	}
+	ctx = metadata.AppendToOutgoingContext(ctx, serviceTokenHeader, c.token)
+	resp, err := c.client.LookupUserByPhone(ctx, &userGRPC.LookupUserByPhoneRequest{PhoneNumber: phone})
+	if err != nil {
+		if status.Code(err) == codes.NotFound {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("user service lookup: %w", err)
+	}
+	return &Recipient{ExternalID: resp.GetId(), MaskedName: resp.GetMaskedName()}, nil
+}
+
+func (c *Client) Close() error {
+	return c.conn.Close()
+}
@@ -0,0 +1,156 @@
+package utils
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Платежные системы
+const (
+	CardTypeVisa       = "visa"
+	CardTypeMastercard = "mastercard"
+	CardTypeMir        = "mir"
+	CardTypeAmex       = "amex"
+	CardTypeUnionPay   = "unionpay"
+	CardTypeUnknown    = "unknown"
+)
+
+var (
+	ErrInvalidExpiryFormat = errors.New("expiry date must be in MM/YY format")
+	ErrCardExpired         = errors.New("card is expired")
+)
+
+// binRange - диапазон BIN (первые цифры номера) платежной системы.
+// Префиксы сравниваются по длине From/To: диапазон 2221-2720 проверяет первые 4 цифры.
+type binRange struct {
+	From     string
+	To       string
+	CardType string
+	Lengths  []int
+	CVVLen   int
+}
+
+// binRanges - таблица диапазонов, более специфичные диапазоны идут первыми
+var binRanges = []binRange{
+	{From: "2200", To: "2204", CardType: CardTypeMir, Lengths: []int{16, 17, 18, 19}, CVVLen: 3},
+	{From: "2221", To: "2720", CardType: CardTypeMastercard, Lengths: []int{16}, CVVLen: 3},
+	{From: "34", To: "34", CardType: CardTypeAmex, Lengths: []int{15}, CVVLen: 4},
+	{From: "37", To: "37", CardType: CardTypeAmex, Lengths: []int{15}, CVVLen: 4},
+	{From: "4", To: "4", CardType: CardTypeVisa, Lengths: []int{13, 16, 19}, CVVLen: 3},
+	{From: "51", To: "55", CardType: CardTypeMastercard, Lengths: []int{16}, CVVLen: 3},
+	{From: "62", To: "62", CardType: CardTypeUnionPay, Lengths: []int{16, 17, 18, 19}, CVVLen: 3},
+}
+
+// CardScheme - результат определения платежной системы по номеру
+type CardScheme struct {
+	CardType string
+	Lengths  []int
+	CVVLen   int
+}
+
+// ValidLength проверяет что длина номера допустима для платежной системы
+func (s CardScheme) ValidLength(n int) bool {
+	for _, l := range s.Lengths {
+		if l == n {
+			return true
+		}
+	}
+	return false
+}
+
+// NormalizeCardNumber убирает пробелы и дефисы из номера карты
+func NormalizeCardNumber(number string) string {
+	return strings.NewReplacer(" ", "", "-", "").Replace(number)
+}
+
+// IsDigits проверяет что строка непустая и состоит только из цифр
+func IsDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// LuhnValid проверяет контрольную сумму номера по алгоритму Луна
+func LuhnValid(number string) bool {
+	if !IsDigits(number) {
+		return false
+	}
+	sum := 0
+	double := false
+	for i := len(number) - 1; i >= 0; i-- {
+		d := int(number[i] - '0')
+		if double {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		double = !double
+	}
+	return sum%10 == 0
+}
+
+// DetectScheme определяет платежную систему по BIN. Второй результат false,
+// если номер не попадает ни в один известный диапазон.
+func DetectScheme(number string) (CardScheme, bool) {
+	for _, r := range binRanges {
+		if len(number) < len(r.From) {
+			continue
+		}
+		prefix := number[:len(r.From)]
+		if prefix >= r.From && prefix <= r.To {
+			return CardScheme{CardType: r.CardType, Lengths: r.Lengths, CVVLen: r.CVVLen}, true
+		}
+	}
+	return CardScheme{CardType: CardTypeUnknown}, false
+}
+
+// DetectCardType определяет платежную систему по BIN
+func DetectCardType(number string) string {
+	scheme, _ := DetectScheme(number)
+	return scheme.CardType
+}
+
+// MaskCardNumber возвращает номер вида "4532 **** **** 9010"
+func MaskCardNumber(number string) string {
+	if len(number) < 8 {
+		return strings.Repeat("*", len(number))
+	}
+	return number[:4] + " **** **** " + number[len(number)-4:]
+}
+
+// ParseExpiry разбирает срок действия в формате MM/YY и возвращает момент,
+// с которого карта считается просроченной (начало следующего месяца, UTC).
+func ParseExpiry(expiry string) (time.Time, error) {
+	parts := strings.Split(strings.TrimSpace(expiry), "/")
+	if len(parts) != 2 || len(parts[0]) != 2 || len(parts[1]) != 2 || !IsDigits(parts[0]) || !IsDigits(parts[1]) {
+		return time.Time{}, ErrInvalidExpiryFormat
+	}
+	month, _ := strconv.Atoi(parts[0])
+	year, _ := strconv.Atoi(parts[1])
+	if month < 1 || month > 12 {
+		return time.Time{}, ErrInvalidExpiryFormat
+	}
+	return time.Date(2000+year, time.Month(month)+1, 1, 0, 0, 0, 0, time.UTC), nil
+}
+
+// ValidateExpiry проверяет формат MM/YY и что карта не просрочена на момент now
+func ValidateExpiry(expiry string, now time.Time) error {
+	expiresAt, err := ParseExpiry(expiry)
+	if err != nil {
+		return err
+	}
+	if !now.Before(expiresAt) {
+		return ErrCardExpired
+	}
+	return nil
+}
@@ -0,0 +1,113 @@
+package utils
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestLuhnValid(t *testing.T) {
+	cases := []struct {
+		number string
+		want   bool
+	}{
+		{"4111111111111111", true},
+		{"4532015112830366", true},
+		{"5555555555554444", true},
+		{"378282246310005", true},
+		{"0", true},
+		{"4111111111111112", false},
+		{"4532015112830367", false},
+		{"", false},
+		{"4111 1111 1111 1111", false}, // номер нормализуется до проверки
+		{"41111111111a1111", false},
+	}
+	for _, tc := range cases {
+		if got := LuhnValid(tc.number); got != tc.want {
+			t.Fatalf("LuhnValid(%q) = %v, want %v", tc.number, got, tc.want)
+		}
+	}
+}
+
+func TestDetectScheme(t *testing.T) {
+	cases := []struct {
+		number   string
+		cardType string
+		known    bool
+		length   int
+		cvvLen   int
+	}{
+		{"4111111111111111", CardTypeVisa, true, 16, 3},
+		{"4222222222222", CardTypeVisa, true, 13, 3},
+		{"5555555555554444", CardTypeMastercard, true, 16, 3},
+		{"2221000000000009", CardTypeMastercard, true, 16, 3},
+		{"2720990000000000", CardTypeMastercard, true, 16, 3},
+		{"2200000000000004", CardTypeMir, true, 16, 3},
+		{"2204000000000000000", CardTypeMir, true, 19, 3},
+		{"378282246310005", CardTypeAmex, true, 15, 4},
+		{"341111111111111", CardTypeAmex, true, 15, 4},
+		{"6200000000000005", CardTypeUnionPay, true, 16, 3},
+		// Границы диапазонов: 2205-2220 и 2721+ не принадлежат ни МИР, ни Mastercard
+		{"2205000000000000", CardTypeUnknown, false, 0, 0},
+		{"2721000000000000", CardTypeUnknown, false, 0, 0},
+		{"5600000000000000", CardTypeUnknown, false, 0, 0},
+		{"3", CardTypeUnknown, false, 0, 0},
+		{"", CardTypeUnknown, false, 0, 0},
+	}
+	for _, tc := range cases {
+		scheme, known := DetectScheme(tc.number)
+		if scheme.CardType != tc.cardType || known != tc.known {
+			t.Fatalf("DetectScheme(%q) = %q, %v, want %q, %v", tc.number, scheme.CardType, known, tc.cardType, tc.known)
+		}
+		if !tc.known {
+			continue
+		}
+		if !scheme.ValidLength(tc.length) {
+			t.Fatalf("DetectScheme(%q): length %d is not valid for %s", tc.number, tc.length, scheme.CardType)
+		}
+		if scheme.CVVLen != tc.cvvLen {
+			t.Fatalf("DetectScheme(%q): CVV length %d, want %d", tc.number, scheme.CVVLen, tc.cvvLen)
+		}
+	}
+}
+
+func TestSchemeValidLength(t *testing.T) {
+	visa, _ := DetectScheme("4111111111111111")
+	for n, want := range map[int]bool{12: false, 13: true, 15: false, 16: true, 19: true, 20: false} {
+		if got := visa.ValidLength(n); got != want {
+			t.Fatalf("visa ValidLength(%d) = %v, want %v", n, got, want)
+		}
+	}
+}
+
+func TestValidateExpiry(t *testing.T) {
+	now := time.Date(2026, time.March, 15, 12, 0, 0, 0, time.UTC)
+	cases := []struct {
+		expiry string
+		now    time.Time
+		err    error
+	}{
+		{"03/26", now, nil},
+		{"12/30", now, nil},
+		{" 04/26 ", now, nil},
+		// Карта действует до конца месяца включительно
+		{"03/26", time.Date(2026, time.March, 31, 23, 59, 59, 0, time.UTC), nil},
+		{"03/26", time.Date(2026, time.April, 1, 0, 0, 0, 0, time.UTC), ErrCardExpired},
+		{"02/26", now, ErrCardExpired},
+		// Декабрь истекает с началом января следующего года
+		{"12/25", time.Date(2025, time.December, 31, 0, 0, 0, 0, time.UTC), nil},
+		{"12/25", time.Date(2026, time.January, 1, 0, 0, 0, 0, time.UTC), ErrCardExpired},
+		{"13/26", now, ErrInvalidExpiryFormat},
+		{"00/26", now, ErrInvalidExpiryFormat},
+		{"3/26", now, ErrInvalidExpiryFormat},
+		{"03/2026", now, ErrInvalidExpiryFormat},
+		{"03-26", now, ErrInvalidExpiryFormat},
+		{"ab/cd", now, ErrInvalidExpiryFormat},
+		{"", now, ErrInvalidExpiryFormat},
+	}
+	for _, tc := range cases {
+		if err := ValidateExpiry(tc.expiry, tc.now); !errors.Is(err, tc.err) {
+			t.Fatalf("ValidateExpiry(%q, %s) = %v, want %v", tc.expiry, tc.now.Format(time.RFC3339), err, tc.err)
+		}
+	}
+}
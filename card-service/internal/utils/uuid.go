@@ -0,0 +1,23 @@
+package utils
+
+// IsUUID проверяет что строка - UUID в каноническом виде
+// xxxxxxxx-xxxx-xxxx-xxxx-xxxxxxxxxxxx (как id пользователей в user-service)
+func IsUUID(s string) bool {
+	if len(s) != 36 {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch i {
+		case 8, 13, 18, 23:
+			if c != '-' {
+				return false
+			}
+		default:
+			if !('0' <= c && c <= '9' || 'a' <= c && c <= 'f' || 'A' <= c && c <= 'F') {
+				return false
+			}
+		}
+	}
+	return true
+}
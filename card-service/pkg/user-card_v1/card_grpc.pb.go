@@ -0,0 +1,821 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.2.0
+// - protoc             v3.21.12
+// source: user-card_v1/card.proto
+
+package card_v1
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+	emptypb "google.golang.org/protobuf/types/known/emptypb"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+// CardV1Client is the client API for CardV1 service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type CardV1Client interface {
+	// === УПРАВЛЕНИЕ КАРТАМИ ===
+	AddCard(ctx context.Context, in *AddCardRequest, opts ...grpc.CallOption) (*AddCardResponse, error)
+	GetCard(ctx context.Context, in *GetCardRequest, opts ...grpc.CallOption) (*GetCardResponse, error)
+	GetUserCards(ctx context.Context, in *GetUserCardsRequest, opts ...grpc.CallOption) (*GetUserCardsResponse, error)
+	UpdateCard(ctx context.Context, in *UpdateCardRequest, opts ...grpc.CallOption) (*UpdateCardResponse, error)
+	DeleteCard(ctx context.Context, in *DeleteCardRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	BlockCard(ctx context.Context, in *BlockCardRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	UnblockCard(ctx context.Context, in *UnblockCardRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	// === ОПЕРАЦИИ С БАЛАНСОМ ===
+	GetBalance(ctx context.Context, in *GetBalanceRequest, opts ...grpc.CallOption) (*GetBalanceResponse, error)
+	Deposit(ctx context.Context, in *DepositRequest, opts ...grpc.CallOption) (*DepositResponse, error)
+	Withdraw(ctx context.Context, in *WithdrawRequest, opts ...grpc.CallOption) (*WithdrawResponse, error)
+	Transfer(ctx context.Context, in *TransferRequest, opts ...grpc.CallOption) (*TransferResponse, error)
+	// === ИСТОРИЯ ТРАНЗАКЦИЙ ===
+	GetTransactions(ctx context.Context, in *GetTransactionsRequest, opts ...grpc.CallOption) (*GetTransactionsResponse, error)
+	GetTransaction(ctx context.Context, in *GetTransactionRequest, opts ...grpc.CallOption) (*GetTransactionResponse, error)
+	ExportStatement(ctx context.Context, in *ExportStatementRequest, opts ...grpc.CallOption) (CardV1_ExportStatementClient, error)
+	// === АНАЛИТИКА ===
+	GetSpendingSummary(ctx context.Context, in *GetSpendingSummaryRequest, opts ...grpc.CallOption) (*GetSpendingSummaryResponse, error)
+	GetBalanceHistory(ctx context.Context, in *GetBalanceHistoryRequest, opts ...grpc.CallOption) (*GetBalanceHistoryResponse, error)
+	// === СОБЫТИЯ ===
+	WatchCardEvents(ctx context.Context, in *WatchCardEventsRequest, opts ...grpc.CallOption) (CardV1_WatchCardEventsClient, error)
+	// === ДЛЯ ДРУГИХ СЕРВИСОВ (internal) ===
+	ProcessPayment(ctx context.Context, in *ProcessPaymentRequest, opts ...grpc.CallOption) (*ProcessPaymentResponse, error)
+	ValidateCard(ctx context.Context, in *ValidateCardRequest, opts ...grpc.CallOption) (*ValidateCardResponse, error)
+}
+
+type cardV1Client struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewCardV1Client(cc grpc.ClientConnInterface) CardV1Client {
+	return &cardV1Client{cc}
+}
+
+func (c *cardV1Client) AddCard(ctx context.Context, in *AddCardRequest, opts ...grpc.CallOption) (*AddCardResponse, error) {
+	out := new(AddCardResponse)
+	err := c.cc.Invoke(ctx, "/card_v1.CardV1/AddCard", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *cardV1Client) GetCard(ctx context.Context, in *GetCardRequest, opts ...grpc.CallOption) (*GetCardResponse, error) {
+	out := new(GetCardResponse)
+	err := c.cc.Invoke(ctx, "/card_v1.CardV1/GetCard", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *cardV1Client) GetUserCards(ctx context.Context, in *GetUserCardsRequest, opts ...grpc.CallOption) (*GetUserCardsResponse, error) {
+	out := new(GetUserCardsResponse)
+	err := c.cc.Invoke(ctx, "/card_v1.CardV1/GetUserCards", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *cardV1Client) UpdateCard(ctx context.Context, in *UpdateCardRequest, opts ...grpc.CallOption) (*UpdateCardResponse, error) {
+	out := new(UpdateCardResponse)
+	err := c.cc.Invoke(ctx, "/card_v1.CardV1/UpdateCard", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *cardV1Client) DeleteCard(ctx context.Context, in *DeleteCardRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, "/card_v1.CardV1/DeleteCard", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *cardV1Client) BlockCard(ctx context.Context, in *BlockCardRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, "/card_v1.CardV1/BlockCard", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *cardV1Client) UnblockCard(ctx context.Context, in *UnblockCardRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, "/card_v1.CardV1/UnblockCard", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *cardV1Client) GetBalance(ctx context.Context, in *GetBalanceRequest, opts ...grpc.CallOption) (*GetBalanceResponse, error) {
+	out := new(GetBalanceResponse)
+	err := c.cc.Invoke(ctx, "/card_v1.CardV1/GetBalance", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *cardV1Client) Deposit(ctx context.Context, in *DepositRequest, opts ...grpc.CallOption) (*DepositResponse, error) {
+	out := new(DepositResponse)
+	err := c.cc.Invoke(ctx, "/card_v1.CardV1/Deposit", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *cardV1Client) Withdraw(ctx context.Context, in *WithdrawRequest, opts ...grpc.CallOption) (*WithdrawResponse, error) {
+	out := new(WithdrawResponse)
+	err := c.cc.Invoke(ctx, "/card_v1.CardV1/Withdraw", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *cardV1Client) Transfer(ctx context.Context, in *TransferRequest, opts ...grpc.CallOption) (*TransferResponse, error) {
+	out := new(TransferResponse)
+	err := c.cc.Invoke(ctx, "/card_v1.CardV1/Transfer", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *cardV1Client) GetTransactions(ctx context.Context, in *GetTransactionsRequest, opts ...grpc.CallOption) (*GetTransactionsResponse, error) {
+	out := new(GetTransactionsResponse)
+	err := c.cc.Invoke(ctx, "/card_v1.CardV1/GetTransactions", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *cardV1Client) GetTransaction(ctx context.Context, in *GetTransactionRequest, opts ...grpc.CallOption) (*GetTransactionResponse, error) {
+	out := new(GetTransactionResponse)
+	err := c.cc.Invoke(ctx, "/card_v1.CardV1/GetTransaction", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *cardV1Client) ExportStatement(ctx context.Context, in *ExportStatementRequest, opts ...grpc.CallOption) (CardV1_ExportStatementClient, error) {
+	stream, err := c.cc.NewStream(ctx, &CardV1_ServiceDesc.Streams[0], "/card_v1.CardV1/ExportStatement", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &cardV1ExportStatementClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type CardV1_ExportStatementClient interface {
+	Recv() (*StatementChunk, error)
+	grpc.ClientStream
+}
+
+type cardV1ExportStatementClient struct {
+	grpc.ClientStream
+}
+
+func (x *cardV1ExportStatementClient) Recv() (*StatementChunk, error) {
+	m := new(StatementChunk)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *cardV1Client) GetSpendingSummary(ctx context.Context, in *GetSpendingSummaryRequest, opts ...grpc.CallOption) (*GetSpendingSummaryResponse, error) {
+	out := new(GetSpendingSummaryResponse)
+	err := c.cc.Invoke(ctx, "/card_v1.CardV1/GetSpendingSummary", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *cardV1Client) GetBalanceHistory(ctx context.Context, in *GetBalanceHistoryRequest, opts ...grpc.CallOption) (*GetBalanceHistoryResponse, error) {
+	out := new(GetBalanceHistoryResponse)
+	err := c.cc.Invoke(ctx, "/card_v1.CardV1/GetBalanceHistory", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *cardV1Client) WatchCardEvents(ctx context.Context, in *WatchCardEventsRequest, opts ...grpc.CallOption) (CardV1_WatchCardEventsClient, error) {
+	stream, err := c.cc.NewStream(ctx, &CardV1_ServiceDesc.Streams[1], "/card_v1.CardV1/WatchCardEvents", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &cardV1WatchCardEventsClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type CardV1_WatchCardEventsClient interface {
+	Recv() (*CardEvent, error)
+	grpc.ClientStream
+}
+
+type cardV1WatchCardEventsClient struct {
+	grpc.ClientStream
+}
+
+func (x *cardV1WatchCardEventsClient) Recv() (*CardEvent, error) {
+	m := new(CardEvent)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *cardV1Client) ProcessPayment(ctx context.Context, in *ProcessPaymentRequest, opts ...grpc.CallOption) (*ProcessPaymentResponse, error) {
+	out := new(ProcessPaymentResponse)
+	err := c.cc.Invoke(ctx, "/card_v1.CardV1/ProcessPayment", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *cardV1Client) ValidateCard(ctx context.Context, in *ValidateCardRequest, opts ...grpc.CallOption) (*ValidateCardResponse, error) {
+	out := new(ValidateCardResponse)
+	err := c.cc.Invoke(ctx, "/card_v1.CardV1/ValidateCard", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// CardV1Server is the server API for CardV1 service.
+// All implementations must embed UnimplementedCardV1Server
+// for forward compatibility
+type CardV1Server interface {
+	// === УПРАВЛЕНИЕ КАРТАМИ ===
+	AddCard(context.Context, *AddCardRequest) (*AddCardResponse, error)
+	GetCard(context.Context, *GetCardRequest) (*GetCardResponse, error)
+	GetUserCards(context.Context, *GetUserCardsRequest) (*GetUserCardsResponse, error)
+	UpdateCard(context.Context, *UpdateCardRequest) (*UpdateCardResponse, error)
+	DeleteCard(context.Context, *DeleteCardRequest) (*emptypb.Empty, error)
+	BlockCard(context.Context, *BlockCardRequest) (*emptypb.Empty, error)
+	UnblockCard(context.Context, *UnblockCardRequest) (*emptypb.Empty, error)
+	// === ОПЕРАЦИИ С БАЛАНСОМ ===
+	GetBalance(context.Context, *GetBalanceRequest) (*GetBalanceResponse, error)
+	Deposit(context.Context, *DepositRequest) (*DepositResponse, error)
+	Withdraw(context.Context, *WithdrawRequest) (*WithdrawResponse, error)
+	Transfer(context.Context, *TransferRequest) (*TransferResponse, error)
+	// === ИСТОРИЯ ТРАНЗАКЦИЙ ===
+	GetTransactions(context.Context, *GetTransactionsRequest) (*GetTransactionsResponse, error)
+	GetTransaction(context.Context, *GetTransactionRequest) (*GetTransactionResponse, error)
+	ExportStatement(*ExportStatementRequest, CardV1_ExportStatementServer) error
+	// === АНАЛИТИКА ===
+	GetSpendingSummary(context.Context, *GetSpendingSummaryRequest) (*GetSpendingSummaryResponse, error)
+	GetBalanceHistory(context.Context, *GetBalanceHistoryRequest) (*GetBalanceHistoryResponse, error)
+	// === СОБЫТИЯ ===
+	WatchCardEvents(*WatchCardEventsRequest, CardV1_WatchCardEventsServer) error
+	// === ДЛЯ ДРУГИХ СЕРВИСОВ (internal) ===
+	ProcessPayment(context.Context, *ProcessPaymentRequest) (*ProcessPaymentResponse, error)
+	ValidateCard(context.Context, *ValidateCardRequest) (*ValidateCardResponse, error)
+	mustEmbedUnimplementedCardV1Server()
+}
+
+// UnimplementedCardV1Server must be embedded to have forward compatible implementations.
+type UnimplementedCardV1Server struct {
+}
+
+func (UnimplementedCardV1Server) AddCard(context.Context, *AddCardRequest) (*AddCardResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method AddCard not implemented")
+}
+func (UnimplementedCardV1Server) GetCard(context.Context, *GetCardRequest) (*GetCardResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetCard not implemented")
+}
+func (UnimplementedCardV1Server) GetUserCards(context.Context, *GetUserCardsRequest) (*GetUserCardsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetUserCards not implemented")
+}
+func (UnimplementedCardV1Server) UpdateCard(context.Context, *UpdateCardRequest) (*UpdateCardResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UpdateCard not implemented")
+}
+func (UnimplementedCardV1Server) DeleteCard(context.Context, *DeleteCardRequest) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteCard not implemented")
+}
+func (UnimplementedCardV1Server) BlockCard(context.Context, *BlockCardRequest) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method BlockCard not implemented")
+}
+func (UnimplementedCardV1Server) UnblockCard(context.Context, *UnblockCardRequest) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UnblockCard not implemented")
+}
+func (UnimplementedCardV1Server) GetBalance(context.Context, *GetBalanceRequest) (*GetBalanceResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetBalance not implemented")
+}
+func (UnimplementedCardV1Server) Deposit(context.Context, *DepositRequest) (*DepositResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Deposit not implemented")
+}
+func (UnimplementedCardV1Server) Withdraw(context.Context, *WithdrawRequest) (*WithdrawResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Withdraw not implemented")
+}
+func (UnimplementedCardV1Server) Transfer(context.Context, *TransferRequest) (*TransferResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Transfer not implemented")
+}
+func (UnimplementedCardV1Server) GetTransactions(context.Context, *GetTransactionsRequest) (*GetTransactionsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetTransactions not implemented")
+}
+func (UnimplementedCardV1Server) GetTransaction(context.Context, *GetTransactionRequest) (*GetTransactionResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetTransaction not implemented")
+}
+func (UnimplementedCardV1Server) ExportStatement(*ExportStatementRequest, CardV1_ExportStatementServer) error {
+	return status.Errorf(codes.Unimplemented, "method ExportStatement not implemented")
+}
+func (UnimplementedCardV1Server) GetSpendingSummary(context.Context, *GetSpendingSummaryRequest) (*GetSpendingSummaryResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetSpendingSummary not implemented")
+}
+func (UnimplementedCardV1Server) GetBalanceHistory(context.Context, *GetBalanceHistoryRequest) (*GetBalanceHistoryResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetBalanceHistory not implemented")
+}
+func (UnimplementedCardV1Server) WatchCardEvents(*WatchCardEventsRequest, CardV1_WatchCardEventsServer) error {
+	return status.Errorf(codes.Unimplemented, "method WatchCardEvents not implemented")
+}
+func (UnimplementedCardV1Server) ProcessPayment(context.Context, *ProcessPaymentRequest) (*ProcessPaymentResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ProcessPayment not implemented")
+}
+func (UnimplementedCardV1Server) ValidateCard(context.Context, *ValidateCardRequest) (*ValidateCardResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ValidateCard not implemented")
+}
+func (UnimplementedCardV1Server) mustEmbedUnimplementedCardV1Server() {}
+
+// UnsafeCardV1Server may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to CardV1Server will
+// result in compilation errors.
+type UnsafeCardV1Server interface {
+	mustEmbedUnimplementedCardV1Server()
+}
+
+func RegisterCardV1Server(s grpc.ServiceRegistrar, srv CardV1Server) {
+	s.RegisterService(&CardV1_ServiceDesc, srv)
+}
+
+func _CardV1_AddCard_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AddCardRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CardV1Server).AddCard(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/card_v1.CardV1/AddCard",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CardV1Server).AddCard(ctx, req.(*AddCardRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CardV1_GetCard_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetCardRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CardV1Server).GetCard(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/card_v1.CardV1/GetCard",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CardV1Server).GetCard(ctx, req.(*GetCardRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CardV1_GetUserCards_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetUserCardsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CardV1Server).GetUserCards(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/card_v1.CardV1/GetUserCards",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CardV1Server).GetUserCards(ctx, req.(*GetUserCardsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CardV1_UpdateCard_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpdateCardRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CardV1Server).UpdateCard(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/card_v1.CardV1/UpdateCard",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CardV1Server).UpdateCard(ctx, req.(*UpdateCardRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CardV1_DeleteCard_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteCardRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CardV1Server).DeleteCard(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/card_v1.CardV1/DeleteCard",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CardV1Server).DeleteCard(ctx, req.(*DeleteCardRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CardV1_BlockCard_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(BlockCardRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CardV1Server).BlockCard(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/card_v1.CardV1/BlockCard",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CardV1Server).BlockCard(ctx, req.(*BlockCardRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CardV1_UnblockCard_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UnblockCardRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CardV1Server).UnblockCard(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/card_v1.CardV1/UnblockCard",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CardV1Server).UnblockCard(ctx, req.(*UnblockCardRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CardV1_GetBalance_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetBalanceRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CardV1Server).GetBalance(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/card_v1.CardV1/GetBalance",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CardV1Server).GetBalance(ctx, req.(*GetBalanceRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CardV1_Deposit_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DepositRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CardV1Server).Deposit(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/card_v1.CardV1/Deposit",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CardV1Server).Deposit(ctx, req.(*DepositRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CardV1_Withdraw_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(WithdrawRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CardV1Server).Withdraw(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/card_v1.CardV1/Withdraw",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CardV1Server).Withdraw(ctx, req.(*WithdrawRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CardV1_Transfer_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(TransferRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CardV1Server).Transfer(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/card_v1.CardV1/Transfer",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CardV1Server).Transfer(ctx, req.(*TransferRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CardV1_GetTransactions_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetTransactionsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CardV1Server).GetTransactions(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/card_v1.CardV1/GetTransactions",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CardV1Server).GetTransactions(ctx, req.(*GetTransactionsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CardV1_GetTransaction_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetTransactionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CardV1Server).GetTransaction(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/card_v1.CardV1/GetTransaction",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CardV1Server).GetTransaction(ctx, req.(*GetTransactionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CardV1_ExportStatement_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(ExportStatementRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(CardV1Server).ExportStatement(m, &cardV1ExportStatementServer{stream})
+}
+
+type CardV1_ExportStatementServer interface {
+	Send(*StatementChunk) error
+	grpc.ServerStream
+}
+
+type cardV1ExportStatementServer struct {
+	grpc.ServerStream
+}
+
+func (x *cardV1ExportStatementServer) Send(m *StatementChunk) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _CardV1_GetSpendingSummary_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetSpendingSummaryRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CardV1Server).GetSpendingSummary(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/card_v1.CardV1/GetSpendingSummary",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CardV1Server).GetSpendingSummary(ctx, req.(*GetSpendingSummaryRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CardV1_GetBalanceHistory_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetBalanceHistoryRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CardV1Server).GetBalanceHistory(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/card_v1.CardV1/GetBalanceHistory",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CardV1Server).GetBalanceHistory(ctx, req.(*GetBalanceHistoryRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CardV1_WatchCardEvents_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchCardEventsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(CardV1Server).WatchCardEvents(m, &cardV1WatchCardEventsServer{stream})
+}
+
+type CardV1_WatchCardEventsServer interface {
+	Send(*CardEvent) error
+	grpc.ServerStream
+}
+
+type cardV1WatchCardEventsServer struct {
+	grpc.ServerStream
+}
+
+func (x *cardV1WatchCardEventsServer) Send(m *CardEvent) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _CardV1_ProcessPayment_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ProcessPaymentRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CardV1Server).ProcessPayment(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/card_v1.CardV1/ProcessPayment",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CardV1Server).ProcessPayment(ctx, req.(*ProcessPaymentRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CardV1_ValidateCard_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ValidateCardRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CardV1Server).ValidateCard(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/card_v1.CardV1/ValidateCard",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CardV1Server).ValidateCard(ctx, req.(*ValidateCardRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// CardV1_ServiceDesc is the grpc.ServiceDesc for CardV1 service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var CardV1_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "card_v1.CardV1",
+	HandlerType: (*CardV1Server)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "AddCard",
+			Handler:    _CardV1_AddCard_Handler,
+		},
+		{
+			MethodName: "GetCard",
+			Handler:    _CardV1_GetCard_Handler,
+		},
+		{
+			MethodName: "GetUserCards",
+			Handler:    _CardV1_GetUserCards_Handler,
+		},
+		{
+			MethodName: "UpdateCard",
+			Handler:    _CardV1_UpdateCard_Handler,
+		},
+		{
+			MethodName: "DeleteCard",
+			Handler:    _CardV1_DeleteCard_Handler,
+		},
+		{
+			MethodName: "BlockCard",
+			Handler:    _CardV1_BlockCard_Handler,
+		},
+		{
+			MethodName: "UnblockCard",
+			Handler:    _CardV1_UnblockCard_Handler,
+		},
+		{
+			MethodName: "GetBalance",
+			Handler:    _CardV1_GetBalance_Handler,
+		},
+		{
+			MethodName: "Deposit",
+			Handler:    _CardV1_Deposit_Handler,
+		},
+		{
+			MethodName: "Withdraw",
+			Handler:    _CardV1_Withdraw_Handler,
+		},
+		{
+			MethodName: "Transfer",
+			Handler:    _CardV1_Transfer_Handler,
+		},
+		{
+			MethodName: "GetTransactions",
+			Handler:    _CardV1_GetTransactions_Handler,
+		},
+		{
+			MethodName: "GetTransaction",
+			Handler:    _CardV1_GetTransaction_Handler,
+		},
+		{
+			MethodName: "GetSpendingSummary",
+			Handler:    _CardV1_GetSpendingSummary_Handler,
+		},
+		{
+			MethodName: "GetBalanceHistory",
+			Handler:    _CardV1_GetBalanceHistory_Handler,
+		},
+		{
+			MethodName: "ProcessPayment",
+			Handler:    _CardV1_ProcessPayment_Handler,
+		},
+		{
+			MethodName: "ValidateCard",
+			Handler:    _CardV1_ValidateCard_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "ExportStatement",
+			Handler:       _CardV1_ExportStatement_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "WatchCardEvents",
+			Handler:       _CardV1_WatchCardEvents_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "user-card_v1/card.proto",
+}
@@ -0,0 +1,1462 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.2.0
+// - protoc             v3.21.12
+// source: user-card_v2/card.proto
+
+package card_v2
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+	emptypb "google.golang.org/protobuf/types/known/emptypb"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+// CardV2Client is the client API for CardV2 service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type CardV2Client interface {
+	// === УПРАВЛЕНИЕ КАРТАМИ ===
+	AddCard(ctx context.Context, in *AddCardRequest, opts ...grpc.CallOption) (*AddCardResponse, error)
+	GetCard(ctx context.Context, in *GetCardRequest, opts ...grpc.CallOption) (*GetCardResponse, error)
+	GetUserCards(ctx context.Context, in *GetUserCardsRequest, opts ...grpc.CallOption) (*GetUserCardsResponse, error)
+	UpdateCard(ctx context.Context, in *UpdateCardRequest, opts ...grpc.CallOption) (*UpdateCardResponse, error)
+	DeleteCard(ctx context.Context, in *DeleteCardRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	BlockCard(ctx context.Context, in *BlockCardRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	UnblockCard(ctx context.Context, in *UnblockCardRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	GetCardStatusHistory(ctx context.Context, in *GetCardStatusHistoryRequest, opts ...grpc.CallOption) (*GetCardStatusHistoryResponse, error)
+	// === ОПЕРАЦИИ С БАЛАНСОМ ===
+	GetBalance(ctx context.Context, in *GetBalanceRequest, opts ...grpc.CallOption) (*GetBalanceResponse, error)
+	Deposit(ctx context.Context, in *DepositRequest, opts ...grpc.CallOption) (*DepositResponse, error)
+	Withdraw(ctx context.Context, in *WithdrawRequest, opts ...grpc.CallOption) (*WithdrawResponse, error)
+	Transfer(ctx context.Context, in *TransferRequest, opts ...grpc.CallOption) (*TransferResponse, error)
+	// === ИСТОРИЯ ТРАНЗАКЦИЙ ===
+	GetTransactions(ctx context.Context, in *GetTransactionsRequest, opts ...grpc.CallOption) (*GetTransactionsResponse, error)
+	GetTransaction(ctx context.Context, in *GetTransactionRequest, opts ...grpc.CallOption) (*GetTransactionResponse, error)
+	// === АНАЛИТИКА ===
+	GetSpendingSummary(ctx context.Context, in *GetSpendingSummaryRequest, opts ...grpc.CallOption) (*GetSpendingSummaryResponse, error)
+	GetBalanceHistory(ctx context.Context, in *GetBalanceHistoryRequest, opts ...grpc.CallOption) (*GetBalanceHistoryResponse, error)
+	// === ДЛЯ ДРУГИХ СЕРВИСОВ (internal) ===
+	ProcessPayment(ctx context.Context, in *ProcessPaymentRequest, opts ...grpc.CallOption) (*ProcessPaymentResponse, error)
+	ProcessSplitPayment(ctx context.Context, in *ProcessSplitPaymentRequest, opts ...grpc.CallOption) (*ProcessSplitPaymentResponse, error)
+	GetPaymentsByOrder(ctx context.Context, in *GetPaymentsByOrderRequest, opts ...grpc.CallOption) (*GetPaymentsByOrderResponse, error)
+	ValidateCard(ctx context.Context, in *ValidateCardRequest, opts ...grpc.CallOption) (*ValidateCardResponse, error)
+	// === ДВУХФАЗНАЯ ОПЛАТА ЗАКАЗА ===
+	AuthorizePayment(ctx context.Context, in *AuthorizePaymentRequest, opts ...grpc.CallOption) (*AuthorizePaymentResponse, error)
+	CapturePayment(ctx context.Context, in *CapturePaymentRequest, opts ...grpc.CallOption) (*CapturePaymentResponse, error)
+	VoidAuthorization(ctx context.Context, in *VoidAuthorizationRequest, opts ...grpc.CallOption) (*VoidAuthorizationResponse, error)
+	// === ВОЗВРАТЫ ===
+	RefundPayment(ctx context.Context, in *RefundPaymentRequest, opts ...grpc.CallOption) (*RefundPaymentResponse, error)
+	// === ЛИМИТЫ ===
+	SetCardLimits(ctx context.Context, in *SetCardLimitsRequest, opts ...grpc.CallOption) (*SetCardLimitsResponse, error)
+	GetCardLimits(ctx context.Context, in *GetCardLimitsRequest, opts ...grpc.CallOption) (*GetCardLimitsResponse, error)
+	// === ЗАПЛАНИРОВАННЫЕ ПЕРЕВОДЫ ===
+	CreateScheduledTransfer(ctx context.Context, in *CreateScheduledTransferRequest, opts ...grpc.CallOption) (*CreateScheduledTransferResponse, error)
+	ListScheduledTransfers(ctx context.Context, in *ListScheduledTransfersRequest, opts ...grpc.CallOption) (*ListScheduledTransfersResponse, error)
+	CancelScheduledTransfer(ctx context.Context, in *CancelScheduledTransferRequest, opts ...grpc.CallOption) (*CancelScheduledTransferResponse, error)
+	// === ПЕРЕВОДЫ ПО НОМЕРУ ТЕЛЕФОНА ===
+	SetDefaultCard(ctx context.Context, in *SetDefaultCardRequest, opts ...grpc.CallOption) (*SetDefaultCardResponse, error)
+	PreviewTransferToUser(ctx context.Context, in *PreviewTransferToUserRequest, opts ...grpc.CallOption) (*PreviewTransferToUserResponse, error)
+	TransferToUser(ctx context.Context, in *TransferToUserRequest, opts ...grpc.CallOption) (*TransferToUserResponse, error)
+	// === ПОДАРОЧНЫЕ КАРТЫ ===
+	RedeemGiftCard(ctx context.Context, in *RedeemGiftCardRequest, opts ...grpc.CallOption) (*RedeemGiftCardResponse, error)
+	GetGiftCardBalance(ctx context.Context, in *GetGiftCardBalanceRequest, opts ...grpc.CallOption) (*GetGiftCardBalanceResponse, error)
+	// === АДМИНИСТРИРОВАНИЕ ===
+	SetCardStatus(ctx context.Context, in *SetCardStatusRequest, opts ...grpc.CallOption) (*SetCardStatusResponse, error)
+	ReconcileBalances(ctx context.Context, in *ReconcileBalancesRequest, opts ...grpc.CallOption) (*ReconcileBalancesResponse, error)
+	IssueGiftCard(ctx context.Context, in *IssueGiftCardRequest, opts ...grpc.CallOption) (*IssueGiftCardResponse, error)
+	AdminSetCardLimits(ctx context.Context, in *AdminSetCardLimitsRequest, opts ...grpc.CallOption) (*AdminSetCardLimitsResponse, error)
+}
+
+type cardV2Client struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewCardV2Client(cc grpc.ClientConnInterface) CardV2Client {
+	return &cardV2Client{cc}
+}
+
+func (c *cardV2Client) AddCard(ctx context.Context, in *AddCardRequest, opts ...grpc.CallOption) (*AddCardResponse, error) {
+	out := new(AddCardResponse)
+	err := c.cc.Invoke(ctx, "/card_v2.CardV2/AddCard", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *cardV2Client) GetCard(ctx context.Context, in *GetCardRequest, opts ...grpc.CallOption) (*GetCardResponse, error) {
+	out := new(GetCardResponse)
+	err := c.cc.Invoke(ctx, "/card_v2.CardV2/GetCard", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *cardV2Client) GetUserCards(ctx context.Context, in *GetUserCardsRequest, opts ...grpc.CallOption) (*GetUserCardsResponse, error) {
+	out := new(GetUserCardsResponse)
+	err := c.cc.Invoke(ctx, "/card_v2.CardV2/GetUserCards", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *cardV2Client) UpdateCard(ctx context.Context, in *UpdateCardRequest, opts ...grpc.CallOption) (*UpdateCardResponse, error) {
+	out := new(UpdateCardResponse)
+	err := c.cc.Invoke(ctx, "/card_v2.CardV2/UpdateCard", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *cardV2Client) DeleteCard(ctx context.Context, in *DeleteCardRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, "/card_v2.CardV2/DeleteCard", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *cardV2Client) BlockCard(ctx context.Context, in *BlockCardRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, "/card_v2.CardV2/BlockCard", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *cardV2Client) UnblockCard(ctx context.Context, in *UnblockCardRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, "/card_v2.CardV2/UnblockCard", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *cardV2Client) GetCardStatusHistory(ctx context.Context, in *GetCardStatusHistoryRequest, opts ...grpc.CallOption) (*GetCardStatusHistoryResponse, error) {
+	out := new(GetCardStatusHistoryResponse)
+	err := c.cc.Invoke(ctx, "/card_v2.CardV2/GetCardStatusHistory", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *cardV2Client) GetBalance(ctx context.Context, in *GetBalanceRequest, opts ...grpc.CallOption) (*GetBalanceResponse, error) {
+	out := new(GetBalanceResponse)
+	err := c.cc.Invoke(ctx, "/card_v2.CardV2/GetBalance", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *cardV2Client) Deposit(ctx context.Context, in *DepositRequest, opts ...grpc.CallOption) (*DepositResponse, error) {
+	out := new(DepositResponse)
+	err := c.cc.Invoke(ctx, "/card_v2.CardV2/Deposit", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *cardV2Client) Withdraw(ctx context.Context, in *WithdrawRequest, opts ...grpc.CallOption) (*WithdrawResponse, error) {
+	out := new(WithdrawResponse)
+	err := c.cc.Invoke(ctx, "/card_v2.CardV2/Withdraw", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *cardV2Client) Transfer(ctx context.Context, in *TransferRequest, opts ...grpc.CallOption) (*TransferResponse, error) {
+	out := new(TransferResponse)
+	err := c.cc.Invoke(ctx, "/card_v2.CardV2/Transfer", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *cardV2Client) GetTransactions(ctx context.Context, in *GetTransactionsRequest, opts ...grpc.CallOption) (*GetTransactionsResponse, error) {
+	out := new(GetTransactionsResponse)
+	err := c.cc.Invoke(ctx, "/card_v2.CardV2/GetTransactions", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *cardV2Client) GetTransaction(ctx context.Context, in *GetTransactionRequest, opts ...grpc.CallOption) (*GetTransactionResponse, error) {
+	out := new(GetTransactionResponse)
+	err := c.cc.Invoke(ctx, "/card_v2.CardV2/GetTransaction", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *cardV2Client) GetSpendingSummary(ctx context.Context, in *GetSpendingSummaryRequest, opts ...grpc.CallOption) (*GetSpendingSummaryResponse, error) {
+	out := new(GetSpendingSummaryResponse)
+	err := c.cc.Invoke(ctx, "/card_v2.CardV2/GetSpendingSummary", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *cardV2Client) GetBalanceHistory(ctx context.Context, in *GetBalanceHistoryRequest, opts ...grpc.CallOption) (*GetBalanceHistoryResponse, error) {
+	out := new(GetBalanceHistoryResponse)
+	err := c.cc.Invoke(ctx, "/card_v2.CardV2/GetBalanceHistory", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *cardV2Client) ProcessPayment(ctx context.Context, in *ProcessPaymentRequest, opts ...grpc.CallOption) (*ProcessPaymentResponse, error) {
+	out := new(ProcessPaymentResponse)
+	err := c.cc.Invoke(ctx, "/card_v2.CardV2/ProcessPayment", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *cardV2Client) ProcessSplitPayment(ctx context.Context, in *ProcessSplitPaymentRequest, opts ...grpc.CallOption) (*ProcessSplitPaymentResponse, error) {
+	out := new(ProcessSplitPaymentResponse)
+	err := c.cc.Invoke(ctx, "/card_v2.CardV2/ProcessSplitPayment", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *cardV2Client) GetPaymentsByOrder(ctx context.Context, in *GetPaymentsByOrderRequest, opts ...grpc.CallOption) (*GetPaymentsByOrderResponse, error) {
+	out := new(GetPaymentsByOrderResponse)
+	err := c.cc.Invoke(ctx, "/card_v2.CardV2/GetPaymentsByOrder", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *cardV2Client) ValidateCard(ctx context.Context, in *ValidateCardRequest, opts ...grpc.CallOption) (*ValidateCardResponse, error) {
+	out := new(ValidateCardResponse)
+	err := c.cc.Invoke(ctx, "/card_v2.CardV2/ValidateCard", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *cardV2Client) AuthorizePayment(ctx context.Context, in *AuthorizePaymentRequest, opts ...grpc.CallOption) (*AuthorizePaymentResponse, error) {
+	out := new(AuthorizePaymentResponse)
+	err := c.cc.Invoke(ctx, "/card_v2.CardV2/AuthorizePayment", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *cardV2Client) CapturePayment(ctx context.Context, in *CapturePaymentRequest, opts ...grpc.CallOption) (*CapturePaymentResponse, error) {
+	out := new(CapturePaymentResponse)
+	err := c.cc.Invoke(ctx, "/card_v2.CardV2/CapturePayment", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *cardV2Client) VoidAuthorization(ctx context.Context, in *VoidAuthorizationRequest, opts ...grpc.CallOption) (*VoidAuthorizationResponse, error) {
+	out := new(VoidAuthorizationResponse)
+	err := c.cc.Invoke(ctx, "/card_v2.CardV2/VoidAuthorization", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *cardV2Client) RefundPayment(ctx context.Context, in *RefundPaymentRequest, opts ...grpc.CallOption) (*RefundPaymentResponse, error) {
+	out := new(RefundPaymentResponse)
+	err := c.cc.Invoke(ctx, "/card_v2.CardV2/RefundPayment", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *cardV2Client) SetCardLimits(ctx context.Context, in *SetCardLimitsRequest, opts ...grpc.CallOption) (*SetCardLimitsResponse, error) {
+	out := new(SetCardLimitsResponse)
+	err := c.cc.Invoke(ctx, "/card_v2.CardV2/SetCardLimits", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *cardV2Client) GetCardLimits(ctx context.Context, in *GetCardLimitsRequest, opts ...grpc.CallOption) (*GetCardLimitsResponse, error) {
+	out := new(GetCardLimitsResponse)
+	err := c.cc.Invoke(ctx, "/card_v2.CardV2/GetCardLimits", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *cardV2Client) CreateScheduledTransfer(ctx context.Context, in *CreateScheduledTransferRequest, opts ...grpc.CallOption) (*CreateScheduledTransferResponse, error) {
+	out := new(CreateScheduledTransferResponse)
+	err := c.cc.Invoke(ctx, "/card_v2.CardV2/CreateScheduledTransfer", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *cardV2Client) ListScheduledTransfers(ctx context.Context, in *ListScheduledTransfersRequest, opts ...grpc.CallOption) (*ListScheduledTransfersResponse, error) {
+	out := new(ListScheduledTransfersResponse)
+	err := c.cc.Invoke(ctx, "/card_v2.CardV2/ListScheduledTransfers", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *cardV2Client) CancelScheduledTransfer(ctx context.Context, in *CancelScheduledTransferRequest, opts ...grpc.CallOption) (*CancelScheduledTransferResponse, error) {
+	out := new(CancelScheduledTransferResponse)
+	err := c.cc.Invoke(ctx, "/card_v2.CardV2/CancelScheduledTransfer", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *cardV2Client) SetDefaultCard(ctx context.Context, in *SetDefaultCardRequest, opts ...grpc.CallOption) (*SetDefaultCardResponse, error) {
+	out := new(SetDefaultCardResponse)
+	err := c.cc.Invoke(ctx, "/card_v2.CardV2/SetDefaultCard", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *cardV2Client) PreviewTransferToUser(ctx context.Context, in *PreviewTransferToUserRequest, opts ...grpc.CallOption) (*PreviewTransferToUserResponse, error) {
+	out := new(PreviewTransferToUserResponse)
+	err := c.cc.Invoke(ctx, "/card_v2.CardV2/PreviewTransferToUser", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *cardV2Client) TransferToUser(ctx context.Context, in *TransferToUserRequest, opts ...grpc.CallOption) (*TransferToUserResponse, error) {
+	out := new(TransferToUserResponse)
+	err := c.cc.Invoke(ctx, "/card_v2.CardV2/TransferToUser", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *cardV2Client) RedeemGiftCard(ctx context.Context, in *RedeemGiftCardRequest, opts ...grpc.CallOption) (*RedeemGiftCardResponse, error) {
+	out := new(RedeemGiftCardResponse)
+	err := c.cc.Invoke(ctx, "/card_v2.CardV2/RedeemGiftCard", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *cardV2Client) GetGiftCardBalance(ctx context.Context, in *GetGiftCardBalanceRequest, opts ...grpc.CallOption) (*GetGiftCardBalanceResponse, error) {
+	out := new(GetGiftCardBalanceResponse)
+	err := c.cc.Invoke(ctx, "/card_v2.CardV2/GetGiftCardBalance", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *cardV2Client) SetCardStatus(ctx context.Context, in *SetCardStatusRequest, opts ...grpc.CallOption) (*SetCardStatusResponse, error) {
+	out := new(SetCardStatusResponse)
+	err := c.cc.Invoke(ctx, "/card_v2.CardV2/SetCardStatus", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *cardV2Client) ReconcileBalances(ctx context.Context, in *ReconcileBalancesRequest, opts ...grpc.CallOption) (*ReconcileBalancesResponse, error) {
+	out := new(ReconcileBalancesResponse)
+	err := c.cc.Invoke(ctx, "/card_v2.CardV2/ReconcileBalances", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *cardV2Client) IssueGiftCard(ctx context.Context, in *IssueGiftCardRequest, opts ...grpc.CallOption) (*IssueGiftCardResponse, error) {
+	out := new(IssueGiftCardResponse)
+	err := c.cc.Invoke(ctx, "/card_v2.CardV2/IssueGiftCard", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *cardV2Client) AdminSetCardLimits(ctx context.Context, in *AdminSetCardLimitsRequest, opts ...grpc.CallOption) (*AdminSetCardLimitsResponse, error) {
+	out := new(AdminSetCardLimitsResponse)
+	err := c.cc.Invoke(ctx, "/card_v2.CardV2/AdminSetCardLimits", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// CardV2Server is the server API for CardV2 service.
+// All implementations must embed UnimplementedCardV2Server
+// for forward compatibility
+type CardV2Server interface {
+	// === УПРАВЛЕНИЕ КАРТАМИ ===
+	AddCard(context.Context, *AddCardRequest) (*AddCardResponse, error)
+	GetCard(context.Context, *GetCardRequest) (*GetCardResponse, error)
+	GetUserCards(context.Context, *GetUserCardsRequest) (*GetUserCardsResponse, error)
+	UpdateCard(context.Context, *UpdateCardRequest) (*UpdateCardResponse, error)
+	DeleteCard(context.Context, *DeleteCardRequest) (*emptypb.Empty, error)
+	BlockCard(context.Context, *BlockCardRequest) (*emptypb.Empty, error)
+	UnblockCard(context.Context, *UnblockCardRequest) (*emptypb.Empty, error)
+	GetCardStatusHistory(context.Context, *GetCardStatusHistoryRequest) (*GetCardStatusHistoryResponse, error)
+	// === ОПЕРАЦИИ С БАЛАНСОМ ===
+	GetBalance(context.Context, *GetBalanceRequest) (*GetBalanceResponse, error)
+	Deposit(context.Context, *DepositRequest) (*DepositResponse, error)
+	Withdraw(context.Context, *WithdrawRequest) (*WithdrawResponse, error)
+	Transfer(context.Context, *TransferRequest) (*TransferResponse, error)
+	// === ИСТОРИЯ ТРАНЗАКЦИЙ ===
+	GetTransactions(context.Context, *GetTransactionsRequest) (*GetTransactionsResponse, error)
+	GetTransaction(context.Context, *GetTransactionRequest) (*GetTransactionResponse, error)
+	// === АНАЛИТИКА ===
+	GetSpendingSummary(context.Context, *GetSpendingSummaryRequest) (*GetSpendingSummaryResponse, error)
+	GetBalanceHistory(context.Context, *GetBalanceHistoryRequest) (*GetBalanceHistoryResponse, error)
+	// === ДЛЯ ДРУГИХ СЕРВИСОВ (internal) ===
+	ProcessPayment(context.Context, *ProcessPaymentRequest) (*ProcessPaymentResponse, error)
+	ProcessSplitPayment(context.Context, *ProcessSplitPaymentRequest) (*ProcessSplitPaymentResponse, error)
+	GetPaymentsByOrder(context.Context, *GetPaymentsByOrderRequest) (*GetPaymentsByOrderResponse, error)
+	ValidateCard(context.Context, *ValidateCardRequest) (*ValidateCardResponse, error)
+	// === ДВУХФАЗНАЯ ОПЛАТА ЗАКАЗА ===
+	AuthorizePayment(context.Context, *AuthorizePaymentRequest) (*AuthorizePaymentResponse, error)
+	CapturePayment(context.Context, *CapturePaymentRequest) (*CapturePaymentResponse, error)
+	VoidAuthorization(context.Context, *VoidAuthorizationRequest) (*VoidAuthorizationResponse, error)
+	// === ВОЗВРАТЫ ===
+	RefundPayment(context.Context, *RefundPaymentRequest) (*RefundPaymentResponse, error)
+	// === ЛИМИТЫ ===
+	SetCardLimits(context.Context, *SetCardLimitsRequest) (*SetCardLimitsResponse, error)
+	GetCardLimits(context.Context, *GetCardLimitsRequest) (*GetCardLimitsResponse, error)
+	// === ЗАПЛАНИРОВАННЫЕ ПЕРЕВОДЫ ===
+	CreateScheduledTransfer(context.Context, *CreateScheduledTransferRequest) (*CreateScheduledTransferResponse, error)
+	ListScheduledTransfers(context.Context, *ListScheduledTransfersRequest) (*ListScheduledTransfersResponse, error)
+	CancelScheduledTransfer(context.Context, *CancelScheduledTransferRequest) (*CancelScheduledTransferResponse, error)
+	// === ПЕРЕВОДЫ ПО НОМЕРУ ТЕЛЕФОНА ===
+	SetDefaultCard(context.Context, *SetDefaultCardRequest) (*SetDefaultCardResponse, error)
+	PreviewTransferToUser(context.Context, *PreviewTransferToUserRequest) (*PreviewTransferToUserResponse, error)
+	TransferToUser(context.Context, *TransferToUserRequest) (*TransferToUserResponse, error)
+	// === ПОДАРОЧНЫЕ КАРТЫ ===
+	RedeemGiftCard(context.Context, *RedeemGiftCardRequest) (*RedeemGiftCardResponse, error)
+	GetGiftCardBalance(context.Context, *GetGiftCardBalanceRequest) (*GetGiftCardBalanceResponse, error)
+	// === АДМИНИСТРИРОВАНИЕ ===
+	SetCardStatus(context.Context, *SetCardStatusRequest) (*SetCardStatusResponse, error)
+	ReconcileBalances(context.Context, *ReconcileBalancesRequest) (*ReconcileBalancesResponse, error)
+	IssueGiftCard(context.Context, *IssueGiftCardRequest) (*IssueGiftCardResponse, error)
+	AdminSetCardLimits(context.Context, *AdminSetCardLimitsRequest) (*AdminSetCardLimitsResponse, error)
+	mustEmbedUnimplementedCardV2Server()
+}
+
+// UnimplementedCardV2Server must be embedded to have forward compatible implementations.
+type UnimplementedCardV2Server struct {
+}
+
+func (UnimplementedCardV2Server) AddCard(context.Context, *AddCardRequest) (*AddCardResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method AddCard not implemented")
+}
+func (UnimplementedCardV2Server) GetCard(context.Context, *GetCardRequest) (*GetCardResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetCard not implemented")
+}
+func (UnimplementedCardV2Server) GetUserCards(context.Context, *GetUserCardsRequest) (*GetUserCardsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetUserCards not implemented")
+}
+func (UnimplementedCardV2Server) UpdateCard(context.Context, *UpdateCardRequest) (*UpdateCardResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UpdateCard not implemented")
+}
+func (UnimplementedCardV2Server) DeleteCard(context.Context, *DeleteCardRequest) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteCard not implemented")
+}
+func (UnimplementedCardV2Server) BlockCard(context.Context, *BlockCardRequest) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method BlockCard not implemented")
+}
+func (UnimplementedCardV2Server) UnblockCard(context.Context, *UnblockCardRequest) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UnblockCard not implemented")
+}
+func (UnimplementedCardV2Server) GetCardStatusHistory(context.Context, *GetCardStatusHistoryRequest) (*GetCardStatusHistoryResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetCardStatusHistory not implemented")
+}
+func (UnimplementedCardV2Server) GetBalance(context.Context, *GetBalanceRequest) (*GetBalanceResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetBalance not implemented")
+}
+func (UnimplementedCardV2Server) Deposit(context.Context, *DepositRequest) (*DepositResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Deposit not implemented")
+}
+func (UnimplementedCardV2Server) Withdraw(context.Context, *WithdrawRequest) (*WithdrawResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Withdraw not implemented")
+}
+func (UnimplementedCardV2Server) Transfer(context.Context, *TransferRequest) (*TransferResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Transfer not implemented")
+}
+func (UnimplementedCardV2Server) GetTransactions(context.Context, *GetTransactionsRequest) (*GetTransactionsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetTransactions not implemented")
+}
+func (UnimplementedCardV2Server) GetTransaction(context.Context, *GetTransactionRequest) (*GetTransactionResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetTransaction not implemented")
+}
+func (UnimplementedCardV2Server) GetSpendingSummary(context.Context, *GetSpendingSummaryRequest) (*GetSpendingSummaryResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetSpendingSummary not implemented")
+}
+func (UnimplementedCardV2Server) GetBalanceHistory(context.Context, *GetBalanceHistoryRequest) (*GetBalanceHistoryResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetBalanceHistory not implemented")
+}
+func (UnimplementedCardV2Server) ProcessPayment(context.Context, *ProcessPaymentRequest) (*ProcessPaymentResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ProcessPayment not implemented")
+}
+func (UnimplementedCardV2Server) ProcessSplitPayment(context.Context, *ProcessSplitPaymentRequest) (*ProcessSplitPaymentResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ProcessSplitPayment not implemented")
+}
+func (UnimplementedCardV2Server) GetPaymentsByOrder(context.Context, *GetPaymentsByOrderRequest) (*GetPaymentsByOrderResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetPaymentsByOrder not implemented")
+}
+func (UnimplementedCardV2Server) ValidateCard(context.Context, *ValidateCardRequest) (*ValidateCardResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ValidateCard not implemented")
+}
+func (UnimplementedCardV2Server) AuthorizePayment(context.Context, *AuthorizePaymentRequest) (*AuthorizePaymentResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method AuthorizePayment not implemented")
+}
+func (UnimplementedCardV2Server) CapturePayment(context.Context, *CapturePaymentRequest) (*CapturePaymentResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CapturePayment not implemented")
+}
+func (UnimplementedCardV2Server) VoidAuthorization(context.Context, *VoidAuthorizationRequest) (*VoidAuthorizationResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method VoidAuthorization not implemented")
+}
+func (UnimplementedCardV2Server) RefundPayment(context.Context, *RefundPaymentRequest) (*RefundPaymentResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RefundPayment not implemented")
+}
+func (UnimplementedCardV2Server) SetCardLimits(context.Context, *SetCardLimitsRequest) (*SetCardLimitsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SetCardLimits not implemented")
+}
+func (UnimplementedCardV2Server) GetCardLimits(context.Context, *GetCardLimitsRequest) (*GetCardLimitsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetCardLimits not implemented")
+}
+func (UnimplementedCardV2Server) CreateScheduledTransfer(context.Context, *CreateScheduledTransferRequest) (*CreateScheduledTransferResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreateScheduledTransfer not implemented")
+}
+func (UnimplementedCardV2Server) ListScheduledTransfers(context.Context, *ListScheduledTransfersRequest) (*ListScheduledTransfersResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListScheduledTransfers not implemented")
+}
+func (UnimplementedCardV2Server) CancelScheduledTransfer(context.Context, *CancelScheduledTransferRequest) (*CancelScheduledTransferResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CancelScheduledTransfer not implemented")
+}
+func (UnimplementedCardV2Server) SetDefaultCard(context.Context, *SetDefaultCardRequest) (*SetDefaultCardResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SetDefaultCard not implemented")
+}
+func (UnimplementedCardV2Server) PreviewTransferToUser(context.Context, *PreviewTransferToUserRequest) (*PreviewTransferToUserResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method PreviewTransferToUser not implemented")
+}
+func (UnimplementedCardV2Server) TransferToUser(context.Context, *TransferToUserRequest) (*TransferToUserResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method TransferToUser not implemented")
+}
+func (UnimplementedCardV2Server) RedeemGiftCard(context.Context, *RedeemGiftCardRequest) (*RedeemGiftCardResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RedeemGiftCard not implemented")
+}
+func (UnimplementedCardV2Server) GetGiftCardBalance(context.Context, *GetGiftCardBalanceRequest) (*GetGiftCardBalanceResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetGiftCardBalance not implemented")
+}
+func (UnimplementedCardV2Server) SetCardStatus(context.Context, *SetCardStatusRequest) (*SetCardStatusResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SetCardStatus not implemented")
+}
+func (UnimplementedCardV2Server) ReconcileBalances(context.Context, *ReconcileBalancesRequest) (*ReconcileBalancesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ReconcileBalances not implemented")
+}
+func (UnimplementedCardV2Server) IssueGiftCard(context.Context, *IssueGiftCardRequest) (*IssueGiftCardResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method IssueGiftCard not implemented")
+}
+func (UnimplementedCardV2Server) AdminSetCardLimits(context.Context, *AdminSetCardLimitsRequest) (*AdminSetCardLimitsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method AdminSetCardLimits not implemented")
+}
+func (UnimplementedCardV2Server) mustEmbedUnimplementedCardV2Server() {}
+
+// UnsafeCardV2Server may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to CardV2Server will
+// result in compilation errors.
+type UnsafeCardV2Server interface {
+	mustEmbedUnimplementedCardV2Server()
+}
+
+func RegisterCardV2Server(s grpc.ServiceRegistrar, srv CardV2Server) {
+	s.RegisterService(&CardV2_ServiceDesc, srv)
+}
+
+func _CardV2_AddCard_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AddCardRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CardV2Server).AddCard(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/card_v2.CardV2/AddCard",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CardV2Server).AddCard(ctx, req.(*AddCardRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CardV2_GetCard_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetCardRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CardV2Server).GetCard(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/card_v2.CardV2/GetCard",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CardV2Server).GetCard(ctx, req.(*GetCardRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CardV2_GetUserCards_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetUserCardsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CardV2Server).GetUserCards(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/card_v2.CardV2/GetUserCards",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CardV2Server).GetUserCards(ctx, req.(*GetUserCardsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CardV2_UpdateCard_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpdateCardRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CardV2Server).UpdateCard(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/card_v2.CardV2/UpdateCard",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CardV2Server).UpdateCard(ctx, req.(*UpdateCardRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CardV2_DeleteCard_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteCardRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CardV2Server).DeleteCard(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/card_v2.CardV2/DeleteCard",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CardV2Server).DeleteCard(ctx, req.(*DeleteCardRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CardV2_BlockCard_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(BlockCardRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CardV2Server).BlockCard(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/card_v2.CardV2/BlockCard",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CardV2Server).BlockCard(ctx, req.(*BlockCardRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CardV2_UnblockCard_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UnblockCardRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CardV2Server).UnblockCard(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/card_v2.CardV2/UnblockCard",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CardV2Server).UnblockCard(ctx, req.(*UnblockCardRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CardV2_GetCardStatusHistory_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetCardStatusHistoryRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CardV2Server).GetCardStatusHistory(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/card_v2.CardV2/GetCardStatusHistory",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CardV2Server).GetCardStatusHistory(ctx, req.(*GetCardStatusHistoryRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CardV2_GetBalance_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetBalanceRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CardV2Server).GetBalance(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/card_v2.CardV2/GetBalance",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CardV2Server).GetBalance(ctx, req.(*GetBalanceRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CardV2_Deposit_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DepositRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CardV2Server).Deposit(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/card_v2.CardV2/Deposit",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CardV2Server).Deposit(ctx, req.(*DepositRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CardV2_Withdraw_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(WithdrawRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CardV2Server).Withdraw(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/card_v2.CardV2/Withdraw",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CardV2Server).Withdraw(ctx, req.(*WithdrawRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CardV2_Transfer_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(TransferRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CardV2Server).Transfer(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/card_v2.CardV2/Transfer",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CardV2Server).Transfer(ctx, req.(*TransferRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CardV2_GetTransactions_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetTransactionsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CardV2Server).GetTransactions(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/card_v2.CardV2/GetTransactions",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CardV2Server).GetTransactions(ctx, req.(*GetTransactionsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CardV2_GetTransaction_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetTransactionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CardV2Server).GetTransaction(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/card_v2.CardV2/GetTransaction",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CardV2Server).GetTransaction(ctx, req.(*GetTransactionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CardV2_GetSpendingSummary_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetSpendingSummaryRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CardV2Server).GetSpendingSummary(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/card_v2.CardV2/GetSpendingSummary",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CardV2Server).GetSpendingSummary(ctx, req.(*GetSpendingSummaryRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CardV2_GetBalanceHistory_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetBalanceHistoryRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CardV2Server).GetBalanceHistory(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/card_v2.CardV2/GetBalanceHistory",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CardV2Server).GetBalanceHistory(ctx, req.(*GetBalanceHistoryRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CardV2_ProcessPayment_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ProcessPaymentRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CardV2Server).ProcessPayment(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/card_v2.CardV2/ProcessPayment",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CardV2Server).ProcessPayment(ctx, req.(*ProcessPaymentRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CardV2_ProcessSplitPayment_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ProcessSplitPaymentRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CardV2Server).ProcessSplitPayment(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/card_v2.CardV2/ProcessSplitPayment",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CardV2Server).ProcessSplitPayment(ctx, req.(*ProcessSplitPaymentRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CardV2_GetPaymentsByOrder_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetPaymentsByOrderRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CardV2Server).GetPaymentsByOrder(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/card_v2.CardV2/GetPaymentsByOrder",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CardV2Server).GetPaymentsByOrder(ctx, req.(*GetPaymentsByOrderRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CardV2_ValidateCard_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ValidateCardRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CardV2Server).ValidateCard(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/card_v2.CardV2/ValidateCard",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CardV2Server).ValidateCard(ctx, req.(*ValidateCardRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CardV2_AuthorizePayment_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AuthorizePaymentRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CardV2Server).AuthorizePayment(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/card_v2.CardV2/AuthorizePayment",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CardV2Server).AuthorizePayment(ctx, req.(*AuthorizePaymentRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CardV2_CapturePayment_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CapturePaymentRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CardV2Server).CapturePayment(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/card_v2.CardV2/CapturePayment",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CardV2Server).CapturePayment(ctx, req.(*CapturePaymentRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CardV2_VoidAuthorization_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(VoidAuthorizationRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CardV2Server).VoidAuthorization(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/card_v2.CardV2/VoidAuthorization",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CardV2Server).VoidAuthorization(ctx, req.(*VoidAuthorizationRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CardV2_RefundPayment_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RefundPaymentRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CardV2Server).RefundPayment(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/card_v2.CardV2/RefundPayment",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CardV2Server).RefundPayment(ctx, req.(*RefundPaymentRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CardV2_SetCardLimits_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SetCardLimitsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CardV2Server).SetCardLimits(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/card_v2.CardV2/SetCardLimits",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CardV2Server).SetCardLimits(ctx, req.(*SetCardLimitsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CardV2_GetCardLimits_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetCardLimitsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CardV2Server).GetCardLimits(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/card_v2.CardV2/GetCardLimits",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CardV2Server).GetCardLimits(ctx, req.(*GetCardLimitsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CardV2_CreateScheduledTransfer_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreateScheduledTransferRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CardV2Server).CreateScheduledTransfer(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/card_v2.CardV2/CreateScheduledTransfer",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CardV2Server).CreateScheduledTransfer(ctx, req.(*CreateScheduledTransferRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CardV2_ListScheduledTransfers_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListScheduledTransfersRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CardV2Server).ListScheduledTransfers(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/card_v2.CardV2/ListScheduledTransfers",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CardV2Server).ListScheduledTransfers(ctx, req.(*ListScheduledTransfersRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CardV2_CancelScheduledTransfer_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CancelScheduledTransferRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CardV2Server).CancelScheduledTransfer(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/card_v2.CardV2/CancelScheduledTransfer",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CardV2Server).CancelScheduledTransfer(ctx, req.(*CancelScheduledTransferRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CardV2_SetDefaultCard_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SetDefaultCardRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CardV2Server).SetDefaultCard(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/card_v2.CardV2/SetDefaultCard",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CardV2Server).SetDefaultCard(ctx, req.(*SetDefaultCardRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CardV2_PreviewTransferToUser_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PreviewTransferToUserRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CardV2Server).PreviewTransferToUser(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/card_v2.CardV2/PreviewTransferToUser",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CardV2Server).PreviewTransferToUser(ctx, req.(*PreviewTransferToUserRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CardV2_TransferToUser_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(TransferToUserRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CardV2Server).TransferToUser(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/card_v2.CardV2/TransferToUser",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CardV2Server).TransferToUser(ctx, req.(*TransferToUserRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CardV2_RedeemGiftCard_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RedeemGiftCardRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CardV2Server).RedeemGiftCard(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/card_v2.CardV2/RedeemGiftCard",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CardV2Server).RedeemGiftCard(ctx, req.(*RedeemGiftCardRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CardV2_GetGiftCardBalance_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetGiftCardBalanceRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CardV2Server).GetGiftCardBalance(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/card_v2.CardV2/GetGiftCardBalance",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CardV2Server).GetGiftCardBalance(ctx, req.(*GetGiftCardBalanceRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CardV2_SetCardStatus_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SetCardStatusRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CardV2Server).SetCardStatus(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/card_v2.CardV2/SetCardStatus",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CardV2Server).SetCardStatus(ctx, req.(*SetCardStatusRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CardV2_ReconcileBalances_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ReconcileBalancesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CardV2Server).ReconcileBalances(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/card_v2.CardV2/ReconcileBalances",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CardV2Server).ReconcileBalances(ctx, req.(*ReconcileBalancesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CardV2_IssueGiftCard_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(IssueGiftCardRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CardV2Server).IssueGiftCard(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/card_v2.CardV2/IssueGiftCard",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CardV2Server).IssueGiftCard(ctx, req.(*IssueGiftCardRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CardV2_AdminSetCardLimits_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AdminSetCardLimitsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CardV2Server).AdminSetCardLimits(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/card_v2.CardV2/AdminSetCardLimits",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CardV2Server).AdminSetCardLimits(ctx, req.(*AdminSetCardLimitsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// CardV2_ServiceDesc is the grpc.ServiceDesc for CardV2 service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var CardV2_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "card_v2.CardV2",
+	HandlerType: (*CardV2Server)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "AddCard",
+			Handler:    _CardV2_AddCard_Handler,
+		},
+		{
+			MethodName: "GetCard",
+			Handler:    _CardV2_GetCard_Handler,
+		},
+		{
+			MethodName: "GetUserCards",
+			Handler:    _CardV2_GetUserCards_Handler,
+		},
+		{
+			MethodName: "UpdateCard",
+			Handler:    _CardV2_UpdateCard_Handler,
+		},
+		{
+			MethodName: "DeleteCard",
+			Handler:    _CardV2_DeleteCard_Handler,
+		},
+		{
+			MethodName: "BlockCard",
+			Handler:    _CardV2_BlockCard_Handler,
+		},
+		{
+			MethodName: "UnblockCard",
+			Handler:    _CardV2_UnblockCard_Handler,
+		},
+		{
+			MethodName: "GetCardStatusHistory",
+			Handler:    _CardV2_GetCardStatusHistory_Handler,
+		},
+		{
+			MethodName: "GetBalance",
+			Handler:    _CardV2_GetBalance_Handler,
+		},
+		{
+			MethodName: "Deposit",
+			Handler:    _CardV2_Deposit_Handler,
+		},
+		{
+			MethodName: "Withdraw",
+			Handler:    _CardV2_Withdraw_Handler,
+		},
+		{
+			MethodName: "Transfer",
+			Handler:    _CardV2_Transfer_Handler,
+		},
+		{
+			MethodName: "GetTransactions",
+			Handler:    _CardV2_GetTransactions_Handler,
+		},
+		{
+			MethodName: "GetTransaction",
+			Handler:    _CardV2_GetTransaction_Handler,
+		},
+		{
+			MethodName: "GetSpendingSummary",
+			Handler:    _CardV2_GetSpendingSummary_Handler,
+		},
+		{
+			MethodName: "GetBalanceHistory",
+			Handler:    _CardV2_GetBalanceHistory_Handler,
+		},
+		{
+			MethodName: "ProcessPayment",
+			Handler:    _CardV2_ProcessPayment_Handler,
+		},
+		{
+			MethodName: "ProcessSplitPayment",
+			Handler:    _CardV2_ProcessSplitPayment_Handler,
+		},
+		{
+			MethodName: "GetPaymentsByOrder",
+			Handler:    _CardV2_GetPaymentsByOrder_Handler,
+		},
+		{
+			MethodName: "ValidateCard",
+			Handler:    _CardV2_ValidateCard_Handler,
+		},
+		{
+			MethodName: "AuthorizePayment",
+			Handler:    _CardV2_AuthorizePayment_Handler,
+		},
+		{
+			MethodName: "CapturePayment",
+			Handler:    _CardV2_CapturePayment_Handler,
+		},
+		{
+			MethodName: "VoidAuthorization",
+			Handler:    _CardV2_VoidAuthorization_Handler,
+		},
+		{
+			MethodName: "RefundPayment",
+			Handler:    _CardV2_RefundPayment_Handler,
+		},
+		{
+			MethodName: "SetCardLimits",
+			Handler:    _CardV2_SetCardLimits_Handler,
+		},
+		{
+			MethodName: "GetCardLimits",
+			Handler:    _CardV2_GetCardLimits_Handler,
+		},
+		{
+			MethodName: "CreateScheduledTransfer",
+			Handler:    _CardV2_CreateScheduledTransfer_Handler,
+		},
+		{
+			MethodName: "ListScheduledTransfers",
+			Handler:    _CardV2_ListScheduledTransfers_Handler,
+		},
+		{
+			MethodName: "CancelScheduledTransfer",
+			Handler:    _CardV2_CancelScheduledTransfer_Handler,
+		},
+		{
+			MethodName: "SetDefaultCard",
+			Handler:    _CardV2_SetDefaultCard_Handler,
+		},
+		{
+			MethodName: "PreviewTransferToUser",
+			Handler:    _CardV2_PreviewTransferToUser_Handler,
+		},
+		{
+			MethodName: "TransferToUser",
+			Handler:    _CardV2_TransferToUser_Handler,
+		},
+		{
+			MethodName: "RedeemGiftCard",
+			Handler:    _CardV2_RedeemGiftCard_Handler,
+		},
+		{
+			MethodName: "GetGiftCardBalance",
+			Handler:    _CardV2_GetGiftCardBalance_Handler,
+		},
+		{
+			MethodName: "SetCardStatus",
+			Handler:    _CardV2_SetCardStatus_Handler,
+		},
+		{
+			MethodName: "ReconcileBalances",
+			Handler:    _CardV2_ReconcileBalances_Handler,
+		},
+		{
+			MethodName: "IssueGiftCard",
+			Handler:    _CardV2_IssueGiftCard_Handler,
+		},
+		{
+			MethodName: "AdminSetCardLimits",
+			Handler:    _CardV2_AdminSetCardLimits_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "user-card_v2/card.proto",
+}
@@ -2,6 +2,7 @@ package config
 
 import (
 	"fmt"
+	"os"
 	"time"
 
 	"github.com/spf13/viper"
@@ -30,8 +31,10 @@ type DatabaseConfig struct {
 
 type JWTConfig struct {
 	SecretKey       string
-	AccessTokenTTL  int // in minutes
-	RefreshTokenTTL int // in minutes
+	AccessTokenTTL  int                 // in minutes
+	RefreshTokenTTL int                 // in minutes
+	ServiceTokens   string              // USER_SERVICE_TOKENS: "<name>:<token>" через запятую, только из окружения
+	ServiceScopes   map[string][]string // области методов для сервисов по имени из ServiceTokens
 }
 type RateLimiterConfig struct {
 	RequestsPerSecond int
@@ -80,7 +83,7 @@ func Load() (*Config, error) {
 	v.SetDefault("rate_limit.requests_per_second", 100)
 	if err := v.ReadInConfig(); err != nil {
 		if _, ok := err.(viper.ConfigFileNotFoundError); !ok {
-			return nil, fmt.Errorf("Error reading config file:", err)
+			return nil, fmt.Errorf("Error reading config file: %w", err)
 		}
 	}
 	accessDuration, err := time.ParseDuration(v.GetString("jwt.access_token_duration"))
@@ -111,6 +114,8 @@ func Load() (*Config, error) {
 			SecretKey:       v.GetString("jwt.secret_key"),
 			AccessTokenTTL:  int(accessDuration.Minutes()),
 			RefreshTokenTTL: int(refreshDuration.Minutes()),
+			ServiceTokens:   os.Getenv("USER_SERVICE_TOKENS"),
+			ServiceScopes:   v.GetStringMapStringSlice("jwt.service_scopes"),
 		},
 		RateLimiter: RateLimiterConfig{
 			RequestsPerSecond: v.GetInt("rate_limit.requests_per_second"),
@@ -2,12 +2,15 @@ package handler
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"user-service/internal/entity"
 	"user-service/internal/middleware"
 	"user-service/internal/service"
 	userGRPC "user-service/pkg/user-service_v1"
 
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
@@ -136,3 +139,20 @@ func (h *grpcHandler) UpdateProfile(ctx context.Context, req *userGRPC.UpdatePro
 	}, nil
 
 }
+
+func (h *grpcHandler) LookupUserByPhone(ctx context.Context, req *userGRPC.LookupUserByPhoneRequest) (*userGRPC.LookupUserByPhoneResponse, error) {
+	recipient, err := h.userService.LookupByPhone(ctx, req.GetPhoneNumber())
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrPhoneRequired):
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		case errors.Is(err, service.ErrUserNotFound):
+			return nil, status.Error(codes.NotFound, err.Error())
+		}
+		return nil, status.Error(codes.Internal, "internal error")
+	}
+	return &userGRPC.LookupUserByPhoneResponse{
+		Id:         recipient.ID,
+		MaskedName: recipient.MaskedName,
+	}, nil
+}
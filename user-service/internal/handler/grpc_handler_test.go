@@ -0,0 +1,54 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"user-service/internal/service"
+	userGRPC "user-service/pkg/user-service_v1"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// lookupService отвечает на LookupByPhone заданной ошибкой
+type lookupService struct {
+	service.UserService
+	err error
+}
+
+func (s *lookupService) LookupByPhone(ctx context.Context, phoneNumber string) (*service.Recipient, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	return &service.Recipient{ID: "user-1", MaskedName: "Иван П."}, nil
+}
+
+func TestLookupUserByPhone(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want codes.Code
+	}{
+		{"found", nil, codes.OK},
+		{"not found", service.ErrUserNotFound, codes.NotFound},
+		{"no phone", service.ErrPhoneRequired, codes.InvalidArgument},
+		{"internal", errors.New("connection refused"), codes.Internal},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := NewGRPCHandler(&lookupService{err: tt.err})
+			resp, err := h.LookupUserByPhone(context.Background(), &userGRPC.LookupUserByPhoneRequest{PhoneNumber: "+79990000001"})
+			if code := status.Code(err); code != tt.want {
+				t.Fatalf("code = %v, want %v", code, tt.want)
+			}
+			if tt.want == codes.OK && (resp.GetId() != "user-1" || resp.GetMaskedName() != "Иван П.") {
+				t.Errorf("resp = %+v", resp)
+			}
+			if tt.want == codes.Internal && status.Convert(err).Message() != "internal error" {
+				t.Errorf("internal error leaked: %v", err)
+			}
+		})
+	}
+}
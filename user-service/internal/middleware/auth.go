@@ -2,6 +2,11 @@ package middleware
 
 import (
 	"context"
+	"crypto/subtle"
+	"fmt"
+	"log"
+	"strings"
+	"user-service/internal/config"
 	"user-service/internal/utils"
 
 	"google.golang.org/grpc"
@@ -10,12 +15,82 @@ import (
 	"google.golang.org/grpc/status"
 )
 
+// ServiceTokenHeader - заголовок с токеном внутреннего сервиса
+const ServiceTokenHeader = "x-service-token"
+
+// ScopeLookup - поиск пользователя по телефону для переводов (card-service)
+const ScopeLookup = "lookup"
+
+// Методы для других сервисов и их области: вызываются только с сервисным токеном
+// сервиса, которому область выдана в jwt.service_scopes. Access-токен пользователя
+// для них не подходит
+var serviceMethods = map[string]string{
+	"/user_service.v1.UserService/LookupUserByPhone": ScopeLookup,
+}
+
 type AuthInterceptor struct {
-	jwtManager *utils.JWTManager
+	jwtManager    *utils.JWTManager
+	serviceTokens map[string]string
+	serviceScopes map[string]map[string]bool
+}
+
+func NewAuthInterceptor(jwtManager *utils.JWTManager, cfg *config.Config) (*AuthInterceptor, error) {
+	tokens, err := parseTokens(cfg.JWT.ServiceTokens)
+	if err != nil {
+		return nil, err
+	}
+	if len(tokens) == 0 {
+		log.Printf("USER_SERVICE_TOKENS is empty, internal methods will reject all calls")
+	}
+	scopes, err := serviceScopes(cfg.JWT.ServiceScopes)
+	if err != nil {
+		return nil, err
+	}
+	for name := range tokens {
+		if len(scopes[name]) == 0 {
+			log.Printf("service %s has a token but no jwt.service_scopes, its calls will be denied", name)
+		}
+	}
+	return &AuthInterceptor{jwtManager: jwtManager, serviceTokens: tokens, serviceScopes: scopes}, nil
 }
 
-func NewAuthInterceptor(jwtManager *utils.JWTManager) *AuthInterceptor {
-	return &AuthInterceptor{jwtManager: jwtManager}
+// serviceScopes проверяет, что каждая выданная область есть у какого-то метода:
+// опечатка в конфиге иначе молча оставила бы сервис без доступа
+func serviceScopes(cfg map[string][]string) (map[string]map[string]bool, error) {
+	known := make(map[string]bool)
+	for _, scope := range serviceMethods {
+		known[scope] = true
+	}
+	scopes := make(map[string]map[string]bool, len(cfg))
+	for name, list := range cfg {
+		scopes[name] = make(map[string]bool, len(list))
+		for _, scope := range list {
+			if !known[scope] {
+				return nil, fmt.Errorf("unknown scope %q for service %s", scope, name)
+			}
+			scopes[name][scope] = true
+		}
+	}
+	return scopes, nil
+}
+
+// parseTokens разбирает USER_SERVICE_TOKENS: "<name>:<token>" через запятую в токены
+// по имени сервиса. Повторяет auth.ParseTokens из card-service: сервисы собираются
+// в отдельных Docker-контекстах и общих пакетов не имеют.
+func parseTokens(s string) (map[string]string, error) {
+	tokens := make(map[string]string)
+	for _, item := range strings.Split(s, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		name, token, ok := strings.Cut(item, ":")
+		if !ok || name == "" || token == "" {
+			return nil, fmt.Errorf("invalid service token %q, want <name>:<token>", name)
+		}
+		tokens[name] = token
+	}
+	return tokens, nil
 }
 
 func (a *AuthInterceptor) Unary() grpc.UnaryServerInterceptor {
@@ -43,6 +118,22 @@ func (a *AuthInterceptor) Unary() grpc.UnaryServerInterceptor {
 			return nil, status.Errorf(codes.Unauthenticated, "metadata is not provided")
 		}
 
+		// Внутренние методы - только по сервисному токену с областью метода
+		if scope, ok := serviceMethods[info.FullMethod]; ok {
+			var token string
+			if values := md.Get(ServiceTokenHeader); len(values) > 0 {
+				token = values[0]
+			}
+			name, ok := matchToken(a.serviceTokens, token)
+			if !ok {
+				return nil, status.Errorf(codes.Unauthenticated, "service token is required")
+			}
+			if !a.serviceScopes[name][scope] {
+				return nil, status.Errorf(codes.PermissionDenied, "service %s may not call %s", name, info.FullMethod)
+			}
+			return handler(ctx, req)
+		}
+
 		// Получаем access token из заголовка authorization
 		values := md["authorization"]
 		if len(values) == 0 {
@@ -75,6 +166,20 @@ func (a *AuthInterceptor) Unary() grpc.UnaryServerInterceptor {
 	}
 }
 
+// matchToken сравнивает со всеми токенами за постоянное время и возвращает имя сервиса
+func matchToken(tokens map[string]string, token string) (string, bool) {
+	if token == "" {
+		return "", false
+	}
+	name := ""
+	for n, t := range tokens {
+		if subtle.ConstantTimeCompare([]byte(token), []byte(t)) == 1 {
+			name = n
+		}
+	}
+	return name, name != ""
+}
+
 // ExtractUserID - безопасно извлекает userID из контекста
 func ExtractUserID(ctx context.Context) (string, error) {
 	userID := ctx.Value("user_id")
@@ -0,0 +1,92 @@
+package middleware
+
+import (
+	"context"
+	"testing"
+
+	"user-service/internal/config"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+const testLookup = "/user_service.v1.UserService/LookupUserByPhone"
+
+func newTestInterceptor(t *testing.T, scopes map[string][]string) *AuthInterceptor {
+	t.Helper()
+	cfg := &config.Config{JWT: config.JWTConfig{
+		ServiceTokens: "card-service:card-token,report-service:report-token",
+		ServiceScopes: scopes,
+	}}
+	a, err := NewAuthInterceptor(nil, cfg)
+	if err != nil {
+		t.Fatalf("NewAuthInterceptor: %v", err)
+	}
+	return a
+}
+
+func TestServiceMethodScopes(t *testing.T) {
+	a := newTestInterceptor(t, map[string][]string{"card-service": {ScopeLookup}})
+	tests := []struct {
+		name     string
+		md       metadata.MD
+		wantCode codes.Code
+	}{
+		{"scoped service", metadata.Pairs(ServiceTokenHeader, "card-token"), codes.OK},
+		{"service without scope", metadata.Pairs(ServiceTokenHeader, "report-token"), codes.PermissionDenied},
+		{"wrong token", metadata.Pairs(ServiceTokenHeader, "card-token2"), codes.Unauthenticated},
+		{"token prefix", metadata.Pairs(ServiceTokenHeader, "card-tok"), codes.Unauthenticated},
+		{"empty token", metadata.Pairs(ServiceTokenHeader, ""), codes.Unauthenticated},
+		{"no token", metadata.MD{}, codes.Unauthenticated},
+		{"user token", metadata.Pairs("authorization", "Bearer card-token"), codes.Unauthenticated},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := metadata.NewIncomingContext(context.Background(), tt.md)
+			called := false
+			_, err := a.Unary()(ctx, nil, &grpc.UnaryServerInfo{FullMethod: testLookup}, func(ctx context.Context, req interface{}) (interface{}, error) {
+				called = true
+				return nil, nil
+			})
+			if code := status.Code(err); code != tt.wantCode || called != (tt.wantCode == codes.OK) {
+				t.Errorf("code = %v, handler called = %v, want %v", code, called, tt.wantCode)
+			}
+		})
+	}
+}
+
+func TestNewAuthInterceptorUnknownScope(t *testing.T) {
+	cfg := &config.Config{JWT: config.JWTConfig{ServiceScopes: map[string][]string{"card-service": {"lookups"}}}}
+	if _, err := NewAuthInterceptor(nil, cfg); err == nil {
+		t.Error("unknown scope want error")
+	}
+}
+
+func TestNewAuthInterceptorInvalidTokens(t *testing.T) {
+	for _, tokens := range []string{"card-service", "card-service:", ":card-token", "card-service:card-token,report-service"} {
+		cfg := &config.Config{JWT: config.JWTConfig{ServiceTokens: tokens}}
+		if _, err := NewAuthInterceptor(nil, cfg); err == nil {
+			t.Errorf("USER_SERVICE_TOKENS=%q want error", tokens)
+		}
+	}
+}
+
+func TestServiceTokenOnlyForServiceMethods(t *testing.T) {
+	a := newTestInterceptor(t, map[string][]string{"card-service": {ScopeLookup}})
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) { return nil, nil }
+
+	// Без metadata сервисный метод не вызывается
+	_, err := a.Unary()(context.Background(), nil, &grpc.UnaryServerInfo{FullMethod: testLookup}, handler)
+	if status.Code(err) != codes.Unauthenticated {
+		t.Errorf("no metadata code = %v, want Unauthenticated", status.Code(err))
+	}
+
+	// Сервисный токен не заменяет access-токен в методах пользователя
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(ServiceTokenHeader, "card-token"))
+	_, err = a.Unary()(ctx, nil, &grpc.UnaryServerInfo{FullMethod: "/user_service.v1.UserService/GetProfile"}, handler)
+	if status.Code(err) != codes.Unauthenticated {
+		t.Errorf("service token on user method code = %v, want Unauthenticated", status.Code(err))
+	}
+}
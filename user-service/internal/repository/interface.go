@@ -2,11 +2,15 @@ package repository
 
 import (
 	"context"
+	"errors"
 	"user-service/internal/entity"
 )
 
+var ErrUserNotFound = errors.New("user not found")
+
 type UserRepository interface {
 	Create(ctx context.Context, user *entity.User) error
+	// GetByPhoneNumber возвращает ErrUserNotFound, если пользователя с таким телефоном нет
 	GetByPhoneNumber(ctx context.Context, username string) (*entity.User, error)
 	SaveRefreshToken(ctx context.Context, rt *entity.RefreshToken) error
 	GetRefreshToken(ctx context.Context, token string) (*entity.RefreshToken, error)
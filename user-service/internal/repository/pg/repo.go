@@ -28,9 +28,12 @@ func (r *userRepo) Create(ctx context.Context, u *entity.User) error {
 
 func (r *userRepo) GetByPhoneNumber(ctx context.Context, phoneNumber string) (*entity.User, error) {
 	row := r.db.Pool.QueryRow(ctx, `
-	  SELECT id, first_name, phone_number, password, created_at, updated_at FROM users WHERE phone_number = $1`, phoneNumber)
+	  SELECT id, first_name, COALESCE(last_name, ''), phone_number, password, created_at, updated_at FROM users WHERE phone_number = $1`, phoneNumber)
 	var u entity.User
-	if err := row.Scan(&u.ID, &u.FirstName, &u.PhoneNumber, &u.Password, &u.CreatedAt, &u.UpdatedAt); err != nil {
+	if err := row.Scan(&u.ID, &u.FirstName, &u.LastName, &u.PhoneNumber, &u.Password, &u.CreatedAt, &u.UpdatedAt); err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, repository.ErrUserNotFound
+		}
 		return nil, err
 	}
 	return &u, nil
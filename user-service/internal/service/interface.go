@@ -2,9 +2,15 @@ package service
 
 import (
 	"context"
+	"errors"
 	"user-service/internal/entity"
 )
 
+var (
+	ErrUserNotFound  = errors.New("user not found")
+	ErrPhoneRequired = errors.New("phone_number is required")
+)
+
 type UserService interface {
 	Register(ctx context.Context, input RegisterInput) (*entity.User, error)
 	Login(ctx context.Context, phoneNumber, password string) (user *entity.User, accessToken, refreshToken string)
@@ -12,4 +18,7 @@ type UserService interface {
 	GetProfileInfo(ctx context.Context, userID string) (*entity.User, error)
 	Logout(ctx context.Context, userID string) error
 	UpdateUserProfile(ctx context.Context, input *entity.User) (*entity.User, error)
+	// LookupByPhone ищет получателя перевода для других сервисов. В ответе только id
+	// и замаскированное имя, остальные данные пользователя наружу не отдаются.
+	LookupByPhone(ctx context.Context, phoneNumber string) (*Recipient, error)
 }
@@ -1,8 +1,11 @@
 package service
 
 import (
+	"errors"
 	"fmt"
+	"strings"
 	"time"
+	"unicode/utf8"
 	"user-service/internal/entity"
 	"user-service/internal/repository"
 	"user-service/internal/utils"
@@ -168,3 +171,35 @@ func (s *userService) UpdateUserProfile(ctx context.Context, input *entity.User)
 	}
 	return exist, nil
 }
+
+// Recipient - пользователь, найденный по телефону
+type Recipient struct {
+	ID         string
+	MaskedName string
+}
+
+func (s *userService) LookupByPhone(ctx context.Context, phoneNumber string) (*Recipient, error) {
+	phoneNumber = strings.TrimSpace(phoneNumber)
+	if phoneNumber == "" {
+		return nil, ErrPhoneRequired
+	}
+	user, err := s.repo.GetByPhoneNumber(ctx, phoneNumber)
+	if err != nil {
+		if errors.Is(err, repository.ErrUserNotFound) {
+			return nil, ErrUserNotFound
+		}
+		return nil, err
+	}
+	return &Recipient{ID: user.ID, MaskedName: MaskName(user.FirstName, user.LastName)}, nil
+}
+
+// MaskName - имя и первая буква фамилии: "Иван П.". Без фамилии - только имя.
+func MaskName(firstName, lastName string) string {
+	firstName = strings.TrimSpace(firstName)
+	lastName = strings.TrimSpace(lastName)
+	if lastName == "" {
+		return firstName
+	}
+	initial, _ := utf8.DecodeRuneInString(lastName)
+	return strings.TrimSpace(firstName + " " + string(initial) + ".")
+}
@@ -0,0 +1,75 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"user-service/internal/entity"
+	"user-service/internal/repository"
+)
+
+// phoneRepo - репозиторий с пользователями по телефону, остальные методы не нужны
+type phoneRepo struct {
+	repository.UserRepository
+	users map[string]*entity.User
+	err   error
+}
+
+func (r *phoneRepo) GetByPhoneNumber(ctx context.Context, phone string) (*entity.User, error) {
+	if r.err != nil {
+		return nil, r.err
+	}
+	u, ok := r.users[phone]
+	if !ok {
+		return nil, repository.ErrUserNotFound
+	}
+	return u, nil
+}
+
+func TestMaskName(t *testing.T) {
+	tests := []struct {
+		first, last string
+		want        string
+	}{
+		{"Иван", "Петров", "Иван П."},
+		{"Ivan", "Petrov", "Ivan P."},
+		{"Иван", "", "Иван"},
+		{" Иван ", "  ", "Иван"},
+		{"Иван", " ёлкин", "Иван ё."},
+		{"", "Петров", "П."},
+	}
+	for _, tt := range tests {
+		if got := MaskName(tt.first, tt.last); got != tt.want {
+			t.Errorf("MaskName(%q, %q) = %q, want %q", tt.first, tt.last, got, tt.want)
+		}
+	}
+}
+
+func TestLookupByPhone(t *testing.T) {
+	repo := &phoneRepo{users: map[string]*entity.User{
+		"+79990000001": {ID: "user-1", FirstName: "Иван", LastName: "Петров", PhoneNumber: "+79990000001", Email: "ivan@example.com"},
+	}}
+	s := NewUserService(repo, nil)
+	ctx := context.Background()
+
+	r, err := s.LookupByPhone(ctx, " +79990000001 ")
+	if err != nil {
+		t.Fatalf("LookupByPhone: %v", err)
+	}
+	if r.ID != "user-1" || r.MaskedName != "Иван П." {
+		t.Errorf("recipient = %+v, want user-1 Иван П.", r)
+	}
+
+	if _, err := s.LookupByPhone(ctx, "+79990000002"); !errors.Is(err, ErrUserNotFound) {
+		t.Errorf("unknown phone err = %v, want ErrUserNotFound", err)
+	}
+	if _, err := s.LookupByPhone(ctx, " "); !errors.Is(err, ErrPhoneRequired) {
+		t.Errorf("empty phone err = %v, want ErrPhoneRequired", err)
+	}
+
+	repo.err = errors.New("connection refused")
+	if _, err := s.LookupByPhone(ctx, "+79990000001"); err == nil || errors.Is(err, ErrUserNotFound) {
+		t.Errorf("repository failure err = %v, want it passed through", err)
+	}
+}
@@ -28,6 +28,9 @@ type UserServiceClient interface {
 	Logout(ctx context.Context, in *LogoutRequest, opts ...grpc.CallOption) (*LogoutResponse, error)
 	GetProfile(ctx context.Context, in *GetProfileRequest, opts ...grpc.CallOption) (*GetProfileResponse, error)
 	UpdateProfile(ctx context.Context, in *UpdateProfileRequest, opts ...grpc.CallOption) (*UpdateProfileResponse, error)
+	// Внутренний метод для других сервисов, вызывается с x-service-token.
+	// Возвращает только id и замаскированное имя, без телефона и email.
+	LookupUserByPhone(ctx context.Context, in *LookupUserByPhoneRequest, opts ...grpc.CallOption) (*LookupUserByPhoneResponse, error)
 }
 
 type userServiceClient struct {
@@ -92,6 +95,15 @@ func (c *userServiceClient) UpdateProfile(ctx context.Context, in *UpdateProfile
 	return out, nil
 }
 
+func (c *userServiceClient) LookupUserByPhone(ctx context.Context, in *LookupUserByPhoneRequest, opts ...grpc.CallOption) (*LookupUserByPhoneResponse, error) {
+	out := new(LookupUserByPhoneResponse)
+	err := c.cc.Invoke(ctx, "/user_service.v1.UserService/LookupUserByPhone", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // UserServiceServer is the server API for UserService service.
 // All implementations must embed UnimplementedUserServiceServer
 // for forward compatibility
@@ -102,6 +114,9 @@ type UserServiceServer interface {
 	Logout(context.Context, *LogoutRequest) (*LogoutResponse, error)
 	GetProfile(context.Context, *GetProfileRequest) (*GetProfileResponse, error)
 	UpdateProfile(context.Context, *UpdateProfileRequest) (*UpdateProfileResponse, error)
+	// Внутренний метод для других сервисов, вызывается с x-service-token.
+	// Возвращает только id и замаскированное имя, без телефона и email.
+	LookupUserByPhone(context.Context, *LookupUserByPhoneRequest) (*LookupUserByPhoneResponse, error)
 	mustEmbedUnimplementedUserServiceServer()
 }
 
@@ -127,6 +142,9 @@ func (UnimplementedUserServiceServer) GetProfile(context.Context, *GetProfileReq
 func (UnimplementedUserServiceServer) UpdateProfile(context.Context, *UpdateProfileRequest) (*UpdateProfileResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method UpdateProfile not implemented")
 }
+func (UnimplementedUserServiceServer) LookupUserByPhone(context.Context, *LookupUserByPhoneRequest) (*LookupUserByPhoneResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method LookupUserByPhone not implemented")
+}
 func (UnimplementedUserServiceServer) mustEmbedUnimplementedUserServiceServer() {}
 
 // UnsafeUserServiceServer may be embedded to opt out of forward compatibility for this service.
@@ -248,6 +266,24 @@ func _UserService_UpdateProfile_Handler(srv interface{}, ctx context.Context, de
 	return interceptor(ctx, in, info, handler)
 }
 
+func _UserService_LookupUserByPhone_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(LookupUserByPhoneRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UserServiceServer).LookupUserByPhone(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/user_service.v1.UserService/LookupUserByPhone",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UserServiceServer).LookupUserByPhone(ctx, req.(*LookupUserByPhoneRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // UserService_ServiceDesc is the grpc.ServiceDesc for UserService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -279,6 +315,10 @@ var UserService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "UpdateProfile",
 			Handler:    _UserService_UpdateProfile_Handler,
 		},
+		{
+			MethodName: "LookupUserByPhone",
+			Handler:    _UserService_LookupUserByPhone_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "user-service_v1/user.proto",